@@ -5,12 +5,21 @@ import (
     "errors"
     "os"
     "strings"
+    "time"
 
     "github.com/golang-jwt/jwt/v4"
     "google.golang.org/grpc"
     "google.golang.org/grpc/metadata"
 )
 
+// claimsKey is the context key under which the interceptor stores the token claims.
+type claimsKey struct{}
+
+const (
+    RoleAdmin = "admin"
+    RoleUser  = "user"
+)
+
 func UnaryJWTInterceptor(
     ctx context.Context,
     req interface{},
@@ -29,6 +38,41 @@ func UnaryJWTInterceptor(
     }
 
     //Otherwise, do the normal token validation
+    ctx, err := authenticate(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return handler(ctx, req)
+}
+
+// StreamJWTInterceptor validates the token of a streaming call, like
+// UnaryJWTInterceptor, and makes the claims available on the stream's context.
+func StreamJWTInterceptor(
+    srv interface{},
+    ss grpc.ServerStream,
+    info *grpc.StreamServerInfo,
+    handler grpc.StreamHandler,
+) error {
+    ctx, err := authenticate(ss.Context())
+    if err != nil {
+        return err
+    }
+    return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream overrides the stream's context with one carrying the claims.
+type authenticatedStream struct {
+    grpc.ServerStream
+    ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+    return s.ctx
+}
+
+// authenticate checks the "authorization" token in the incoming metadata and
+// returns ctx with its claims attached.
+func authenticate(ctx context.Context) (context.Context, error) {
     md, ok := metadata.FromIncomingContext(ctx)
     if !ok {
         return nil, errors.New("missing metadata")
@@ -50,6 +94,79 @@ func UnaryJWTInterceptor(
         return nil, errors.New("invalid token")
     }
 
-    
-    return handler(ctx, req)
+    // Make the claims available to handlers (e.g. for RequireRole)
+    if claims, ok := token.Claims.(jwt.MapClaims); ok {
+        ctx = context.WithValue(ctx, claimsKey{}, claims)
+    }
+    return ctx, nil
+}
+
+// ClaimsFromContext returns the JWT claims stored by the JWT interceptors.
+func ClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+    claims, ok := ctx.Value(claimsKey{}).(jwt.MapClaims)
+    return claims, ok
+}
+
+// RequireRole fails unless the caller's token carries the given role claim.
+func RequireRole(ctx context.Context, role string) error {
+    claims, ok := ClaimsFromContext(ctx)
+    if !ok {
+        return errors.New("missing token claims")
+    }
+    if r, _ := claims["role"].(string); r != role {
+        return errors.New("permission denied: requires role " + role)
+    }
+    return nil
+}
+
+// CallerEmail returns the email claim of the caller's token, or "" if absent.
+func CallerEmail(ctx context.Context) string {
+    claims, ok := ClaimsFromContext(ctx)
+    if !ok {
+        return ""
+    }
+    email, _ := claims["email"].(string)
+    return email
+}
+
+// AuthorizeUser returns the user a request acts for: the caller, unless an admin
+// names another user. An empty userID means the caller; a user naming anyone
+// else is rejected.
+func AuthorizeUser(ctx context.Context, userID string) (string, error) {
+    caller := CallerEmail(ctx)
+    if caller == "" {
+        return "", errors.New("missing caller identity")
+    }
+    if userID == "" || userID == caller {
+        return caller, nil
+    }
+    if RequireRole(ctx, RoleAdmin) == nil {
+        return userID, nil
+    }
+    return "", errors.New("permission denied: cannot act for user " + userID)
+}
+
+// ServiceToken signs a short-lived admin token for background jobs that call other
+// services without a user request to forward (e.g. scheduled corporate actions).
+func ServiceToken(service string) (string, error) {
+    return shortLivedToken(service, RoleAdmin)
+}
+
+// UserToken signs a short-lived token for a user, for a service acting on their
+// behalf when they aren't the caller (e.g. settling the maker side of a fill).
+func UserToken(email string) (string, error) {
+    return shortLivedToken(email, RoleUser)
+}
+
+func shortLivedToken(email, role string) (string, error) {
+    secret := os.Getenv("JWT_SECRET")
+    if secret == "" {
+        return "", errors.New("JWT_SECRET is not set")
+    }
+    claims := jwt.MapClaims{
+        "email": email,
+        "role":  role,
+        "exp":   time.Now().Add(5 * time.Minute).Unix(),
+    }
+    return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
 }
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+    "context"
+    "testing"
+
+    "github.com/golang-jwt/jwt/v4"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+)
+
+func withClaims(email, role string) context.Context {
+    return context.WithValue(context.Background(), claimsKey{}, jwt.MapClaims{"email": email, "role": role})
+}
+
+func TestAuthorizeUser(t *testing.T) {
+    tests := []struct {
+        name    string
+        ctx     context.Context
+        userID  string
+        want    string
+        wantErr bool
+    }{
+        {"defaults to caller", withClaims("alice@example.com", RoleUser), "", "alice@example.com", false},
+        {"own user", withClaims("alice@example.com", RoleUser), "alice@example.com", "alice@example.com", false},
+        {"other user", withClaims("alice@example.com", RoleUser), "bob@example.com", "", true},
+        {"admin for other user", withClaims("ops@example.com", RoleAdmin), "bob@example.com", "bob@example.com", false},
+        {"no claims", context.Background(), "bob@example.com", "", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := AuthorizeUser(tt.ctx, tt.userID)
+            if (err != nil) != tt.wantErr || got != tt.want {
+                t.Fatalf("AuthorizeUser = %q, %v; want %q, wantErr %v", got, err, tt.want, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestUserTokenPassesInterceptor(t *testing.T) {
+    t.Setenv("JWT_SECRET", "test-secret")
+    token, err := UserToken("alice@example.com")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", token))
+    info := &grpc.UnaryServerInfo{FullMethod: "/billing.BillingService/GetBalance"}
+    _, err = UnaryJWTInterceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+        if got := CallerEmail(ctx); got != "alice@example.com" {
+            t.Fatalf("CallerEmail = %q", got)
+        }
+        if RequireRole(ctx, RoleAdmin) == nil {
+            t.Fatal("a user token must not carry the admin role")
+        }
+        return nil, nil
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+}
+
+type fakeStream struct {
+    grpc.ServerStream
+    ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func TestStreamJWTInterceptor(t *testing.T) {
+    t.Setenv("JWT_SECRET", "test-secret")
+    info := &grpc.StreamServerInfo{FullMethod: "/trade.TradeService/QuoteSession"}
+
+    err := StreamJWTInterceptor(nil, &fakeStream{ctx: context.Background()}, info, func(srv interface{}, ss grpc.ServerStream) error {
+        t.Fatal("handler ran without a token")
+        return nil
+    })
+    if err == nil {
+        t.Fatal("expected a missing token to be rejected")
+    }
+
+    token, err := UserToken("alice@example.com")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", token))
+    err = StreamJWTInterceptor(nil, &fakeStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+        if got := CallerEmail(ss.Context()); got != "alice@example.com" {
+            t.Fatalf("CallerEmail = %q", got)
+        }
+        return nil
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+}
@@ -0,0 +1,50 @@
+// Package testmongo points config.DB at a throwaway database for tests that
+// need a real MongoDB. They are skipped unless MONGO_TEST_URI is set, e.g.
+//
+//     MONGO_TEST_URI=mongodb://localhost:27017 go test ./...
+//
+// Transactions additionally need the server to run as a replica set.
+package testmongo
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Use connects to MONGO_TEST_URI, sets config.DB to a database unique to the
+// test and drops it when the test ends. Tests using it must not run in parallel
+// with each other, since config.DB is global.
+func Use(t *testing.T) *mongo.Database {
+    t.Helper()
+    uri := os.Getenv("MONGO_TEST_URI")
+    if uri == "" {
+        t.Skip("MONGO_TEST_URI not set; skipping MongoDB test")
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+    if err != nil {
+        t.Fatalf("failed to connect to %s: %v", uri, err)
+    }
+    if err := client.Ping(ctx, nil); err != nil {
+        t.Fatalf("failed to reach %s: %v", uri, err)
+    }
+
+    db := client.Database(fmt.Sprintf("swapsync_test_%d", time.Now().UnixNano()))
+    previous := config.DB
+    config.DB = db
+    t.Cleanup(func() {
+        config.DB = previous
+        db.Drop(context.Background())
+        client.Disconnect(context.Background())
+    })
+    return db
+}
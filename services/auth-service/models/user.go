@@ -4,4 +4,5 @@ type User struct {
     ID       string `json:"id" bson:"_id,omitempty"`
     Email    string `json:"email" bson:"email"`
     Password string `json:"password" bson:"password"`
+    Role     string `json:"role" bson:"role"` // "user" or "admin"
 }
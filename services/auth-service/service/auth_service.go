@@ -23,6 +23,7 @@ func RegisterUser(email, password string) (string, error) {
     newUser := models.User{
         Email:    email,
         Password: string(hashedPassword),
+        Role:     "user", // admins are promoted directly in the users collection
     }
     
     newUser.ID = uuid.NewString()
@@ -41,20 +42,25 @@ func AuthenticateUser(email, password string) (string, error) {
     if err != nil {
         return "", errors.New("invalid password")
     }
-    token, err := GenerateJWT(email)
+    role := user.Role
+    if role == "" {
+        role = "user"
+    }
+    token, err := GenerateJWT(email, role)
     if err != nil {
         return "", err
     }
     return token, nil
 }
 
-func GenerateJWT(email string) (string, error) {
+func GenerateJWT(email, role string) (string, error) {
     secret := os.Getenv("JWT_SECRET")
     if secret == "" {
         return "", errors.New("JWT_SECRET not set")
     }
     claims := jwt.MapClaims{
         "email": email,
+        "role":  role,
         "exp":   time.Now().Add(time.Hour * 24).Unix(), // 24-hour expiration
     }
     token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -10,7 +10,9 @@ import (
     "github.com/joho/godotenv"
 
     "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/internal/middleware"
     pb "github.com/ankan8/swapsync/backend/services/billing-service/proto"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
     "github.com/ankan8/swapsync/backend/services/billing-service/service"
     "google.golang.org/grpc"
 )
@@ -22,12 +24,31 @@ func main() {
         fmt.Println("Warning: .env file not found, using system environment variables")
     }
 
-    // Debugging
-    fmt.Printf("DEBUG: RAZORPAY_KEY_ID=%s, SECRET length=%d\n",
-        os.Getenv("RAZORPAY_KEY_ID"), len(os.Getenv("RAZORPAY_KEY_SECRET")))
-
     // 2) Connect to MongoDB if storing transactions/wallet data
     config.ConnectDB()
+    if err := repository.EnsureWalletIndexes(); err != nil {
+        log.Fatalf("Failed to create wallet indexes: %v", err)
+    }
+    if err := repository.EnsureLedgerIndexes(); err != nil {
+        log.Fatalf("Failed to create ledger indexes: %v", err)
+    }
+    if err := repository.EnsureWithdrawalIndexes(); err != nil {
+        log.Fatalf("Failed to create withdrawal indexes: %v", err)
+    }
+    if err := repository.EnsureMarginIndexes(); err != nil {
+        log.Fatalf("Failed to create margin indexes: %v", err)
+    }
+    if err := repository.BackfillTransactionFields(); err != nil {
+        log.Fatalf("Failed to backfill transaction fields: %v", err)
+    }
+    if err := service.MigrateOpeningBalances(); err != nil {
+        log.Fatalf("Failed to journal opening balances: %v", err)
+    }
+    reconcileInterval, err := service.LedgerReconcileIntervalFromEnv()
+    if err != nil {
+        log.Fatalf("Invalid ledger config: %v", err)
+    }
+    service.StartLedgerReconciler(reconcileInterval)
 
     // 3) Listen on port 50055
     lis, err := net.Listen("tcp", ":50055")
@@ -36,11 +57,57 @@ func main() {
     }
 
     // 4) Create gRPC server
-    grpcServer := grpc.NewServer()
+    grpcServer := grpc.NewServer(
+        grpc.UnaryInterceptor(middleware.UnaryJWTInterceptor),
+    )
 
     // 5) Register our BillingServiceServer
     //    This is the struct that implements all the methods (CalculateCommission, ProcessPayment, DepositFunds, etc.)
-    pb.RegisterBillingServiceServer(grpcServer, &service.BillingServiceServer{})
+    feeSchedule, err := service.LoadFeeSchedule()
+    if err != nil {
+        log.Fatalf("Failed to load fee schedule: %v", err)
+    }
+    gateway, err := service.PaymentGatewayFromEnv()
+    if err != nil {
+        log.Fatalf("Failed to configure payment gateway: %v", err)
+    }
+    withdrawalConfig, err := service.WithdrawalConfigFromEnv()
+    if err != nil {
+        log.Fatalf("Invalid withdrawal config: %v", err)
+    }
+    payouts, err := service.PayoutGatewayFromEnv()
+    if err != nil {
+        log.Fatalf("Failed to configure payout gateway: %v", err)
+    }
+    withdrawals := service.NewWithdrawalService(withdrawalConfig, payouts)
+    withdrawals.Start()
+    marginConfig, err := service.LoadMarginConfig()
+    if err != nil {
+        log.Fatalf("Failed to load margin config: %v", err)
+    }
+    marginInterval, err := service.MarginMonitorIntervalFromEnv()
+    if err != nil {
+        log.Fatalf("Invalid margin config: %v", err)
+    }
+    margin := service.NewMarginService(marginConfig, marginInterval)
+    margin.Start()
+    pb.RegisterBillingServiceServer(grpcServer, &service.BillingServiceServer{
+        FeeSchedule: feeSchedule,
+        Gateway:     gateway,
+        Withdrawals: withdrawals,
+        Margin:      margin,
+    })
+
+    // Payments are captured when the gateway's webhook arrives
+    if secret := os.Getenv("RAZORPAY_WEBHOOK_SECRET"); secret != "" {
+        webhookAddr, err := service.WebhookAddrFromEnv()
+        if err != nil {
+            log.Fatalf("Invalid webhook config: %v", err)
+        }
+        service.StartWebhookServer(webhookAddr, &service.WebhookHandler{Secret: secret, Gateway: gateway})
+    } else {
+        log.Println("RAZORPAY_WEBHOOK_SECRET not set: payment webhooks are disabled and payments stay PENDING")
+    }
 
     log.Printf("Billing Service listening on %v", lis.Addr())
 
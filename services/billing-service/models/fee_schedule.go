@@ -0,0 +1,54 @@
+package models
+
+// FeeRates holds the commission rates and per-trade bounds for one asset class.
+type FeeRates struct {
+    MakerRate float64 `json:"maker_rate" bson:"maker_rate"`
+    TakerRate float64 `json:"taker_rate" bson:"taker_rate"`
+    MinFee    float64 `json:"min_fee" bson:"min_fee"`
+    MaxFee    float64 `json:"max_fee" bson:"max_fee"`
+}
+
+// FeeOverride replaces some of the schedule's default rates for one asset
+// class. A field left out (nil) inherits the default, so an explicit 0 - a
+// zero maker rate, say - can still be configured.
+type FeeOverride struct {
+    MakerRate *float64 `json:"maker_rate,omitempty" bson:"maker_rate,omitempty"`
+    TakerRate *float64 `json:"taker_rate,omitempty" bson:"taker_rate,omitempty"`
+    MinFee    *float64 `json:"min_fee,omitempty" bson:"min_fee,omitempty"`
+    MaxFee    *float64 `json:"max_fee,omitempty" bson:"max_fee,omitempty"`
+}
+
+// VolumeTier gives a discount on the rate once a user's trailing 30-day traded
+// notional reaches MinVolume. Discount is a fraction, e.g. 0.25 = 25% off.
+type VolumeTier struct {
+    Name      string  `json:"name" bson:"name"`
+    MinVolume float64 `json:"min_volume" bson:"min_volume"`
+    Discount  float64 `json:"discount" bson:"discount"`
+}
+
+// FeeSchedule is the full maker/taker commission configuration.
+type FeeSchedule struct {
+    FeeRates
+    // AssetClasses overrides the default rates per asset class (e.g. "EQUITY", "ETF", "CRYPTO").
+    AssetClasses map[string]FeeOverride `json:"asset_classes" bson:"asset_classes"`
+    // SymbolAssetClasses maps a symbol to its asset class when the caller doesn't supply one.
+    SymbolAssetClasses map[string]string `json:"symbol_asset_classes" bson:"symbol_asset_classes"`
+    DefaultAssetClass  string            `json:"default_asset_class" bson:"default_asset_class"`
+    // Tiers must be sorted by MinVolume ascending.
+    Tiers []VolumeTier `json:"tiers" bson:"tiers"`
+}
+
+// FeeBreakdown explains how a commission was computed.
+type FeeBreakdown struct {
+    Liquidity       string
+    AssetClass      string
+    Tier            string
+    ThirtyDayVolume float64
+    BaseRate        float64
+    Discount        float64
+    AppliedRate     float64
+    GrossFee        float64
+    MinFee          float64
+    MaxFee          float64
+    Commission      float64
+}
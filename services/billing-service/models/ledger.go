@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Posting is one leg of a journal entry. Exactly one of Debit and Credit is set.
+type Posting struct {
+    Account string  `bson:"account"`
+    Debit   float64 `bson:"debit"`
+    Credit  float64 `bson:"credit"`
+}
+
+// JournalEntry is a balanced double-entry record: total debits equal total credits.
+type JournalEntry struct {
+    EntryID     string    `bson:"entry_id"`
+    Type        string    `bson:"type"`      // "DEPOSIT", "WITHDRAWAL", "TRADE_DEBIT", "TRADE_CREDIT", "FEE", ...
+    UserID      string    `bson:"user_id"`   // user whose cash moved, if any
+    Reference   string    `bson:"reference"` // trade/execution/payment ID the entry belongs to
+    Description string    `bson:"description"`
+    Currency    string    `bson:"currency"`
+    Postings    []Posting `bson:"postings"`
+    CreatedAt   time.Time `bson:"created_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// MarginRates are an instrument's margin requirements as fractions of position
+// value: InitialRate of a purchase must be covered by equity, and equity must
+// stay above MaintenanceRate of the position while it is held.
+type MarginRates struct {
+    InitialRate     float64 `json:"initial_rate" bson:"initial_rate"`
+    MaintenanceRate float64 `json:"maintenance_rate" bson:"maintenance_rate"`
+}
+
+// MarginConfig is the platform's margin lending configuration.
+type MarginConfig struct {
+    MarginRates // for instruments without an entry in Symbols
+    // Symbols overrides the rates per instrument; a rate of 1 makes it non-marginable.
+    Symbols map[string]MarginRates `json:"symbols" bson:"symbols"`
+    // AnnualInterestRate is charged on borrowed cash, accrued daily, e.g. 0.12 = 12%.
+    AnnualInterestRate float64 `json:"annual_interest_rate" bson:"annual_interest_rate"`
+    // CallBuffer puts the margin call level above maintenance, e.g. 0.1 calls at
+    // 110% of the maintenance requirement, so users are warned before liquidation.
+    CallBuffer float64 `json:"call_buffer" bson:"call_buffer"`
+}
+
+// MarginAccount is a user's margin lending state. Loan is cash lent to buy
+// securities, including interest charged on it.
+type MarginAccount struct {
+    UserID            string    `bson:"user_id"`
+    Enabled           bool      `bson:"enabled"`
+    Loan              float64   `bson:"loan"`
+    InterestCharged   float64   `bson:"interest_charged"` // lifetime total
+    InterestAccruedAt time.Time `bson:"interest_accrued_at"`
+    InterestRemainder float64   `bson:"interest_remainder"` // accrued but below a cent, carried to the next charge
+    Status            string    `bson:"status"` // OK, MARGIN_CALL or LIQUIDATING
+    CallIssuedAt      time.Time `bson:"call_issued_at,omitempty"`
+    LastEquity        float64   `bson:"last_equity"`
+    LastCheckedAt     time.Time `bson:"last_checked_at,omitempty"`
+    CreatedAt         time.Time `bson:"created_at"`
+    UpdatedAt         time.Time `bson:"updated_at"`
+}
@@ -1,10 +1,18 @@
 package models
 
 type Transaction struct {
-  TransactionID string  `bson:"transaction_id"`
-  UserID        string  `bson:"user_id"`
-  Amount        float64 `bson:"amount"`
-  Method        string  `bson:"method"`
-  Timestamp     string  `bson:"timestamp"`
-  Success       bool    `bson:"success"`
+  TransactionID  string  `bson:"transaction_id"`
+  UserID         string  `bson:"user_id"`
+  Amount         float64 `bson:"amount"`
+  Method         string  `bson:"method"`
+  Type           string  `bson:"type"`   // "PAYMENT" (trade commission) or "DEPOSIT" (wallet top-up)
+  Status         string  `bson:"status"` // PENDING, CAPTURED, FAILED, PARTIALLY_REFUNDED or REFUNDED
+  GatewayOrderID string  `bson:"gateway_order_id,omitempty"`
+  PaymentID      string  `bson:"payment_id,omitempty"`
+  FailureReason  string  `bson:"failure_reason,omitempty"`
+  RefundedAmount float64 `bson:"refunded_amount"`
+  Timestamp      string  `bson:"timestamp"`
+  CapturedAt     string  `bson:"captured_at,omitempty"`
+  UpdatedAt      string  `bson:"updated_at,omitempty"`
+  Success        bool    `bson:"success"` // true once the payment is captured
 }
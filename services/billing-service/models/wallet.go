@@ -1,6 +1,8 @@
 package models
 
 type Wallet struct {
-    UserID  string  `bson:"user_id"`
-    Balance float64 `bson:"balance"`
+    UserID   string  `bson:"user_id"`
+    Balance  float64 `bson:"balance"`
+    Currency string  `bson:"currency"` // ISO code; empty on wallets created before currencies
+
 }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// WalletAdjustment is the audit record of a direct, admin-authorized wallet credit
+// (anything that moves money into a wallet without a gateway payment behind it).
+type WalletAdjustment struct {
+    AdjustmentID string    `bson:"adjustment_id"`
+    UserID       string    `bson:"user_id"`
+    Amount       float64   `bson:"amount"`
+    Purpose      string    `bson:"purpose"`   // ADJUSTMENT, TRADE or DIVIDEND
+    Reference    string    `bson:"reference"` // e.g. trade or corporate action ID
+    Reason       string    `bson:"reason"`
+    Admin        string    `bson:"admin"` // email from the caller's token
+    Status       string    `bson:"status"` // PENDING, APPLIED or FAILED
+    Error        string    `bson:"error,omitempty"`
+    CreatedAt    time.Time `bson:"created_at"`
+}
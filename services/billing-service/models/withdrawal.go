@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Withdrawal is a request to pay wallet money out to the user's bank. The amount
+// is held (debited from the wallet) from the moment it is requested until it is
+// either paid out or reversed.
+type Withdrawal struct {
+    WithdrawalID   string            `bson:"withdrawal_id"`
+    UserID         string            `bson:"user_id"`
+    Amount         float64           `bson:"amount"`
+    Currency       string            `bson:"currency"`
+    Destination    string            `bson:"destination"` // payout fund account / bank reference
+    Status         string            `bson:"status"`
+    PayoutID       string            `bson:"payout_id,omitempty"`
+    PayoutAttempts int               `bson:"payout_attempts,omitempty"`
+    FailureReason  string            `bson:"failure_reason,omitempty"`
+    ReviewedBy     string            `bson:"reviewed_by,omitempty"`
+    ReviewNote     string            `bson:"review_note,omitempty"`
+    RequestedAt    time.Time         `bson:"requested_at"`
+    UpdatedAt      time.Time         `bson:"updated_at"`
+    History        []WithdrawalEvent `bson:"history"`
+}
+
+// WithdrawalEvent records one status change.
+type WithdrawalEvent struct {
+    Status string    `bson:"status"`
+    At     time.Time `bson:"at"`
+    Note   string    `bson:"note,omitempty"`
+}
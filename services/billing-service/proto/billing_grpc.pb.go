@@ -19,11 +19,28 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	BillingService_CalculateCommission_FullMethodName = "/billing.BillingService/CalculateCommission"
-	BillingService_ProcessPayment_FullMethodName      = "/billing.BillingService/ProcessPayment"
-	BillingService_DepositFunds_FullMethodName        = "/billing.BillingService/DepositFunds"
-	BillingService_WithdrawFunds_FullMethodName       = "/billing.BillingService/WithdrawFunds"
-	BillingService_GetBalance_FullMethodName          = "/billing.BillingService/GetBalance"
+	BillingService_CalculateCommission_FullMethodName   = "/billing.BillingService/CalculateCommission"
+	BillingService_ProcessPayment_FullMethodName        = "/billing.BillingService/ProcessPayment"
+	BillingService_RefundPayment_FullMethodName         = "/billing.BillingService/RefundPayment"
+	BillingService_InitiateDeposit_FullMethodName       = "/billing.BillingService/InitiateDeposit"
+	BillingService_GetTransaction_FullMethodName        = "/billing.BillingService/GetTransaction"
+	BillingService_DepositFunds_FullMethodName          = "/billing.BillingService/DepositFunds"
+	BillingService_ListWalletAdjustments_FullMethodName = "/billing.BillingService/ListWalletAdjustments"
+	BillingService_WithdrawFunds_FullMethodName         = "/billing.BillingService/WithdrawFunds"
+	BillingService_GetBalance_FullMethodName            = "/billing.BillingService/GetBalance"
+	BillingService_RequestWithdrawal_FullMethodName     = "/billing.BillingService/RequestWithdrawal"
+	BillingService_ApproveWithdrawal_FullMethodName     = "/billing.BillingService/ApproveWithdrawal"
+	BillingService_RejectWithdrawal_FullMethodName      = "/billing.BillingService/RejectWithdrawal"
+	BillingService_GetWithdrawal_FullMethodName         = "/billing.BillingService/GetWithdrawal"
+	BillingService_ListWithdrawals_FullMethodName       = "/billing.BillingService/ListWithdrawals"
+	BillingService_GetMarginAccount_FullMethodName      = "/billing.BillingService/GetMarginAccount"
+	BillingService_SetMarginEnabled_FullMethodName      = "/billing.BillingService/SetMarginEnabled"
+	BillingService_BorrowMargin_FullMethodName          = "/billing.BillingService/BorrowMargin"
+	BillingService_RepayMarginLoan_FullMethodName       = "/billing.BillingService/RepayMarginLoan"
+	BillingService_RestoreMarginLoan_FullMethodName     = "/billing.BillingService/RestoreMarginLoan"
+	BillingService_GetLedger_FullMethodName             = "/billing.BillingService/GetLedger"
+	BillingService_ListTransactions_FullMethodName      = "/billing.BillingService/ListTransactions"
+	BillingService_GenerateStatement_FullMethodName     = "/billing.BillingService/GenerateStatement"
 )
 
 // BillingServiceClient is the client API for BillingService service.
@@ -33,10 +50,40 @@ type BillingServiceClient interface {
 	// Existing RPCs
 	CalculateCommission(ctx context.Context, in *CalculateCommissionRequest, opts ...grpc.CallOption) (*CalculateCommissionResponse, error)
 	ProcessPayment(ctx context.Context, in *ProcessPaymentRequest, opts ...grpc.CallOption) (*ProcessPaymentResponse, error)
+	// Returns a captured payment (in full or in part) to the payer and takes it out of the wallet.
+	RefundPayment(ctx context.Context, in *RefundPaymentRequest, opts ...grpc.CallOption) (*RefundPaymentResponse, error)
 	// New RPCs for wallet
+	// Users top up by paying a gateway order; the wallet is credited once the capture is verified.
+	InitiateDeposit(ctx context.Context, in *InitiateDepositRequest, opts ...grpc.CallOption) (*InitiateDepositResponse, error)
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	// Admin-only direct credit (trade corrections, dividends, manual adjustments), audited.
 	DepositFunds(ctx context.Context, in *DepositFundsRequest, opts ...grpc.CallOption) (*DepositFundsResponse, error)
+	ListWalletAdjustments(ctx context.Context, in *ListWalletAdjustmentsRequest, opts ...grpc.CallOption) (*ListWalletAdjustmentsResponse, error)
+	// Immediate debits for trade settlement; bank withdrawals go through RequestWithdrawal.
 	WithdrawFunds(ctx context.Context, in *WithdrawFundsRequest, opts ...grpc.CallOption) (*WithdrawFundsResponse, error)
 	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error)
+	// Withdrawals to the user's bank: the amount is held on request, reviewed by an
+	// admin above a threshold, then paid out through the payout gateway or returned.
+	RequestWithdrawal(ctx context.Context, in *RequestWithdrawalRequest, opts ...grpc.CallOption) (*Withdrawal, error)
+	ApproveWithdrawal(ctx context.Context, in *ReviewWithdrawalRequest, opts ...grpc.CallOption) (*Withdrawal, error)
+	RejectWithdrawal(ctx context.Context, in *ReviewWithdrawalRequest, opts ...grpc.CallOption) (*Withdrawal, error)
+	GetWithdrawal(ctx context.Context, in *GetWithdrawalRequest, opts ...grpc.CallOption) (*Withdrawal, error)
+	ListWithdrawals(ctx context.Context, in *ListWithdrawalsRequest, opts ...grpc.CallOption) (*ListWithdrawalsResponse, error)
+	// Margin accounts: cash lent against positions, valued against live quotes,
+	// with interest, margin calls and automatic liquidation below maintenance.
+	GetMarginAccount(ctx context.Context, in *GetMarginAccountRequest, opts ...grpc.CallOption) (*MarginAccountResponse, error)
+	SetMarginEnabled(ctx context.Context, in *SetMarginEnabledRequest, opts ...grpc.CallOption) (*MarginAccountResponse, error)
+	// Pays for a purchase from the wallet and lends what it can't cover, within buying power (used by the Trade Service).
+	BorrowMargin(ctx context.Context, in *BorrowMarginRequest, opts ...grpc.CallOption) (*BorrowMarginResponse, error)
+	RepayMarginLoan(ctx context.Context, in *RepayMarginLoanRequest, opts ...grpc.CallOption) (*RepayMarginLoanResponse, error)
+	// Puts back a sale's repayment when the sale is busted (used by the Trade Service).
+	RestoreMarginLoan(ctx context.Context, in *RestoreMarginLoanRequest, opts ...grpc.CallOption) (*RestoreMarginLoanResponse, error)
+	// Double-entry ledger: journal entries for a user's cash account (or any account),
+	// with the ledger-derived balance reconciled against the wallet.
+	GetLedger(ctx context.Context, in *GetLedgerRequest, opts ...grpc.CallOption) (*GetLedgerResponse, error)
+	// Transaction history and account statements
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	GenerateStatement(ctx context.Context, in *GenerateStatementRequest, opts ...grpc.CallOption) (*StatementResponse, error)
 }
 
 type billingServiceClient struct {
@@ -67,6 +114,36 @@ func (c *billingServiceClient) ProcessPayment(ctx context.Context, in *ProcessPa
 	return out, nil
 }
 
+func (c *billingServiceClient) RefundPayment(ctx context.Context, in *RefundPaymentRequest, opts ...grpc.CallOption) (*RefundPaymentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefundPaymentResponse)
+	err := c.cc.Invoke(ctx, BillingService_RefundPayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) InitiateDeposit(ctx context.Context, in *InitiateDepositRequest, opts ...grpc.CallOption) (*InitiateDepositResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InitiateDepositResponse)
+	err := c.cc.Invoke(ctx, BillingService_InitiateDeposit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, BillingService_GetTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *billingServiceClient) DepositFunds(ctx context.Context, in *DepositFundsRequest, opts ...grpc.CallOption) (*DepositFundsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DepositFundsResponse)
@@ -77,6 +154,16 @@ func (c *billingServiceClient) DepositFunds(ctx context.Context, in *DepositFund
 	return out, nil
 }
 
+func (c *billingServiceClient) ListWalletAdjustments(ctx context.Context, in *ListWalletAdjustmentsRequest, opts ...grpc.CallOption) (*ListWalletAdjustmentsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWalletAdjustmentsResponse)
+	err := c.cc.Invoke(ctx, BillingService_ListWalletAdjustments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *billingServiceClient) WithdrawFunds(ctx context.Context, in *WithdrawFundsRequest, opts ...grpc.CallOption) (*WithdrawFundsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(WithdrawFundsResponse)
@@ -97,6 +184,136 @@ func (c *billingServiceClient) GetBalance(ctx context.Context, in *GetBalanceReq
 	return out, nil
 }
 
+func (c *billingServiceClient) RequestWithdrawal(ctx context.Context, in *RequestWithdrawalRequest, opts ...grpc.CallOption) (*Withdrawal, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Withdrawal)
+	err := c.cc.Invoke(ctx, BillingService_RequestWithdrawal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) ApproveWithdrawal(ctx context.Context, in *ReviewWithdrawalRequest, opts ...grpc.CallOption) (*Withdrawal, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Withdrawal)
+	err := c.cc.Invoke(ctx, BillingService_ApproveWithdrawal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) RejectWithdrawal(ctx context.Context, in *ReviewWithdrawalRequest, opts ...grpc.CallOption) (*Withdrawal, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Withdrawal)
+	err := c.cc.Invoke(ctx, BillingService_RejectWithdrawal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) GetWithdrawal(ctx context.Context, in *GetWithdrawalRequest, opts ...grpc.CallOption) (*Withdrawal, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Withdrawal)
+	err := c.cc.Invoke(ctx, BillingService_GetWithdrawal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) ListWithdrawals(ctx context.Context, in *ListWithdrawalsRequest, opts ...grpc.CallOption) (*ListWithdrawalsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWithdrawalsResponse)
+	err := c.cc.Invoke(ctx, BillingService_ListWithdrawals_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) GetMarginAccount(ctx context.Context, in *GetMarginAccountRequest, opts ...grpc.CallOption) (*MarginAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MarginAccountResponse)
+	err := c.cc.Invoke(ctx, BillingService_GetMarginAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) SetMarginEnabled(ctx context.Context, in *SetMarginEnabledRequest, opts ...grpc.CallOption) (*MarginAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MarginAccountResponse)
+	err := c.cc.Invoke(ctx, BillingService_SetMarginEnabled_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) BorrowMargin(ctx context.Context, in *BorrowMarginRequest, opts ...grpc.CallOption) (*BorrowMarginResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BorrowMarginResponse)
+	err := c.cc.Invoke(ctx, BillingService_BorrowMargin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) RepayMarginLoan(ctx context.Context, in *RepayMarginLoanRequest, opts ...grpc.CallOption) (*RepayMarginLoanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RepayMarginLoanResponse)
+	err := c.cc.Invoke(ctx, BillingService_RepayMarginLoan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) RestoreMarginLoan(ctx context.Context, in *RestoreMarginLoanRequest, opts ...grpc.CallOption) (*RestoreMarginLoanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreMarginLoanResponse)
+	err := c.cc.Invoke(ctx, BillingService_RestoreMarginLoan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) GetLedger(ctx context.Context, in *GetLedgerRequest, opts ...grpc.CallOption) (*GetLedgerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLedgerResponse)
+	err := c.cc.Invoke(ctx, BillingService_GetLedger_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, BillingService_ListTransactions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) GenerateStatement(ctx context.Context, in *GenerateStatementRequest, opts ...grpc.CallOption) (*StatementResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatementResponse)
+	err := c.cc.Invoke(ctx, BillingService_GenerateStatement_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BillingServiceServer is the server API for BillingService service.
 // All implementations must embed UnimplementedBillingServiceServer
 // for forward compatibility.
@@ -104,10 +321,40 @@ type BillingServiceServer interface {
 	// Existing RPCs
 	CalculateCommission(context.Context, *CalculateCommissionRequest) (*CalculateCommissionResponse, error)
 	ProcessPayment(context.Context, *ProcessPaymentRequest) (*ProcessPaymentResponse, error)
+	// Returns a captured payment (in full or in part) to the payer and takes it out of the wallet.
+	RefundPayment(context.Context, *RefundPaymentRequest) (*RefundPaymentResponse, error)
 	// New RPCs for wallet
+	// Users top up by paying a gateway order; the wallet is credited once the capture is verified.
+	InitiateDeposit(context.Context, *InitiateDepositRequest) (*InitiateDepositResponse, error)
+	GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error)
+	// Admin-only direct credit (trade corrections, dividends, manual adjustments), audited.
 	DepositFunds(context.Context, *DepositFundsRequest) (*DepositFundsResponse, error)
+	ListWalletAdjustments(context.Context, *ListWalletAdjustmentsRequest) (*ListWalletAdjustmentsResponse, error)
+	// Immediate debits for trade settlement; bank withdrawals go through RequestWithdrawal.
 	WithdrawFunds(context.Context, *WithdrawFundsRequest) (*WithdrawFundsResponse, error)
 	GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error)
+	// Withdrawals to the user's bank: the amount is held on request, reviewed by an
+	// admin above a threshold, then paid out through the payout gateway or returned.
+	RequestWithdrawal(context.Context, *RequestWithdrawalRequest) (*Withdrawal, error)
+	ApproveWithdrawal(context.Context, *ReviewWithdrawalRequest) (*Withdrawal, error)
+	RejectWithdrawal(context.Context, *ReviewWithdrawalRequest) (*Withdrawal, error)
+	GetWithdrawal(context.Context, *GetWithdrawalRequest) (*Withdrawal, error)
+	ListWithdrawals(context.Context, *ListWithdrawalsRequest) (*ListWithdrawalsResponse, error)
+	// Margin accounts: cash lent against positions, valued against live quotes,
+	// with interest, margin calls and automatic liquidation below maintenance.
+	GetMarginAccount(context.Context, *GetMarginAccountRequest) (*MarginAccountResponse, error)
+	SetMarginEnabled(context.Context, *SetMarginEnabledRequest) (*MarginAccountResponse, error)
+	// Pays for a purchase from the wallet and lends what it can't cover, within buying power (used by the Trade Service).
+	BorrowMargin(context.Context, *BorrowMarginRequest) (*BorrowMarginResponse, error)
+	RepayMarginLoan(context.Context, *RepayMarginLoanRequest) (*RepayMarginLoanResponse, error)
+	// Puts back a sale's repayment when the sale is busted (used by the Trade Service).
+	RestoreMarginLoan(context.Context, *RestoreMarginLoanRequest) (*RestoreMarginLoanResponse, error)
+	// Double-entry ledger: journal entries for a user's cash account (or any account),
+	// with the ledger-derived balance reconciled against the wallet.
+	GetLedger(context.Context, *GetLedgerRequest) (*GetLedgerResponse, error)
+	// Transaction history and account statements
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	GenerateStatement(context.Context, *GenerateStatementRequest) (*StatementResponse, error)
 	mustEmbedUnimplementedBillingServiceServer()
 }
 
@@ -124,15 +371,66 @@ func (UnimplementedBillingServiceServer) CalculateCommission(context.Context, *C
 func (UnimplementedBillingServiceServer) ProcessPayment(context.Context, *ProcessPaymentRequest) (*ProcessPaymentResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ProcessPayment not implemented")
 }
+func (UnimplementedBillingServiceServer) RefundPayment(context.Context, *RefundPaymentRequest) (*RefundPaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundPayment not implemented")
+}
+func (UnimplementedBillingServiceServer) InitiateDeposit(context.Context, *InitiateDepositRequest) (*InitiateDepositResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InitiateDeposit not implemented")
+}
+func (UnimplementedBillingServiceServer) GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
 func (UnimplementedBillingServiceServer) DepositFunds(context.Context, *DepositFundsRequest) (*DepositFundsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DepositFunds not implemented")
 }
+func (UnimplementedBillingServiceServer) ListWalletAdjustments(context.Context, *ListWalletAdjustmentsRequest) (*ListWalletAdjustmentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWalletAdjustments not implemented")
+}
 func (UnimplementedBillingServiceServer) WithdrawFunds(context.Context, *WithdrawFundsRequest) (*WithdrawFundsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method WithdrawFunds not implemented")
 }
 func (UnimplementedBillingServiceServer) GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
 }
+func (UnimplementedBillingServiceServer) RequestWithdrawal(context.Context, *RequestWithdrawalRequest) (*Withdrawal, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestWithdrawal not implemented")
+}
+func (UnimplementedBillingServiceServer) ApproveWithdrawal(context.Context, *ReviewWithdrawalRequest) (*Withdrawal, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveWithdrawal not implemented")
+}
+func (UnimplementedBillingServiceServer) RejectWithdrawal(context.Context, *ReviewWithdrawalRequest) (*Withdrawal, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectWithdrawal not implemented")
+}
+func (UnimplementedBillingServiceServer) GetWithdrawal(context.Context, *GetWithdrawalRequest) (*Withdrawal, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWithdrawal not implemented")
+}
+func (UnimplementedBillingServiceServer) ListWithdrawals(context.Context, *ListWithdrawalsRequest) (*ListWithdrawalsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWithdrawals not implemented")
+}
+func (UnimplementedBillingServiceServer) GetMarginAccount(context.Context, *GetMarginAccountRequest) (*MarginAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMarginAccount not implemented")
+}
+func (UnimplementedBillingServiceServer) SetMarginEnabled(context.Context, *SetMarginEnabledRequest) (*MarginAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMarginEnabled not implemented")
+}
+func (UnimplementedBillingServiceServer) BorrowMargin(context.Context, *BorrowMarginRequest) (*BorrowMarginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BorrowMargin not implemented")
+}
+func (UnimplementedBillingServiceServer) RepayMarginLoan(context.Context, *RepayMarginLoanRequest) (*RepayMarginLoanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RepayMarginLoan not implemented")
+}
+func (UnimplementedBillingServiceServer) RestoreMarginLoan(context.Context, *RestoreMarginLoanRequest) (*RestoreMarginLoanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreMarginLoan not implemented")
+}
+func (UnimplementedBillingServiceServer) GetLedger(context.Context, *GetLedgerRequest) (*GetLedgerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLedger not implemented")
+}
+func (UnimplementedBillingServiceServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedBillingServiceServer) GenerateStatement(context.Context, *GenerateStatementRequest) (*StatementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenerateStatement not implemented")
+}
 func (UnimplementedBillingServiceServer) mustEmbedUnimplementedBillingServiceServer() {}
 func (UnimplementedBillingServiceServer) testEmbeddedByValue()                        {}
 
@@ -190,6 +488,60 @@ func _BillingService_ProcessPayment_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BillingService_RefundPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).RefundPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_RefundPayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).RefundPayment(ctx, req.(*RefundPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_InitiateDeposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InitiateDepositRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).InitiateDeposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_InitiateDeposit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).InitiateDeposit(ctx, req.(*InitiateDepositRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _BillingService_DepositFunds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DepositFundsRequest)
 	if err := dec(in); err != nil {
@@ -208,6 +560,24 @@ func _BillingService_DepositFunds_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BillingService_ListWalletAdjustments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWalletAdjustmentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).ListWalletAdjustments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_ListWalletAdjustments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).ListWalletAdjustments(ctx, req.(*ListWalletAdjustmentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _BillingService_WithdrawFunds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(WithdrawFundsRequest)
 	if err := dec(in); err != nil {
@@ -244,6 +614,240 @@ func _BillingService_GetBalance_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BillingService_RequestWithdrawal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestWithdrawalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).RequestWithdrawal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_RequestWithdrawal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).RequestWithdrawal(ctx, req.(*RequestWithdrawalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_ApproveWithdrawal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReviewWithdrawalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).ApproveWithdrawal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_ApproveWithdrawal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).ApproveWithdrawal(ctx, req.(*ReviewWithdrawalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_RejectWithdrawal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReviewWithdrawalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).RejectWithdrawal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_RejectWithdrawal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).RejectWithdrawal(ctx, req.(*ReviewWithdrawalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_GetWithdrawal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWithdrawalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).GetWithdrawal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_GetWithdrawal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).GetWithdrawal(ctx, req.(*GetWithdrawalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_ListWithdrawals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWithdrawalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).ListWithdrawals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_ListWithdrawals_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).ListWithdrawals(ctx, req.(*ListWithdrawalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_GetMarginAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMarginAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).GetMarginAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_GetMarginAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).GetMarginAccount(ctx, req.(*GetMarginAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_SetMarginEnabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMarginEnabledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).SetMarginEnabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_SetMarginEnabled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).SetMarginEnabled(ctx, req.(*SetMarginEnabledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_BorrowMargin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BorrowMarginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).BorrowMargin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_BorrowMargin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).BorrowMargin(ctx, req.(*BorrowMarginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_RepayMarginLoan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepayMarginLoanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).RepayMarginLoan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_RepayMarginLoan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).RepayMarginLoan(ctx, req.(*RepayMarginLoanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_RestoreMarginLoan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreMarginLoanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).RestoreMarginLoan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_RestoreMarginLoan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).RestoreMarginLoan(ctx, req.(*RestoreMarginLoanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_GetLedger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLedgerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).GetLedger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_GetLedger_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).GetLedger(ctx, req.(*GetLedgerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_GenerateStatement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateStatementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).GenerateStatement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_GenerateStatement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).GenerateStatement(ctx, req.(*GenerateStatementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BillingService_ServiceDesc is the grpc.ServiceDesc for BillingService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -259,10 +863,26 @@ var BillingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ProcessPayment",
 			Handler:    _BillingService_ProcessPayment_Handler,
 		},
+		{
+			MethodName: "RefundPayment",
+			Handler:    _BillingService_RefundPayment_Handler,
+		},
+		{
+			MethodName: "InitiateDeposit",
+			Handler:    _BillingService_InitiateDeposit_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _BillingService_GetTransaction_Handler,
+		},
 		{
 			MethodName: "DepositFunds",
 			Handler:    _BillingService_DepositFunds_Handler,
 		},
+		{
+			MethodName: "ListWalletAdjustments",
+			Handler:    _BillingService_ListWalletAdjustments_Handler,
+		},
 		{
 			MethodName: "WithdrawFunds",
 			Handler:    _BillingService_WithdrawFunds_Handler,
@@ -271,6 +891,58 @@ var BillingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBalance",
 			Handler:    _BillingService_GetBalance_Handler,
 		},
+		{
+			MethodName: "RequestWithdrawal",
+			Handler:    _BillingService_RequestWithdrawal_Handler,
+		},
+		{
+			MethodName: "ApproveWithdrawal",
+			Handler:    _BillingService_ApproveWithdrawal_Handler,
+		},
+		{
+			MethodName: "RejectWithdrawal",
+			Handler:    _BillingService_RejectWithdrawal_Handler,
+		},
+		{
+			MethodName: "GetWithdrawal",
+			Handler:    _BillingService_GetWithdrawal_Handler,
+		},
+		{
+			MethodName: "ListWithdrawals",
+			Handler:    _BillingService_ListWithdrawals_Handler,
+		},
+		{
+			MethodName: "GetMarginAccount",
+			Handler:    _BillingService_GetMarginAccount_Handler,
+		},
+		{
+			MethodName: "SetMarginEnabled",
+			Handler:    _BillingService_SetMarginEnabled_Handler,
+		},
+		{
+			MethodName: "BorrowMargin",
+			Handler:    _BillingService_BorrowMargin_Handler,
+		},
+		{
+			MethodName: "RepayMarginLoan",
+			Handler:    _BillingService_RepayMarginLoan_Handler,
+		},
+		{
+			MethodName: "RestoreMarginLoan",
+			Handler:    _BillingService_RestoreMarginLoan_Handler,
+		},
+		{
+			MethodName: "GetLedger",
+			Handler:    _BillingService_GetLedger_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _BillingService_ListTransactions_Handler,
+		},
+		{
+			MethodName: "GenerateStatement",
+			Handler:    _BillingService_GenerateStatement_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "billing.proto",
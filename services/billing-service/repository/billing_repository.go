@@ -2,11 +2,15 @@ package repository
 
 import (
     "context"
-    
+    "errors"
+    "fmt"
+    "time"
 
     "github.com/ankan8/swapsync/backend/internal/config"
     "github.com/ankan8/swapsync/backend/services/billing-service/models"
     "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func InsertTransaction(tx *models.Transaction) error {
@@ -32,3 +36,227 @@ func GetTransactionsByUserID(userID string) ([]models.Transaction, error) {
     }
     return txs, nil
 }
+
+// ErrTransactionNotFound is returned when no transaction matches.
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+func findTransaction(filter bson.M) (*models.Transaction, error) {
+    coll := config.DB.Collection("transactions")
+    var t models.Transaction
+    err := coll.FindOne(context.Background(), filter).Decode(&t)
+    if err == mongo.ErrNoDocuments {
+        return nil, ErrTransactionNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+func GetTransaction(txID string) (*models.Transaction, error) {
+    return findTransaction(bson.M{"transaction_id": txID})
+}
+
+// GetTransactionByOrderID finds the transaction for a payment gateway order.
+func GetTransactionByOrderID(orderID string) (*models.Transaction, error) {
+    return findTransaction(bson.M{"gateway_order_id": orderID})
+}
+
+// TransitionTransaction moves a transaction from status `from` to `to`, setting
+// fields alongside. It reports false if the transaction wasn't in `from`, so of
+// several callers racing on the same payment exactly one wins.
+func TransitionTransaction(txID, from, to string, fields map[string]interface{}) (bool, error) {
+    coll := config.DB.Collection("transactions")
+    set := bson.M{"status": to, "updated_at": time.Now().Format(time.RFC3339)}
+    for k, v := range fields {
+        set[k] = v
+    }
+    res, err := coll.UpdateOne(context.Background(), bson.M{"transaction_id": txID, "status": from}, bson.M{"$set": set})
+    if err != nil {
+        return false, err
+    }
+    return res.ModifiedCount == 1, nil
+}
+
+// AdjustRefundedAmount adds delta to a captured payment's refunded amount and sets
+// its status to PARTIALLY_REFUNDED, REFUNDED or back to CAPTURED to match. A
+// positive delta is only applied if the total stays within the payment amount.
+func AdjustRefundedAmount(txID string, delta float64) (*models.Transaction, error) {
+    coll := config.DB.Collection("transactions")
+    refunded := bson.M{"$ifNull": bson.A{"$refunded_amount", 0}}
+    filter := bson.M{
+        "transaction_id": txID,
+        "status":         bson.M{"$in": bson.A{"CAPTURED", "PARTIALLY_REFUNDED", "REFUNDED"}},
+    }
+    if delta > 0 {
+        filter["$expr"] = bson.M{"$lte": bson.A{
+            bson.M{"$add": bson.A{refunded, delta}},
+            bson.M{"$add": bson.A{"$amount", 0.005}}, // float rounding
+        }}
+    }
+    update := mongo.Pipeline{
+        {{Key: "$set", Value: bson.M{
+            "refunded_amount": bson.M{"$add": bson.A{refunded, delta}},
+            "updated_at":      time.Now().Format(time.RFC3339),
+        }}},
+        {{Key: "$set", Value: bson.M{"status": bson.M{"$switch": bson.M{
+            "branches": bson.A{
+                bson.M{"case": bson.M{"$lte": bson.A{"$refunded_amount", 0.005}}, "then": "CAPTURED"},
+                bson.M{"case": bson.M{"$gte": bson.A{"$refunded_amount", bson.M{"$subtract": bson.A{"$amount", 0.005}}}}, "then": "REFUNDED"},
+            },
+            "default": "PARTIALLY_REFUNDED",
+        }}}}},
+    }
+    opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+    var t models.Transaction
+    err := coll.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&t)
+    if err == mongo.ErrNoDocuments {
+        return nil, fmt.Errorf("transaction %s is not refundable for %.2f", txID, delta)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+// SumCapturedDepositsSince totals a user's deposits captured since `since`, net
+// of refunds.
+func SumCapturedDepositsSince(userID string, since time.Time) (float64, error) {
+    coll := config.DB.Collection("transactions")
+    pipeline := mongo.Pipeline{
+        {{Key: "$match", Value: bson.M{
+            "user_id": userID,
+            "type":    "DEPOSIT",
+            "status":  bson.M{"$in": bson.A{"CAPTURED", "PARTIALLY_REFUNDED"}},
+        }}},
+        // captured_at is an RFC3339 string in whatever offset the writer ran with,
+        // so it is compared as an instant rather than as a string
+        {{Key: "$match", Value: bson.M{"$expr": bson.M{"$gte": bson.A{
+            bson.M{"$dateFromString": bson.M{"dateString": "$captured_at", "onError": nil, "onNull": nil}},
+            since.UTC(),
+        }}}}},
+        {{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": bson.M{"$subtract": bson.A{
+            "$amount", bson.M{"$ifNull": bson.A{"$refunded_amount", 0}},
+        }}}}}},
+    }
+    return sumAggregate(coll, pipeline)
+}
+
+// TransactionFilter narrows ListTransactions; empty fields match everything.
+type TransactionFilter struct {
+    UserID string
+    Type   string
+    Status string
+    From   time.Time // inclusive
+    To     time.Time // exclusive
+}
+
+// ListTransactions returns one page of matching transactions, newest first, and
+// the total number of matches.
+func ListTransactions(f TransactionFilter, offset, limit int64) ([]models.Transaction, int64, error) {
+    coll := config.DB.Collection("transactions")
+    filter := bson.M{"user_id": f.UserID}
+    if f.Type != "" {
+        filter["type"] = f.Type
+    }
+    if f.Status != "" {
+        filter["status"] = f.Status
+    }
+    // Timestamps are stored as local-time RFC3339 strings, which sort chronologically
+    ts := bson.M{}
+    if !f.From.IsZero() {
+        ts["$gte"] = f.From.Local().Format(time.RFC3339)
+    }
+    if !f.To.IsZero() {
+        ts["$lt"] = f.To.Local().Format(time.RFC3339)
+    }
+    if len(ts) > 0 {
+        filter["timestamp"] = ts
+    }
+
+    total, err := coll.CountDocuments(context.Background(), filter)
+    if err != nil {
+        return nil, 0, err
+    }
+    opts := options.Find().
+        SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "transaction_id", Value: 1}}).
+        SetSkip(offset).
+        SetLimit(limit)
+    cursor, err := coll.Find(context.Background(), filter, opts)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer cursor.Close(context.Background())
+
+    var txs []models.Transaction
+    for cursor.Next(context.Background()) {
+        var t models.Transaction
+        if err := cursor.Decode(&t); err != nil {
+            return nil, 0, err
+        }
+        txs = append(txs, t)
+    }
+    return txs, total, nil
+}
+
+// BackfillTransactionFields sets type and status on transactions recorded before
+// those fields existed (all of them were payments). Payments recorded as
+// SUCCEEDED predate the capture lifecycle and count as captured.
+func BackfillTransactionFields() error {
+    coll := config.DB.Collection("transactions")
+    ctx := context.Background()
+    if _, err := coll.UpdateMany(ctx, bson.M{"type": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"type": "PAYMENT"}}); err != nil {
+        return err
+    }
+    if _, err := coll.UpdateMany(ctx, bson.M{"status": "SUCCEEDED"}, bson.M{"$set": bson.M{"status": "CAPTURED"}}); err != nil {
+        return err
+    }
+    if _, err := coll.UpdateMany(ctx, bson.M{"status": bson.M{"$exists": false}, "success": true}, bson.M{"$set": bson.M{"status": "CAPTURED"}}); err != nil {
+        return err
+    }
+    _, err := coll.UpdateMany(ctx, bson.M{"status": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"status": "FAILED"}})
+    return err
+}
+
+// GetTradedVolumeSince sums the notional of a user's trades in the shared "trades"
+// collection since the given time, in the wallet currency. Busted trades never
+// happened and don't count.
+func GetTradedVolumeSince(userID string, since time.Time) (float64, error) {
+    coll := config.DB.Collection("trades")
+    pipeline := mongo.Pipeline{
+        {{Key: "$match", Value: bson.M{
+            "user_id": userID,
+            "status":  bson.M{"$ne": "BUSTED"},
+        }}},
+        // Timestamps are RFC3339 strings in the trade service's local offset,
+        // so they are compared as instants rather than as strings
+        {{Key: "$match", Value: bson.M{"$expr": bson.M{"$gte": bson.A{
+            bson.M{"$dateFromString": bson.M{"dateString": "$timestamp", "onError": nil, "onNull": nil}},
+            since.UTC(),
+        }}}}},
+        {{Key: "$group", Value: bson.M{
+            "_id":    nil,
+            // settlement_amount is in the wallet currency; older trades only have price*quantity
+            "volume": bson.M{"$sum": bson.M{"$ifNull": bson.A{
+                "$settlement_amount",
+                bson.M{"$multiply": bson.A{"$price", "$quantity"}},
+            }}},
+        }}},
+    }
+    cursor, err := coll.Aggregate(context.Background(), pipeline)
+    if err != nil {
+        return 0, err
+    }
+    defer cursor.Close(context.Background())
+
+    var result struct {
+        Volume float64 `bson:"volume"`
+    }
+    if cursor.Next(context.Background()) {
+        if err := cursor.Decode(&result); err != nil {
+            return 0, err
+        }
+    }
+    return result.Volume, cursor.Err()
+}
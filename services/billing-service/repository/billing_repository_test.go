@@ -0,0 +1,46 @@
+package repository
+
+import (
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/testmongo"
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+)
+
+func TestSumCapturedDepositsComparesInstants(t *testing.T) {
+    testmongo.Use(t)
+    now := time.Now()
+    since := now.Add(-time.Hour)
+    east, west := time.FixedZone("UTC+10", 10*3600), time.FixedZone("UTC-10", -10*3600)
+
+    deposits := []struct {
+        id         string
+        capturedAt string
+        amount     float64
+        refunded   float64
+        status     string
+    }{
+        // Within the hour, written in offsets that sort either side of `since` as strings
+        {"east", now.Add(-30 * time.Minute).In(east).Format(time.RFC3339), 100, 0, "CAPTURED"},
+        {"west", now.Add(-30 * time.Minute).In(west).Format(time.RFC3339), 50, 20, "PARTIALLY_REFUNDED"},
+        // Older than the hour, though as a +10:00 string it sorts after `since`
+        {"old", now.Add(-2 * time.Hour).In(east).Format(time.RFC3339), 1000, 0, "CAPTURED"},
+        {"refunded", now.In(east).Format(time.RFC3339), 70, 70, "REFUNDED"},
+        {"unparsable", "yesterday", 500, 0, "CAPTURED"},
+    }
+    for _, d := range deposits {
+        if err := InsertTransaction(&models.Transaction{TransactionID: d.id, UserID: "alice", Type: "DEPOSIT",
+            Status: d.status, Amount: d.amount, RefundedAmount: d.refunded, CapturedAt: d.capturedAt}); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    total, err := SumCapturedDepositsSince("alice", since)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if total != 130 {
+        t.Fatalf("total = %.2f, want 130 (100 + 50 - 20)", total)
+    }
+}
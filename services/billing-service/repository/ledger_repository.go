@@ -0,0 +1,114 @@
+package repository
+
+import (
+    "context"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ledgerCollection = "ledger_entries"
+
+// EnsureLedgerIndexes indexes entries by account and time for balance and audit queries.
+func EnsureLedgerIndexes() error {
+    coll := config.DB.Collection(ledgerCollection)
+    _, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+        {Keys: bson.D{{Key: "entry_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "postings.account", Value: 1}, {Key: "created_at", Value: 1}}},
+        {Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+    })
+    return err
+}
+
+func InsertJournalEntry(entry *models.JournalEntry) error {
+    _, err := config.DB.Collection(ledgerCollection).InsertOne(context.Background(), entry)
+    return err
+}
+
+// GetJournalEntries returns entries touching account (or all entries if empty) with
+// from <= created_at < to, newest first unless oldestFirst is set.
+func GetJournalEntries(account string, from, to time.Time, limit int64, oldestFirst bool) ([]models.JournalEntry, error) {
+    filter := bson.M{}
+    if account != "" {
+        filter["postings.account"] = account
+    }
+    created := bson.M{}
+    if !from.IsZero() {
+        created["$gte"] = from
+    }
+    if !to.IsZero() {
+        created["$lt"] = to
+    }
+    if len(created) > 0 {
+        filter["created_at"] = created
+    }
+    order := -1
+    if oldestFirst {
+        order = 1
+    }
+    opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: order}})
+    if limit > 0 {
+        opts.SetLimit(limit)
+    }
+    cursor, err := config.DB.Collection(ledgerCollection).Find(context.Background(), filter, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var entries []models.JournalEntry
+    for cursor.Next(context.Background()) {
+        var e models.JournalEntry
+        if err := cursor.Decode(&e); err != nil {
+            return nil, err
+        }
+        entries = append(entries, e)
+    }
+    return entries, nil
+}
+
+// AccountTotals sums the debits and credits posted to account before `before`
+// (zero = the whole history).
+func AccountTotals(account string, before time.Time) (debits, credits float64, err error) {
+    match := bson.M{"postings.account": account}
+    if !before.IsZero() {
+        match["created_at"] = bson.M{"$lt": before}
+    }
+    pipeline := mongo.Pipeline{
+        {{Key: "$match", Value: match}},
+        {{Key: "$unwind", Value: "$postings"}},
+        {{Key: "$match", Value: bson.M{"postings.account": account}}},
+        {{Key: "$group", Value: bson.M{
+            "_id":     nil,
+            "debits":  bson.M{"$sum": "$postings.debit"},
+            "credits": bson.M{"$sum": "$postings.credit"},
+        }}},
+    }
+    cursor, err := config.DB.Collection(ledgerCollection).Aggregate(context.Background(), pipeline)
+    if err != nil {
+        return 0, 0, err
+    }
+    defer cursor.Close(context.Background())
+
+    var result struct {
+        Debits  float64 `bson:"debits"`
+        Credits float64 `bson:"credits"`
+    }
+    if cursor.Next(context.Background()) {
+        if err := cursor.Decode(&result); err != nil {
+            return 0, 0, err
+        }
+    }
+    return result.Debits, result.Credits, nil
+}
+
+// HasJournalEntries reports whether any entry touches account.
+func HasJournalEntries(account string) (bool, error) {
+    n, err := config.DB.Collection(ledgerCollection).CountDocuments(context.Background(),
+        bson.M{"postings.account": account}, options.Count().SetLimit(1))
+    return n > 0, err
+}
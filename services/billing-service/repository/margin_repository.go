@@ -0,0 +1,197 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+    // ErrMarginAccountNotFound is returned when the user has never opened a margin account.
+    ErrMarginAccountNotFound = errors.New("margin account not found")
+    // ErrLoanChanged is returned when a repayment is larger than the outstanding loan.
+    ErrLoanChanged = errors.New("margin loan is smaller than the repayment")
+)
+
+// EnsureMarginIndexes makes user_id unique, keeps the monitor's scan of
+// accounts with a loan indexed and allows one margin lock per user.
+func EnsureMarginIndexes() error {
+    coll := config.DB.Collection("margin_accounts")
+    _, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+        {Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "loan", Value: 1}}},
+    })
+    if err != nil {
+        return err
+    }
+    _, err = config.DB.Collection("margin_locks").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+        Keys:    bson.D{{Key: "user_id", Value: 1}},
+        Options: options.Index().SetUnique(true),
+    })
+    return err
+}
+
+// AcquireMarginLock takes the user's margin lock for owner until ttl passes,
+// so one purchase at a time checks buying power and takes its loan. It returns
+// false while another owner holds an unexpired lock, the same way
+// AcquireWithdrawalLock does.
+func AcquireMarginLock(userID, owner string, ttl time.Duration) (bool, error) {
+    coll := config.DB.Collection("margin_locks")
+    now := time.Now().UTC()
+    _, err := coll.UpdateOne(context.Background(),
+        bson.M{"user_id": userID, "expires_at": bson.M{"$lt": now}},
+        bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(ttl)}},
+        options.Update().SetUpsert(true))
+    if mongo.IsDuplicateKeyError(err) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// ReleaseMarginLock drops the user's margin lock if owner still holds it.
+func ReleaseMarginLock(userID, owner string) error {
+    coll := config.DB.Collection("margin_locks")
+    _, err := coll.DeleteOne(context.Background(), bson.M{"user_id": userID, "owner": owner})
+    return err
+}
+
+func GetMarginAccount(userID string) (*models.MarginAccount, error) {
+    coll := config.DB.Collection("margin_accounts")
+    var a models.MarginAccount
+    err := coll.FindOne(context.Background(), bson.M{"user_id": userID}).Decode(&a)
+    if err == mongo.ErrNoDocuments {
+        return nil, ErrMarginAccountNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &a, nil
+}
+
+// SetMarginEnabled turns margin lending on or off for a user, opening the
+// account on first use. Disabling only succeeds while nothing is borrowed.
+func SetMarginEnabled(userID string, enabled bool) (*models.MarginAccount, error) {
+    coll := config.DB.Collection("margin_accounts")
+    now := time.Now().UTC()
+    filter := bson.M{"user_id": userID}
+    opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+    if enabled {
+        opts.SetUpsert(true)
+    } else {
+        filter["loan"] = bson.M{"$lte": 0}
+    }
+    update := bson.M{
+        "$set": bson.M{"enabled": enabled, "updated_at": now},
+        "$setOnInsert": bson.M{
+            "loan":                0.0,
+            "interest_charged":    0.0,
+            "interest_accrued_at": now,
+            "status":              "OK",
+            "created_at":          now,
+        },
+    }
+
+    var a models.MarginAccount
+    err := coll.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&a)
+    if err == mongo.ErrNoDocuments {
+        if _, getErr := GetMarginAccount(userID); getErr != nil {
+            return nil, getErr
+        }
+        return nil, errors.New("repay the margin loan before disabling margin")
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &a, nil
+}
+
+// AdjustMarginLoan adds delta to the loan (negative to repay) and returns the
+// account. A repayment never takes the loan below zero.
+func AdjustMarginLoan(userID string, delta float64) (*models.MarginAccount, error) {
+    coll := config.DB.Collection("margin_accounts")
+    filter := bson.M{"user_id": userID}
+    if delta < 0 {
+        filter["loan"] = bson.M{"$gte": -delta}
+    }
+    update := bson.M{
+        "$inc": bson.M{"loan": delta},
+        "$set": bson.M{"updated_at": time.Now().UTC()},
+    }
+    opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+    var a models.MarginAccount
+    err := coll.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&a)
+    if err == mongo.ErrNoDocuments {
+        if _, getErr := GetMarginAccount(userID); getErr != nil {
+            return nil, getErr
+        }
+        return nil, ErrLoanChanged
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &a, nil
+}
+
+// AccrueMarginInterest adds interest to the loan for the period ending at `to`
+// and stores the sub-cent remainder to carry into the next period. It only
+// applies if interest was last accrued at `from`, so a period is never charged
+// twice.
+func AccrueMarginInterest(userID string, from, to time.Time, interest, remainder float64) (bool, error) {
+    coll := config.DB.Collection("margin_accounts")
+    res, err := coll.UpdateOne(context.Background(),
+        bson.M{"user_id": userID, "interest_accrued_at": from},
+        bson.M{
+            "$inc": bson.M{"loan": interest, "interest_charged": interest},
+            "$set": bson.M{"interest_accrued_at": to, "interest_remainder": remainder, "updated_at": time.Now().UTC()},
+        })
+    if err != nil {
+        return false, err
+    }
+    return res.ModifiedCount > 0, nil
+}
+
+// SetMarginStatus records the monitor's verdict on an account.
+func SetMarginStatus(userID, status string, equity float64, fields map[string]interface{}) error {
+    coll := config.DB.Collection("margin_accounts")
+    now := time.Now().UTC()
+    set := bson.M{"status": status, "last_equity": equity, "last_checked_at": now, "updated_at": now}
+    for k, v := range fields {
+        set[k] = v
+    }
+    _, err := coll.UpdateOne(context.Background(), bson.M{"user_id": userID}, bson.M{"$set": set})
+    return err
+}
+
+// ListMonitoredMarginAccounts returns accounts that owe money or are not in good standing.
+func ListMonitoredMarginAccounts() ([]models.MarginAccount, error) {
+    coll := config.DB.Collection("margin_accounts")
+    filter := bson.M{"$or": []bson.M{
+        {"loan": bson.M{"$gt": 0}},
+        {"status": bson.M{"$ne": "OK"}},
+    }}
+    cursor, err := coll.Find(context.Background(), filter)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var out []models.MarginAccount
+    for cursor.Next(context.Background()) {
+        var a models.MarginAccount
+        if err := cursor.Decode(&a); err != nil {
+            return nil, err
+        }
+        out = append(out, a)
+    }
+    return out, nil
+}
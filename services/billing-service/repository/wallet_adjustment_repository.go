@@ -0,0 +1,55 @@
+package repository
+
+import (
+    "context"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func InsertWalletAdjustment(a *models.WalletAdjustment) error {
+    coll := config.DB.Collection("wallet_adjustments")
+    _, err := coll.InsertOne(context.Background(), a)
+    return err
+}
+
+// SetWalletAdjustmentStatus records whether the credit behind an adjustment went through.
+func SetWalletAdjustmentStatus(adjustmentID, status, errMsg string) error {
+    coll := config.DB.Collection("wallet_adjustments")
+    set := bson.M{"status": status}
+    if errMsg != "" {
+        set["error"] = errMsg
+    }
+    _, err := coll.UpdateOne(context.Background(), bson.M{"adjustment_id": adjustmentID}, bson.M{"$set": set})
+    return err
+}
+
+// ListWalletAdjustments returns a user's adjustments (all users if empty), newest first.
+func ListWalletAdjustments(userID string, limit int64) ([]models.WalletAdjustment, error) {
+    coll := config.DB.Collection("wallet_adjustments")
+    filter := bson.M{}
+    if userID != "" {
+        filter["user_id"] = userID
+    }
+    opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+    if limit > 0 {
+        opts.SetLimit(limit)
+    }
+    cursor, err := coll.Find(context.Background(), filter, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var out []models.WalletAdjustment
+    for cursor.Next(context.Background()) {
+        var a models.WalletAdjustment
+        if err := cursor.Decode(&a); err != nil {
+            return nil, err
+        }
+        out = append(out, a)
+    }
+    return out, nil
+}
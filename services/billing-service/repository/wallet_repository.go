@@ -2,12 +2,14 @@ package repository
 
 import (
     "context"
+    "errors"
     "fmt"
 
     "github.com/ankan8/swapsync/backend/internal/config"
     "github.com/ankan8/swapsync/backend/services/billing-service/models"
     "go.mongodb.org/mongo-driver/bson"
     "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // GetWallet fetches the wallet document for a user.
@@ -24,23 +26,125 @@ func GetWallet(userID string) (*models.Wallet, error) {
     return &w, nil
 }
 
-// CreateWallet initializes a new wallet with balance=0.
-func CreateWallet(userID string) error {
+var (
+    // ErrWalletNotFound is returned when debiting a user who has no wallet.
+    ErrWalletNotFound = errors.New("wallet not found")
+    // ErrInsufficientFunds is returned when a debit would overdraw the wallet.
+    ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// EnsureWalletIndexes makes user_id unique, so concurrent first deposits can't
+// create two wallets for one user.
+func EnsureWalletIndexes() error {
     coll := config.DB.Collection("wallets")
-    _, err := coll.InsertOne(context.Background(), models.Wallet{
-        UserID:  userID,
-        Balance: 0,
+    _, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+        Keys:    bson.D{{Key: "user_id", Value: 1}},
+        Options: options.Index().SetUnique(true),
     })
     return err
 }
 
-// UpdateWalletBalance sets the wallet's balance to newBalance.
-func UpdateWalletBalance(userID string, newBalance float64) error {
+// CreditWallet atomically adds amount to the user's balance, creating the wallet in
+// currency on first use, and returns the updated wallet.
+func CreditWallet(userID string, amount float64, currency string) (*models.Wallet, error) {
     coll := config.DB.Collection("wallets")
-    _, err := coll.UpdateOne(
-        context.Background(),
-        bson.M{"user_id": userID},
-        bson.M{"$set": bson.M{"balance": newBalance}},
-    )
-    return err
+    update := bson.M{
+        "$inc":         bson.M{"balance": amount},
+        "$setOnInsert": bson.M{"currency": currency},
+    }
+    opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+    var w models.Wallet
+    err := coll.FindOneAndUpdate(context.Background(), bson.M{"user_id": userID}, update, opts).Decode(&w)
+    if mongo.IsDuplicateKeyError(err) {
+        // Lost the race to create the wallet; it exists now, so the retry is a plain $inc
+        err = coll.FindOneAndUpdate(context.Background(), bson.M{"user_id": userID}, update, opts).Decode(&w)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &w, nil
+}
+
+// DebitWallet atomically subtracts amount if the balance covers it and returns the
+// updated wallet. The balance check and the update are one conditional $inc, so
+// concurrent debits can never overdraw the wallet.
+func DebitWallet(userID string, amount float64) (*models.Wallet, error) {
+    return DebitWalletKeeping(userID, amount, 0)
+}
+
+// DebitWalletKeeping is DebitWallet that also leaves at least keep in the
+// wallet, checked in the same conditional $inc.
+func DebitWalletKeeping(userID string, amount, keep float64) (*models.Wallet, error) {
+    coll := config.DB.Collection("wallets")
+    filter := bson.M{"user_id": userID, "balance": bson.M{"$gte": amount + keep}}
+    update := bson.M{"$inc": bson.M{"balance": -amount}}
+    opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+    var w models.Wallet
+    err := coll.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&w)
+    if err == mongo.ErrNoDocuments {
+        // Either there is no wallet or it doesn't hold enough
+        if _, getErr := GetWallet(userID); getErr != nil {
+            return nil, ErrWalletNotFound
+        }
+        return nil, ErrInsufficientFunds
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &w, nil
+}
+
+// DebitWalletUpTo atomically takes as much of amount as the balance covers and
+// returns how much it took with the updated wallet. A wallet that is empty (or
+// missing) gives 0 and is left as it is.
+func DebitWalletUpTo(userID string, amount float64) (float64, *models.Wallet, error) {
+    coll := config.DB.Collection("wallets")
+    update := mongo.Pipeline{
+        {{Key: "$set", Value: bson.M{"balance": bson.M{"$cond": bson.A{
+            bson.M{"$gte": bson.A{"$balance", amount}},
+            bson.M{"$subtract": bson.A{"$balance", amount}},
+            bson.M{"$min": bson.A{"$balance", 0}},
+        }}}}},
+    }
+    opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+    var w models.Wallet
+    err := coll.FindOneAndUpdate(context.Background(), bson.M{"user_id": userID}, update, opts).Decode(&w)
+    if err == mongo.ErrNoDocuments {
+        return 0, nil, nil
+    }
+    if err != nil {
+        return 0, nil, err
+    }
+    taken := amount
+    if w.Balance < amount {
+        taken = w.Balance
+        if taken < 0 {
+            taken = 0
+        }
+    }
+    w.Balance -= taken
+    return taken, &w, nil
+}
+
+// GetAllWallets returns every wallet, used for ledger migration and reconciliation.
+func GetAllWallets() ([]models.Wallet, error) {
+    coll := config.DB.Collection("wallets")
+    cursor, err := coll.Find(context.Background(), bson.M{})
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var wallets []models.Wallet
+    for cursor.Next(context.Background()) {
+        var w models.Wallet
+        if err := cursor.Decode(&w); err != nil {
+            return nil, err
+        }
+        wallets = append(wallets, w)
+    }
+    return wallets, nil
 }
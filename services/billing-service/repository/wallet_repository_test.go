@@ -0,0 +1,104 @@
+package repository
+
+import (
+    "context"
+    "sync"
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/internal/testmongo"
+    "go.mongodb.org/mongo-driver/bson"
+)
+
+func TestConcurrentFirstCreditsCreateOneWallet(t *testing.T) {
+    db := testmongo.Use(t)
+    if err := EnsureWalletIndexes(); err != nil {
+        t.Fatal(err)
+    }
+
+    const workers = 50
+    var wg sync.WaitGroup
+    errs := make(chan error, workers)
+    for i := 0; i < workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if _, err := CreditWallet("alice", 10, "USD"); err != nil {
+                errs <- err
+            }
+        }()
+    }
+    wg.Wait()
+    close(errs)
+    for err := range errs {
+        t.Fatalf("CreditWallet: %v", err)
+    }
+
+    n, err := db.Collection("wallets").CountDocuments(context.Background(), bson.M{"user_id": "alice"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n != 1 {
+        t.Fatalf("%d wallets for alice, want 1", n)
+    }
+    w, err := GetWallet("alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if w.Balance != workers*10 {
+        t.Fatalf("balance = %.2f, want %.2f", w.Balance, float64(workers*10))
+    }
+}
+
+func TestConcurrentDebitsNeverOverdraw(t *testing.T) {
+    testmongo.Use(t)
+    if err := EnsureWalletIndexes(); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := CreditWallet("alice", 100, "USD"); err != nil {
+        t.Fatal(err)
+    }
+
+    // 30 debits of 7 against 100: exactly 14 fit
+    const workers, amount = 30, 7.0
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    succeeded, rejected := 0, 0
+    for i := 0; i < workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            w, err := DebitWallet("alice", amount)
+            mu.Lock()
+            defer mu.Unlock()
+            switch {
+            case err == ErrInsufficientFunds:
+                rejected++
+            case err != nil:
+                t.Errorf("DebitWallet: %v", err)
+            case w.Balance < 0:
+                t.Errorf("balance went negative: %.2f", w.Balance)
+            default:
+                succeeded++
+            }
+        }()
+    }
+    wg.Wait()
+
+    if succeeded != 14 || rejected != workers-14 {
+        t.Fatalf("%d debits succeeded and %d were rejected, want 14 and %d", succeeded, rejected, workers-14)
+    }
+    w, err := GetWallet("alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if w.Balance != 2 {
+        t.Fatalf("balance = %.2f, want 2.00", w.Balance)
+    }
+}
+
+func TestDebitWithoutWallet(t *testing.T) {
+    testmongo.Use(t)
+    if _, err := DebitWallet("nobody", 1); err != ErrWalletNotFound {
+        t.Fatalf("err = %v, want ErrWalletNotFound", err)
+    }
+}
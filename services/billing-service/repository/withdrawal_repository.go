@@ -0,0 +1,200 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrWithdrawalNotFound is returned when no withdrawal matches.
+var ErrWithdrawalNotFound = errors.New("withdrawal not found")
+
+// EnsureWithdrawalIndexes makes withdrawal ids unique and keeps the worker's
+// by-status scans and the per-user daily limit lookups indexed.
+func EnsureWithdrawalIndexes() error {
+    coll := config.DB.Collection("withdrawals")
+    _, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+        {Keys: bson.D{{Key: "withdrawal_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "status", Value: 1}}},
+        {Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "requested_at", Value: -1}}},
+    })
+    if err != nil {
+        return err
+    }
+    _, err = config.DB.Collection("withdrawal_locks").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+        Keys:    bson.D{{Key: "user_id", Value: 1}},
+        Options: options.Index().SetUnique(true),
+    })
+    return err
+}
+
+// AcquireWithdrawalLock takes the user's withdrawal lock for owner until ttl
+// passes, so one request at a time checks the limits and places its hold. It
+// returns false while another owner holds an unexpired lock: the upsert only
+// matches an expired lock, and the unique user_id index rejects a second one.
+func AcquireWithdrawalLock(userID, owner string, ttl time.Duration) (bool, error) {
+    coll := config.DB.Collection("withdrawal_locks")
+    now := time.Now().UTC()
+    _, err := coll.UpdateOne(context.Background(),
+        bson.M{"user_id": userID, "expires_at": bson.M{"$lt": now}},
+        bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(ttl)}},
+        options.Update().SetUpsert(true))
+    if mongo.IsDuplicateKeyError(err) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// ReleaseWithdrawalLock drops the user's withdrawal lock if owner still holds it.
+func ReleaseWithdrawalLock(userID, owner string) error {
+    coll := config.DB.Collection("withdrawal_locks")
+    _, err := coll.DeleteOne(context.Background(), bson.M{"user_id": userID, "owner": owner})
+    return err
+}
+
+func InsertWithdrawal(w *models.Withdrawal) error {
+    coll := config.DB.Collection("withdrawals")
+    _, err := coll.InsertOne(context.Background(), w)
+    return err
+}
+
+func GetWithdrawal(withdrawalID string) (*models.Withdrawal, error) {
+    coll := config.DB.Collection("withdrawals")
+    var w models.Withdrawal
+    err := coll.FindOne(context.Background(), bson.M{"withdrawal_id": withdrawalID}).Decode(&w)
+    if err == mongo.ErrNoDocuments {
+        return nil, ErrWithdrawalNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &w, nil
+}
+
+// TransitionWithdrawal moves a withdrawal from status `from` to `to`, setting
+// fields and appending to its history. It returns the updated withdrawal, or
+// nil if it wasn't in `from` (another worker or reviewer got there first).
+func TransitionWithdrawal(withdrawalID, from, to, note string, fields map[string]interface{}) (*models.Withdrawal, error) {
+    coll := config.DB.Collection("withdrawals")
+    now := time.Now().UTC()
+    set := bson.M{"status": to, "updated_at": now}
+    for k, v := range fields {
+        set[k] = v
+    }
+    update := bson.M{
+        "$set":  set,
+        "$push": bson.M{"history": models.WithdrawalEvent{Status: to, At: now, Note: note}},
+    }
+    opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+    var w models.Withdrawal
+    err := coll.FindOneAndUpdate(context.Background(), bson.M{"withdrawal_id": withdrawalID, "status": from}, update, opts).Decode(&w)
+    if err == mongo.ErrNoDocuments {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &w, nil
+}
+
+// SetWithdrawalPayoutID records the gateway payout for a withdrawal still being processed.
+func SetWithdrawalPayoutID(withdrawalID, payoutID string) (bool, error) {
+    coll := config.DB.Collection("withdrawals")
+    res, err := coll.UpdateOne(context.Background(),
+        bson.M{"withdrawal_id": withdrawalID, "status": "PROCESSING"},
+        bson.M{"$set": bson.M{"payout_id": payoutID, "updated_at": time.Now().UTC()}})
+    if err != nil {
+        return false, err
+    }
+    return res.MatchedCount > 0, nil
+}
+
+// IncrementPayoutAttempts counts a failed payout submission and returns the new total.
+func IncrementPayoutAttempts(withdrawalID string) (int, error) {
+    coll := config.DB.Collection("withdrawals")
+    opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+    var w models.Withdrawal
+    err := coll.FindOneAndUpdate(context.Background(), bson.M{"withdrawal_id": withdrawalID},
+        bson.M{"$inc": bson.M{"payout_attempts": 1}}, opts).Decode(&w)
+    if err == mongo.ErrNoDocuments {
+        return 0, ErrWithdrawalNotFound
+    }
+    if err != nil {
+        return 0, err
+    }
+    return w.PayoutAttempts, nil
+}
+
+// ListWithdrawals returns withdrawals newest first, filtered by user and/or status.
+func ListWithdrawals(userID, status string, limit int64) ([]models.Withdrawal, error) {
+    coll := config.DB.Collection("withdrawals")
+    filter := bson.M{}
+    if userID != "" {
+        filter["user_id"] = userID
+    }
+    if status != "" {
+        filter["status"] = status
+    }
+    opts := options.Find().SetSort(bson.D{{Key: "requested_at", Value: -1}})
+    if limit > 0 {
+        opts.SetLimit(limit)
+    }
+    cursor, err := coll.Find(context.Background(), filter, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var out []models.Withdrawal
+    for cursor.Next(context.Background()) {
+        var w models.Withdrawal
+        if err := cursor.Decode(&w); err != nil {
+            return nil, err
+        }
+        out = append(out, w)
+    }
+    return out, nil
+}
+
+// SumWithdrawalsSince totals a user's withdrawals requested since `since`,
+// ignoring those whose money went back to the wallet.
+func SumWithdrawalsSince(userID string, since time.Time, excludeStatuses []string) (float64, error) {
+    coll := config.DB.Collection("withdrawals")
+    pipeline := mongo.Pipeline{
+        {{Key: "$match", Value: bson.M{
+            "user_id":      userID,
+            "requested_at": bson.M{"$gte": since},
+            "status":       bson.M{"$nin": excludeStatuses},
+        }}},
+        {{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}}},
+    }
+    return sumAggregate(coll, pipeline)
+}
+
+func sumAggregate(coll *mongo.Collection, pipeline mongo.Pipeline) (float64, error) {
+    cursor, err := coll.Aggregate(context.Background(), pipeline)
+    if err != nil {
+        return 0, err
+    }
+    defer cursor.Close(context.Background())
+
+    var result struct {
+        Total float64 `bson:"total"`
+    }
+    if cursor.Next(context.Background()) {
+        if err := cursor.Decode(&result); err != nil {
+            return 0, err
+        }
+    }
+    return result.Total, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/testmongo"
+)
+
+func TestWithdrawalLockIsExclusive(t *testing.T) {
+    testmongo.Use(t)
+    if err := EnsureWithdrawalIndexes(); err != nil {
+        t.Fatal(err)
+    }
+
+    if ok, err := AcquireWithdrawalLock("alice", "a", time.Minute); err != nil || !ok {
+        t.Fatalf("first acquire = %v, %v", ok, err)
+    }
+    if ok, err := AcquireWithdrawalLock("alice", "b", time.Minute); err != nil || ok {
+        t.Fatalf("acquire while held = %v, %v; want false", ok, err)
+    }
+    if ok, err := AcquireWithdrawalLock("bob", "b", time.Minute); err != nil || !ok {
+        t.Fatalf("another user's lock = %v, %v", ok, err)
+    }
+
+    // Only the owner can release it
+    if err := ReleaseWithdrawalLock("alice", "b"); err != nil {
+        t.Fatal(err)
+    }
+    if ok, _ := AcquireWithdrawalLock("alice", "b", time.Minute); ok {
+        t.Fatal("a non-owner released the lock")
+    }
+    if err := ReleaseWithdrawalLock("alice", "a"); err != nil {
+        t.Fatal(err)
+    }
+    if ok, err := AcquireWithdrawalLock("alice", "b", time.Millisecond); err != nil || !ok {
+        t.Fatalf("acquire after release = %v, %v", ok, err)
+    }
+
+    // An expired lock is taken over
+    time.Sleep(5 * time.Millisecond)
+    if ok, err := AcquireWithdrawalLock("alice", "c", time.Minute); err != nil || !ok {
+        t.Fatalf("acquire after expiry = %v, %v", ok, err)
+    }
+}
@@ -2,15 +2,18 @@ package service
 
 import (
     "context"
+    "errors"
     "fmt"
     "log"
     "os"
+    "strconv"
+    "strings"
     "time"
 
+    "github.com/ankan8/swapsync/backend/internal/middleware"
     "github.com/ankan8/swapsync/backend/services/billing-service/models"
     "github.com/ankan8/swapsync/backend/services/billing-service/repository"
     "github.com/google/uuid"
-    "github.com/razorpay/razorpay-go"
 
     notificationpb "github.com/ankan8/swapsync/backend/services/notification-service/proto"
     "google.golang.org/grpc"
@@ -22,178 +25,845 @@ import (
 // BillingServiceServer is the struct that implements all Billing gRPC methods.
 type BillingServiceServer struct {
     pb.UnimplementedBillingServiceServer
+
+    // FeeSchedule drives CalculateCommission; nil means DefaultFeeSchedule.
+    FeeSchedule *models.FeeSchedule
+
+    // Gateway collects payments; nil means an offline FakeGateway that always succeeds.
+    Gateway PaymentGateway
+
+    // Withdrawals runs bank withdrawals; nil means default limits and an offline
+    // FakePayoutGateway, with no background worker.
+    Withdrawals *WithdrawalService
+
+    // Margin lends against positions; nil means DefaultMarginConfig with no monitor.
+    Margin *MarginService
+}
+
+var (
+    defaultGateway     = NewFakeGateway(FakeGatewayConfig{})
+    defaultWithdrawals = NewWithdrawalService(DefaultWithdrawalConfig(), NewFakePayoutGateway(FakePayoutSuccess, 0))
+    defaultMargin      = NewMarginService(DefaultMarginConfig(), 0)
+)
+
+func (s *BillingServiceServer) gateway() PaymentGateway {
+    if s.Gateway == nil {
+        return defaultGateway
+    }
+    return s.Gateway
 }
 
+func (s *BillingServiceServer) withdrawals() *WithdrawalService {
+    if s.Withdrawals == nil {
+        return defaultWithdrawals
+    }
+    return s.Withdrawals
+}
+
+func (s *BillingServiceServer) margin() *MarginService {
+    if s.Margin == nil {
+        return defaultMargin
+    }
+    return s.Margin
+}
+
+// Transaction types and statuses stored on models.Transaction. A payment is
+// PENDING until the gateway confirms it; a CAPTURED deposit funds the wallet and
+// a CAPTURED payment (trade commission) goes to fee income. A payment with
+// method WALLET is taken from the user's wallet and never reaches the gateway.
+const (
+    TxTypePayment = "PAYMENT"
+    TxTypeDeposit = "DEPOSIT"
+
+    PaymentMethodWallet = "WALLET"
+
+    TxPending           = "PENDING"
+    TxCaptured          = "CAPTURED"
+    TxFailed            = "FAILED"
+    TxPartiallyRefunded = "PARTIALLY_REFUNDED"
+    TxRefunded          = "REFUNDED"
+)
+
+const (
+    defaultTransactionPageSize = 50
+    maxTransactionPageSize     = 500
+)
+
 // CalculateCommission implements the gRPC method for calculating commission.
+// The rate depends on the fill's liquidity flag, the asset class and the user's 30-day volume tier.
 func (s *BillingServiceServer) CalculateCommission(ctx context.Context, req *pb.CalculateCommissionRequest) (*pb.CalculateCommissionResponse, error) {
-    tradeAmount := req.GetTradeAmount()
-    commission := calculateCommission(tradeAmount)
-    return &pb.CalculateCommissionResponse{Commission: commission}, nil
+    // The tier reveals the user's 30-day volume, so it's only quoted to them (or a service)
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    schedule := s.FeeSchedule
+    if schedule == nil {
+        schedule = DefaultFeeSchedule()
+    }
+
+    breakdown, err := calculateCommission(schedule, userID, req.GetTradeAmount(),
+        req.GetLiquidity(), req.GetAssetClass(), req.GetSymbol())
+    if err != nil {
+        return nil, err
+    }
+    return &pb.CalculateCommissionResponse{
+        Commission: breakdown.Commission,
+        Breakdown: &pb.FeeBreakdown{
+            Liquidity:       breakdown.Liquidity,
+            AssetClass:      breakdown.AssetClass,
+            Tier:            breakdown.Tier,
+            ThirtyDayVolume: breakdown.ThirtyDayVolume,
+            BaseRate:        breakdown.BaseRate,
+            Discount:        breakdown.Discount,
+            AppliedRate:     breakdown.AppliedRate,
+            GrossFee:        breakdown.GrossFee,
+            MinFee:          breakdown.MinFee,
+            MaxFee:          breakdown.MaxFee,
+        },
+    }, nil
 }
 
 // ProcessPayment implements the gRPC method for processing payment.
+// A WALLET payment is debited from the caller's wallet straight away; any other
+// method opens a gateway order that is captured when the gateway reports it paid
+// (see WebhookHandler).
 func (s *BillingServiceServer) ProcessPayment(ctx context.Context, req *pb.ProcessPaymentRequest) (*pb.ProcessPaymentResponse, error) {
-    userID := req.GetUserId()
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
     amount := req.GetAmount()
     method := req.GetMethod()
 
-    success, txID, err := processPayment(userID, amount, method)
+    tx, err := processPayment(s.gateway(), TxTypePayment, userID, amount, method)
     if err != nil {
         return nil, err
     }
     return &pb.ProcessPaymentResponse{
-        Success:       success,
-        TransactionId: txID,
+        Success:       tx.Status != TxFailed,
+        TransactionId: tx.TransactionID,
+        Status:        tx.Status,
+        OrderId:       tx.GatewayOrderID,
     }, nil
 }
 
-// DepositFunds implements the gRPC method for depositing funds.
+// RefundPayment refunds a captured payment through the gateway that took it (admin only).
+func (s *BillingServiceServer) RefundPayment(ctx context.Context, req *pb.RefundPaymentRequest) (*pb.RefundPaymentResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    if req.GetTransactionId() == "" {
+        return nil, fmt.Errorf("transaction_id is required")
+    }
+    if req.GetAmount() < 0 {
+        return nil, fmt.Errorf("invalid refund amount")
+    }
+    tx, refundID, wallet, err := refundPayment(s.gateway(), req.GetTransactionId(), req.GetAmount())
+    if err != nil {
+        return &pb.RefundPaymentResponse{Success: false}, err
+    }
+    resp := &pb.RefundPaymentResponse{
+        Success:        true,
+        RefundId:       refundID,
+        Status:         tx.Status,
+        RefundedAmount: tx.RefundedAmount,
+    }
+    if wallet != nil {
+        resp.NewBalance = wallet.Balance
+    }
+    return resp, nil
+}
+
+// InitiateDeposit starts a wallet top-up: it opens a gateway order for the user to
+// pay, and the wallet is credited only once the gateway confirms the capture.
+func (s *BillingServiceServer) InitiateDeposit(ctx context.Context, req *pb.InitiateDepositRequest) (*pb.InitiateDepositResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    method := req.GetMethod()
+    if method == "" {
+        method = "GATEWAY"
+    }
+    tx, err := processPayment(s.gateway(), TxTypeDeposit, userID, req.GetAmount(), method)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.InitiateDepositResponse{
+        Success:       tx.Status != TxFailed,
+        TransactionId: tx.TransactionID,
+        OrderId:       tx.GatewayOrderID,
+        Status:        tx.Status,
+        Amount:        tx.Amount,
+        Currency:      WalletCurrency(),
+    }, nil
+}
+
+// GetTransaction returns one of the caller's payments, e.g. to poll a deposit
+// until it is captured. Admins may read anyone's.
+func (s *BillingServiceServer) GetTransaction(ctx context.Context, req *pb.GetTransactionRequest) (*pb.Transaction, error) {
+    t, err := repository.GetTransaction(req.GetTransactionId())
+    if err != nil {
+        return nil, err
+    }
+    if _, err := middleware.AuthorizeUser(ctx, t.UserID); err != nil {
+        return nil, err
+    }
+    return toPbTransaction(t), nil
+}
+
+// DepositFunds credits a wallet directly, with no payment behind it. It is an
+// admin-only adjustment: trade corrections, dividends and manual fixes use it,
+// while users top up through InitiateDeposit. Every call leaves an audit record.
 func (s *BillingServiceServer) DepositFunds(ctx context.Context, req *pb.DepositFundsRequest) (*pb.DepositFundsResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
     userID := req.GetUserId()
     amount := req.GetAmount()
 
     if amount <= 0 {
         return &pb.DepositFundsResponse{Success: false}, fmt.Errorf("invalid deposit amount")
     }
-
-    // Try to fetch wallet
-    wallet, err := walletRepo.GetWallet(userID)
-    if err != nil {
-        // If no wallet, create one
-        if err.Error() == fmt.Sprintf("no wallet found for user=%s", userID) {
-            if createErr := walletRepo.CreateWallet(userID); createErr != nil {
-                return nil, createErr
-            }
-            wallet = &models.Wallet{UserID: userID, Balance: 0}
-        } else {
-            return nil, err
-        }
+    purpose := strings.ToUpper(req.GetPurpose())
+    if purpose == "" {
+        purpose = PurposeAdjustment
+    }
+    if purpose == PurposeMarginLoan || purpose == PurposeWithdrawalReversal || purpose == PurposeFee {
+        return &pb.DepositFundsResponse{Success: false}, fmt.Errorf("purpose %s is booked by billing itself", purpose)
+    }
+    if purpose == PurposeAdjustment && req.GetReason() == "" {
+        return &pb.DepositFundsResponse{Success: false}, fmt.Errorf("a reason is required for a manual adjustment")
     }
 
-    newBalance := wallet.Balance + amount
-    if err := walletRepo.UpdateWalletBalance(userID, newBalance); err != nil {
-        return &pb.DepositFundsResponse{Success: false}, err
+    wallet, adj, err := AdjustWallet(userID, amount, purpose, req.GetReference(), req.GetReason(), middleware.CallerEmail(ctx))
+    if err != nil {
+        return &pb.DepositFundsResponse{Success: false, AdjustmentId: adj}, err
     }
 
     return &pb.DepositFundsResponse{
-        Success:    true,
-        NewBalance: newBalance,
+        Success:      true,
+        NewBalance:   wallet.Balance,
+        AdjustmentId: adj,
     }, nil
 }
 
+// ListWalletAdjustments returns the audit trail of direct wallet credits (admin only).
+func (s *BillingServiceServer) ListWalletAdjustments(ctx context.Context, req *pb.ListWalletAdjustmentsRequest) (*pb.ListWalletAdjustmentsResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    adjustments, err := repository.ListWalletAdjustments(req.GetUserId(), req.GetLimit())
+    if err != nil {
+        return nil, err
+    }
+    resp := &pb.ListWalletAdjustmentsResponse{}
+    for _, a := range adjustments {
+        resp.Adjustments = append(resp.Adjustments, &pb.WalletAdjustment{
+            AdjustmentId: a.AdjustmentID,
+            UserId:       a.UserID,
+            Amount:       a.Amount,
+            Purpose:      a.Purpose,
+            Reference:    a.Reference,
+            Reason:       a.Reason,
+            Admin:        a.Admin,
+            Status:       a.Status,
+            Error:        a.Error,
+            CreatedAt:    a.CreatedAt.Format(time.RFC3339),
+        })
+    }
+    return resp, nil
+}
+
 // WithdrawFunds implements the gRPC method for withdrawing funds.
+// The balance check and debit are a single conditional update, so concurrent
+// withdrawals can't overdraw the wallet. Money leaving for the user's bank goes
+// through RequestWithdrawal instead, which applies limits and review.
 func (s *BillingServiceServer) WithdrawFunds(ctx context.Context, req *pb.WithdrawFundsRequest) (*pb.WithdrawFundsResponse, error) {
-    userID := req.GetUserId()
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return &pb.WithdrawFundsResponse{Success: false}, err
+    }
     amount := req.GetAmount()
 
     if amount <= 0 {
         return &pb.WithdrawFundsResponse{Success: false}, fmt.Errorf("invalid withdraw amount")
     }
+    switch strings.ToUpper(req.GetPurpose()) {
+    case PurposeTrade:
+    case "":
+        return &pb.WithdrawFundsResponse{Success: false}, fmt.Errorf("withdrawals to a bank account must use RequestWithdrawal")
+    case PurposeMarginRepayment:
+        return &pb.WithdrawFundsResponse{Success: false}, fmt.Errorf("margin loans are repaid through RepayMarginLoan")
+    case PurposeFee:
+        return &pb.WithdrawFundsResponse{Success: false}, fmt.Errorf("commissions are paid through ProcessPayment")
+    }
 
-    wallet, err := walletRepo.GetWallet(userID)
+    wallet, err := DebitUser(userID, amount, req.GetPurpose(), req.GetReference())
     if err != nil {
         return &pb.WithdrawFundsResponse{Success: false}, err
     }
 
-    if wallet.Balance < amount {
-        return &pb.WithdrawFundsResponse{Success: false}, fmt.Errorf("insufficient funds")
+    return &pb.WithdrawFundsResponse{
+        Success:    true,
+        NewBalance: wallet.Balance,
+    }, nil
+}
+
+// RequestWithdrawal holds the amount and starts a withdrawal to the user's bank.
+func (s *BillingServiceServer) RequestWithdrawal(ctx context.Context, req *pb.RequestWithdrawalRequest) (*pb.Withdrawal, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
     }
+    wd, err := s.withdrawals().Request(userID, req.GetAmount(), req.GetDestination())
+    if err != nil {
+        return nil, err
+    }
+    return toPbWithdrawal(wd), nil
+}
 
-    newBalance := wallet.Balance - amount
-    if err := walletRepo.UpdateWalletBalance(userID, newBalance); err != nil {
-        return &pb.WithdrawFundsResponse{Success: false}, err
+// ApproveWithdrawal releases a withdrawal held for review (admin only).
+func (s *BillingServiceServer) ApproveWithdrawal(ctx context.Context, req *pb.ReviewWithdrawalRequest) (*pb.Withdrawal, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
     }
+    wd, err := s.withdrawals().Approve(req.GetWithdrawalId(), middleware.CallerEmail(ctx), req.GetNote())
+    if err != nil {
+        return nil, err
+    }
+    return toPbWithdrawal(wd), nil
+}
 
-    return &pb.WithdrawFundsResponse{
-        Success:     true,
-        NewBalance:  newBalance,
-    }, nil
+// RejectWithdrawal turns down a withdrawal held for review and returns the
+// money to the wallet (admin only).
+func (s *BillingServiceServer) RejectWithdrawal(ctx context.Context, req *pb.ReviewWithdrawalRequest) (*pb.Withdrawal, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    wd, err := s.withdrawals().Reject(req.GetWithdrawalId(), middleware.CallerEmail(ctx), req.GetNote())
+    if err != nil {
+        return nil, err
+    }
+    return toPbWithdrawal(wd), nil
+}
+
+// GetWithdrawal returns one of the caller's withdrawals; admins may read anyone's.
+func (s *BillingServiceServer) GetWithdrawal(ctx context.Context, req *pb.GetWithdrawalRequest) (*pb.Withdrawal, error) {
+    wd, err := repository.GetWithdrawal(req.GetWithdrawalId())
+    if err != nil {
+        return nil, err
+    }
+    if _, err := middleware.AuthorizeUser(ctx, wd.UserID); err != nil {
+        return nil, err
+    }
+    return toPbWithdrawal(wd), nil
+}
+
+// ListWithdrawals lists the caller's withdrawals. An admin may name any user,
+// or none to see everyone's (e.g. the review queue).
+func (s *BillingServiceServer) ListWithdrawals(ctx context.Context, req *pb.ListWithdrawalsRequest) (*pb.ListWithdrawalsResponse, error) {
+    userID := req.GetUserId()
+    if userID != "" || middleware.RequireRole(ctx, middleware.RoleAdmin) != nil {
+        var err error
+        if userID, err = middleware.AuthorizeUser(ctx, userID); err != nil {
+            return nil, err
+        }
+    }
+    withdrawals, err := repository.ListWithdrawals(userID, strings.ToUpper(req.GetStatus()), req.GetLimit())
+    if err != nil {
+        return nil, err
+    }
+    resp := &pb.ListWithdrawalsResponse{}
+    for i := range withdrawals {
+        resp.Withdrawals = append(resp.Withdrawals, toPbWithdrawal(&withdrawals[i]))
+    }
+    return resp, nil
+}
+
+// GetMarginAccount values the user's margin account against live quotes.
+func (s *BillingServiceServer) GetMarginAccount(ctx context.Context, req *pb.GetMarginAccountRequest) (*pb.MarginAccountResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    m := s.margin()
+    snap, err := m.Snapshot(userID)
+    if err != nil {
+        return nil, err
+    }
+    resp := toPbMarginAccount(m, snap)
+    if acct, err := repository.GetMarginAccount(userID); err == nil {
+        resp.Enabled = acct.Enabled
+        resp.InterestCharged = acct.InterestCharged
+    }
+    if req.GetSymbol() != "" {
+        resp.BuyingPower = snap.BuyingPower(m.RatesFor(req.GetSymbol()).InitialRate)
+    }
+    return resp, nil
+}
+
+// SetMarginEnabled opts the user in to (or out of) margin lending.
+func (s *BillingServiceServer) SetMarginEnabled(ctx context.Context, req *pb.SetMarginEnabledRequest) (*pb.MarginAccountResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    if _, err := s.margin().SetEnabled(userID, req.GetEnabled()); err != nil {
+        return nil, err
+    }
+    return s.GetMarginAccount(ctx, &pb.GetMarginAccountRequest{UserId: userID})
+}
+
+// BorrowMargin pays for a purchase from the wallet, lending the part the
+// wallet can't cover. Only services (the Trade Service, while settling a BUY)
+// may borrow, so a loan is always spent on the position that secures it.
+func (s *BillingServiceServer) BorrowMargin(ctx context.Context, req *pb.BorrowMarginRequest) (*pb.BorrowMarginResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    borrowed, wallet, err := s.margin().Borrow(req.GetUserId(), req.GetSymbol(), req.GetAmount(), req.GetReference())
+    if err != nil {
+        return nil, err
+    }
+    resp := &pb.BorrowMarginResponse{Borrowed: borrowed}
+    if wallet != nil {
+        resp.NewBalance = wallet.Balance
+    }
+    if acct, err := repository.GetMarginAccount(req.GetUserId()); err == nil {
+        resp.Loan = acct.Loan
+    }
+    return resp, nil
+}
+
+// RepayMarginLoan pays down the caller's loan from the wallet. Repayments from
+// sale proceeds, which no wallet money backs, are reserved for services.
+func (s *BillingServiceServer) RepayMarginLoan(ctx context.Context, req *pb.RepayMarginLoanRequest) (*pb.RepayMarginLoanResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    source := strings.ToUpper(req.GetSource())
+    if source == "" {
+        source = RepaySourceWallet
+    }
+    if source == RepaySourceSale {
+        if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+            return nil, err
+        }
+    }
+    repaid, acct, err := s.margin().Repay(userID, req.GetAmount(), source, req.GetReference())
+    if err != nil {
+        return nil, err
+    }
+    resp := &pb.RepayMarginLoanResponse{Repaid: repaid}
+    if acct != nil {
+        resp.Loan = acct.Loan
+    }
+    return resp, nil
+}
+
+// RestoreMarginLoan reinstates the part of a margin loan that a busted sale
+// repaid (admin only).
+func (s *BillingServiceServer) RestoreMarginLoan(ctx context.Context, req *pb.RestoreMarginLoanRequest) (*pb.RestoreMarginLoanResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    acct, err := s.margin().RestoreSaleRepayment(req.GetUserId(), req.GetAmount(), req.GetReference())
+    if err != nil {
+        return nil, err
+    }
+    return &pb.RestoreMarginLoanResponse{Restored: req.GetAmount(), Loan: acct.Loan}, nil
 }
 
 // GetBalance implements the gRPC method for retrieving wallet balance.
 func (s *BillingServiceServer) GetBalance(ctx context.Context, req *pb.GetBalanceRequest) (*pb.GetBalanceResponse, error) {
-    userID := req.GetUserId()
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return &pb.GetBalanceResponse{Success: false}, err
+    }
 
     wallet, err := walletRepo.GetWallet(userID)
     if err != nil {
         return &pb.GetBalanceResponse{Success: false}, err
     }
 
+    currency := wallet.Currency
+    if currency == "" {
+        currency = WalletCurrency()
+    }
     return &pb.GetBalanceResponse{
-        Success: true,
-        Balance: wallet.Balance,
+        Success:  true,
+        Balance:  wallet.Balance,
+        Currency: currency,
     }, nil
 }
 
-// calculateCommission is an internal helper for the logic of calculating trade commission.
-func calculateCommission(tradeAmount float64) float64 {
-    fmt.Printf("Received trade amount: %.2f\n", tradeAmount)
-    if tradeAmount <= 0 {
-        fmt.Println("Warning: Invalid trade amount received.")
-        return 0
+// GetLedger returns journal entries for an account along with its ledger-derived
+// balance. For a user's cash account the balance is reconciled against the wallet.
+// Users see their own cash account; any other account is for admins.
+func (s *BillingServiceServer) GetLedger(ctx context.Context, req *pb.GetLedgerRequest) (*pb.GetLedgerResponse, error) {
+    account := req.GetAccount()
+    userID := req.GetUserId()
+    if account == "" || account == UserCashAccount(middleware.CallerEmail(ctx)) {
+        var err error
+        if userID, err = middleware.AuthorizeUser(ctx, userID); err != nil {
+            return nil, err
+        }
+        if account == "" {
+            account = UserCashAccount(userID)
+        }
+    } else if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    from, err := parseOptionalTime(req.GetFrom())
+    if err != nil {
+        return nil, fmt.Errorf("invalid from: %v", err)
+    }
+    to, err := parseOptionalTime(req.GetTo())
+    if err != nil {
+        return nil, fmt.Errorf("invalid to: %v", err)
+    }
+
+    entries, err := repository.GetJournalEntries(account, from, to, req.GetLimit(), false)
+    if err != nil {
+        return nil, err
+    }
+    debits, credits, balance, err := AccountBalance(account)
+    if err != nil {
+        return nil, err
     }
-    commission := tradeAmount * 0.001
-    fmt.Printf("Calculated Commission: %.2f\n", commission)
-    return commission
+    resp := &pb.GetLedgerResponse{
+        Account:      account,
+        TotalDebits:  debits,
+        TotalCredits: credits,
+        Balance:      balance,
+        Reconciled:   true,
+    }
+    if req.GetAccount() == "" {
+        r, err := ReconcileWallet(userID)
+        if err != nil {
+            return nil, err
+        }
+        resp.WalletBalance = r.WalletBalance
+        resp.Reconciled = r.Reconciled()
+    }
+    for _, e := range entries {
+        entry := &pb.JournalEntry{
+            EntryId:     e.EntryID,
+            Type:        e.Type,
+            UserId:      e.UserID,
+            Reference:   e.Reference,
+            Description: e.Description,
+            Currency:    e.Currency,
+            CreatedAt:   e.CreatedAt.Format(time.RFC3339),
+        }
+        for _, p := range e.Postings {
+            entry.Postings = append(entry.Postings, &pb.LedgerPosting{Account: p.Account, Debit: p.Debit, Credit: p.Credit})
+        }
+        resp.Entries = append(resp.Entries, entry)
+    }
+    return resp, nil
 }
 
-// processPayment is an internal helper that creates a Razorpay order, stores a transaction, and notifies the user.
-func processPayment(userID string, amount float64, method string) (bool, string, error) {
-    if userID == "" || amount <= 0 || method == "" {
-        return false, "", fmt.Errorf("invalid payment details: userID=%s, amount=%.2f, method=%s",
-            userID, amount, method)
+// ListTransactions pages through a user's transactions, newest first, optionally
+// filtered by type, status and a [from, to) time range.
+func (s *BillingServiceServer) ListTransactions(ctx context.Context, req *pb.ListTransactionsRequest) (*pb.ListTransactionsResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    from, err := parseOptionalTime(req.GetFrom())
+    if err != nil {
+        return nil, fmt.Errorf("invalid from: %v", err)
+    }
+    to, err := parseOptionalTime(req.GetTo())
+    if err != nil {
+        return nil, fmt.Errorf("invalid to: %v", err)
+    }
+    pageSize := int64(req.GetPageSize())
+    if pageSize <= 0 {
+        pageSize = defaultTransactionPageSize
+    }
+    if pageSize > maxTransactionPageSize {
+        pageSize = maxTransactionPageSize
+    }
+    var offset int64
+    if token := req.GetPageToken(); token != "" {
+        offset, err = strconv.ParseInt(token, 10, 64)
+        if err != nil || offset < 0 {
+            return nil, fmt.Errorf("invalid page_token")
+        }
+    }
+
+    txs, total, err := repository.ListTransactions(repository.TransactionFilter{
+        UserID: userID,
+        Type:   strings.ToUpper(req.GetType()),
+        Status: strings.ToUpper(req.GetStatus()),
+        From:   from,
+        To:     to,
+    }, offset, pageSize)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list transactions: %v", err)
+    }
+
+    resp := &pb.ListTransactionsResponse{TotalCount: total}
+    for i := range txs {
+        resp.Transactions = append(resp.Transactions, toPbTransaction(&txs[i]))
+    }
+    if next := offset + int64(len(txs)); next < total {
+        resp.NextPageToken = strconv.FormatInt(next, 10)
+    }
+    return resp, nil
+}
+
+// GenerateStatement builds a user's statement for a month (or an explicit
+// from/to range) from the ledger, optionally rendered as CSV or PDF.
+func (s *BillingServiceServer) GenerateStatement(ctx context.Context, req *pb.GenerateStatementRequest) (*pb.StatementResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    from, to, err := ParseStatementPeriod(req.GetPeriod())
+    if err != nil {
+        return nil, err
+    }
+    if req.GetFrom() != "" {
+        if from, err = time.Parse(time.RFC3339, req.GetFrom()); err != nil {
+            return nil, fmt.Errorf("invalid from: %v", err)
+        }
+    }
+    if req.GetTo() != "" {
+        if to, err = time.Parse(time.RFC3339, req.GetTo()); err != nil {
+            return nil, fmt.Errorf("invalid to: %v", err)
+        }
     }
 
-    // Create a Razorpay client
-    keyID := os.Getenv("RAZORPAY_KEY_ID")
-    keySecret := os.Getenv("RAZORPAY_KEY_SECRET")
-    fmt.Printf("DEBUG: Using Razorpay Key ID=%s, Secret length=%d\n", keyID, len(keySecret))
+    st, err := GenerateStatement(userID, from, to)
+    if err != nil {
+        return nil, err
+    }
+    resp := &pb.StatementResponse{
+        UserId:         st.UserID,
+        Currency:       st.Currency,
+        From:           st.From.Format(time.RFC3339),
+        To:             st.To.Format(time.RFC3339),
+        OpeningBalance: st.Opening,
+        ClosingBalance: st.Closing,
+        TotalCredits:   st.TotalCredits,
+        TotalDebits:    st.TotalDebits,
+    }
+    for _, l := range st.Lines {
+        resp.Lines = append(resp.Lines, &pb.StatementLine{
+            Date:        l.Date.Format(time.RFC3339),
+            Type:        l.Type,
+            Description: l.Description,
+            Reference:   l.Reference,
+            Debit:       l.Debit,
+            Credit:      l.Credit,
+            Balance:     l.Balance,
+        })
+    }
 
-    client := razorpay.NewClient(keyID, keySecret)
+    switch strings.ToUpper(req.GetFormat()) {
+    case "":
+    case "CSV":
+        if resp.Content, err = st.CSV(); err != nil {
+            return nil, err
+        }
+        resp.ContentType = "text/csv"
+        resp.Filename = st.Filename("csv")
+    case "PDF":
+        if resp.Content, err = st.PDF(); err != nil {
+            return nil, err
+        }
+        resp.ContentType = "application/pdf"
+        resp.Filename = st.Filename("pdf")
+    default:
+        return nil, fmt.Errorf("unsupported statement format %q (use CSV or PDF)", req.GetFormat())
+    }
+    return resp, nil
+}
 
-    // Convert amount to paise if currency is INR
-    razorAmount := int64(amount * 100)
+func toPbTransaction(t *models.Transaction) *pb.Transaction {
+    return &pb.Transaction{
+        TransactionId:  t.TransactionID,
+        UserId:         t.UserID,
+        Amount:         t.Amount,
+        Method:         t.Method,
+        Type:           t.Type,
+        Status:         t.Status,
+        Timestamp:      t.Timestamp,
+        GatewayOrderId: t.GatewayOrderID,
+        PaymentId:      t.PaymentID,
+        RefundedAmount: t.RefundedAmount,
+        FailureReason:  t.FailureReason,
+    }
+}
 
-    // Create a Razorpay order
-    orderData := map[string]interface{}{
-        "amount":          razorAmount,
-        "currency":        "INR",
-        "receipt":         fmt.Sprintf("tx_%s", userID),
-        "payment_capture": 1,
+func toPbWithdrawal(w *models.Withdrawal) *pb.Withdrawal {
+    out := &pb.Withdrawal{
+        WithdrawalId:  w.WithdrawalID,
+        UserId:        w.UserID,
+        Amount:        w.Amount,
+        Currency:      w.Currency,
+        Destination:   w.Destination,
+        Status:        w.Status,
+        PayoutId:      w.PayoutID,
+        FailureReason: w.FailureReason,
+        ReviewedBy:    w.ReviewedBy,
+        ReviewNote:    w.ReviewNote,
+        RequestedAt:   w.RequestedAt.Format(time.RFC3339),
+        UpdatedAt:     w.UpdatedAt.Format(time.RFC3339),
+    }
+    for _, e := range w.History {
+        out.History = append(out.History, &pb.WithdrawalEvent{Status: e.Status, At: e.At.Format(time.RFC3339), Note: e.Note})
     }
-    order, err := client.Order.Create(orderData, nil)
+    return out
+}
+
+func toPbMarginAccount(m *MarginService, snap *MarginSnapshot) *pb.MarginAccountResponse {
+    resp := &pb.MarginAccountResponse{
+        UserId:                 snap.UserID,
+        Status:                 snap.Status,
+        Currency:               snap.Currency,
+        Cash:                   snap.Cash,
+        Loan:                   snap.Loan,
+        MarketValue:            snap.MarketValue,
+        Equity:                 snap.Equity,
+        InitialRequirement:     snap.InitialRequirement,
+        MaintenanceRequirement: snap.MaintenanceRequirement,
+        CallLevel:              snap.CallLevel,
+        ExcessEquity:           snap.ExcessEquity(),
+        BuyingPower:            snap.BuyingPower(m.Config.InitialRate),
+        AnnualInterestRate:     m.Config.AnnualInterestRate,
+    }
+    for _, p := range snap.Positions {
+        resp.Positions = append(resp.Positions, &pb.MarginPosition{
+            Symbol:          p.Symbol,
+            Quantity:        p.Quantity,
+            Price:           p.Price,
+            MarketValue:     p.MarketValue,
+            InitialRate:     p.Rates.InitialRate,
+            MaintenanceRate: p.Rates.MaintenanceRate,
+            PriceError:      p.PriceError,
+        })
+    }
+    return resp
+}
+
+func parseOptionalTime(v string) (time.Time, error) {
+    if v == "" {
+        return time.Time{}, nil
+    }
+    return time.Parse(time.RFC3339, v)
+}
+
+// WalletCurrency is the currency new wallets are held in: WALLET_CURRENCY, default INR.
+// Trade-service converts trade notional into it at execution.
+func WalletCurrency() string {
+    if v := os.Getenv("WALLET_CURRENCY"); v != "" {
+        return strings.ToUpper(v)
+    }
+    return "INR"
+}
+
+// calculateCommission is an internal helper for the logic of calculating trade commission.
+// It looks up the user's trailing 30-day traded volume and applies the fee schedule.
+func calculateCommission(schedule *models.FeeSchedule, userID string, tradeAmount float64, liquidity, assetClass, symbol string) (*models.FeeBreakdown, error) {
+    if tradeAmount <= 0 {
+        log.Printf("Invalid trade amount %.2f for user=%s\n", tradeAmount, userID)
+        return &models.FeeBreakdown{Liquidity: liquidity, AssetClass: assetClass}, nil
+    }
+
+    volume := 0.0
+    if userID != "" {
+        v, err := repository.GetTradedVolumeSince(userID, time.Now().AddDate(0, 0, -30))
+        if err != nil {
+            // Fall back to the base tier rather than failing the trade.
+            log.Printf("Failed to load 30-day volume for user=%s: %v\n", userID, err)
+        } else {
+            volume = v
+        }
+    }
+
+    breakdown, err := computeFee(schedule, tradeAmount, volume, liquidity, assetClass, symbol)
     if err != nil {
-        return false, "", fmt.Errorf("failed to create Razorpay order: %v", err)
+        return nil, err
+    }
+    return breakdown, nil
+}
+
+// processPayment is an internal helper that opens a gateway order and stores a PENDING transaction
+// of txType. A declined payment is recorded as FAILED. If the gateway reports the order paid
+// straight away it is captured here; otherwise capture happens when the webhook arrives.
+// A WALLET payment skips the gateway and is captured from the wallet (see payFromWallet).
+func processPayment(gateway PaymentGateway, txType, userID string, amount float64, method string) (*models.Transaction, error) {
+    if userID == "" || amount <= 0 || method == "" {
+        return nil, fmt.Errorf("invalid payment details: userID=%s, amount=%.2f, method=%s",
+            userID, amount, method)
     }
-    razorOrderID := order["id"].(string)
-    fmt.Printf("Razorpay Order Created: %s\n", razorOrderID)
 
     txID := uuid.NewString()
-    success := true
+    if strings.EqualFold(method, PaymentMethodWallet) {
+        if txType != TxTypePayment {
+            return nil, fmt.Errorf("a deposit can't be paid from the wallet")
+        }
+        return payFromWallet(&models.Transaction{
+            TransactionID: txID,
+            UserID:        userID,
+            Amount:        amount,
+            Method:        PaymentMethodWallet,
+            Type:          txType,
+            Status:        TxPending,
+            Timestamp:     time.Now().Format(time.RFC3339),
+        })
+    }
+    order, err := gateway.CreateOrder(amount, WalletCurrency(), txID)
+    if err != nil && !errors.Is(err, ErrPaymentDeclined) {
+        return nil, err
+    }
 
     tx := &models.Transaction{
         TransactionID: txID,
         UserID:        userID,
         Amount:        amount,
         Method:        method,
+        Type:          txType,
+        Status:        TxPending,
         Timestamp:     time.Now().Format(time.RFC3339),
-        Success:       success,
+    }
+    if err != nil {
+        tx.Status = TxFailed
+        tx.FailureReason = err.Error()
+        log.Printf("Payment of %.2f for user=%s declined\n", amount, userID)
+    } else {
+        tx.GatewayOrderID = order.OrderID
+        log.Printf("%s order %s created for transaction %s\n", gateway.Name(), order.OrderID, txID)
     }
 
     err = repository.InsertTransaction(tx)
     if err != nil {
-        fmt.Printf("Error: Failed to record transaction: %v\n", err)
-        return false, "", fmt.Errorf("failed to record transaction: %v", err)
+        return nil, fmt.Errorf("failed to record transaction: %v", err)
     }
-    fmt.Printf("Transaction Recorded Successfully: %+v\n", tx)
-
-    notifyUserBilling(userID, amount, method)
+    log.Printf("Transaction %s recorded (%s, %s)\n", tx.TransactionID, tx.Type, tx.Status)
 
-    return success, txID, nil
+    if tx.Status == TxFailed {
+        notifyUserBilling(userID, fmt.Sprintf("Your payment of %.2f via %s was declined.", amount, method))
+        return tx, nil
+    }
+    if order.Status == OrderPaid {
+        if err := capturePayment(tx, order.PaymentID); err != nil {
+            // Left PENDING; the gateway's webhook will retry the capture
+            log.Printf("Error capturing payment %s: %v\n", txID, err)
+        }
+    }
+    return tx, nil
 }
 
-// notifyUserBilling sends a notification to the user about a successful payment.
-func notifyUserBilling(userID string, amount float64, method string) {
+// notifyUserBilling sends the user a notification about one of their payments.
+func notifyUserBilling(userID, message string) {
     conn, err := grpc.Dial("localhost:50056", grpc.WithInsecure())
     if err != nil {
         log.Printf("Error dialing Notification Service: %v\n", err)
@@ -203,8 +873,6 @@ func notifyUserBilling(userID string, amount float64, method string) {
 
     notifClient := notificationpb.NewNotificationServiceClient(conn)
 
-    message := fmt.Sprintf("Your payment of %.2f via %s was processed successfully!", amount, method)
-
     // Call SendNotification
     _, err = notifClient.SendNotification(context.Background(), &notificationpb.SendNotificationRequest{
         UserId:  userID,
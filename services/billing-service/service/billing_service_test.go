@@ -0,0 +1,130 @@
+package service
+
+import (
+    "context"
+    "strings"
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/internal/middleware"
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    pb "github.com/ankan8/swapsync/backend/services/billing-service/proto"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+)
+
+// callerContext returns the context a handler sees when token passes the JWT interceptor.
+func callerContext(t *testing.T, token string, err error) context.Context {
+    t.Helper()
+    if err != nil {
+        t.Fatal(err)
+    }
+    in := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", token))
+    var ctx context.Context
+    _, err = middleware.UnaryJWTInterceptor(in, nil, &grpc.UnaryServerInfo{FullMethod: "/billing.BillingService/Test"},
+        func(c context.Context, _ interface{}) (interface{}, error) {
+            ctx = c
+            return nil, nil
+        })
+    if err != nil {
+        t.Fatal(err)
+    }
+    return ctx
+}
+
+func asUser(t *testing.T, email string) context.Context {
+    t.Setenv("JWT_SECRET", "test-secret")
+    token, err := middleware.UserToken(email)
+    return callerContext(t, token, err)
+}
+
+func asAdmin(t *testing.T) context.Context {
+    t.Setenv("JWT_SECRET", "test-secret")
+    token, err := middleware.ServiceToken("billing-test")
+    return callerContext(t, token, err)
+}
+
+func assertDenied(t *testing.T, name string, err error) {
+    t.Helper()
+    if err == nil || !strings.Contains(err.Error(), "permission denied") {
+        t.Errorf("%s: err = %v, want permission denied", name, err)
+    }
+}
+
+func TestUserRPCsRejectAnotherUsersID(t *testing.T) {
+    ctx := asUser(t, "alice@example.com")
+    s := &BillingServiceServer{}
+    const bob = "bob@example.com"
+
+    _, err := s.ListTransactions(ctx, &pb.ListTransactionsRequest{UserId: bob})
+    assertDenied(t, "ListTransactions", err)
+    _, err = s.ListWithdrawals(ctx, &pb.ListWithdrawalsRequest{UserId: bob})
+    assertDenied(t, "ListWithdrawals", err)
+    _, err = s.GetLedger(ctx, &pb.GetLedgerRequest{UserId: bob})
+    assertDenied(t, "GetLedger", err)
+    _, err = s.GetLedger(ctx, &pb.GetLedgerRequest{Account: UserCashAccount(bob)})
+    assertDenied(t, "GetLedger by account", err)
+    _, err = s.GetLedger(ctx, &pb.GetLedgerRequest{Account: AccountFeeIncome})
+    assertDenied(t, "GetLedger of a platform account", err)
+    _, err = s.GenerateStatement(ctx, &pb.GenerateStatementRequest{UserId: bob, Period: "2024-01"})
+    assertDenied(t, "GenerateStatement", err)
+    _, err = s.SetMarginEnabled(ctx, &pb.SetMarginEnabledRequest{UserId: bob, Enabled: true})
+    assertDenied(t, "SetMarginEnabled", err)
+    _, err = s.GetMarginAccount(ctx, &pb.GetMarginAccountRequest{UserId: bob})
+    assertDenied(t, "GetMarginAccount", err)
+    _, err = s.RepayMarginLoan(ctx, &pb.RepayMarginLoanRequest{UserId: bob, Amount: 1})
+    assertDenied(t, "RepayMarginLoan", err)
+    _, err = s.GetBalance(ctx, &pb.GetBalanceRequest{UserId: bob})
+    assertDenied(t, "GetBalance", err)
+    _, err = s.WithdrawFunds(ctx, &pb.WithdrawFundsRequest{UserId: bob, Amount: 1, Purpose: PurposeTrade})
+    assertDenied(t, "WithdrawFunds", err)
+    _, err = s.RequestWithdrawal(ctx, &pb.RequestWithdrawalRequest{UserId: bob, Amount: 1})
+    assertDenied(t, "RequestWithdrawal", err)
+    _, err = s.InitiateDeposit(ctx, &pb.InitiateDepositRequest{UserId: bob, Amount: 1})
+    assertDenied(t, "InitiateDeposit", err)
+    _, err = s.ProcessPayment(ctx, &pb.ProcessPaymentRequest{UserId: bob, Amount: 1, Method: PaymentMethodWallet})
+    assertDenied(t, "ProcessPayment", err)
+    _, err = s.CalculateCommission(ctx, &pb.CalculateCommissionRequest{UserId: bob, TradeAmount: 1000})
+    assertDenied(t, "CalculateCommission", err)
+}
+
+func TestRPCsWithoutCallerAreRejected(t *testing.T) {
+    s := &BillingServiceServer{}
+    if _, err := s.ListTransactions(context.Background(), &pb.ListTransactionsRequest{UserId: "alice@example.com"}); err == nil {
+        t.Fatal("ListTransactions without a caller succeeded")
+    }
+}
+
+func TestRecordsAreReadableOnlyByTheirOwner(t *testing.T) {
+    useBillingDB(t)
+    const alice, bob = "alice@example.com", "bob@example.com"
+    if err := repository.InsertTransaction(&models.Transaction{TransactionID: "tx-1", UserID: alice, Amount: 5, Type: TxTypeDeposit, Status: TxPending}); err != nil {
+        t.Fatal(err)
+    }
+    if err := repository.InsertWithdrawal(&models.Withdrawal{WithdrawalID: "wd-1", UserID: alice, Amount: 5}); err != nil {
+        t.Fatal(err)
+    }
+    s := &BillingServiceServer{}
+
+    _, err := s.GetTransaction(asUser(t, bob), &pb.GetTransactionRequest{TransactionId: "tx-1"})
+    assertDenied(t, "GetTransaction by another user", err)
+    _, err = s.GetWithdrawal(asUser(t, bob), &pb.GetWithdrawalRequest{WithdrawalId: "wd-1"})
+    assertDenied(t, "GetWithdrawal by another user", err)
+
+    for name, ctx := range map[string]context.Context{"owner": asUser(t, alice), "admin": asAdmin(t)} {
+        if _, err := s.GetTransaction(ctx, &pb.GetTransactionRequest{TransactionId: "tx-1"}); err != nil {
+            t.Errorf("GetTransaction by %s: %v", name, err)
+        }
+        if _, err := s.GetWithdrawal(ctx, &pb.GetWithdrawalRequest{WithdrawalId: "wd-1"}); err != nil {
+            t.Errorf("GetWithdrawal by %s: %v", name, err)
+        }
+    }
+
+    // An admin naming no one sees every user's withdrawals; a user only their own
+    if resp, err := s.ListWithdrawals(asAdmin(t), &pb.ListWithdrawalsRequest{}); err != nil || len(resp.GetWithdrawals()) != 1 {
+        t.Errorf("ListWithdrawals by admin: %v, %v", resp, err)
+    }
+    if resp, err := s.ListWithdrawals(asUser(t, bob), &pb.ListWithdrawalsRequest{}); err != nil || len(resp.GetWithdrawals()) != 0 {
+        t.Errorf("ListWithdrawals by bob: %v, %v", resp, err)
+    }
+}
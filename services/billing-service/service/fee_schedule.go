@@ -0,0 +1,187 @@
+package service
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "sort"
+    "strings"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+)
+
+const (
+    LiquidityMaker = "MAKER"
+    LiquidityTaker = "TAKER"
+)
+
+// DefaultFeeSchedule is used when FEE_SCHEDULE_FILE is not set.
+// The taker rate matches the old flat 0.1% commission.
+func DefaultFeeSchedule() *models.FeeSchedule {
+    return &models.FeeSchedule{
+        FeeRates: models.FeeRates{
+            MakerRate: 0.0005,
+            TakerRate: 0.001,
+            MinFee:    0,
+            MaxFee:    0, // 0 = no cap
+        },
+        AssetClasses: map[string]models.FeeOverride{
+            "ETF":    {MakerRate: feeValue(0.0003), TakerRate: feeValue(0.0007)},
+            "CRYPTO": {MakerRate: feeValue(0.001), TakerRate: feeValue(0.002)},
+        },
+        DefaultAssetClass: "EQUITY",
+        Tiers: []models.VolumeTier{
+            {Name: "STANDARD", MinVolume: 0, Discount: 0},
+            {Name: "SILVER", MinVolume: 1000000, Discount: 0.10},
+            {Name: "GOLD", MinVolume: 10000000, Discount: 0.25},
+            {Name: "PLATINUM", MinVolume: 50000000, Discount: 0.40},
+        },
+    }
+}
+
+// LoadFeeSchedule reads the fee schedule from the JSON file in FEE_SCHEDULE_FILE,
+// falling back to DefaultFeeSchedule if the variable is unset.
+func LoadFeeSchedule() (*models.FeeSchedule, error) {
+    path := os.Getenv("FEE_SCHEDULE_FILE")
+    if path == "" {
+        log.Println("FEE_SCHEDULE_FILE not set, using default fee schedule")
+        return DefaultFeeSchedule(), nil
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read fee schedule %s: %v", path, err)
+    }
+    var schedule models.FeeSchedule
+    if err := json.Unmarshal(data, &schedule); err != nil {
+        return nil, fmt.Errorf("failed to parse fee schedule %s: %v", path, err)
+    }
+    if err := validateFeeRates("fee schedule", schedule.FeeRates); err != nil {
+        return nil, err
+    }
+    // computeFee looks asset classes up upper-cased
+    classes := make(map[string]models.FeeOverride, len(schedule.AssetClasses))
+    for class, override := range schedule.AssetClasses {
+        classes[strings.ToUpper(class)] = override
+    }
+    schedule.AssetClasses = classes
+    for class := range schedule.AssetClasses {
+        // Check the merged rates: an override can break the bounds on either side
+        if err := validateFeeRates("fee schedule asset class "+class, assetClassRates(&schedule, class)); err != nil {
+            return nil, err
+        }
+    }
+    for _, tier := range schedule.Tiers {
+        // A discount outside [0,1] would make the commission negative
+        if tier.Discount < 0 || tier.Discount > 1 {
+            return nil, fmt.Errorf("fee schedule tier %s: discount %.4f must be between 0 and 1", tier.Name, tier.Discount)
+        }
+    }
+    sort.Slice(schedule.Tiers, func(i, j int) bool {
+        return schedule.Tiers[i].MinVolume < schedule.Tiers[j].MinVolume
+    })
+    log.Printf("Loaded fee schedule from %s (%d tiers, %d asset class overrides)\n",
+        path, len(schedule.Tiers), len(schedule.AssetClasses))
+    return &schedule, nil
+}
+
+// validateFeeRates rejects rates and bounds that could produce a negative
+// commission, which would credit the user, or a min_fee above the max_fee.
+func validateFeeRates(name string, r models.FeeRates) error {
+    if r.MakerRate < 0 || r.TakerRate < 0 || r.MinFee < 0 || r.MaxFee < 0 {
+        return fmt.Errorf("%s: rates and fee bounds must not be negative", name)
+    }
+    if r.MaxFee > 0 && r.MinFee > r.MaxFee {
+        return fmt.Errorf("%s: min_fee %.4f exceeds max_fee %.4f", name, r.MinFee, r.MaxFee)
+    }
+    return nil
+}
+
+func feeValue(v float64) *float64 {
+    return &v
+}
+
+// assetClassRates merges an asset class's overrides onto the schedule defaults.
+func assetClassRates(schedule *models.FeeSchedule, assetClass string) models.FeeRates {
+    rates := schedule.FeeRates
+    override, ok := schedule.AssetClasses[assetClass]
+    if !ok {
+        return rates
+    }
+    if override.MakerRate != nil {
+        rates.MakerRate = *override.MakerRate
+    }
+    if override.TakerRate != nil {
+        rates.TakerRate = *override.TakerRate
+    }
+    if override.MinFee != nil {
+        rates.MinFee = *override.MinFee
+    }
+    if override.MaxFee != nil {
+        rates.MaxFee = *override.MaxFee
+    }
+    return rates
+}
+
+// computeFee applies the schedule to a single fill:
+// 1) pick maker/taker rate, overridden per asset class
+// 2) apply the volume tier discount for the trailing 30-day notional
+// 3) clamp to the min/max fee per trade
+func computeFee(schedule *models.FeeSchedule, tradeAmount, thirtyDayVolume float64, liquidity, assetClass, symbol string) (*models.FeeBreakdown, error) {
+    liquidity = strings.ToUpper(liquidity)
+    if liquidity == "" {
+        liquidity = LiquidityTaker
+    }
+    if liquidity != LiquidityMaker && liquidity != LiquidityTaker {
+        return nil, fmt.Errorf("invalid liquidity flag %q, expected MAKER or TAKER", liquidity)
+    }
+
+    if assetClass == "" {
+        assetClass = schedule.SymbolAssetClasses[symbol]
+    }
+    if assetClass == "" {
+        assetClass = schedule.DefaultAssetClass
+    }
+    assetClass = strings.ToUpper(assetClass)
+
+    rates := assetClassRates(schedule, assetClass)
+
+    baseRate := rates.TakerRate
+    if liquidity == LiquidityMaker {
+        baseRate = rates.MakerRate
+    }
+
+    tierName := ""
+    discount := 0.0
+    for _, tier := range schedule.Tiers {
+        if thirtyDayVolume >= tier.MinVolume {
+            tierName = tier.Name
+            discount = tier.Discount
+        }
+    }
+
+    appliedRate := baseRate * (1 - discount)
+    grossFee := tradeAmount * appliedRate
+    commission := grossFee
+    if commission < rates.MinFee {
+        commission = rates.MinFee
+    }
+    if rates.MaxFee > 0 && commission > rates.MaxFee {
+        commission = rates.MaxFee
+    }
+
+    return &models.FeeBreakdown{
+        Liquidity:       liquidity,
+        AssetClass:      assetClass,
+        Tier:            tierName,
+        ThirtyDayVolume: thirtyDayVolume,
+        BaseRate:        baseRate,
+        Discount:        discount,
+        AppliedRate:     appliedRate,
+        GrossFee:        grossFee,
+        MinFee:          rates.MinFee,
+        MaxFee:          rates.MaxFee,
+        Commission:      commission,
+    }, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+    "math"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+)
+
+func TestComputeFeeZeroOverride(t *testing.T) {
+    schedule := DefaultFeeSchedule()
+    schedule.AssetClasses["ETF"] = models.FeeOverride{MakerRate: feeValue(0)}
+
+    maker, err := computeFee(schedule, 10000, 0, LiquidityMaker, "ETF", "SPY")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if maker.BaseRate != 0 || maker.Commission != 0 {
+        t.Fatalf("maker rate = %v, commission = %v; want an explicit 0 override to apply", maker.BaseRate, maker.Commission)
+    }
+
+    // Fields left out still inherit the default
+    taker, err := computeFee(schedule, 10000, 0, LiquidityTaker, "ETF", "SPY")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if taker.BaseRate != schedule.TakerRate {
+        t.Fatalf("taker rate = %v, want inherited %v", taker.BaseRate, schedule.TakerRate)
+    }
+}
+
+func TestComputeFeeTiersAndBounds(t *testing.T) {
+    schedule := DefaultFeeSchedule()
+    schedule.MinFee = 1
+    schedule.MaxFee = 50
+
+    tests := []struct {
+        name       string
+        amount     float64
+        volume     float64
+        liquidity  string
+        assetClass string
+        tier       string
+        commission float64
+    }{
+        {"standard taker", 10000, 0, "", "", "STANDARD", 10},
+        {"gold maker", 10000, 20000000, LiquidityMaker, "", "GOLD", 3.75},
+        {"crypto taker", 10000, 0, LiquidityTaker, "crypto", "STANDARD", 20},
+        {"min fee", 100, 0, LiquidityTaker, "", "STANDARD", 1},
+        {"max fee", 1000000, 0, LiquidityTaker, "", "STANDARD", 50},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            fee, err := computeFee(schedule, tt.amount, tt.volume, tt.liquidity, tt.assetClass, "AAPL")
+            if err != nil {
+                t.Fatal(err)
+            }
+            if fee.Tier != tt.tier || math.Abs(fee.Commission-tt.commission) > 1e-9 {
+                t.Fatalf("got tier %s commission %v, want %s %v", fee.Tier, fee.Commission, tt.tier, tt.commission)
+            }
+        })
+    }
+}
+
+func TestLoadFeeScheduleValidates(t *testing.T) {
+    tests := []struct {
+        name    string
+        json    string
+        wantErr bool
+    }{
+        {"valid", `{"min_fee": 1, "max_fee": 20, "asset_classes": {"crypto": {"maker_rate": 0, "max_fee": 40}}}`, false},
+        {"top level", `{"min_fee": 30, "max_fee": 20}`, true},
+        {"override max below default min", `{"min_fee": 5, "asset_classes": {"ETF": {"max_fee": 2}}}`, true},
+        {"override min above default max", `{"max_fee": 20, "asset_classes": {"ETF": {"min_fee": 25}}}`, true},
+        {"negative rate", `{"maker_rate": -0.001, "taker_rate": 0.001}`, true},
+        {"negative override rate", `{"taker_rate": 0.001, "asset_classes": {"ETF": {"taker_rate": -0.0005}}}`, true},
+        {"negative min fee", `{"min_fee": -1}`, true},
+        {"discount above one", `{"taker_rate": 0.001, "tiers": [{"name": "VIP", "min_volume": 0, "discount": 1.5}]}`, true},
+        {"negative discount", `{"taker_rate": 0.001, "tiers": [{"name": "VIP", "min_volume": 0, "discount": -0.1}]}`, true},
+        {"full discount", `{"taker_rate": 0.001, "tiers": [{"name": "VIP", "min_volume": 0, "discount": 1}]}`, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            path := filepath.Join(t.TempDir(), "fees.json")
+            if err := os.WriteFile(path, []byte(tt.json), 0o600); err != nil {
+                t.Fatal(err)
+            }
+            t.Setenv("FEE_SCHEDULE_FILE", path)
+            schedule, err := LoadFeeSchedule()
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+            }
+            if err == nil {
+                if _, ok := schedule.AssetClasses["CRYPTO"]; !ok && len(schedule.AssetClasses) > 0 {
+                    t.Fatalf("asset classes not upper-cased: %v", schedule.AssetClasses)
+                }
+            }
+        })
+    }
+}
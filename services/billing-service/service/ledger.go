@@ -0,0 +1,308 @@
+package service
+
+import (
+    "fmt"
+    "log"
+    "math"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+    "github.com/google/uuid"
+)
+
+// Journal entry types.
+const (
+    EntryDeposit            = "DEPOSIT"
+    EntryWithdrawal         = "WITHDRAWAL"
+    EntryTradeDebit         = "TRADE_DEBIT"
+    EntryTradeCredit        = "TRADE_CREDIT"
+    EntryFee                = "FEE"
+    EntryRefund             = "REFUND"
+    EntryDividend           = "DIVIDEND"
+    EntryOpeningBalance     = "OPENING_BALANCE"
+    EntryAdjustment         = "ADJUSTMENT"
+    EntryPayout             = "PAYOUT"
+    EntryWithdrawalReversal = "WITHDRAWAL_REVERSAL"
+    EntryMarginLoan         = "MARGIN_LOAN"
+    EntryMarginRepayment    = "MARGIN_REPAYMENT"
+    EntryMarginRestore      = "MARGIN_RESTORE"
+    EntryMarginInterest     = "MARGIN_INTEREST"
+)
+
+// Purposes for CreditUser/DebitUser; empty means money moving between the
+// user's bank and their wallet through the payment gateway.
+const (
+    PurposeTrade      = "TRADE"
+    PurposeDividend   = "DIVIDEND"
+    PurposeRefund     = "REFUND"     // a card payment going back to the payer (or coming back if the refund fails)
+    PurposeAdjustment = "ADJUSTMENT" // an admin credit with no payment behind it
+    PurposeFee        = "FEE"        // a commission paid from the wallet, or refunded to it
+
+    PurposeWithdrawalReversal = "WITHDRAWAL_REVERSAL" // a held withdrawal coming back to the wallet
+    PurposeMarginLoan         = "MARGIN_LOAN"         // cash lent to the user to fund a purchase
+    PurposeMarginRepayment    = "MARGIN_REPAYMENT"    // wallet cash paying down the margin loan
+)
+
+// Platform accounts. User cash accounts are named by UserCashAccount.
+const (
+    AccountFeeIncome               = "platform:fee_income"
+    AccountGatewayClearing         = "clearing:payment_gateway"
+    AccountTradeClearing           = "clearing:trade_settlement"
+    AccountCorporateActionClearing = "clearing:corporate_actions"
+    AccountOpeningBalances         = "equity:opening_balances"
+    AccountAdjustments             = "platform:adjustments"
+    AccountWithdrawalClearing      = "clearing:withdrawals"  // held withdrawals not yet paid out
+    AccountMarginLoans             = "platform:margin_loans" // cash lent to users; an asset, so debits exceed credits
+    AccountMarginInterest          = "platform:margin_interest"
+)
+
+// ledgerTolerance absorbs float rounding when comparing debits, credits and balances.
+const ledgerTolerance = 0.005
+
+// UserCashAccount is the ledger account holding a user's wallet cash. It is a
+// liability of the platform, so its balance is credits minus debits.
+func UserCashAccount(userID string) string {
+    return "user:" + userID + ":cash"
+}
+
+// CreditUser adds amount to the user's wallet and posts the matching entry:
+// Dr <contra account>, Cr user cash. If the entry can't be stored the credit is
+// reversed, so the wallet never holds money the ledger doesn't know about.
+func CreditUser(userID string, amount float64, purpose, reference string) (*models.Wallet, error) {
+    entryType, contra, err := creditEntryType(purpose)
+    if err != nil {
+        return nil, err
+    }
+    wallet, err := repository.CreditWallet(userID, amount, WalletCurrency())
+    if err != nil {
+        return nil, err
+    }
+    err = PostEntry(entryType, userID, reference, fmt.Sprintf("%s of %.2f", strings.ToLower(entryType), amount), walletCurrency(wallet),
+        models.Posting{Account: contra, Debit: amount},
+        models.Posting{Account: UserCashAccount(userID), Credit: amount},
+    )
+    if err != nil {
+        if _, revErr := repository.DebitWallet(userID, amount); revErr != nil {
+            log.Printf("LEDGER DRIFT: credit of %.2f to %s not journaled and not reversed: %v\n", amount, userID, revErr)
+        }
+        return nil, fmt.Errorf("failed to post ledger entry: %v", err)
+    }
+    return wallet, nil
+}
+
+// DebitUser takes amount from the user's wallet (never overdrawing it) and posts
+// Dr user cash, Cr <contra account>. A failed post re-credits the wallet.
+func DebitUser(userID string, amount float64, purpose, reference string) (*models.Wallet, error) {
+    return debitUserKeeping(userID, amount, 0, purpose, reference)
+}
+
+// debitUserKeeping is DebitUser that fails with ErrInsufficientFunds unless at
+// least keep stays in the wallet after the debit.
+func debitUserKeeping(userID string, amount, keep float64, purpose, reference string) (*models.Wallet, error) {
+    entryType, contra, err := debitEntryType(purpose)
+    if err != nil {
+        return nil, err
+    }
+    wallet, err := repository.DebitWalletKeeping(userID, amount, keep)
+    if err != nil {
+        return nil, err
+    }
+    err = PostEntry(entryType, userID, reference, fmt.Sprintf("%s of %.2f", strings.ToLower(entryType), amount), walletCurrency(wallet),
+        models.Posting{Account: UserCashAccount(userID), Debit: amount},
+        models.Posting{Account: contra, Credit: amount},
+    )
+    if err != nil {
+        if _, revErr := repository.CreditWallet(userID, amount, WalletCurrency()); revErr != nil {
+            log.Printf("LEDGER DRIFT: debit of %.2f from %s not journaled and not reversed: %v\n", amount, userID, revErr)
+        }
+        return nil, fmt.Errorf("failed to post ledger entry: %v", err)
+    }
+    return wallet, nil
+}
+
+func creditEntryType(purpose string) (string, string, error) {
+    switch strings.ToUpper(purpose) {
+    case "":
+        return EntryDeposit, AccountGatewayClearing, nil
+    case PurposeTrade:
+        return EntryTradeCredit, AccountTradeClearing, nil
+    case PurposeDividend:
+        return EntryDividend, AccountCorporateActionClearing, nil
+    case PurposeRefund:
+        return EntryRefund, AccountGatewayClearing, nil
+    case PurposeAdjustment:
+        return EntryAdjustment, AccountAdjustments, nil
+    case PurposeWithdrawalReversal:
+        return EntryWithdrawalReversal, AccountWithdrawalClearing, nil
+    case PurposeMarginLoan:
+        return EntryMarginLoan, AccountMarginLoans, nil
+    case PurposeFee:
+        return EntryRefund, AccountFeeIncome, nil
+    }
+    return "", "", fmt.Errorf("unknown deposit purpose %q", purpose)
+}
+
+func debitEntryType(purpose string) (string, string, error) {
+    switch strings.ToUpper(purpose) {
+    case "":
+        return EntryWithdrawal, AccountWithdrawalClearing, nil
+    case PurposeTrade:
+        return EntryTradeDebit, AccountTradeClearing, nil
+    case PurposeRefund:
+        return EntryRefund, AccountGatewayClearing, nil
+    case PurposeMarginRepayment:
+        return EntryMarginRepayment, AccountMarginLoans, nil
+    case PurposeFee:
+        return EntryFee, AccountFeeIncome, nil
+    }
+    return "", "", fmt.Errorf("unknown withdrawal purpose %q", purpose)
+}
+
+func walletCurrency(w *models.Wallet) string {
+    if w != nil && w.Currency != "" {
+        return w.Currency
+    }
+    return WalletCurrency()
+}
+
+// PostEntry stores a journal entry after checking that it balances.
+func PostEntry(entryType, userID, reference, description, currency string, postings ...models.Posting) error {
+    if len(postings) < 2 {
+        return fmt.Errorf("a journal entry needs at least two postings")
+    }
+    var debits, credits float64
+    for _, p := range postings {
+        if p.Debit < 0 || p.Credit < 0 || (p.Debit > 0) == (p.Credit > 0) {
+            return fmt.Errorf("posting to %s must have exactly one positive debit or credit", p.Account)
+        }
+        debits += p.Debit
+        credits += p.Credit
+    }
+    if math.Abs(debits-credits) > ledgerTolerance {
+        return fmt.Errorf("unbalanced journal entry: debits %.2f != credits %.2f", debits, credits)
+    }
+    return repository.InsertJournalEntry(&models.JournalEntry{
+        EntryID:     uuid.NewString(),
+        Type:        entryType,
+        UserID:      userID,
+        Reference:   reference,
+        Description: description,
+        Currency:    currency,
+        Postings:    postings,
+        CreatedAt:   time.Now().UTC(),
+    })
+}
+
+// AccountBalance is an account's credits minus debits.
+func AccountBalance(account string) (debits, credits, balance float64, err error) {
+    debits, credits, err = repository.AccountTotals(account, time.Time{})
+    if err != nil {
+        return 0, 0, 0, err
+    }
+    return debits, credits, credits - debits, nil
+}
+
+// Reconciliation compares a wallet's stored balance with its ledger account.
+type Reconciliation struct {
+    UserID        string
+    WalletBalance float64
+    LedgerBalance float64
+}
+
+func (r Reconciliation) Reconciled() bool {
+    return math.Abs(r.WalletBalance-r.LedgerBalance) <= ledgerTolerance
+}
+
+// ReconcileWallet derives the user's balance from the ledger and compares it to the wallet.
+func ReconcileWallet(userID string) (*Reconciliation, error) {
+    r := &Reconciliation{UserID: userID}
+    if wallet, err := repository.GetWallet(userID); err == nil {
+        r.WalletBalance = wallet.Balance
+    }
+    _, _, balance, err := AccountBalance(UserCashAccount(userID))
+    if err != nil {
+        return nil, err
+    }
+    r.LedgerBalance = balance
+    return r, nil
+}
+
+// MigrateOpeningBalances journals the balance of every wallet that predates the
+// ledger, so derived balances start from what the wallet already held.
+func MigrateOpeningBalances() error {
+    wallets, err := repository.GetAllWallets()
+    if err != nil {
+        return err
+    }
+    migrated := 0
+    for _, w := range wallets {
+        if w.Balance == 0 {
+            continue
+        }
+        has, err := repository.HasJournalEntries(UserCashAccount(w.UserID))
+        if err != nil {
+            return err
+        }
+        if has {
+            continue
+        }
+        equity := models.Posting{Account: AccountOpeningBalances, Debit: w.Balance}
+        cash := models.Posting{Account: UserCashAccount(w.UserID), Credit: w.Balance}
+        if w.Balance < 0 {
+            equity = models.Posting{Account: AccountOpeningBalances, Credit: -w.Balance}
+            cash = models.Posting{Account: UserCashAccount(w.UserID), Debit: -w.Balance}
+        }
+        if err := PostEntry(EntryOpeningBalance, w.UserID, "", "balance before the ledger was introduced", walletCurrency(&w), equity, cash); err != nil {
+            return err
+        }
+        migrated++
+    }
+    if migrated > 0 {
+        log.Printf("Ledger: journaled opening balances for %d wallets\n", migrated)
+    }
+    return nil
+}
+
+// LedgerReconcileIntervalFromEnv reads LEDGER_RECONCILE_INTERVAL (Go duration, default 1h, 0 = off).
+func LedgerReconcileIntervalFromEnv() (time.Duration, error) {
+    v := os.Getenv("LEDGER_RECONCILE_INTERVAL")
+    if v == "" {
+        return time.Hour, nil
+    }
+    d, err := time.ParseDuration(v)
+    if err != nil || d < 0 {
+        return 0, fmt.Errorf("invalid LEDGER_RECONCILE_INTERVAL %q", v)
+    }
+    return d, nil
+}
+
+// StartLedgerReconciler periodically compares every wallet with the ledger and logs drift.
+func StartLedgerReconciler(interval time.Duration) {
+    if interval <= 0 {
+        return
+    }
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for range ticker.C {
+            wallets, err := repository.GetAllWallets()
+            if err != nil {
+                log.Printf("Ledger reconciliation failed: %v\n", err)
+                continue
+            }
+            for _, w := range wallets {
+                r, err := ReconcileWallet(w.UserID)
+                if err != nil {
+                    log.Printf("Ledger reconciliation for %s failed: %v\n", w.UserID, err)
+                    continue
+                }
+                if !r.Reconciled() {
+                    log.Printf("LEDGER DRIFT: user %s wallet=%.2f ledger=%.2f\n", r.UserID, r.WalletBalance, r.LedgerBalance)
+                }
+            }
+        }
+    }()
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+    "math"
+    "sync"
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/internal/testmongo"
+    pb "github.com/ankan8/swapsync/backend/services/billing-service/proto"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+)
+
+func useBillingDB(t *testing.T) {
+    t.Helper()
+    testmongo.Use(t)
+    if err := repository.EnsureWalletIndexes(); err != nil {
+        t.Fatal(err)
+    }
+    if err := repository.EnsureLedgerIndexes(); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func assertReconciled(t *testing.T, userID string, want float64) {
+    t.Helper()
+    r, err := ReconcileWallet(userID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if math.Abs(r.WalletBalance-want) > ledgerTolerance {
+        t.Fatalf("wallet balance = %.2f, want %.2f", r.WalletBalance, want)
+    }
+    if !r.Reconciled() {
+        t.Fatalf("wallet %.2f and ledger %.2f disagree", r.WalletBalance, r.LedgerBalance)
+    }
+}
+
+func TestConcurrentWithdrawFunds(t *testing.T) {
+    useBillingDB(t)
+    if _, err := CreditUser("alice", 50, "", "opening"); err != nil {
+        t.Fatal(err)
+    }
+
+    // 40 withdrawals of 10 against 50: exactly 5 succeed
+    s := &BillingServiceServer{}
+    ctx := asUser(t, "alice")
+    const workers = 40
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    succeeded, rejected := 0, 0
+    for i := 0; i < workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            resp, err := s.WithdrawFunds(ctx, &pb.WithdrawFundsRequest{UserId: "alice", Amount: 10, Purpose: PurposeTrade})
+            mu.Lock()
+            defer mu.Unlock()
+            switch {
+            case err == repository.ErrInsufficientFunds:
+                rejected++
+            case err != nil:
+                t.Errorf("WithdrawFunds: %v", err)
+            case resp.GetNewBalance() < 0:
+                t.Errorf("balance went negative: %.2f", resp.GetNewBalance())
+            default:
+                succeeded++
+            }
+        }()
+    }
+    wg.Wait()
+
+    if succeeded != 5 || rejected != workers-5 {
+        t.Fatalf("%d withdrawals succeeded and %d were rejected, want 5 and %d", succeeded, rejected, workers-5)
+    }
+    assertReconciled(t, "alice", 0)
+}
+
+func TestConcurrentCreditsAndDebits(t *testing.T) {
+    useBillingDB(t)
+    if _, err := CreditUser("alice", 20, "", "opening"); err != nil {
+        t.Fatal(err)
+    }
+
+    const credits, debits = 30, 30
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    debited, rejected := 0, 0
+    for i := 0; i < credits; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if _, err := CreditUser("alice", 5, PurposeTrade, "sale"); err != nil {
+                t.Errorf("CreditUser: %v", err)
+            }
+        }()
+    }
+    for i := 0; i < debits; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            w, err := DebitUser("alice", 8, PurposeTrade, "buy")
+            mu.Lock()
+            defer mu.Unlock()
+            switch {
+            case err == repository.ErrInsufficientFunds:
+                rejected++
+            case err != nil:
+                t.Errorf("DebitUser: %v", err)
+            case w.Balance < 0:
+                t.Errorf("balance went negative: %.2f", w.Balance)
+            default:
+                debited++
+            }
+        }()
+    }
+    wg.Wait()
+
+    if debited+rejected != debits {
+        t.Fatalf("%d debits accounted for, want %d", debited+rejected, debits)
+    }
+    // 20 + 30*5 = 170 can cover at most 21 debits of 8, so some are always refused
+    if debited > 21 || rejected < debits-21 {
+        t.Fatalf("%d debits succeeded, at most 21 fit", debited)
+    }
+    assertReconciled(t, "alice", 20+credits*5-float64(debited)*8)
+}
@@ -0,0 +1,491 @@
+package service
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "math"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+    "github.com/google/uuid"
+)
+
+// Margin account statuses, set by the margin monitor.
+const (
+    MarginStatusOK          = "OK"
+    MarginStatusCall        = "MARGIN_CALL" // equity below the call level: the user has been warned
+    MarginStatusLiquidating = "LIQUIDATING" // equity below maintenance: positions are being sold
+)
+
+// Sources of a margin loan repayment.
+const (
+    RepaySourceWallet = "WALLET" // cash in the wallet
+    RepaySourceSale   = "SALE"   // proceeds of a sale, which don't pass through the wallet
+)
+
+// marginInterestPeriod is how often the monitor charges interest. Interest is
+// also brought up to date whenever the loan changes.
+const marginInterestPeriod = 24 * time.Hour
+
+// ErrMarginNotEnabled is returned when a user without margin enabled needs to borrow.
+var ErrMarginNotEnabled = errors.New("margin is not enabled for this account")
+
+// DefaultMarginConfig is used when MARGIN_CONFIG_FILE is not set: 50% initial and
+// 25% maintenance margin, 12% a year interest, margin calls at 110% of maintenance.
+func DefaultMarginConfig() *models.MarginConfig {
+    return &models.MarginConfig{
+        MarginRates:        models.MarginRates{InitialRate: 0.5, MaintenanceRate: 0.25},
+        Symbols:            map[string]models.MarginRates{},
+        AnnualInterestRate: 0.12,
+        CallBuffer:         0.1,
+    }
+}
+
+// LoadMarginConfig reads the margin configuration from the JSON file in
+// MARGIN_CONFIG_FILE, falling back to DefaultMarginConfig if the variable is unset.
+func LoadMarginConfig() (*models.MarginConfig, error) {
+    path := os.Getenv("MARGIN_CONFIG_FILE")
+    if path == "" {
+        log.Println("MARGIN_CONFIG_FILE not set, using default margin config")
+        return DefaultMarginConfig(), nil
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read margin config %s: %v", path, err)
+    }
+    var cfg models.MarginConfig
+    if err := json.Unmarshal(data, &cfg); err != nil {
+        return nil, fmt.Errorf("failed to parse margin config %s: %v", path, err)
+    }
+    if err := validateMarginRates("default", cfg.MarginRates); err != nil {
+        return nil, err
+    }
+    symbols := map[string]models.MarginRates{}
+    for symbol, rates := range cfg.Symbols {
+        rates = inheritMarginRates(rates, cfg.MarginRates)
+        if err := validateMarginRates(symbol, rates); err != nil {
+            return nil, err
+        }
+        symbols[strings.ToUpper(symbol)] = rates
+    }
+    cfg.Symbols = symbols
+    if cfg.AnnualInterestRate < 0 || cfg.CallBuffer < 0 {
+        return nil, fmt.Errorf("margin config annual_interest_rate and call_buffer must not be negative")
+    }
+    log.Printf("Loaded margin config from %s (%d instrument overrides)\n", path, len(cfg.Symbols))
+    return &cfg, nil
+}
+
+func validateMarginRates(name string, r models.MarginRates) error {
+    if r.MaintenanceRate <= 0 || r.MaintenanceRate > r.InitialRate || r.InitialRate > 1 {
+        return fmt.Errorf("margin rates for %s must satisfy 0 < maintenance_rate <= initial_rate <= 1, got %.2f/%.2f",
+            name, r.InitialRate, r.MaintenanceRate)
+    }
+    return nil
+}
+
+// inheritMarginRates fills zero rates from the default, like fee rate overrides.
+func inheritMarginRates(r, def models.MarginRates) models.MarginRates {
+    if r.InitialRate == 0 {
+        r.InitialRate = def.InitialRate
+    }
+    if r.MaintenanceRate == 0 {
+        r.MaintenanceRate = def.MaintenanceRate
+    }
+    return r
+}
+
+// MarginMonitorIntervalFromEnv reads MARGIN_MONITOR_INTERVAL (a Go duration,
+// default 15s; 0 disables the monitor).
+func MarginMonitorIntervalFromEnv() (time.Duration, error) {
+    v := os.Getenv("MARGIN_MONITOR_INTERVAL")
+    if v == "" {
+        return 15 * time.Second, nil
+    }
+    d, err := time.ParseDuration(v)
+    if err != nil || d < 0 {
+        return 0, fmt.Errorf("invalid MARGIN_MONITOR_INTERVAL %q", v)
+    }
+    return d, nil
+}
+
+// MarginService lends cash against users' positions and watches the loans.
+type MarginService struct {
+    Config          *models.MarginConfig
+    MonitorInterval time.Duration
+}
+
+func NewMarginService(cfg *models.MarginConfig, monitorInterval time.Duration) *MarginService {
+    return &MarginService{Config: cfg, MonitorInterval: monitorInterval}
+}
+
+// RatesFor returns the margin requirements of symbol.
+func (m *MarginService) RatesFor(symbol string) models.MarginRates {
+    if r, ok := m.Config.Symbols[strings.ToUpper(symbol)]; ok {
+        return r
+    }
+    return m.Config.MarginRates
+}
+
+// MarginPosition is one long position valued for margin, in the wallet currency.
+type MarginPosition struct {
+    Symbol      string
+    Quantity    float64
+    Price       float64 // the bid, what a sale would fetch now
+    MarketValue float64
+    Rates       models.MarginRates
+    PriceError  string // why there is no price; the position is then valued at 0
+}
+
+// MarginSnapshot values a user's account against current quotes. Equity is
+// what would be left after selling everything and repaying the loan.
+type MarginSnapshot struct {
+    UserID                 string
+    Status                 string
+    Currency               string
+    Cash                   float64
+    Loan                   float64
+    MarketValue            float64
+    Equity                 float64
+    InitialRequirement     float64
+    MaintenanceRequirement float64
+    CallLevel              float64
+    Positions              []MarginPosition
+}
+
+// Unpriced lists the positions that couldn't be priced and count for nothing.
+func (s *MarginSnapshot) Unpriced() []string {
+    var out []string
+    for _, p := range s.Positions {
+        if p.PriceError != "" {
+            out = append(out, p.Symbol)
+        }
+    }
+    return out
+}
+
+// ExcessEquity is the equity not tied up by the initial margin of open positions.
+func (s *MarginSnapshot) ExcessEquity() float64 {
+    return s.Equity - s.InitialRequirement
+}
+
+// BuyingPower is how much of an instrument with the given initial rate could
+// be bought, using cash first and borrowing the rest.
+func (s *MarginSnapshot) BuyingPower(initialRate float64) float64 {
+    if initialRate <= 0 {
+        return 0
+    }
+    return math.Max(s.ExcessEquity()/initialRate, 0)
+}
+
+// marginPositions fetches a user's priced positions; tests replace it to
+// value accounts without the Portfolio and Market Data services.
+var marginPositions = fetchMarginPositions
+
+// Snapshot values userID's account: wallet cash, the margin loan, and long
+// positions from the Portfolio Service priced at the bid from the Market Data
+// Service, converted to the wallet currency. Positions that can't be priced are
+// listed but valued at 0, which only ever understates equity.
+func (m *MarginService) Snapshot(userID string) (*MarginSnapshot, error) {
+    snap := &MarginSnapshot{UserID: userID, Status: MarginStatusOK, Currency: WalletCurrency()}
+    acct, err := repository.GetMarginAccount(userID)
+    if err == nil {
+        snap.Loan = acct.Loan
+        snap.Status = acct.Status
+    } else if err != repository.ErrMarginAccountNotFound {
+        return nil, err
+    }
+    if wallet, err := repository.GetWallet(userID); err == nil {
+        snap.Cash = wallet.Balance
+        snap.Currency = walletCurrency(wallet)
+    }
+
+    positions, err := marginPositions(userID, snap.Currency)
+    if err != nil {
+        return nil, err
+    }
+    for _, p := range positions {
+        p.Rates = m.RatesFor(p.Symbol)
+        p.MarketValue = p.Quantity * p.Price
+        snap.MarketValue += p.MarketValue
+        snap.InitialRequirement += p.MarketValue * p.Rates.InitialRate
+        snap.MaintenanceRequirement += p.MarketValue * p.Rates.MaintenanceRate
+        snap.Positions = append(snap.Positions, p)
+    }
+    snap.Equity = snap.Cash + snap.MarketValue - snap.Loan
+    snap.CallLevel = snap.MaintenanceRequirement * (1 + m.Config.CallBuffer)
+    return snap, nil
+}
+
+// SetEnabled opts a user in to or out of margin lending.
+func (m *MarginService) SetEnabled(userID string, enabled bool) (*models.MarginAccount, error) {
+    if userID == "" {
+        return nil, fmt.Errorf("user_id is required")
+    }
+    acct, err := repository.SetMarginEnabled(userID, enabled)
+    if err != nil {
+        return nil, err
+    }
+    log.Printf("Margin enabled=%v for user=%s\n", enabled, userID)
+    return acct, nil
+}
+
+// Borrow pays for a purchase of cost in symbol from the wallet and lends what
+// the wallet can't cover, if the account's buying power for symbol allows the
+// whole purchase. The wallet gives up whatever its balance covers in one
+// conditional update, so the loan is never sized from a stale balance and the
+// caller doesn't debit the cost again. It returns the amount borrowed (0 if
+// the wallet covered the cost).
+func (m *MarginService) Borrow(userID, symbol string, cost float64, reference string) (float64, *models.Wallet, error) {
+    if cost <= 0 {
+        return 0, nil, fmt.Errorf("invalid purchase amount")
+    }
+    acct, err := repository.GetMarginAccount(userID)
+    if err == repository.ErrMarginAccountNotFound || (err == nil && !acct.Enabled) {
+        return 0, nil, ErrMarginNotEnabled
+    }
+    if err != nil {
+        return 0, nil, err
+    }
+    if acct.Status != MarginStatusOK {
+        return 0, nil, fmt.Errorf("margin account is in %s: new borrowing is suspended", acct.Status)
+    }
+    rates := m.RatesFor(symbol)
+    if rates.InitialRate >= 1 {
+        return 0, nil, fmt.Errorf("%s can't be bought on margin", symbol)
+    }
+    // Concurrent purchases would each pass the check on the same snapshot, so
+    // it and the loan are taken under the user's margin lock.
+    release, err := lockMargin(userID)
+    if err != nil {
+        return 0, nil, err
+    }
+    defer release()
+    // How the cost splits between cash and loan doesn't change the check: the
+    // purchase leaves equity as it is and adds cost x InitialRate to the requirement.
+    snap, err := m.Snapshot(userID)
+    if err != nil {
+        return 0, nil, fmt.Errorf("failed to value margin account: %v", err)
+    }
+    if bp := snap.BuyingPower(rates.InitialRate); cost > bp+ledgerTolerance {
+        return 0, nil, fmt.Errorf("insufficient buying power for %s: need %.2f, have %.2f", symbol, cost, bp)
+    }
+
+    if err := m.accrueInterest(acct, time.Now().UTC(), true); err != nil {
+        return 0, nil, err
+    }
+    taken, wallet, err := repository.DebitWalletUpTo(userID, cost)
+    if err != nil {
+        return 0, nil, err
+    }
+    shortfall := cost - taken
+    if shortfall > 0 {
+        if _, err := repository.AdjustMarginLoan(userID, shortfall); err != nil {
+            m.undoPurchase(userID, taken, 0)
+            return 0, nil, err
+        }
+    }
+
+    // One entry for the purchase: Dr user cash (what the wallet paid) and
+    // Dr margin loans (what was lent), Cr trade clearing
+    var postings []models.Posting
+    if taken > 0 {
+        postings = append(postings, models.Posting{Account: UserCashAccount(userID), Debit: taken})
+    }
+    if shortfall > 0 {
+        postings = append(postings, models.Posting{Account: AccountMarginLoans, Debit: shortfall})
+    }
+    postings = append(postings, models.Posting{Account: AccountTradeClearing, Credit: cost})
+    if err := PostEntry(EntryTradeDebit, userID, reference, fmt.Sprintf("trade_debit of %.2f, %.2f of it on margin", cost, shortfall), walletCurrency(wallet), postings...); err != nil {
+        m.undoPurchase(userID, taken, shortfall)
+        return 0, nil, fmt.Errorf("failed to post ledger entry: %v", err)
+    }
+    if shortfall > 0 {
+        log.Printf("Margin loan of %.2f to user=%s for %s (%s)\n", shortfall, userID, symbol, reference)
+    }
+    return shortfall, wallet, nil
+}
+
+// marginLockTTL bounds how long a crashed purchase can keep a user's margin
+// locked; marginLockWait is how long a purchase queues behind another one for
+// the same user before giving up.
+const (
+    marginLockTTL  = 30 * time.Second
+    marginLockWait = 5 * time.Second
+)
+
+// lockMargin waits for the user's margin lock and returns the func that
+// releases it.
+func lockMargin(userID string) (func(), error) {
+    owner := uuid.NewString()
+    deadline := time.Now().Add(marginLockWait)
+    for {
+        ok, err := repository.AcquireMarginLock(userID, owner, marginLockTTL)
+        if err != nil {
+            return nil, fmt.Errorf("failed to lock margin account: %v", err)
+        }
+        if ok {
+            break
+        }
+        if time.Now().After(deadline) {
+            return nil, fmt.Errorf("another margin purchase for %s is in progress, try again", userID)
+        }
+        time.Sleep(25 * time.Millisecond)
+    }
+    return func() {
+        if err := repository.ReleaseMarginLock(userID, owner); err != nil {
+            log.Printf("Failed to release margin lock for %s: %v\n", userID, err)
+        }
+    }, nil
+}
+
+// undoPurchase hands back what Borrow took before its ledger entry was posted.
+func (m *MarginService) undoPurchase(userID string, taken, borrowed float64) {
+    if borrowed > 0 {
+        if _, err := repository.AdjustMarginLoan(userID, -borrowed); err != nil {
+            log.Printf("Margin loan of %.2f to %s not posted and not reversed: %v\n", borrowed, userID, err)
+        }
+    }
+    if taken > 0 {
+        if _, err := repository.CreditWallet(userID, taken, WalletCurrency()); err != nil {
+            log.Printf("LEDGER DRIFT: purchase debit of %.2f from %s not journaled and not reversed: %v\n", taken, userID, err)
+        }
+    }
+}
+
+// Repay pays down the margin loan from the wallet or from sale proceeds. A
+// wallet repayment of 0 repays everything outstanding; any amount is capped at
+// the loan. It returns the amount repaid, which is 0 for users without a loan.
+func (m *MarginService) Repay(userID string, amount float64, source, reference string) (float64, *models.MarginAccount, error) {
+    source = strings.ToUpper(source)
+    if source != RepaySourceWallet && source != RepaySourceSale {
+        return 0, nil, fmt.Errorf("unknown repayment source %q (use WALLET or SALE)", source)
+    }
+    if amount < 0 || (source == RepaySourceSale && amount == 0) {
+        return 0, nil, fmt.Errorf("invalid repayment amount")
+    }
+    acct, err := repository.GetMarginAccount(userID)
+    if err == repository.ErrMarginAccountNotFound {
+        return 0, nil, nil
+    }
+    if err != nil {
+        return 0, nil, err
+    }
+    if err := m.accrueInterest(acct, time.Now().UTC(), true); err != nil {
+        return 0, nil, err
+    }
+    if acct, err = repository.GetMarginAccount(userID); err != nil {
+        return 0, nil, err
+    }
+    if amount == 0 || amount > acct.Loan {
+        amount = acct.Loan
+    }
+    if amount <= ledgerTolerance {
+        return 0, acct, nil
+    }
+
+    // Reduce the loan first: the update only applies if the loan still covers
+    // the repayment, so concurrent repayments can't overpay it.
+    acct, err = repository.AdjustMarginLoan(userID, -amount)
+    if err != nil {
+        return 0, nil, err
+    }
+    switch source {
+    case RepaySourceWallet:
+        _, err = DebitUser(userID, amount, PurposeMarginRepayment, reference)
+    case RepaySourceSale:
+        // Sale proceeds go straight to the loan: Dr trade clearing, Cr margin loans
+        err = PostEntry(EntryMarginRepayment, userID, reference, fmt.Sprintf("margin repayment of %.2f from sale proceeds", amount), WalletCurrency(),
+            models.Posting{Account: AccountTradeClearing, Debit: amount},
+            models.Posting{Account: AccountMarginLoans, Credit: amount},
+        )
+    }
+    if err != nil {
+        if _, revErr := repository.AdjustMarginLoan(userID, amount); revErr != nil {
+            log.Printf("Margin repayment of %.2f by %s failed and the loan was not restored: %v\n", amount, userID, revErr)
+        }
+        return 0, nil, err
+    }
+    log.Printf("Margin repayment of %.2f by user=%s from %s (%s); loan now %.2f\n", amount, userID, source, reference, acct.Loan)
+    return amount, acct, nil
+}
+
+// RestoreSaleRepayment puts amount back on the loan after the sale whose
+// proceeds repaid it was busted: the reverse of a SALE repayment.
+func (m *MarginService) RestoreSaleRepayment(userID string, amount float64, reference string) (*models.MarginAccount, error) {
+    if amount <= 0 {
+        return nil, fmt.Errorf("invalid restore amount")
+    }
+    acct, err := repository.GetMarginAccount(userID)
+    if err != nil {
+        return nil, err
+    }
+    if err := m.accrueInterest(acct, time.Now().UTC(), true); err != nil {
+        return nil, err
+    }
+    acct, err = repository.AdjustMarginLoan(userID, amount)
+    if err != nil {
+        return nil, err
+    }
+    // Dr margin loans, Cr trade clearing
+    if err := PostEntry(EntryMarginRestore, userID, reference, fmt.Sprintf("margin loan of %.2f restored after busted sale", amount), WalletCurrency(),
+        models.Posting{Account: AccountMarginLoans, Debit: amount},
+        models.Posting{Account: AccountTradeClearing, Credit: amount},
+    ); err != nil {
+        if _, revErr := repository.AdjustMarginLoan(userID, -amount); revErr != nil {
+            log.Printf("Margin restore of %.2f for %s not posted and not reversed: %v\n", amount, userID, revErr)
+        }
+        return nil, err
+    }
+    log.Printf("Margin loan of user=%s restored by %.2f after busted sale %s; loan now %.2f\n", userID, amount, reference, acct.Loan)
+    return acct, nil
+}
+
+// accrueInterest charges interest on the loan up to now by adding it to the
+// loan. Unless force is set (the loan is about to change), it waits until a
+// full marginInterestPeriod has passed. Only whole cents are charged; the rest
+// is carried on the account, so frequent accruals on a small loan don't round
+// the interest away.
+func (m *MarginService) accrueInterest(acct *models.MarginAccount, now time.Time, force bool) error {
+    elapsed := now.Sub(acct.InterestAccruedAt)
+    if elapsed <= 0 || (!force && elapsed < marginInterestPeriod) {
+        return nil
+    }
+    interest, remainder := splitInterest(acct.Loan*m.Config.AnnualInterestRate*elapsed.Hours()/(365*24) + acct.InterestRemainder)
+    applied, err := repository.AccrueMarginInterest(acct.UserID, acct.InterestAccruedAt, now, interest, remainder)
+    if err != nil {
+        return fmt.Errorf("failed to accrue margin interest: %v", err)
+    }
+    if !applied || interest <= 0 {
+        return nil
+    }
+    // Interest is capitalised: Dr margin loans, Cr margin interest income
+    if err := PostEntry(EntryMarginInterest, acct.UserID, "", fmt.Sprintf("interest on %.2f margin loan", acct.Loan), WalletCurrency(),
+        models.Posting{Account: AccountMarginLoans, Debit: interest},
+        models.Posting{Account: AccountMarginInterest, Credit: interest},
+    ); err != nil {
+        // Take the interest back off the loan so the next accrual charges the period again
+        if _, revErr := repository.AccrueMarginInterest(acct.UserID, now, acct.InterestAccruedAt, -interest, acct.InterestRemainder); revErr != nil {
+            log.Printf("Margin interest of %.2f for %s not posted and not reversed: %v\n", interest, acct.UserID, revErr)
+        }
+        return fmt.Errorf("failed to post margin interest: %v", err)
+    }
+    return nil
+}
+
+// splitInterest splits accrued interest into the whole cents to charge and the
+// remainder to carry.
+func splitInterest(accrued float64) (float64, float64) {
+    if accrued <= 0 {
+        return 0, 0
+    }
+    // The epsilon keeps 0.07 from flooring to 0.06 through float error
+    cents := math.Floor(accrued*100+1e-6) / 100
+    return cents, math.Max(accrued-cents, 0)
+}
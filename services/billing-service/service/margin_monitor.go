@@ -0,0 +1,315 @@
+package service
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/middleware"
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+
+    pbMarketData "github.com/ankan8/swapsync/backend/services/market-data-service/proto"
+    pbPortfolio "github.com/ankan8/swapsync/backend/services/portfolio-service/proto"
+    pbTrade "github.com/ankan8/swapsync/backend/services/trade-service/proto"
+)
+
+// Start runs the margin monitor: every MonitorInterval each account with a
+// loan is charged any interest due, has idle wallet cash swept into the loan,
+// and is valued against live quotes to issue margin calls and liquidate.
+func (m *MarginService) Start() {
+    if m.MonitorInterval <= 0 {
+        return
+    }
+    go func() {
+        ticker := time.NewTicker(m.MonitorInterval)
+        defer ticker.Stop()
+        for range ticker.C {
+            m.checkAll()
+        }
+    }()
+}
+
+func (m *MarginService) checkAll() {
+    accounts, err := repository.ListMonitoredMarginAccounts()
+    if err != nil {
+        log.Printf("Margin monitor: failed to list accounts: %v\n", err)
+        return
+    }
+    for i := range accounts {
+        if err := m.check(&accounts[i]); err != nil {
+            log.Printf("Margin monitor: user=%s: %v\n", accounts[i].UserID, err)
+        }
+    }
+}
+
+// check brings one account up to date and acts on its margin level.
+func (m *MarginService) check(acct *models.MarginAccount) error {
+    if err := m.accrueInterest(acct, time.Now().UTC(), false); err != nil {
+        return err
+    }
+    if err := m.sweepCash(acct.UserID); err != nil {
+        log.Printf("Margin monitor: cash sweep for user=%s failed: %v\n", acct.UserID, err)
+    }
+    snap, err := m.Snapshot(acct.UserID)
+    if err != nil {
+        return err
+    }
+    if unpriced := snap.Unpriced(); len(unpriced) > 0 && snap.Loan > ledgerTolerance && snap.Equity < snap.CallLevel {
+        // Unpriced positions count for nothing, so equity is understated; wait
+        // for prices rather than call or liquidate on it
+        log.Printf("Margin monitor: user=%s equity %.2f below call level %.2f but %s unpriced; leaving status %s\n",
+            snap.UserID, snap.Equity, snap.CallLevel, strings.Join(unpriced, ", "), snap.Status)
+        return nil
+    }
+
+    switch {
+    case snap.Loan > ledgerTolerance && snap.Equity < snap.MaintenanceRequirement:
+        return m.liquidate(snap)
+    case snap.Loan > ledgerTolerance && snap.Equity < snap.CallLevel:
+        if snap.Status == MarginStatusCall {
+            return repository.SetMarginStatus(snap.UserID, MarginStatusCall, snap.Equity, nil)
+        }
+        if err := repository.SetMarginStatus(snap.UserID, MarginStatusCall, snap.Equity,
+            map[string]interface{}{"call_issued_at": time.Now().UTC()}); err != nil {
+            return err
+        }
+        log.Printf("Margin call for user=%s: equity %.2f, call level %.2f, maintenance %.2f\n",
+            snap.UserID, snap.Equity, snap.CallLevel, snap.MaintenanceRequirement)
+        notifyUserBilling(snap.UserID, fmt.Sprintf(
+            "Margin call: your account equity of %.2f %s is below the required %.2f. Deposit funds or sell positions; "+
+                "below %.2f positions will be sold automatically to repay your margin loan.",
+            snap.Equity, snap.Currency, snap.CallLevel, snap.MaintenanceRequirement))
+        return nil
+    default:
+        if err := repository.SetMarginStatus(snap.UserID, MarginStatusOK, snap.Equity, nil); err != nil {
+            return err
+        }
+        if snap.Status != MarginStatusOK {
+            notifyUserBilling(snap.UserID, "Your margin call has been resolved; your account is back in good standing.")
+        }
+        return nil
+    }
+}
+
+// sweepCash applies idle wallet cash to the loan, so the user isn't charged
+// interest on money they hold. Equity doesn't change.
+func (m *MarginService) sweepCash(userID string) error {
+    wallet, err := repository.GetWallet(userID)
+    if err != nil || wallet.Balance <= ledgerTolerance {
+        return nil
+    }
+    acct, err := repository.GetMarginAccount(userID)
+    if err != nil || acct.Loan <= ledgerTolerance {
+        return err
+    }
+    _, _, err = m.Repay(userID, math.Min(wallet.Balance, acct.Loan), RepaySourceWallet, "cash sweep")
+    return err
+}
+
+// liquidationOrder is one sale the monitor sends to the Trade Service.
+type liquidationOrder struct {
+    Symbol   string
+    Quantity float64
+}
+
+// liquidationOrders picks sales that bring equity back above the call level.
+// Selling a value V repays V of the loan, so equity is unchanged while the
+// requirement falls by V times the position's maintenance rate; the riskiest
+// positions (highest maintenance rate) go first, then the largest.
+func liquidationOrders(snap *MarginSnapshot, callBuffer float64) []liquidationOrder {
+    deficit := snap.CallLevel - snap.Equity
+    positions := append([]MarginPosition(nil), snap.Positions...)
+    sort.SliceStable(positions, func(i, j int) bool {
+        if positions[i].Rates.MaintenanceRate != positions[j].Rates.MaintenanceRate {
+            return positions[i].Rates.MaintenanceRate > positions[j].Rates.MaintenanceRate
+        }
+        return positions[i].MarketValue > positions[j].MarketValue
+    })
+
+    var orders []liquidationOrder
+    for _, p := range positions {
+        if deficit <= 0 {
+            break
+        }
+        freed := p.Rates.MaintenanceRate * (1 + callBuffer)
+        if p.Price <= 0 || p.Quantity <= 0 || freed <= 0 {
+            continue
+        }
+        qty := p.Quantity
+        if value := deficit / freed; value < p.MarketValue {
+            // Whole shares where the holding allows, never more than is held
+            qty = math.Min(math.Ceil(value/p.Price), p.Quantity)
+        }
+        orders = append(orders, liquidationOrder{Symbol: p.Symbol, Quantity: qty})
+        deficit -= qty * p.Price * freed
+    }
+    return orders
+}
+
+// liquidate sells positions through the Trade Service until the account is
+// back above the call level; the sale proceeds repay the loan as they settle.
+// The next check sees the result and clears the status.
+func (m *MarginService) liquidate(snap *MarginSnapshot) error {
+    orders := liquidationOrders(snap, m.Config.CallBuffer)
+    if snap.Status == MarginStatusLiquidating && len(orders) == 0 {
+        return repository.SetMarginStatus(snap.UserID, MarginStatusLiquidating, snap.Equity, nil)
+    }
+    if err := repository.SetMarginStatus(snap.UserID, MarginStatusLiquidating, snap.Equity, nil); err != nil {
+        return err
+    }
+    log.Printf("Liquidating user=%s: equity %.2f below maintenance %.2f, %d orders\n",
+        snap.UserID, snap.Equity, snap.MaintenanceRequirement, len(orders))
+    if len(orders) == 0 {
+        log.Printf("User=%s has no positions left to sell; %.2f of margin loan is uncovered\n", snap.UserID, snap.Loan-snap.Cash)
+        notifyUserBilling(snap.UserID, fmt.Sprintf(
+            "Your account equity of %.2f %s is below the maintenance requirement and there are no positions left to sell. "+
+                "Please deposit funds to repay your margin loan of %.2f.", snap.Equity, snap.Currency, snap.Loan))
+        return nil
+    }
+
+    token, err := middleware.ServiceToken("billing-service")
+    if err != nil {
+        return err
+    }
+    var sold []string
+    for _, o := range orders {
+        if _, err := placeLiquidationOrder(snap.UserID, o.Symbol, o.Quantity, token); err != nil {
+            log.Printf("Liquidation SELL %.2f %s for user=%s failed: %v\n", o.Quantity, o.Symbol, snap.UserID, err)
+            continue
+        }
+        sold = append(sold, fmt.Sprintf("%.2f %s", o.Quantity, o.Symbol))
+    }
+    notifyUserBilling(snap.UserID, fmt.Sprintf(
+        "Your account equity of %.2f %s fell below the maintenance requirement of %.2f. "+
+            "To repay your margin loan we sold: %s.", snap.Equity, snap.Currency, snap.MaintenanceRequirement, strings.Join(sold, ", ")))
+    return nil
+}
+
+func outgoingContext(token string) context.Context {
+    md := metadata.New(map[string]string{"authorization": token})
+    return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+// fetchMarginPositions returns the user's long positions from the Portfolio
+// Service, priced at the bid (the last price if there is none) and converted
+// into currency through the Market Data Service. Positions without a quote or
+// FX rate are returned unpriced rather than failing the whole account.
+func fetchMarginPositions(userID, currency string) ([]MarginPosition, error) {
+    token, err := middleware.ServiceToken("billing-service")
+    if err != nil {
+        return nil, err
+    }
+    ctx := outgoingContext(token)
+
+    portfolioConn, err := grpc.Dial("localhost:50052", grpc.WithInsecure())
+    if err != nil {
+        return nil, fmt.Errorf("failed to dial portfolio service: %v", err)
+    }
+    defer portfolioConn.Close()
+    portfolio, err := pbPortfolio.NewPortfolioServiceClient(portfolioConn).GetPortfolio(ctx, &pbPortfolio.GetPortfolioRequest{UserId: userID})
+    if err != nil {
+        return nil, fmt.Errorf("GetPortfolio RPC failed: %v", err)
+    }
+
+    var positions []MarginPosition
+    var symbols []string
+    for _, h := range portfolio.GetHoldings() {
+        if h.GetQuantity() > 0 {
+            positions = append(positions, MarginPosition{Symbol: h.GetSymbol(), Quantity: h.GetQuantity()})
+            symbols = append(symbols, h.GetSymbol())
+        }
+    }
+    if len(positions) == 0 {
+        return nil, nil
+    }
+
+    mdConn, err := grpc.Dial("localhost:50054", grpc.WithInsecure())
+    if err != nil {
+        return nil, fmt.Errorf("failed to dial market data service: %v", err)
+    }
+    defer mdConn.Close()
+    md := pbMarketData.NewMarketDataServiceClient(mdConn)
+    quotes, err := md.GetQuotes(ctx, &pbMarketData.GetQuotesRequest{Symbols: symbols})
+    if err != nil {
+        return nil, fmt.Errorf("GetQuotes RPC failed: %v", err)
+    }
+    prices := map[string]float64{}
+    quoteErrors := map[string]string{}
+    for _, q := range quotes.GetQuotes() {
+        price := q.GetBid()
+        if price <= 0 {
+            price = q.GetPrice()
+        }
+        prices[strings.ToUpper(q.GetSymbol())] = price
+    }
+    for _, e := range quotes.GetErrors() {
+        quoteErrors[strings.ToUpper(e.GetSymbol())] = e.GetError()
+    }
+    priceMarginPositions(positions, prices, quoteErrors, func(symbol string) (float64, error) {
+        fx, err := md.GetFXRate(ctx, &pbMarketData.GetFXRateRequest{Symbol: symbol, Quote: currency})
+        if err != nil {
+            return 0, fmt.Errorf("GetFXRate RPC failed: %v", err)
+        }
+        if fx.GetRate() <= 0 {
+            return 0, fmt.Errorf("no usable FX rate for %s/%s", fx.GetBase(), currency)
+        }
+        return fx.GetRate(), nil
+    })
+    return positions, nil
+}
+
+// priceMarginPositions sets each position's price in the wallet currency from
+// prices (in the instrument's currency) and fx. A position without a usable
+// quote or FX rate keeps a zero price and records why in PriceError, so one
+// missing quote doesn't stop the rest of the account from being valued.
+func priceMarginPositions(positions []MarginPosition, prices map[string]float64, quoteErrors map[string]string,
+    fx func(symbol string) (float64, error)) {
+    for i := range positions {
+        p := &positions[i]
+        price, ok := prices[strings.ToUpper(p.Symbol)]
+        if !ok || price <= 0 {
+            p.PriceError = "no usable price"
+            if e := quoteErrors[strings.ToUpper(p.Symbol)]; e != "" {
+                p.PriceError = "no quote: " + e
+            }
+            continue
+        }
+        rate, err := fx(p.Symbol)
+        if err != nil {
+            p.PriceError = err.Error()
+            continue
+        }
+        p.Price = price * rate
+    }
+}
+
+// placeLiquidationOrder sells quantity of symbol at market for the user
+// through the Trade Service.
+func placeLiquidationOrder(userID, symbol string, quantity float64, token string) (string, error) {
+    conn, err := grpc.Dial("localhost:50053", grpc.WithInsecure())
+    if err != nil {
+        return "", fmt.Errorf("failed to dial trade service: %v", err)
+    }
+    defer conn.Close()
+
+    resp, err := pbTrade.NewTradeServiceClient(conn).PlaceOrder(outgoingContext(token), &pbTrade.PlaceOrderRequest{
+        UserId:    userID,
+        Symbol:    symbol,
+        Quantity:  quantity,
+        OrderType: "SELL",
+    })
+    if err != nil {
+        return "", fmt.Errorf("PlaceOrder RPC failed: %v", err)
+    }
+    if !resp.GetSuccess() {
+        return "", fmt.Errorf("PlaceOrder responded with success=false")
+    }
+    return resp.GetOrderId(), nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+    "errors"
+    "math"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+)
+
+func TestSplitInterest(t *testing.T) {
+    cases := []struct {
+        accrued, cents, remainder float64
+    }{
+        {0, 0, 0},
+        {-1, 0, 0},
+        {0.004, 0, 0.004},
+        {0.07, 0.07, 0},
+        {0.0329, 0.03, 0.0029},
+        {12.3456, 12.34, 0.0056},
+    }
+    for _, c := range cases {
+        cents, remainder := splitInterest(c.accrued)
+        if math.Abs(cents-c.cents) > 1e-9 || math.Abs(remainder-c.remainder) > 1e-9 {
+            t.Errorf("splitInterest(%v) = %v, %v; want %v, %v", c.accrued, cents, remainder, c.cents, c.remainder)
+        }
+    }
+}
+
+func TestPriceMarginPositionsSkipsMissingQuotes(t *testing.T) {
+    positions := []MarginPosition{
+        {Symbol: "AAPL", Quantity: 10},
+        {Symbol: "GONE", Quantity: 5},
+        {Symbol: "ZERO", Quantity: 5},
+        {Symbol: "SAP.DE", Quantity: 2},
+    }
+    prices := map[string]float64{"AAPL": 100, "ZERO": 0, "SAP.DE": 50}
+    quoteErrors := map[string]string{"GONE": "symbol not found"}
+    fx := func(symbol string) (float64, error) {
+        if symbol == "SAP.DE" {
+            return 0, errors.New("no usable FX rate for EUR/USD")
+        }
+        return 1, nil
+    }
+    priceMarginPositions(positions, prices, quoteErrors, fx)
+
+    if positions[0].Price != 100 || positions[0].PriceError != "" {
+        t.Errorf("AAPL = %.2f (%q), want 100", positions[0].Price, positions[0].PriceError)
+    }
+    for _, p := range positions[1:] {
+        if p.Price != 0 || p.PriceError == "" {
+            t.Errorf("%s = %.2f (%q), want unpriced", p.Symbol, p.Price, p.PriceError)
+        }
+    }
+    if positions[1].PriceError != "no quote: symbol not found" {
+        t.Errorf("GONE error = %q", positions[1].PriceError)
+    }
+
+    snap := &MarginSnapshot{Positions: positions}
+    if got := snap.Unpriced(); len(got) != 3 {
+        t.Errorf("Unpriced() = %v, want the three positions without a price", got)
+    }
+    // An unpriced position is never sold to cover a deficit
+    snap.CallLevel, snap.Equity = 1000, 0
+    for i := range snap.Positions {
+        snap.Positions[i].MarketValue = snap.Positions[i].Quantity * snap.Positions[i].Price
+        snap.Positions[i].Rates.MaintenanceRate = 0.25
+    }
+    for _, o := range liquidationOrders(snap, 0.1) {
+        if o.Symbol != "AAPL" {
+            t.Errorf("liquidation order for unpriced %s", o.Symbol)
+        }
+    }
+}
+
+// useMargin opens a margin account for userID with positions worth value.
+func useMargin(t *testing.T, userID string, value float64) *MarginService {
+    t.Helper()
+    useBillingDB(t)
+    if err := repository.EnsureMarginIndexes(); err != nil {
+        t.Fatal(err)
+    }
+    prev := marginPositions
+    marginPositions = func(string, string) ([]MarginPosition, error) {
+        return []MarginPosition{{Symbol: "AAPL", Quantity: value / 100, Price: 100}}, nil
+    }
+    t.Cleanup(func() { marginPositions = prev })
+    if _, err := repository.SetMarginEnabled(userID, true); err != nil {
+        t.Fatal(err)
+    }
+    return NewMarginService(DefaultMarginConfig(), 0)
+}
+
+func TestInterestRemainderCarriesAcrossAccruals(t *testing.T) {
+    m := useMargin(t, "alice", 0)
+    if _, err := repository.AdjustMarginLoan("alice", 100); err != nil {
+        t.Fatal(err)
+    }
+
+    // Hourly accruals on 100 at 12% are 0.0014 each, which used to round to 0
+    acct, err := repository.GetMarginAccount("alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    start := acct.InterestAccruedAt
+    for h := 1; h <= 24; h++ {
+        if err := m.accrueInterest(acct, start.Add(time.Duration(h)*time.Hour), true); err != nil {
+            t.Fatal(err)
+        }
+        if acct, err = repository.GetMarginAccount("alice"); err != nil {
+            t.Fatal(err)
+        }
+    }
+    daily := 100 * 0.12 / 365
+    if math.Abs(acct.InterestCharged+acct.InterestRemainder-daily) > 1e-6 {
+        t.Fatalf("charged %.4f + carried %.4f, want %.4f", acct.InterestCharged, acct.InterestRemainder, daily)
+    }
+    if acct.InterestCharged != 0.03 {
+        t.Fatalf("charged %.4f, want 0.03", acct.InterestCharged)
+    }
+    _, _, balance, err := AccountBalance(AccountMarginInterest)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if math.Abs(balance-0.03) > 1e-9 {
+        t.Fatalf("interest income %.4f, want 0.03", balance)
+    }
+}
+
+func TestBorrowLendsOnlyWhatTheWalletLacks(t *testing.T) {
+    m := useMargin(t, "alice", 1000)
+    if _, err := CreditUser("alice", 100, PurposeAdjustment, "seed"); err != nil {
+        t.Fatal(err)
+    }
+
+    borrowed, wallet, err := m.Borrow("alice", "AAPL", 250, "exec-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if borrowed != 150 || wallet.Balance != 0 {
+        t.Fatalf("borrowed %.2f with %.2f left, want 150 and 0", borrowed, wallet.Balance)
+    }
+    assertReconciled(t, "alice", 0)
+    acct, err := repository.GetMarginAccount("alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if acct.Loan != 150 {
+        t.Fatalf("loan = %.2f, want 150", acct.Loan)
+    }
+}
+
+func TestConcurrentBorrowsStayWithinBuyingPower(t *testing.T) {
+    m := useMargin(t, "alice", 1000)
+
+    // Each 300 fits the initial buying power of 1000, but once two are lent the
+    // equity of 400 is below the 500 requirement, so the rest must be refused.
+    const workers = 5
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    var total float64
+    refused := 0
+    for i := 0; i < workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            borrowed, _, err := m.Borrow("alice", "AAPL", 300, "exec")
+            mu.Lock()
+            defer mu.Unlock()
+            if err != nil {
+                if !strings.Contains(err.Error(), "insufficient buying power") {
+                    t.Error(err)
+                }
+                refused++
+                return
+            }
+            total += borrowed
+        }()
+    }
+    wg.Wait()
+
+    if math.Abs(total-600) > ledgerTolerance || refused != workers-2 {
+        t.Fatalf("lent %.2f with %d refused, want 600 and %d", total, refused, workers-2)
+    }
+    assertReconciled(t, "alice", 0)
+    acct, err := repository.GetMarginAccount("alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if math.Abs(acct.Loan-600) > ledgerTolerance {
+        t.Fatalf("loan = %.2f, want 600", acct.Loan)
+    }
+}
+
+func TestConcurrentBorrowsSplitTheWallet(t *testing.T) {
+    m := useMargin(t, "alice", 1000)
+    if _, err := CreditUser("alice", 100, PurposeAdjustment, "seed"); err != nil {
+        t.Fatal(err)
+    }
+
+    const workers = 5
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    var total float64
+    for i := 0; i < workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            borrowed, _, err := m.Borrow("alice", "AAPL", 50, "exec")
+            if err != nil {
+                t.Error(err)
+                return
+            }
+            mu.Lock()
+            total += borrowed
+            mu.Unlock()
+        }()
+    }
+    wg.Wait()
+
+    // 250 of purchases: the wallet's 100 and 150 lent, however they interleave
+    if math.Abs(total-150) > ledgerTolerance {
+        t.Fatalf("borrowed %.2f in total, want 150", total)
+    }
+    assertReconciled(t, "alice", 0)
+    acct, err := repository.GetMarginAccount("alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if math.Abs(acct.Loan-150) > ledgerTolerance {
+        t.Fatalf("loan = %.2f, want 150", acct.Loan)
+    }
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/razorpay/razorpay-go"
+)
+
+const (
+    GatewayRazorpay = "razorpay"
+    GatewayFake     = "fake"
+)
+
+// Gateway order states.
+const (
+    OrderCreated = "CREATED" // awaiting payment
+    OrderPaid    = "PAID"
+    OrderFailed  = "FAILED"
+)
+
+// Fake gateway behaviours.
+const (
+    FakeModeSuccess      = "success"       // orders are paid immediately
+    FakeModeDecline      = "decline"       // orders are rejected
+    FakeModeTimeout      = "timeout"       // the gateway never answers
+    FakeModeAsync        = "async"         // orders are created and paid after a delay
+    FakeModeAsyncDecline = "async_decline" // orders are created and their payment fails after a delay
+)
+
+var (
+    ErrPaymentDeclined = errors.New("payment declined by gateway")
+    ErrGatewayTimeout  = errors.New("payment gateway timed out")
+)
+
+// GatewayOrder is an order as the payment gateway sees it.
+type GatewayOrder struct {
+    OrderID       string
+    Amount        float64
+    Currency      string
+    Receipt       string
+    Status        string
+    PaymentID     string
+    FailureReason string
+}
+
+// PaymentGateway collects money from users on the platform's behalf.
+type PaymentGateway interface {
+    Name() string
+    // CreateOrder opens an order for amount (in major units). A declined
+    // payment returns ErrPaymentDeclined and a hung gateway ErrGatewayTimeout.
+    CreateOrder(amount float64, currency, receipt string) (*GatewayOrder, error)
+    FetchOrder(orderID string) (*GatewayOrder, error)
+    // Refund returns amount of a captured payment to the payer and returns the refund id.
+    Refund(paymentID string, amount float64) (string, error)
+}
+
+// PaymentGatewayFromEnv builds PAYMENT_GATEWAY: "razorpay" (default), using
+// RAZORPAY_KEY_ID/RAZORPAY_KEY_SECRET, or "fake", configured by FakeGatewayConfigFromEnv.
+func PaymentGatewayFromEnv() (PaymentGateway, error) {
+    var g PaymentGateway
+    switch name := strings.ToLower(os.Getenv("PAYMENT_GATEWAY")); name {
+    case "", GatewayRazorpay:
+        g = NewRazorpayGateway(os.Getenv("RAZORPAY_KEY_ID"), os.Getenv("RAZORPAY_KEY_SECRET"))
+    case GatewayFake:
+        cfg, err := FakeGatewayConfigFromEnv()
+        if err != nil {
+            return nil, err
+        }
+        fake := NewFakeGateway(cfg)
+        if secret := os.Getenv("RAZORPAY_WEBHOOK_SECRET"); secret != "" {
+            url := os.Getenv("FAKE_PAYMENT_WEBHOOK_URL")
+            if url == "" {
+                addr, err := WebhookAddrFromEnv()
+                if err != nil {
+                    return nil, err
+                }
+                url = "http://localhost" + addr + WebhookPath
+            }
+            fake.Webhooks = NewWebhookSimulator(url, secret)
+        }
+        g = fake
+    default:
+        return nil, fmt.Errorf("unknown PAYMENT_GATEWAY %q (use razorpay or fake)", name)
+    }
+    log.Printf("Payment gateway: %s\n", g.Name())
+    return g, nil
+}
+
+// toSubunits converts a major-unit amount to the currency's subunit (paise for INR).
+func toSubunits(amount float64) int64 {
+    return int64(amount*100 + 0.5)
+}
+
+// RazorpayGateway creates orders through the Razorpay API.
+type RazorpayGateway struct {
+    client *razorpay.Client
+}
+
+func NewRazorpayGateway(keyID, keySecret string) *RazorpayGateway {
+    return &RazorpayGateway{client: razorpay.NewClient(keyID, keySecret)}
+}
+
+func (g *RazorpayGateway) Name() string { return GatewayRazorpay }
+
+func (g *RazorpayGateway) CreateOrder(amount float64, currency, receipt string) (*GatewayOrder, error) {
+    order, err := g.client.Order.Create(map[string]interface{}{
+        "amount":          toSubunits(amount),
+        "currency":        currency,
+        "receipt":         receipt,
+        "payment_capture": 1,
+    }, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create Razorpay order: %v", err)
+    }
+    return razorpayOrder(order), nil
+}
+
+func (g *RazorpayGateway) FetchOrder(orderID string) (*GatewayOrder, error) {
+    order, err := g.client.Order.Fetch(orderID, nil, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch Razorpay order %s: %v", orderID, err)
+    }
+    return razorpayOrder(order), nil
+}
+
+func (g *RazorpayGateway) Refund(paymentID string, amount float64) (string, error) {
+    refund, err := g.client.Payment.Refund(paymentID, int(toSubunits(amount)), nil, nil)
+    if err != nil {
+        return "", fmt.Errorf("failed to refund Razorpay payment %s: %v", paymentID, err)
+    }
+    id, _ := refund["id"].(string)
+    return id, nil
+}
+
+func razorpayOrder(m map[string]interface{}) *GatewayOrder {
+    o := &GatewayOrder{Status: OrderCreated}
+    o.OrderID, _ = m["id"].(string)
+    o.Currency, _ = m["currency"].(string)
+    o.Receipt, _ = m["receipt"].(string)
+    if amount, ok := m["amount"].(float64); ok {
+        o.Amount = amount / 100
+    }
+    if status, _ := m["status"].(string); status == "paid" {
+        o.Status = OrderPaid
+    }
+    return o
+}
+
+// FakeGatewayConfig controls how the fake gateway behaves.
+type FakeGatewayConfig struct {
+    Mode         string
+    Latency      time.Duration // added to every call; how long a timeout takes
+    CaptureDelay time.Duration // async modes: time until an order's payment settles
+}
+
+// FakeGatewayConfigFromEnv reads FAKE_PAYMENT_MODE (success, decline, timeout,
+// async or async_decline; default success), FAKE_PAYMENT_LATENCY and
+// FAKE_PAYMENT_CAPTURE_DELAY (Go durations, defaults 0 and 2s).
+func FakeGatewayConfigFromEnv() (FakeGatewayConfig, error) {
+    cfg := FakeGatewayConfig{Mode: FakeModeSuccess, CaptureDelay: 2 * time.Second}
+    if v := os.Getenv("FAKE_PAYMENT_MODE"); v != "" {
+        cfg.Mode = strings.ToLower(v)
+    }
+    switch cfg.Mode {
+    case FakeModeSuccess, FakeModeDecline, FakeModeTimeout, FakeModeAsync, FakeModeAsyncDecline:
+    default:
+        return cfg, fmt.Errorf("invalid FAKE_PAYMENT_MODE %q", cfg.Mode)
+    }
+    for name, dst := range map[string]*time.Duration{
+        "FAKE_PAYMENT_LATENCY":       &cfg.Latency,
+        "FAKE_PAYMENT_CAPTURE_DELAY": &cfg.CaptureDelay,
+    } {
+        v := os.Getenv(name)
+        if v == "" {
+            continue
+        }
+        d, err := time.ParseDuration(v)
+        if err != nil || d < 0 {
+            return cfg, fmt.Errorf("invalid %s %q", name, v)
+        }
+        *dst = d
+    }
+    return cfg, nil
+}
+
+// FakeGateway is an in-memory gateway for local runs and tests; it never
+// touches the network, except to deliver webhooks to our own endpoint when
+// Webhooks is set.
+type FakeGateway struct {
+    // Webhooks, if set, receives payment.captured and payment.failed events the
+    // way Razorpay would send them.
+    Webhooks *WebhookSimulator
+
+    mu      sync.Mutex
+    cfg     FakeGatewayConfig
+    orders  map[string]*GatewayOrder
+    refunds map[string]float64 // payment id -> amount refunded
+}
+
+func NewFakeGateway(cfg FakeGatewayConfig) *FakeGateway {
+    if cfg.Mode == "" {
+        cfg.Mode = FakeModeSuccess
+    }
+    return &FakeGateway{cfg: cfg, orders: map[string]*GatewayOrder{}, refunds: map[string]float64{}}
+}
+
+func (g *FakeGateway) Name() string {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    return GatewayFake + ":" + g.cfg.Mode
+}
+
+// SetMode switches the behaviour for subsequent orders.
+func (g *FakeGateway) SetMode(mode string) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    g.cfg.Mode = mode
+}
+
+func (g *FakeGateway) CreateOrder(amount float64, currency, receipt string) (*GatewayOrder, error) {
+    g.mu.Lock()
+    cfg := g.cfg
+    g.mu.Unlock()
+
+    time.Sleep(cfg.Latency)
+    if cfg.Mode == FakeModeTimeout {
+        return nil, ErrGatewayTimeout
+    }
+
+    order := &GatewayOrder{
+        OrderID:  "order_fake_" + uuid.NewString()[:8],
+        Amount:   amount,
+        Currency: currency,
+        Receipt:  receipt,
+        Status:   OrderCreated,
+    }
+    switch cfg.Mode {
+    case FakeModeSuccess:
+        order.Status = OrderPaid
+        order.PaymentID = "pay_fake_" + uuid.NewString()[:8]
+    case FakeModeDecline:
+        order.Status = OrderFailed
+        order.FailureReason = "card declined"
+    }
+
+    // Copy it before it's shared: an async settlement can change it at any time
+    g.mu.Lock()
+    g.orders[order.OrderID] = order
+    out := *order
+    g.mu.Unlock()
+
+    switch cfg.Mode {
+    case FakeModeDecline:
+        return nil, ErrPaymentDeclined
+    case FakeModeSuccess:
+        g.notify(WebhookPaymentCaptured, out)
+    case FakeModeAsync:
+        time.AfterFunc(cfg.CaptureDelay, func() { g.settle(out.OrderID, OrderPaid) })
+    case FakeModeAsyncDecline:
+        time.AfterFunc(cfg.CaptureDelay, func() { g.settle(out.OrderID, OrderFailed) })
+    }
+    return &out, nil
+}
+
+// settle completes a pending order as paid or failed and sends the webhook.
+func (g *FakeGateway) settle(orderID, status string) {
+    g.mu.Lock()
+    o, ok := g.orders[orderID]
+    if !ok || o.Status != OrderCreated {
+        g.mu.Unlock()
+        return
+    }
+    o.Status = status
+    o.PaymentID = "pay_fake_" + uuid.NewString()[:8]
+    event := WebhookPaymentCaptured
+    if status == OrderFailed {
+        o.FailureReason = "payment failed at the bank"
+        event = WebhookPaymentFailed
+    }
+    snapshot := *o
+    g.mu.Unlock()
+    g.notify(event, snapshot)
+}
+
+func (g *FakeGateway) notify(event string, order GatewayOrder) {
+    if g.Webhooks == nil {
+        return
+    }
+    go g.Webhooks.Deliver(event, order)
+}
+
+func (g *FakeGateway) FetchOrder(orderID string) (*GatewayOrder, error) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    o, ok := g.orders[orderID]
+    if !ok {
+        return nil, fmt.Errorf("order %s not found", orderID)
+    }
+    out := *o
+    return &out, nil
+}
+
+func (g *FakeGateway) Refund(paymentID string, amount float64) (string, error) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    for _, o := range g.orders {
+        if o.PaymentID != paymentID || o.Status != OrderPaid {
+            continue
+        }
+        if g.refunds[paymentID]+amount > o.Amount+ledgerTolerance {
+            return "", fmt.Errorf("refund of %.2f exceeds the unrefunded amount of payment %s", amount, paymentID)
+        }
+        g.refunds[paymentID] += amount
+        return "rfnd_fake_" + uuid.NewString()[:8], nil
+    }
+    return "", fmt.Errorf("payment %s not found or not captured", paymentID)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+)
+
+func TestFakeGatewayModes(t *testing.T) {
+    tests := []struct {
+        mode   string
+        err    error
+        status string
+    }{
+        {FakeModeSuccess, nil, OrderPaid},
+        {FakeModeDecline, ErrPaymentDeclined, OrderFailed},
+        {FakeModeTimeout, ErrGatewayTimeout, ""},
+        {FakeModeAsync, nil, OrderCreated},
+    }
+    for _, tt := range tests {
+        g := NewFakeGateway(FakeGatewayConfig{Mode: tt.mode, CaptureDelay: time.Hour})
+        order, err := g.CreateOrder(25, "INR", "rcpt-1")
+        if !errors.Is(err, tt.err) {
+            t.Errorf("%s: err = %v, want %v", tt.mode, err, tt.err)
+            continue
+        }
+        if tt.err != nil {
+            if order != nil {
+                t.Errorf("%s: returned an order alongside %v", tt.mode, err)
+            }
+            continue
+        }
+        if order.Status != tt.status || order.Amount != 25 || order.Receipt != "rcpt-1" {
+            t.Errorf("%s: order %+v, want status %s", tt.mode, order, tt.status)
+        }
+        if (order.PaymentID != "") != (tt.status == OrderPaid) {
+            t.Errorf("%s: payment id %q with status %s", tt.mode, order.PaymentID, order.Status)
+        }
+    }
+}
+
+func TestFakeGatewayTimeoutWaitsForLatency(t *testing.T) {
+    g := NewFakeGateway(FakeGatewayConfig{Mode: FakeModeTimeout, Latency: 30 * time.Millisecond})
+    start := time.Now()
+    if _, err := g.CreateOrder(10, "INR", "r"); err != ErrGatewayTimeout {
+        t.Fatalf("err = %v, want ErrGatewayTimeout", err)
+    }
+    if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+        t.Fatalf("timed out after %v, before the configured latency", elapsed)
+    }
+}
+
+// webhookRecorder is a gateway webhook endpoint that passes each verified
+// event to the test.
+func webhookRecorder(t *testing.T, secret string) (*httptest.Server, <-chan razorpayWebhook) {
+    t.Helper()
+    events := make(chan razorpayWebhook, 4)
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        if !VerifyWebhookSignature(body, r.Header.Get("X-Razorpay-Signature"), secret) {
+            t.Errorf("webhook with an invalid signature")
+            w.WriteHeader(http.StatusUnauthorized)
+            return
+        }
+        var hook razorpayWebhook
+        if err := json.Unmarshal(body, &hook); err != nil {
+            t.Errorf("invalid webhook body: %v", err)
+        }
+        events <- hook
+    }))
+    t.Cleanup(srv.Close)
+    return srv, events
+}
+
+func TestFakeGatewayAsyncSettlement(t *testing.T) {
+    tests := []struct {
+        mode, event, status string
+    }{
+        {FakeModeAsync, WebhookPaymentCaptured, OrderPaid},
+        {FakeModeAsyncDecline, WebhookPaymentFailed, OrderFailed},
+    }
+    for _, tt := range tests {
+        srv, events := webhookRecorder(t, "whsec")
+        g := NewFakeGateway(FakeGatewayConfig{Mode: tt.mode, CaptureDelay: 20 * time.Millisecond})
+        g.Webhooks = NewWebhookSimulator(srv.URL, "whsec")
+
+        order, err := g.CreateOrder(40, "INR", "r")
+        if err != nil {
+            t.Fatal(err)
+        }
+        if order.Status != OrderCreated {
+            t.Fatalf("%s: new order is %s, want %s", tt.mode, order.Status, OrderCreated)
+        }
+
+        select {
+        case hook := <-events:
+            p := hook.Payload.Payment.Entity
+            if hook.Event != tt.event || p.OrderID != order.OrderID || p.Amount != 4000 || p.ID == "" {
+                t.Fatalf("%s: webhook %s for %+v", tt.mode, hook.Event, p)
+            }
+        case <-time.After(2 * time.Second):
+            t.Fatalf("%s: no webhook delivered", tt.mode)
+        }
+        fetched, err := g.FetchOrder(order.OrderID)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if fetched.Status != tt.status {
+            t.Fatalf("%s: order is %s after settling, want %s", tt.mode, fetched.Status, tt.status)
+        }
+    }
+}
+
+func TestFakeGatewayRefundLimits(t *testing.T) {
+    g := NewFakeGateway(FakeGatewayConfig{})
+    order, err := g.CreateOrder(30, "INR", "r")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := g.Refund(order.PaymentID, 20); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := g.Refund(order.PaymentID, 15); err == nil {
+        t.Fatal("refunded more than the payment")
+    }
+    if _, err := g.Refund(order.PaymentID, 10); err != nil {
+        t.Fatalf("refunding the rest: %v", err)
+    }
+    if _, err := g.Refund("pay_unknown", 1); err == nil {
+        t.Fatal("refunded an unknown payment")
+    }
+}
+
+func TestDepositLifecycleWithFakeGateway(t *testing.T) {
+    useBillingDB(t)
+    g := NewFakeGateway(FakeGatewayConfig{})
+
+    // Paid straight away: captured and credited
+    tx, err := processPayment(g, TxTypeDeposit, "alice", 100, "GATEWAY")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if tx.Status != TxCaptured {
+        t.Fatalf("success: status %s, want %s", tx.Status, TxCaptured)
+    }
+    assertReconciled(t, "alice", 100)
+
+    // Declined: recorded as failed, nothing credited
+    g.SetMode(FakeModeDecline)
+    if tx, err = processPayment(g, TxTypeDeposit, "alice", 50, "GATEWAY"); err != nil || tx.Status != TxFailed {
+        t.Fatalf("decline: %+v, %v; want FAILED", tx, err)
+    }
+    assertReconciled(t, "alice", 100)
+
+    // Timed out: an error and no transaction
+    g.SetMode(FakeModeTimeout)
+    if _, err = processPayment(g, TxTypeDeposit, "alice", 50, "GATEWAY"); err != ErrGatewayTimeout {
+        t.Fatalf("timeout: err = %v, want ErrGatewayTimeout", err)
+    }
+    assertReconciled(t, "alice", 100)
+
+    // Async: pending until the signed webhook reaches our handler
+    handler := &WebhookHandler{Secret: "whsec"}
+    srv := httptest.NewServer(handler)
+    defer srv.Close()
+    g = NewFakeGateway(FakeGatewayConfig{Mode: FakeModeAsync, CaptureDelay: 20 * time.Millisecond})
+    g.Webhooks = NewWebhookSimulator(srv.URL, "whsec")
+    handler.Gateway = g
+    tx, err = processPayment(g, TxTypeDeposit, "alice", 25, "GATEWAY")
+    if err != nil || tx.Status != TxPending {
+        t.Fatalf("async: %+v, %v; want PENDING", tx, err)
+    }
+    deadline := time.Now().Add(3 * time.Second)
+    for {
+        stored, err := repository.GetTransaction(tx.TransactionID)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if stored.Status == TxCaptured {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("async: still %s after the webhook", stored.Status)
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    assertReconciled(t, "alice", 125)
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+)
+
+// handlePaymentCaptured settles the transaction for a captured payment. The
+// webhook is signed, but before money reaches the wallet the amount must match
+// what we asked for and the gateway must confirm the order is paid.
+func handlePaymentCaptured(gateway PaymentGateway, p razorpayPayment) error {
+    tx, err := repository.GetTransactionByOrderID(p.OrderID)
+    if err == repository.ErrTransactionNotFound {
+        log.Printf("Ignoring capture of unknown order %s\n", p.OrderID)
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    if tx.Status != TxPending && tx.Status != TxFailed {
+        return nil // already captured; the gateway redelivers webhooks
+    }
+    if p.Amount != toSubunits(tx.Amount) || (p.Currency != "" && !strings.EqualFold(p.Currency, WalletCurrency())) {
+        log.Printf("Capture of order %s is %d %s, expected %d %s; not crediting\n",
+            p.OrderID, p.Amount, p.Currency, toSubunits(tx.Amount), WalletCurrency())
+        _, err := repository.TransitionTransaction(tx.TransactionID, TxPending, TxFailed, map[string]interface{}{
+            "payment_id":     p.ID,
+            "failure_reason": "captured amount does not match the order",
+        })
+        return err
+    }
+    order, err := gateway.FetchOrder(p.OrderID)
+    if err != nil {
+        return err
+    }
+    if order.Status != OrderPaid {
+        return fmt.Errorf("gateway reports order %s as %s, not paid", p.OrderID, order.Status)
+    }
+    return capturePayment(tx, p.ID)
+}
+
+// capturePayment marks tx CAPTURED and books it: a deposit is credited to the
+// wallet, a commission payment to fee income. Only the caller that wins the
+// status transition books it, so redelivered webhooks are harmless. A payment
+// can follow a failed attempt on the same order, so FAILED is capturable too.
+func capturePayment(tx *models.Transaction, paymentID string) error {
+    fields := map[string]interface{}{
+        "payment_id":     paymentID,
+        "success":        true,
+        "failure_reason": "",
+        "captured_at":    time.Now().Format(time.RFC3339),
+    }
+    from := TxPending
+    won, err := repository.TransitionTransaction(tx.TransactionID, from, TxCaptured, fields)
+    if err == nil && !won {
+        from = TxFailed
+        won, err = repository.TransitionTransaction(tx.TransactionID, from, TxCaptured, fields)
+    }
+    if err != nil || !won {
+        return err
+    }
+
+    if err := bookCapture(tx); err != nil {
+        // Put it back so the gateway's retry can book it
+        if _, revErr := repository.TransitionTransaction(tx.TransactionID, TxCaptured, from, map[string]interface{}{"success": false}); revErr != nil {
+            log.Printf("Payment %s captured but not booked and not reverted: %v\n", tx.TransactionID, revErr)
+        }
+        return err
+    }
+    tx.Status = TxCaptured
+    tx.PaymentID = paymentID
+    tx.Success = true
+    log.Printf("Payment %s (%s) captured: %.2f from user=%s\n", tx.TransactionID, tx.Type, tx.Amount, tx.UserID)
+    if tx.Type == TxTypeDeposit {
+        notifyUserBilling(tx.UserID, fmt.Sprintf("Your deposit of %.2f via %s was received and added to your wallet.", tx.Amount, tx.Method))
+    } else {
+        notifyUserBilling(tx.UserID, fmt.Sprintf("Your payment of %.2f via %s was processed successfully!", tx.Amount, tx.Method))
+    }
+    return nil
+}
+
+func bookCapture(tx *models.Transaction) error {
+    if tx.Type == TxTypeDeposit {
+        if _, err := CreditUser(tx.UserID, tx.Amount, "", tx.TransactionID); err != nil {
+            return fmt.Errorf("failed to credit wallet: %v", err)
+        }
+        return nil
+    }
+    // The commission was collected through the gateway: Dr gateway clearing, Cr fee income
+    if err := PostEntry(EntryFee, tx.UserID, tx.TransactionID, fmt.Sprintf("%s payment of %.2f", tx.Method, tx.Amount), WalletCurrency(),
+        models.Posting{Account: AccountGatewayClearing, Debit: tx.Amount},
+        models.Posting{Account: AccountFeeIncome, Credit: tx.Amount},
+    ); err != nil {
+        return fmt.Errorf("failed to post fee: %v", err)
+    }
+    return nil
+}
+
+// payFromWallet captures a WALLET payment: the amount is debited from the
+// user's wallet to fee income (Dr user cash, Cr fee income) under the
+// transaction's ID, so it can be refunded like a gateway payment. A wallet
+// that can't cover it leaves the transaction FAILED.
+func payFromWallet(tx *models.Transaction) (*models.Transaction, error) {
+    if err := repository.InsertTransaction(tx); err != nil {
+        return nil, fmt.Errorf("failed to record transaction: %v", err)
+    }
+    if _, err := DebitUser(tx.UserID, tx.Amount, PurposeFee, tx.TransactionID); err != nil {
+        if _, failErr := repository.TransitionTransaction(tx.TransactionID, TxPending, TxFailed, map[string]interface{}{
+            "failure_reason": err.Error(),
+        }); failErr != nil {
+            log.Printf("Failed to mark wallet payment %s failed: %v\n", tx.TransactionID, failErr)
+        }
+        tx.Status = TxFailed
+        tx.FailureReason = err.Error()
+        log.Printf("Wallet payment of %.2f for user=%s failed: %v\n", tx.Amount, tx.UserID, err)
+        return tx, nil
+    }
+
+    capturedAt := time.Now().Format(time.RFC3339)
+    if _, err := repository.TransitionTransaction(tx.TransactionID, TxPending, TxCaptured, map[string]interface{}{
+        "success":     true,
+        "captured_at": capturedAt,
+    }); err != nil {
+        // The wallet is already debited; put it back rather than leave a PENDING payment that took money
+        if _, creditErr := CreditUser(tx.UserID, tx.Amount, PurposeFee, tx.TransactionID); creditErr != nil {
+            log.Printf("Wallet payment %s debited but not recorded and not reversed: %v\n", tx.TransactionID, creditErr)
+        }
+        return nil, fmt.Errorf("failed to record wallet payment: %v", err)
+    }
+    tx.Status = TxCaptured
+    tx.Success = true
+    tx.CapturedAt = capturedAt
+    log.Printf("Wallet payment %s captured: %.2f from user=%s\n", tx.TransactionID, tx.Amount, tx.UserID)
+    return tx, nil
+}
+
+// handlePaymentFailed records a failed payment attempt on a pending order.
+func handlePaymentFailed(p razorpayPayment) error {
+    tx, err := repository.GetTransactionByOrderID(p.OrderID)
+    if err == repository.ErrTransactionNotFound {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    reason := p.ErrorDescription
+    if reason == "" {
+        reason = "payment failed"
+    }
+    won, err := repository.TransitionTransaction(tx.TransactionID, TxPending, TxFailed, map[string]interface{}{
+        "payment_id":     p.ID,
+        "failure_reason": reason,
+    })
+    if err != nil || !won {
+        return err
+    }
+    log.Printf("Payment %s failed: %s\n", tx.TransactionID, reason)
+    notifyUserBilling(tx.UserID, fmt.Sprintf("Your payment of %.2f via %s failed: %s", tx.Amount, tx.Method, reason))
+    return nil
+}
+
+// refundPayment returns amount (0 = everything not yet refunded) of a captured
+// payment to the payer. The refund is reserved on the transaction and, for a
+// deposit, taken out of the wallet before the gateway is asked; both are undone
+// if it refuses. A refunded commission is taken back out of fee income; one paid
+// from the wallet goes straight back into it. The returned wallet is nil for
+// commission refunds through the gateway.
+func refundPayment(gateway PaymentGateway, txID string, amount float64) (*models.Transaction, string, *models.Wallet, error) {
+    tx, err := repository.GetTransaction(txID)
+    if err != nil {
+        return nil, "", nil, err
+    }
+    walletPaid := tx.Method == PaymentMethodWallet
+    if tx.PaymentID == "" && !walletPaid {
+        return nil, "", nil, fmt.Errorf("transaction %s has no captured gateway payment", txID)
+    }
+    if amount == 0 {
+        amount = tx.Amount - tx.RefundedAmount
+    }
+    if amount <= 0 {
+        return nil, "", nil, fmt.Errorf("invalid refund amount")
+    }
+
+    if _, err := repository.AdjustRefundedAmount(txID, amount); err != nil {
+        return nil, "", nil, err
+    }
+    release := func() {
+        if _, err := repository.AdjustRefundedAmount(txID, -amount); err != nil {
+            log.Printf("Failed to release refund reservation on %s: %v\n", txID, err)
+        }
+    }
+
+    var wallet *models.Wallet
+    if walletPaid {
+        // Dr fee income, Cr user cash; no gateway involved
+        wallet, err = CreditUser(tx.UserID, amount, PurposeFee, txID)
+        if err != nil {
+            release()
+            return nil, "", nil, fmt.Errorf("failed to credit wallet for refund: %v", err)
+        }
+        tx, err = repository.GetTransaction(txID)
+        if err != nil {
+            return nil, "", nil, err
+        }
+        log.Printf("Refunded %.2f of wallet payment %s\n", amount, txID)
+        notifyUserBilling(tx.UserID, fmt.Sprintf("A refund of %.2f for your commission payment was added to your wallet.", amount))
+        return tx, "", wallet, nil
+    }
+    if tx.Type == TxTypeDeposit {
+        wallet, err = DebitUser(tx.UserID, amount, PurposeRefund, txID)
+        if err != nil {
+            release()
+            return nil, "", nil, fmt.Errorf("failed to debit wallet for refund: %v", err)
+        }
+    }
+    refundID, err := gateway.Refund(tx.PaymentID, amount)
+    if err != nil {
+        if wallet != nil {
+            if _, creditErr := CreditUser(tx.UserID, amount, PurposeRefund, txID); creditErr != nil {
+                log.Printf("Refund of %.2f on %s failed and the wallet debit was not reversed: %v\n", amount, txID, creditErr)
+            }
+        }
+        release()
+        return nil, "", nil, err
+    }
+    if wallet == nil {
+        if err := PostEntry(EntryRefund, tx.UserID, txID, fmt.Sprintf("refund of %.2f commission", amount), WalletCurrency(),
+            models.Posting{Account: AccountFeeIncome, Debit: amount},
+            models.Posting{Account: AccountGatewayClearing, Credit: amount},
+        ); err != nil {
+            log.Printf("Error posting commission refund %s to the ledger: %v\n", refundID, err)
+        }
+    }
+
+    tx, err = repository.GetTransaction(txID)
+    if err != nil {
+        return nil, "", nil, err
+    }
+    log.Printf("Refunded %.2f of payment %s (refund %s)\n", amount, txID, refundID)
+    notifyUserBilling(tx.UserID, fmt.Sprintf("A refund of %.2f for your %s payment is on its way.", amount, tx.Method))
+    return tx, refundID, wallet, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+)
+
+func TestWalletPaymentDebitsCashAndRefundsToWallet(t *testing.T) {
+    useBillingDB(t)
+    if _, err := CreditUser("alice", 50, "", "opening"); err != nil {
+        t.Fatal(err)
+    }
+
+    // No gateway is involved in a wallet payment
+    tx, err := processPayment(nil, TxTypePayment, "alice", 12, PaymentMethodWallet)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if tx.Status != TxCaptured {
+        t.Fatalf("status = %s, want %s", tx.Status, TxCaptured)
+    }
+    assertReconciled(t, "alice", 38)
+    if _, _, fees, err := AccountBalance(AccountFeeIncome); err != nil || fees != 12 {
+        t.Fatalf("fee income = %.2f (%v), want 12.00", fees, err)
+    }
+
+    refunded, _, wallet, err := refundPayment(nil, tx.TransactionID, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if refunded.Status != TxRefunded || wallet == nil || wallet.Balance != 50 {
+        t.Fatalf("after refund: status %s, wallet %+v; want %s and 50.00", refunded.Status, wallet, TxRefunded)
+    }
+    assertReconciled(t, "alice", 50)
+    if _, _, fees, _ := AccountBalance(AccountFeeIncome); fees != 0 {
+        t.Fatalf("fee income after refund = %.2f, want 0", fees)
+    }
+    if _, _, _, err := refundPayment(nil, tx.TransactionID, 1); err == nil {
+        t.Fatal("refunded more than was paid")
+    }
+}
+
+func TestWalletPaymentFailsWithoutFunds(t *testing.T) {
+    useBillingDB(t)
+    if _, err := CreditUser("alice", 5, "", "opening"); err != nil {
+        t.Fatal(err)
+    }
+    tx, err := processPayment(nil, TxTypePayment, "alice", 12, PaymentMethodWallet)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if tx.Status != TxFailed {
+        t.Fatalf("status = %s, want %s", tx.Status, TxFailed)
+    }
+    stored, err := repository.GetTransaction(tx.TransactionID)
+    if err != nil || stored.Status != TxFailed {
+        t.Fatalf("stored transaction %+v (%v), want FAILED", stored, err)
+    }
+    assertReconciled(t, "alice", 5)
+}
+
+func TestDepositCannotBePaidFromWallet(t *testing.T) {
+    if _, err := processPayment(nil, TxTypeDeposit, "alice", 10, PaymentMethodWallet); err == nil {
+        t.Fatal("a deposit was paid from the wallet")
+    }
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+const (
+    PayoutGatewayRazorpayX = "razorpayx"
+    PayoutGatewayFake      = "fake"
+)
+
+// Payout states.
+const (
+    PayoutProcessing = "PROCESSING" // accepted, money not yet with the bank
+    PayoutPaid       = "PAID"
+    PayoutFailed     = "FAILED"
+)
+
+// Fake payout behaviours.
+const (
+    FakePayoutSuccess = "success" // payouts are paid immediately
+    FakePayoutFail    = "fail"    // payouts are rejected by the bank
+    FakePayoutAsync   = "async"   // payouts process and are paid after a delay
+)
+
+// Payout is a transfer from the platform to a user's bank account.
+type Payout struct {
+    PayoutID      string
+    Status        string
+    FailureReason string
+}
+
+// PayoutGateway sends withdrawals to users' bank accounts.
+type PayoutGateway interface {
+    Name() string
+    // Payout transfers amount to destination. reference identifies the
+    // withdrawal and makes retries idempotent.
+    Payout(reference string, amount float64, currency, destination string) (*Payout, error)
+    FetchPayout(payoutID string) (*Payout, error)
+}
+
+// PayoutGatewayFromEnv builds PAYOUT_GATEWAY: "razorpayx" (default), using
+// RAZORPAY_KEY_ID/RAZORPAY_KEY_SECRET and RAZORPAYX_ACCOUNT_NUMBER, or "fake",
+// configured by FAKE_PAYOUT_MODE (success, fail or async) and FAKE_PAYOUT_DELAY.
+func PayoutGatewayFromEnv() (PayoutGateway, error) {
+    var g PayoutGateway
+    switch name := strings.ToLower(os.Getenv("PAYOUT_GATEWAY")); name {
+    case "", PayoutGatewayRazorpayX:
+        g = NewRazorpayXPayoutGateway(os.Getenv("RAZORPAY_KEY_ID"), os.Getenv("RAZORPAY_KEY_SECRET"), os.Getenv("RAZORPAYX_ACCOUNT_NUMBER"))
+    case PayoutGatewayFake:
+        mode := strings.ToLower(os.Getenv("FAKE_PAYOUT_MODE"))
+        switch mode {
+        case "":
+            mode = FakePayoutSuccess
+        case FakePayoutSuccess, FakePayoutFail, FakePayoutAsync:
+        default:
+            return nil, fmt.Errorf("invalid FAKE_PAYOUT_MODE %q", mode)
+        }
+        delay := 5 * time.Second
+        if v := os.Getenv("FAKE_PAYOUT_DELAY"); v != "" {
+            d, err := time.ParseDuration(v)
+            if err != nil || d < 0 {
+                return nil, fmt.Errorf("invalid FAKE_PAYOUT_DELAY %q", v)
+            }
+            delay = d
+        }
+        g = NewFakePayoutGateway(mode, delay)
+    default:
+        return nil, fmt.Errorf("unknown PAYOUT_GATEWAY %q (use razorpayx or fake)", name)
+    }
+    log.Printf("Payout gateway: %s\n", g.Name())
+    return g, nil
+}
+
+const razorpayXPayoutsURL = "https://api.razorpay.com/v1/payouts"
+
+// RazorpayXPayoutGateway pays out through the RazorpayX Payouts API. The
+// destination is the user's RazorpayX fund account id.
+type RazorpayXPayoutGateway struct {
+    keyID, keySecret string
+    accountNumber    string
+    client           *http.Client
+}
+
+func NewRazorpayXPayoutGateway(keyID, keySecret, accountNumber string) *RazorpayXPayoutGateway {
+    return &RazorpayXPayoutGateway{
+        keyID:         keyID,
+        keySecret:     keySecret,
+        accountNumber: accountNumber,
+        client:        &http.Client{Timeout: 15 * time.Second},
+    }
+}
+
+func (g *RazorpayXPayoutGateway) Name() string { return PayoutGatewayRazorpayX }
+
+func (g *RazorpayXPayoutGateway) Payout(reference string, amount float64, currency, destination string) (*Payout, error) {
+    if g.accountNumber == "" {
+        return nil, fmt.Errorf("RAZORPAYX_ACCOUNT_NUMBER is not set")
+    }
+    if destination == "" {
+        return nil, fmt.Errorf("a fund account is required for RazorpayX payouts")
+    }
+    body, err := json.Marshal(map[string]interface{}{
+        "account_number":       g.accountNumber,
+        "fund_account_id":      destination,
+        "amount":               toSubunits(amount),
+        "currency":             currency,
+        "mode":                 "IMPS",
+        "purpose":              "payout",
+        "queue_if_low_balance": true,
+        "reference_id":         reference,
+    })
+    if err != nil {
+        return nil, err
+    }
+    req, err := http.NewRequest(http.MethodPost, razorpayXPayoutsURL, bytes.NewReader(body))
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("X-Payout-Idempotency", reference)
+    return g.do(req)
+}
+
+func (g *RazorpayXPayoutGateway) FetchPayout(payoutID string) (*Payout, error) {
+    req, err := http.NewRequest(http.MethodGet, razorpayXPayoutsURL+"/"+payoutID, nil)
+    if err != nil {
+        return nil, err
+    }
+    return g.do(req)
+}
+
+func (g *RazorpayXPayoutGateway) do(req *http.Request) (*Payout, error) {
+    req.SetBasicAuth(g.keyID, g.keySecret)
+    resp, err := g.client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("RazorpayX request failed: %v", err)
+    }
+    defer resp.Body.Close()
+
+    var out struct {
+        ID            string `json:"id"`
+        Status        string `json:"status"`
+        FailureReason string `json:"failure_reason"`
+        Error         struct {
+            Description string `json:"description"`
+        } `json:"error"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+        return nil, fmt.Errorf("invalid RazorpayX response: %v", err)
+    }
+    if resp.StatusCode/100 != 2 {
+        return nil, fmt.Errorf("RazorpayX returned %s: %s", resp.Status, out.Error.Description)
+    }
+    p := &Payout{PayoutID: out.ID, Status: PayoutProcessing}
+    switch out.Status {
+    case "processed":
+        p.Status = PayoutPaid
+    case "failed", "rejected", "reversed", "cancelled":
+        p.Status = PayoutFailed
+        p.FailureReason = out.FailureReason
+        if p.FailureReason == "" {
+            p.FailureReason = "payout " + out.Status
+        }
+    }
+    return p, nil
+}
+
+// FakePayoutGateway pays out in memory for local runs and tests.
+type FakePayoutGateway struct {
+    mode  string
+    delay time.Duration
+
+    mu      sync.Mutex
+    payouts map[string]*Payout
+    byRef   map[string]string // reference -> payout id, for idempotent retries
+}
+
+func NewFakePayoutGateway(mode string, delay time.Duration) *FakePayoutGateway {
+    if mode == "" {
+        mode = FakePayoutSuccess
+    }
+    return &FakePayoutGateway{mode: mode, delay: delay, payouts: map[string]*Payout{}, byRef: map[string]string{}}
+}
+
+func (g *FakePayoutGateway) Name() string { return PayoutGatewayFake + ":" + g.mode }
+
+func (g *FakePayoutGateway) Payout(reference string, amount float64, currency, destination string) (*Payout, error) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    if id, ok := g.byRef[reference]; ok {
+        out := *g.payouts[id]
+        return &out, nil
+    }
+
+    p := &Payout{PayoutID: "pout_fake_" + uuid.NewString()[:8], Status: PayoutPaid}
+    switch g.mode {
+    case FakePayoutFail:
+        p.Status = PayoutFailed
+        p.FailureReason = "beneficiary bank rejected the transfer"
+    case FakePayoutAsync:
+        p.Status = PayoutProcessing
+        time.AfterFunc(g.delay, func() {
+            g.mu.Lock()
+            defer g.mu.Unlock()
+            p.Status = PayoutPaid
+        })
+    }
+    g.payouts[p.PayoutID] = p
+    g.byRef[reference] = p.PayoutID
+    out := *p
+    return &out, nil
+}
+
+func (g *FakePayoutGateway) FetchPayout(payoutID string) (*Payout, error) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    p, ok := g.payouts[payoutID]
+    if !ok {
+        return nil, fmt.Errorf("payout %s not found", payoutID)
+    }
+    out := *p
+    return &out, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+    "bytes"
+    "encoding/csv"
+    "fmt"
+    "math"
+    "strconv"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+)
+
+// StatementLine is one movement on a user's cash account.
+type StatementLine struct {
+    Date        time.Time
+    Type        string
+    Description string
+    Reference   string
+    Debit       float64 // money out of the wallet
+    Credit      float64 // money into the wallet
+    Balance     float64 // running balance after this line
+}
+
+// Statement itemizes a user's cash account over [From, To).
+type Statement struct {
+    UserID       string
+    Currency     string
+    From         time.Time
+    To           time.Time
+    Opening      float64
+    Closing      float64
+    TotalCredits float64
+    TotalDebits  float64
+    Lines        []StatementLine
+}
+
+// GenerateStatement builds a statement from the ledger: the opening balance is
+// everything journaled to the user's cash account before from, and each entry
+// in the period becomes a line with a running balance.
+func GenerateStatement(userID string, from, to time.Time) (*Statement, error) {
+    if userID == "" {
+        return nil, fmt.Errorf("user_id is required")
+    }
+    if !to.After(from) {
+        return nil, fmt.Errorf("statement period must end after it starts")
+    }
+    account := UserCashAccount(userID)
+
+    debits, credits, err := repository.AccountTotals(account, from)
+    if err != nil {
+        return nil, fmt.Errorf("failed to compute opening balance: %v", err)
+    }
+    entries, err := repository.GetJournalEntries(account, from, to, 0, true)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load journal entries: %v", err)
+    }
+
+    st := &Statement{
+        UserID:   userID,
+        Currency: WalletCurrency(),
+        From:     from,
+        To:       to,
+        Opening:  credits - debits,
+    }
+    if wallet, err := repository.GetWallet(userID); err == nil {
+        st.Currency = walletCurrency(wallet)
+    }
+
+    balance := st.Opening
+    for _, e := range entries {
+        line := StatementLine{
+            Date:        e.CreatedAt,
+            Type:        e.Type,
+            Description: e.Description,
+            Reference:   e.Reference,
+        }
+        for _, p := range e.Postings {
+            if p.Account != account {
+                continue
+            }
+            line.Debit += p.Debit
+            line.Credit += p.Credit
+        }
+        balance += line.Credit - line.Debit
+        line.Balance = balance
+        st.TotalCredits += line.Credit
+        st.TotalDebits += line.Debit
+        st.Lines = append(st.Lines, line)
+    }
+    st.Closing = balance
+    return st, nil
+}
+
+// ParseStatementPeriod turns a "YYYY-MM" month into [first of month, first of
+// next month) in UTC. An empty period means the current month.
+func ParseStatementPeriod(period string) (time.Time, time.Time, error) {
+    var start time.Time
+    if period == "" {
+        now := time.Now().UTC()
+        start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+    } else {
+        t, err := time.Parse("2006-01", period)
+        if err != nil {
+            return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q (use YYYY-MM)", period)
+        }
+        start = t
+    }
+    return start, start.AddDate(0, 1, 0), nil
+}
+
+// Filename is the suggested download name for the statement in the given extension.
+func (s *Statement) Filename(ext string) string {
+    return fmt.Sprintf("statement_%s_%s_%s.%s", s.UserID, s.From.Format("20060102"), s.To.Format("20060102"), ext)
+}
+
+// CSV renders the statement with a summary header followed by one row per line.
+func (s *Statement) CSV() ([]byte, error) {
+    var buf bytes.Buffer
+    w := csv.NewWriter(&buf)
+    rows := [][]string{
+        {"user_id", s.UserID},
+        {"currency", s.Currency},
+        {"from", s.From.Format(time.RFC3339)},
+        {"to", s.To.Format(time.RFC3339)},
+        {"opening_balance", formatAmount(s.Opening)},
+        {"total_credits", formatAmount(s.TotalCredits)},
+        {"total_debits", formatAmount(s.TotalDebits)},
+        {"closing_balance", formatAmount(s.Closing)},
+        {},
+        {"date", "type", "description", "reference", "debit", "credit", "balance"},
+    }
+    for _, l := range s.Lines {
+        rows = append(rows, []string{
+            l.Date.Format(time.RFC3339),
+            l.Type,
+            l.Description,
+            l.Reference,
+            formatAmount(l.Debit),
+            formatAmount(l.Credit),
+            formatAmount(l.Balance),
+        })
+    }
+    if err := w.WriteAll(rows); err != nil {
+        return nil, fmt.Errorf("failed to write statement CSV: %v", err)
+    }
+    return buf.Bytes(), nil
+}
+
+func formatAmount(v float64) string {
+    // Avoid printing "-0.00" for balances that round to zero
+    if math.Abs(v) < ledgerTolerance {
+        v = 0
+    }
+    return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+    "time"
+)
+
+// The PDF is written by hand: plain Courier text on A4 pages, which is all a
+// statement needs and keeps the service free of a PDF dependency.
+const (
+    pdfPageWidth    = 595
+    pdfPageHeight   = 842
+    pdfMargin       = 40
+    pdfFontSize     = 8
+    pdfLeading      = 11
+    pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+// PDF renders the statement as a fixed-width text report.
+func (s *Statement) PDF() ([]byte, error) {
+    return renderTextPDF(s.textLines()), nil
+}
+
+func (s *Statement) textLines() []string {
+    lines := []string{
+        "ACCOUNT STATEMENT",
+        "",
+        fmt.Sprintf("User:     %s", s.UserID),
+        fmt.Sprintf("Currency: %s", s.Currency),
+        fmt.Sprintf("Period:   %s to %s", s.From.Format("2006-01-02"), s.To.Format("2006-01-02")),
+        "",
+        fmt.Sprintf("Opening balance: %14s", formatAmount(s.Opening)),
+        fmt.Sprintf("Total credits:   %14s", formatAmount(s.TotalCredits)),
+        fmt.Sprintf("Total debits:    %14s", formatAmount(s.TotalDebits)),
+        fmt.Sprintf("Closing balance: %14s", formatAmount(s.Closing)),
+        "",
+    }
+    header := fmt.Sprintf("%-16s %-15s %-34s %12s %12s %12s", "Date", "Type", "Description", "Debit", "Credit", "Balance")
+    lines = append(lines, header, strings.Repeat("-", len(header)))
+    if len(s.Lines) == 0 {
+        lines = append(lines, "No movements in this period.")
+    }
+    for _, l := range s.Lines {
+        debit, credit := "", ""
+        if l.Debit != 0 {
+            debit = formatAmount(l.Debit)
+        }
+        if l.Credit != 0 {
+            credit = formatAmount(l.Credit)
+        }
+        lines = append(lines, fmt.Sprintf("%-16s %-15s %-34s %12s %12s %12s",
+            l.Date.UTC().Format("2006-01-02 15:04"), truncate(l.Type, 15), truncate(l.Description, 34),
+            debit, credit, formatAmount(l.Balance)))
+    }
+    lines = append(lines, "", fmt.Sprintf("Generated %s", time.Now().UTC().Format(time.RFC3339)))
+    return lines
+}
+
+func truncate(v string, n int) string {
+    if len(v) <= n {
+        return v
+    }
+    return v[:n-1] + "~"
+}
+
+// renderTextPDF lays lines out top to bottom, starting a new page when one fills.
+func renderTextPDF(lines []string) []byte {
+    var pages [][]string
+    for len(lines) > pdfLinesPerPage {
+        pages = append(pages, lines[:pdfLinesPerPage])
+        lines = lines[pdfLinesPerPage:]
+    }
+    pages = append(pages, lines)
+
+    // Object layout: 1 catalog, 2 page tree, 3 font, then a page and its
+    // content stream for each page.
+    var objects []string
+    kids := make([]string, len(pages))
+    for i := range pages {
+        kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+    }
+    objects = append(objects,
+        "<< /Type /Catalog /Pages 2 0 R >>",
+        fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+        "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+    )
+    for i, page := range pages {
+        var content bytes.Buffer
+        fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+        for _, l := range page {
+            fmt.Fprintf(&content, "(%s) '\n", pdfEscape(l))
+        }
+        content.WriteString("ET")
+        objects = append(objects,
+            fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+                pdfPageWidth, pdfPageHeight, 5+2*i),
+            fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+        )
+    }
+
+    var buf bytes.Buffer
+    buf.WriteString("%PDF-1.4\n")
+    offsets := make([]int, len(objects))
+    for i, obj := range objects {
+        offsets[i] = buf.Len()
+        fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+    }
+    xref := buf.Len()
+    fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+    for _, off := range offsets {
+        fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+    }
+    fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+    return buf.Bytes()
+}
+
+// pdfEscape escapes a string literal and replaces characters Courier's
+// WinAnsi encoding can't show.
+func pdfEscape(v string) string {
+    var b strings.Builder
+    for _, r := range v {
+        switch {
+        case r == '\\' || r == '(' || r == ')':
+            b.WriteByte('\\')
+            b.WriteRune(r)
+        case r < 32 || r > 126:
+            b.WriteByte('?')
+        default:
+            b.WriteRune(r)
+        }
+    }
+    return b.String()
+}
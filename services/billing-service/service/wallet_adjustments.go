@@ -0,0 +1,48 @@
+package service
+
+import (
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+    "github.com/google/uuid"
+)
+
+// AdjustWallet credits a wallet without a payment behind it and keeps an audit
+// record of who did it and why. The record is written first, so a credit can
+// never happen without one; it is marked APPLIED or FAILED afterwards.
+func AdjustWallet(userID string, amount float64, purpose, reference, reason, admin string) (*models.Wallet, string, error) {
+    adj := &models.WalletAdjustment{
+        AdjustmentID: uuid.NewString(),
+        UserID:       userID,
+        Amount:       amount,
+        Purpose:      purpose,
+        Reference:    reference,
+        Reason:       reason,
+        Admin:        admin,
+        Status:       "PENDING",
+        CreatedAt:    time.Now().UTC(),
+    }
+    if adj.Reference == "" {
+        adj.Reference = adj.AdjustmentID
+    }
+    if err := repository.InsertWalletAdjustment(adj); err != nil {
+        return nil, "", fmt.Errorf("failed to record wallet adjustment: %v", err)
+    }
+
+    wallet, err := CreditUser(userID, amount, purpose, adj.Reference)
+    status, errMsg := "APPLIED", ""
+    if err != nil {
+        status, errMsg = "FAILED", err.Error()
+    }
+    if auditErr := repository.SetWalletAdjustmentStatus(adj.AdjustmentID, status, errMsg); auditErr != nil {
+        log.Printf("Failed to mark wallet adjustment %s %s: %v\n", adj.AdjustmentID, status, auditErr)
+    }
+    if err != nil {
+        return nil, adj.AdjustmentID, err
+    }
+    log.Printf("Wallet adjustment %s: %.2f %s to user=%s by %s (%s)\n", adj.AdjustmentID, amount, purpose, userID, admin, reason)
+    return wallet, adj.AdjustmentID, nil
+}
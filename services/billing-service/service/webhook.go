@@ -0,0 +1,131 @@
+package service
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "os"
+)
+
+// WebhookPath is where the gateway posts payment events.
+const WebhookPath = "/webhooks/razorpay"
+
+// Razorpay webhook events we act on.
+const (
+    WebhookPaymentCaptured = "payment.captured"
+    WebhookPaymentFailed   = "payment.failed"
+)
+
+// maxWebhookBody caps how much of a webhook request is read.
+const maxWebhookBody = 1 << 20
+
+// razorpayWebhook is the part of a Razorpay webhook body we use.
+type razorpayWebhook struct {
+    Event   string `json:"event"`
+    Payload struct {
+        Payment struct {
+            Entity razorpayPayment `json:"entity"`
+        } `json:"payment"`
+    } `json:"payload"`
+}
+
+type razorpayPayment struct {
+    ID               string `json:"id"`
+    OrderID          string `json:"order_id"`
+    Amount           int64  `json:"amount"` // subunits
+    Currency         string `json:"currency"`
+    Status           string `json:"status"`
+    ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// WebhookAddrFromEnv reads BILLING_WEBHOOK_ADDR, the HTTP listen address for
+// gateway webhooks (default ":8055").
+func WebhookAddrFromEnv() (string, error) {
+    v := os.Getenv("BILLING_WEBHOOK_ADDR")
+    if v == "" {
+        return ":8055", nil
+    }
+    if v[0] != ':' {
+        return "", fmt.Errorf("invalid BILLING_WEBHOOK_ADDR %q (use :port)", v)
+    }
+    return v, nil
+}
+
+// SignWebhook is Razorpay's webhook signature: hex HMAC-SHA256 of the raw body.
+func SignWebhook(body []byte, secret string) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write(body)
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks signature against the body in constant time.
+func VerifyWebhookSignature(body []byte, signature, secret string) bool {
+    if secret == "" || signature == "" {
+        return false
+    }
+    return hmac.Equal([]byte(SignWebhook(body, secret)), []byte(signature))
+}
+
+// WebhookHandler receives Razorpay payment webhooks. Only signed requests are
+// accepted, and a captured payment is checked against the gateway before the
+// wallet is credited.
+type WebhookHandler struct {
+    Secret  string
+    Gateway PaymentGateway
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+    if err != nil {
+        http.Error(w, "failed to read body", http.StatusBadRequest)
+        return
+    }
+    if !VerifyWebhookSignature(body, r.Header.Get("X-Razorpay-Signature"), h.Secret) {
+        log.Printf("Rejected webhook with an invalid signature from %s\n", r.RemoteAddr)
+        http.Error(w, "invalid signature", http.StatusUnauthorized)
+        return
+    }
+
+    var event razorpayWebhook
+    if err := json.Unmarshal(body, &event); err != nil {
+        http.Error(w, "invalid payload", http.StatusBadRequest)
+        return
+    }
+    p := event.Payload.Payment.Entity
+    switch event.Event {
+    case WebhookPaymentCaptured:
+        err = handlePaymentCaptured(h.Gateway, p)
+    case WebhookPaymentFailed:
+        err = handlePaymentFailed(p)
+    default:
+        log.Printf("Ignoring webhook event %s\n", event.Event)
+    }
+    if err != nil {
+        // A non-2xx status makes the gateway retry the delivery
+        log.Printf("Webhook %s for order %s failed: %v\n", event.Event, p.OrderID, err)
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    w.WriteHeader(http.StatusOK)
+}
+
+// StartWebhookServer serves handler on addr in the background.
+func StartWebhookServer(addr string, handler *WebhookHandler) {
+    mux := http.NewServeMux()
+    mux.Handle(WebhookPath, handler)
+    go func() {
+        log.Printf("Billing webhooks listening on %s%s\n", addr, WebhookPath)
+        if err := http.ListenAndServe(addr, mux); err != nil {
+            log.Printf("Webhook server stopped: %v\n", err)
+        }
+    }()
+}
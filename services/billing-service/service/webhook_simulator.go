@@ -0,0 +1,81 @@
+package service
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// webhookAttempts is how many times the simulator delivers an event before giving
+// up, backing off between attempts like the real gateway.
+const webhookAttempts = 3
+
+// WebhookSimulator posts Razorpay-format, correctly signed webhooks to a local
+// endpoint, so the payment lifecycle can be exercised without Razorpay.
+type WebhookSimulator struct {
+    URL    string
+    Secret string
+    client *http.Client
+}
+
+func NewWebhookSimulator(url, secret string) *WebhookSimulator {
+    return &WebhookSimulator{URL: url, Secret: secret, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Send delivers one event for order and returns an error unless the endpoint
+// answers 2xx.
+func (s *WebhookSimulator) Send(event string, order GatewayOrder) error {
+    var hook razorpayWebhook
+    hook.Event = event
+    hook.Payload.Payment.Entity = razorpayPayment{
+        ID:       order.PaymentID,
+        OrderID:  order.OrderID,
+        Amount:   toSubunits(order.Amount),
+        Currency: order.Currency,
+        Status:   strings.TrimPrefix(event, "payment."),
+    }
+    if event == WebhookPaymentFailed {
+        hook.Payload.Payment.Entity.ErrorDescription = order.FailureReason
+    }
+    body, err := json.Marshal(hook)
+    if err != nil {
+        return err
+    }
+
+    req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("X-Razorpay-Signature", SignWebhook(body, s.Secret))
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode/100 != 2 {
+        return fmt.Errorf("webhook endpoint returned %s", resp.Status)
+    }
+    return nil
+}
+
+// Deliver sends an event, retrying with backoff, and logs if it never lands.
+func (s *WebhookSimulator) Deliver(event string, order GatewayOrder) {
+    backoff := time.Second
+    for attempt := 1; ; attempt++ {
+        err := s.Send(event, order)
+        if err == nil {
+            return
+        }
+        if attempt == webhookAttempts {
+            log.Printf("Simulated webhook %s for order %s not delivered: %v\n", event, order.OrderID, err)
+            return
+        }
+        time.Sleep(backoff)
+        backoff *= 2
+    }
+}
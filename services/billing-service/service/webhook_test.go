@@ -0,0 +1,114 @@
+package service
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+)
+
+func webhookBody(t *testing.T, event string, p razorpayPayment) []byte {
+    t.Helper()
+    var hook razorpayWebhook
+    hook.Event = event
+    hook.Payload.Payment.Entity = p
+    body, err := json.Marshal(hook)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return body
+}
+
+func postWebhook(h *WebhookHandler, body []byte, signature string) int {
+    req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader(string(body)))
+    if signature != "" {
+        req.Header.Set("X-Razorpay-Signature", signature)
+    }
+    rec := httptest.NewRecorder()
+    h.ServeHTTP(rec, req)
+    return rec.Code
+}
+
+func TestWebhookRejectsUnsignedRequests(t *testing.T) {
+    h := &WebhookHandler{Secret: "whsec", Gateway: NewFakeGateway(FakeGatewayConfig{})}
+    body := webhookBody(t, WebhookPaymentCaptured, razorpayPayment{ID: "pay_1", OrderID: "order_1", Amount: 100})
+
+    tests := []struct {
+        name      string
+        signature string
+    }{
+        {"no signature", ""},
+        {"wrong secret", SignWebhook(body, "other-secret")},
+        {"garbage", "not-a-signature"},
+    }
+    for _, tt := range tests {
+        if code := postWebhook(h, body, tt.signature); code != http.StatusUnauthorized {
+            t.Errorf("%s: status %d, want %d", tt.name, code, http.StatusUnauthorized)
+        }
+    }
+
+    // A signature over a different body doesn't carry over
+    tampered := webhookBody(t, WebhookPaymentCaptured, razorpayPayment{ID: "pay_1", OrderID: "order_1", Amount: 100000})
+    if code := postWebhook(h, tampered, SignWebhook(body, "whsec")); code != http.StatusUnauthorized {
+        t.Errorf("tampered body: status %d, want %d", code, http.StatusUnauthorized)
+    }
+
+    // With no secret configured nothing verifies
+    if code := postWebhook(&WebhookHandler{}, body, SignWebhook(body, "")); code != http.StatusUnauthorized {
+        t.Errorf("no secret: status %d, want %d", code, http.StatusUnauthorized)
+    }
+}
+
+func TestWebhookAcceptsSignedRequests(t *testing.T) {
+    h := &WebhookHandler{Secret: "whsec", Gateway: NewFakeGateway(FakeGatewayConfig{})}
+    body := webhookBody(t, "payment.authorized", razorpayPayment{ID: "pay_1", OrderID: "order_1"})
+    if code := postWebhook(h, body, SignWebhook(body, "whsec")); code != http.StatusOK {
+        t.Fatalf("signed event: status %d, want %d", code, http.StatusOK)
+    }
+
+    req := httptest.NewRequest(http.MethodGet, WebhookPath, nil)
+    rec := httptest.NewRecorder()
+    h.ServeHTTP(rec, req)
+    if rec.Code != http.StatusMethodNotAllowed {
+        t.Fatalf("GET: status %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+    }
+}
+
+func TestSignedCaptureCreditsOnlyTheOrderedAmount(t *testing.T) {
+    useBillingDB(t)
+    g := NewFakeGateway(FakeGatewayConfig{Mode: FakeModeAsync, CaptureDelay: 1 << 62})
+    h := &WebhookHandler{Secret: "whsec", Gateway: g}
+    tx, err := processPayment(g, TxTypeDeposit, "alice", 40, "GATEWAY")
+    if err != nil || tx.Status != TxPending {
+        t.Fatalf("deposit: %+v, %v; want PENDING", tx, err)
+    }
+
+    // The order isn't paid at the gateway yet, so a signed capture is refused and retried
+    capture := razorpayPayment{ID: "pay_1", OrderID: tx.GatewayOrderID, Amount: 4000, Currency: WalletCurrency()}
+    body := webhookBody(t, WebhookPaymentCaptured, capture)
+    if code := postWebhook(h, body, SignWebhook(body, "whsec")); code != http.StatusInternalServerError {
+        t.Fatalf("capture of an unpaid order: status %d, want %d", code, http.StatusInternalServerError)
+    }
+    g.settle(tx.GatewayOrderID, OrderPaid)
+
+    // Unsigned: ignored
+    if code := postWebhook(h, body, ""); code != http.StatusUnauthorized {
+        t.Fatalf("unsigned capture: status %d, want %d", code, http.StatusUnauthorized)
+    }
+    assertReconciled(t, "alice", 0)
+
+    // Signed: captured and credited once, however often it is delivered
+    for i := 0; i < 2; i++ {
+        if code := postWebhook(h, body, SignWebhook(body, "whsec")); code != http.StatusOK {
+            t.Fatalf("signed capture: status %d, want %d", code, http.StatusOK)
+        }
+    }
+    stored, err := repository.GetTransaction(tx.TransactionID)
+    if err != nil || stored.Status != TxCaptured {
+        t.Fatalf("transaction %+v (%v), want CAPTURED", stored, err)
+    }
+    assertReconciled(t, "alice", 40)
+}
@@ -0,0 +1,419 @@
+package service
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "strconv"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+    "github.com/google/uuid"
+)
+
+// Withdrawal states. The amount leaves the wallet when the withdrawal is
+// REQUESTED and either reaches the bank (PAID_OUT) or comes back (REVERSED):
+//
+//  REQUESTED -> [PENDING_REVIEW ->] APPROVED -> PROCESSING -> PAID_OUT
+//  PENDING_REVIEW -> REJECTED -> REVERSED
+//  PROCESSING -> FAILED -> REVERSED
+const (
+    WithdrawalRequested     = "REQUESTED"
+    WithdrawalPendingReview = "PENDING_REVIEW"
+    WithdrawalApproved      = "APPROVED"
+    WithdrawalProcessing    = "PROCESSING"
+    WithdrawalPaidOut       = "PAID_OUT"
+    WithdrawalFailed        = "FAILED"
+    WithdrawalRejected      = "REJECTED"
+    WithdrawalReversed      = "REVERSED"
+)
+
+// maxPayoutAttempts bounds how often a payout that errors is resubmitted before
+// the withdrawal is failed and reversed. Resubmits reuse the withdrawal id as the
+// idempotency key, so a payout that did go through is never sent twice.
+const maxPayoutAttempts = 5
+
+// WithdrawalConfig holds the limits applied to withdrawal requests.
+type WithdrawalConfig struct {
+    ReviewThreshold float64       // amounts above this need an admin's approval; 0 = no review
+    DailyLimit      float64       // per user over a rolling 24h; 0 = no limit
+    CoolingOff      time.Duration // deposits captured this recently can't be withdrawn
+    WorkerInterval  time.Duration // how often approved and in-flight withdrawals are advanced
+}
+
+func DefaultWithdrawalConfig() WithdrawalConfig {
+    return WithdrawalConfig{
+        ReviewThreshold: 50000,
+        DailyLimit:      200000,
+        CoolingOff:      24 * time.Hour,
+        WorkerInterval:  30 * time.Second,
+    }
+}
+
+// WithdrawalConfigFromEnv reads WITHDRAWAL_REVIEW_THRESHOLD, WITHDRAWAL_DAILY_LIMIT
+// (wallet currency amounts), WITHDRAWAL_COOLING_OFF and WITHDRAWAL_WORKER_INTERVAL
+// (Go durations) on top of the defaults.
+func WithdrawalConfigFromEnv() (WithdrawalConfig, error) {
+    cfg := DefaultWithdrawalConfig()
+    for name, dst := range map[string]*float64{
+        "WITHDRAWAL_REVIEW_THRESHOLD": &cfg.ReviewThreshold,
+        "WITHDRAWAL_DAILY_LIMIT":      &cfg.DailyLimit,
+    } {
+        if v := os.Getenv(name); v != "" {
+            f, err := strconv.ParseFloat(v, 64)
+            if err != nil || f < 0 {
+                return cfg, fmt.Errorf("invalid %s %q", name, v)
+            }
+            *dst = f
+        }
+    }
+    for name, dst := range map[string]*time.Duration{
+        "WITHDRAWAL_COOLING_OFF":     &cfg.CoolingOff,
+        "WITHDRAWAL_WORKER_INTERVAL": &cfg.WorkerInterval,
+    } {
+        if v := os.Getenv(name); v != "" {
+            d, err := time.ParseDuration(v)
+            if err != nil || d < 0 {
+                return cfg, fmt.Errorf("invalid %s %q", name, v)
+            }
+            *dst = d
+        }
+    }
+    return cfg, nil
+}
+
+// WithdrawalService runs the withdrawal workflow against a payout gateway.
+type WithdrawalService struct {
+    Config  WithdrawalConfig
+    Payouts PayoutGateway
+}
+
+func NewWithdrawalService(cfg WithdrawalConfig, payouts PayoutGateway) *WithdrawalService {
+    return &WithdrawalService{Config: cfg, Payouts: payouts}
+}
+
+// Request checks the daily limit and cooling-off period, holds the amount by
+// debiting the wallet, and queues the withdrawal for review or payout.
+func (w *WithdrawalService) Request(userID string, amount float64, destination string) (*models.Withdrawal, error) {
+    if userID == "" {
+        return nil, fmt.Errorf("user_id is required")
+    }
+    if amount <= 0 {
+        return nil, fmt.Errorf("invalid withdraw amount")
+    }
+    now := time.Now().UTC()
+    wd := &models.Withdrawal{
+        WithdrawalID: uuid.NewString(),
+        UserID:       userID,
+        Amount:       amount,
+        Destination:  destination,
+        Status:       WithdrawalRequested,
+        RequestedAt:  now,
+        UpdatedAt:    now,
+        History:      []models.WithdrawalEvent{{Status: WithdrawalRequested, At: now}},
+    }
+    if err := w.hold(wd); err != nil {
+        return nil, err
+    }
+    notifyUserBilling(userID, fmt.Sprintf("Your withdrawal of %.2f %s has been requested.", amount, wd.Currency))
+
+    next, note := WithdrawalApproved, "auto-approved"
+    if w.Config.ReviewThreshold > 0 && amount > w.Config.ReviewThreshold {
+        next, note = WithdrawalPendingReview, fmt.Sprintf("above the review threshold of %.2f", w.Config.ReviewThreshold)
+    }
+    updated, err := repository.TransitionWithdrawal(wd.WithdrawalID, WithdrawalRequested, next, note, nil)
+    if err != nil || updated == nil {
+        // Left REQUESTED (or already queued by the worker); the worker picks it up
+        if err != nil {
+            log.Printf("Failed to queue withdrawal %s: %v\n", wd.WithdrawalID, err)
+        }
+        return wd, nil
+    }
+    w.announce(updated)
+    if next == WithdrawalApproved {
+        go w.process(updated)
+    }
+    return updated, nil
+}
+
+// withdrawalLockTTL bounds how long a crashed request can keep a user's
+// withdrawals locked; withdrawalLockWait is how long a request queues behind
+// another one for the same user before giving up.
+const (
+    withdrawalLockTTL  = 30 * time.Second
+    withdrawalLockWait = 5 * time.Second
+)
+
+// hold checks the limits, debits the wallet and records the withdrawal under the
+// user's withdrawal lock, so concurrent requests can't each pass the daily limit
+// on the same history. The cooling-off check is part of the conditional debit,
+// so a trade spending the wallet in between can't free up recent deposits.
+func (w *WithdrawalService) hold(wd *models.Withdrawal) error {
+    userID, amount := wd.UserID, wd.Amount
+    deadline := time.Now().Add(withdrawalLockWait)
+    for {
+        ok, err := repository.AcquireWithdrawalLock(userID, wd.WithdrawalID, withdrawalLockTTL)
+        if err != nil {
+            return fmt.Errorf("failed to lock withdrawals: %v", err)
+        }
+        if ok {
+            break
+        }
+        if time.Now().After(deadline) {
+            return fmt.Errorf("another withdrawal for %s is in progress, try again", userID)
+        }
+        time.Sleep(25 * time.Millisecond)
+    }
+    defer func() {
+        if err := repository.ReleaseWithdrawalLock(userID, wd.WithdrawalID); err != nil {
+            log.Printf("Failed to release withdrawal lock for %s: %v\n", userID, err)
+        }
+    }()
+
+    now := time.Now().UTC()
+    if w.Config.DailyLimit > 0 {
+        used, err := repository.SumWithdrawalsSince(userID, now.Add(-24*time.Hour),
+            []string{WithdrawalFailed, WithdrawalRejected, WithdrawalReversed})
+        if err != nil {
+            return fmt.Errorf("failed to check daily limit: %v", err)
+        }
+        if used+amount > w.Config.DailyLimit+ledgerTolerance {
+            return fmt.Errorf("daily withdrawal limit of %.2f exceeded: %.2f already requested in the last 24h", w.Config.DailyLimit, used)
+        }
+    }
+    var recent float64
+    if w.Config.CoolingOff > 0 {
+        var err error
+        recent, err = repository.SumCapturedDepositsSince(userID, now.Add(-w.Config.CoolingOff))
+        if err != nil {
+            return fmt.Errorf("failed to check recent deposits: %v", err)
+        }
+    }
+
+    wallet, err := debitUserKeeping(userID, amount, maxAmount(recent-ledgerTolerance, 0), "", wd.WithdrawalID)
+    if err == repository.ErrInsufficientFunds && recent > 0 {
+        available := -recent
+        if current, getErr := repository.GetWallet(userID); getErr == nil {
+            available += current.Balance
+        }
+        return fmt.Errorf("only %.2f is withdrawable: %.2f was deposited in the last %s and is still cooling off",
+            maxAmount(available, 0), recent, w.Config.CoolingOff)
+    }
+    if err != nil {
+        return err
+    }
+    wd.Currency = walletCurrency(wallet)
+    if err := repository.InsertWithdrawal(wd); err != nil {
+        if _, revErr := CreditUser(userID, amount, PurposeWithdrawalReversal, wd.WithdrawalID); revErr != nil {
+            log.Printf("Withdrawal %s not recorded and its hold not released: %v\n", wd.WithdrawalID, revErr)
+        }
+        return fmt.Errorf("failed to record withdrawal: %v", err)
+    }
+    return nil
+}
+
+// Approve releases a withdrawal held for review to be paid out.
+func (w *WithdrawalService) Approve(withdrawalID, admin, note string) (*models.Withdrawal, error) {
+    wd, err := repository.TransitionWithdrawal(withdrawalID, WithdrawalPendingReview, WithdrawalApproved, note,
+        map[string]interface{}{"reviewed_by": admin, "review_note": note})
+    if err != nil {
+        return nil, err
+    }
+    if wd == nil {
+        return nil, w.notInState(withdrawalID, WithdrawalPendingReview)
+    }
+    w.announce(wd)
+    go w.process(wd)
+    return wd, nil
+}
+
+// Reject turns down a withdrawal held for review and returns the money to the wallet.
+func (w *WithdrawalService) Reject(withdrawalID, admin, note string) (*models.Withdrawal, error) {
+    if note == "" {
+        return nil, fmt.Errorf("a reason is required to reject a withdrawal")
+    }
+    wd, err := repository.TransitionWithdrawal(withdrawalID, WithdrawalPendingReview, WithdrawalRejected, note,
+        map[string]interface{}{"reviewed_by": admin, "review_note": note, "failure_reason": note})
+    if err != nil {
+        return nil, err
+    }
+    if wd == nil {
+        return nil, w.notInState(withdrawalID, WithdrawalPendingReview)
+    }
+    w.announce(wd)
+    if reversed := w.reverse(wd); reversed != nil {
+        return reversed, nil
+    }
+    return wd, nil
+}
+
+func (w *WithdrawalService) notInState(withdrawalID, status string) error {
+    wd, err := repository.GetWithdrawal(withdrawalID)
+    if err != nil {
+        return err
+    }
+    return fmt.Errorf("withdrawal %s is %s, not %s", withdrawalID, wd.Status, status)
+}
+
+// process submits the payout for an approved withdrawal.
+func (w *WithdrawalService) process(wd *models.Withdrawal) {
+    claimed, err := repository.TransitionWithdrawal(wd.WithdrawalID, WithdrawalApproved, WithdrawalProcessing, "payout submitted", nil)
+    if err != nil || claimed == nil {
+        return // someone else is processing it
+    }
+    w.submitPayout(claimed)
+}
+
+func (w *WithdrawalService) submitPayout(wd *models.Withdrawal) {
+    p, err := w.Payouts.Payout(wd.WithdrawalID, wd.Amount, wd.Currency, wd.Destination)
+    if err != nil {
+        attempts, incErr := repository.IncrementPayoutAttempts(wd.WithdrawalID)
+        if incErr != nil || attempts < maxPayoutAttempts {
+            log.Printf("Payout for withdrawal %s failed (attempt %d), will retry: %v\n", wd.WithdrawalID, attempts, err)
+            return
+        }
+        w.fail(wd, fmt.Sprintf("payout could not be submitted: %v", err))
+        return
+    }
+    w.applyPayout(wd, p)
+}
+
+// applyPayout moves a PROCESSING withdrawal on according to its payout's state.
+func (w *WithdrawalService) applyPayout(wd *models.Withdrawal, p *Payout) {
+    switch p.Status {
+    case PayoutPaid:
+        paid, err := repository.TransitionWithdrawal(wd.WithdrawalID, WithdrawalProcessing, WithdrawalPaidOut, "paid out",
+            map[string]interface{}{"payout_id": p.PayoutID})
+        if err != nil || paid == nil {
+            return
+        }
+        // The held money has left through the gateway: Dr withdrawal clearing, Cr gateway clearing
+        if err := PostEntry(EntryPayout, wd.UserID, wd.WithdrawalID, fmt.Sprintf("payout %s of %.2f", p.PayoutID, wd.Amount), wd.Currency,
+            models.Posting{Account: AccountWithdrawalClearing, Debit: wd.Amount},
+            models.Posting{Account: AccountGatewayClearing, Credit: wd.Amount},
+        ); err != nil {
+            log.Printf("Error posting payout %s to the ledger: %v\n", p.PayoutID, err)
+        }
+        w.announce(paid)
+    case PayoutFailed:
+        w.fail(wd, p.FailureReason)
+    default:
+        if wd.PayoutID == "" {
+            if _, err := repository.SetWithdrawalPayoutID(wd.WithdrawalID, p.PayoutID); err != nil {
+                log.Printf("Failed to record payout %s on withdrawal %s: %v\n", p.PayoutID, wd.WithdrawalID, err)
+            }
+        }
+    }
+}
+
+func (w *WithdrawalService) fail(wd *models.Withdrawal, reason string) {
+    failed, err := repository.TransitionWithdrawal(wd.WithdrawalID, WithdrawalProcessing, WithdrawalFailed, reason,
+        map[string]interface{}{"failure_reason": reason})
+    if err != nil || failed == nil {
+        return
+    }
+    w.announce(failed)
+    w.reverse(failed)
+}
+
+// reverse returns a failed or rejected withdrawal's money to the wallet. It
+// claims the withdrawal first, so the money is returned at most once.
+func (w *WithdrawalService) reverse(wd *models.Withdrawal) *models.Withdrawal {
+    reversed, err := repository.TransitionWithdrawal(wd.WithdrawalID, wd.Status, WithdrawalReversed, "funds returned to wallet", nil)
+    if err != nil || reversed == nil {
+        return nil
+    }
+    if _, err := CreditUser(wd.UserID, wd.Amount, PurposeWithdrawalReversal, wd.WithdrawalID); err != nil {
+        // Put it back so the worker retries the reversal
+        log.Printf("Failed to reverse withdrawal %s: %v\n", wd.WithdrawalID, err)
+        if _, err := repository.TransitionWithdrawal(wd.WithdrawalID, WithdrawalReversed, wd.Status, "reversal failed, will retry", nil); err != nil {
+            log.Printf("Withdrawal %s marked reversed but not credited: %v\n", wd.WithdrawalID, err)
+        }
+        return nil
+    }
+    w.announce(reversed)
+    return reversed
+}
+
+// announce tells the user their withdrawal reached a new stage.
+func (w *WithdrawalService) announce(wd *models.Withdrawal) {
+    var msg string
+    switch wd.Status {
+    case WithdrawalPendingReview:
+        msg = "is being reviewed"
+    case WithdrawalApproved:
+        msg = "has been approved and will be paid out shortly"
+    case WithdrawalPaidOut:
+        msg = "has been paid out to your bank account"
+    case WithdrawalFailed:
+        msg = "could not be paid out: " + wd.FailureReason
+    case WithdrawalRejected:
+        msg = "was rejected: " + wd.FailureReason
+    case WithdrawalReversed:
+        msg = "was returned to your wallet"
+    default:
+        return
+    }
+    notifyUserBilling(wd.UserID, fmt.Sprintf("Your withdrawal of %.2f %s %s.", wd.Amount, wd.Currency, msg))
+}
+
+// Start runs the worker that advances withdrawals: queues stranded requests,
+// submits approved payouts, polls in-flight ones and retries reversals.
+func (w *WithdrawalService) Start() {
+    if w.Config.WorkerInterval <= 0 {
+        return
+    }
+    go func() {
+        ticker := time.NewTicker(w.Config.WorkerInterval)
+        defer ticker.Stop()
+        for range ticker.C {
+            w.advance()
+        }
+    }()
+}
+
+func (w *WithdrawalService) advance() {
+    for _, status := range []string{WithdrawalRequested, WithdrawalApproved, WithdrawalProcessing, WithdrawalFailed, WithdrawalRejected} {
+        pending, err := repository.ListWithdrawals("", status, 0)
+        if err != nil {
+            log.Printf("Withdrawal worker: failed to list %s withdrawals: %v\n", status, err)
+            continue
+        }
+        for i := range pending {
+            wd := &pending[i]
+            switch status {
+            case WithdrawalRequested:
+                next, note := WithdrawalApproved, "auto-approved"
+                if w.Config.ReviewThreshold > 0 && wd.Amount > w.Config.ReviewThreshold {
+                    next, note = WithdrawalPendingReview, fmt.Sprintf("above the review threshold of %.2f", w.Config.ReviewThreshold)
+                }
+                if queued, err := repository.TransitionWithdrawal(wd.WithdrawalID, WithdrawalRequested, next, note, nil); err == nil && queued != nil {
+                    w.announce(queued)
+                }
+            case WithdrawalApproved:
+                w.process(wd)
+            case WithdrawalProcessing:
+                if wd.PayoutID == "" {
+                    w.submitPayout(wd)
+                    continue
+                }
+                p, err := w.Payouts.FetchPayout(wd.PayoutID)
+                if err != nil {
+                    log.Printf("Withdrawal worker: failed to fetch payout %s: %v\n", wd.PayoutID, err)
+                    continue
+                }
+                w.applyPayout(wd, p)
+            case WithdrawalFailed, WithdrawalRejected:
+                w.reverse(wd)
+            }
+        }
+    }
+}
+
+func maxAmount(a, b float64) float64 {
+    if a > b {
+        return a
+    }
+    return b
+}
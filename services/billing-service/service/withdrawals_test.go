@@ -0,0 +1,89 @@
+package service
+
+import (
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/billing-service/models"
+    "github.com/ankan8/swapsync/backend/services/billing-service/repository"
+)
+
+// useWithdrawals returns a service that sends every request to review, so no
+// payout goroutines outlive the test.
+func useWithdrawals(t *testing.T, dailyLimit float64, coolingOff time.Duration) *WithdrawalService {
+    t.Helper()
+    useBillingDB(t)
+    if err := repository.EnsureWithdrawalIndexes(); err != nil {
+        t.Fatal(err)
+    }
+    cfg := WithdrawalConfig{ReviewThreshold: 1, DailyLimit: dailyLimit, CoolingOff: coolingOff, WorkerInterval: time.Hour}
+    return NewWithdrawalService(cfg, NewFakePayoutGateway(FakePayoutSuccess, 0))
+}
+
+// requestConcurrently fires n withdrawals of amount at once and returns how many were accepted.
+func requestConcurrently(t *testing.T, w *WithdrawalService, userID string, n int, amount float64) int {
+    t.Helper()
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    accepted := 0
+    for i := 0; i < n; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if _, err := w.Request(userID, amount, "acct"); err == nil {
+                mu.Lock()
+                accepted++
+                mu.Unlock()
+            }
+        }()
+    }
+    wg.Wait()
+    return accepted
+}
+
+func TestConcurrentWithdrawalsRespectDailyLimit(t *testing.T) {
+    w := useWithdrawals(t, 100, 0)
+    if _, err := CreditUser("alice", 1000, PurposeAdjustment, "seed"); err != nil {
+        t.Fatal(err)
+    }
+
+    if got := requestConcurrently(t, w, "alice", 10, 30); got != 3 {
+        t.Fatalf("%d withdrawals accepted, want 3", got)
+    }
+    used, err := repository.SumWithdrawalsSince("alice", time.Now().Add(-time.Hour), nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if used != 90 {
+        t.Fatalf("withdrawn %.2f, want 90", used)
+    }
+    assertReconciled(t, "alice", 910)
+}
+
+func TestConcurrentWithdrawalsKeepRecentDeposits(t *testing.T) {
+    w := useWithdrawals(t, 0, 24*time.Hour)
+    if _, err := CreditUser("alice", 500, PurposeAdjustment, "seed"); err != nil {
+        t.Fatal(err)
+    }
+    err := repository.InsertTransaction(&models.Transaction{
+        TransactionID: "dep-1",
+        UserID:        "alice",
+        Amount:        300,
+        Type:          "DEPOSIT",
+        Status:        TxCaptured,
+        CapturedAt:    time.Now().Format(time.RFC3339),
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if _, err := w.Request("alice", 250, "acct"); err == nil || !strings.Contains(err.Error(), "only 200.00 is withdrawable") {
+        t.Fatalf("withdrawing cooling-off money: err = %v", err)
+    }
+    if got := requestConcurrently(t, w, "alice", 5, 100); got != 2 {
+        t.Fatalf("%d withdrawals accepted, want 2", got)
+    }
+    assertReconciled(t, "alice", 300)
+}
@@ -3,12 +3,20 @@ package main
 import (
     "context"
     "fmt"
+    "io"
     "log"
     "net"
     "os"
+    "sort"
+    "strings"
+    "time"
 
     "github.com/joho/godotenv"
 
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/internal/middleware"
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+
     pb "github.com/ankan8/swapsync/backend/services/market-data-service/proto"
     "github.com/ankan8/swapsync/backend/services/market-data-service/service"
     "google.golang.org/grpc"
@@ -17,25 +25,479 @@ import (
 // server implements the MarketDataServiceServer interface.
 type server struct {
     pb.UnimplementedMarketDataServiceServer
+    hub    *service.QuoteHub
+    alerts *service.AlertEngine
 }
 
-// GetQuote calls service.FetchQuote to get the real price, then returns it.
+// GetQuote returns the (possibly cached) quote along with its freshness.
 func (s *server) GetQuote(ctx context.Context, req *pb.GetQuoteRequest) (*pb.GetQuoteResponse, error) {
-    sym, price, ts, err := service.FetchQuote(req.GetSymbol())
+    q, fresh, err := service.FetchQuoteWithFreshness(req.GetSymbol())
     if err != nil {
         return nil, err
     }
+    return toQuoteResponse(q, fresh), nil
+}
 
-    // Debug
-    fmt.Printf("DEBUG: Market Data returning price=%.2f for symbol=%s\n", price, sym)
+// GetQuotes fetches several symbols at once and reports per-symbol failures.
+func (s *server) GetQuotes(ctx context.Context, req *pb.GetQuotesRequest) (*pb.GetQuotesResponse, error) {
+    resp := &pb.GetQuotesResponse{}
+    for _, r := range service.FetchQuotes(req.GetSymbols()) {
+        if r.Err != nil {
+            resp.Errors = append(resp.Errors, &pb.QuoteError{Symbol: r.Symbol, Error: r.Err.Error()})
+            continue
+        }
+        resp.Quotes = append(resp.Quotes, toQuoteResponse(r.Quote, r.Freshness))
+    }
+    return resp, nil
+}
 
-    return &pb.GetQuoteResponse{
-        Symbol:    sym,
-        Price:     price,
-        Timestamp: ts,
+// SearchSymbols returns instruments matching the query, best matches first.
+func (s *server) SearchSymbols(ctx context.Context, req *pb.SearchSymbolsRequest) (*pb.SearchSymbolsResponse, error) {
+    found, err := service.SearchSymbols(req.GetQuery(), int(req.GetLimit()))
+    if err != nil {
+        return nil, err
+    }
+    resp := &pb.SearchSymbolsResponse{}
+    for _, inst := range found {
+        resp.Instruments = append(resp.Instruments, &pb.Instrument{
+            Symbol:   inst.Symbol,
+            Name:     inst.Name,
+            Type:     inst.Type,
+            Region:   inst.Region,
+            Currency: inst.Currency,
+            Source:   inst.Source,
+        })
+    }
+    return resp, nil
+}
+
+// GetFXRate returns the conversion rate between two currencies.
+func (s *server) GetFXRate(ctx context.Context, req *pb.GetFXRateRequest) (*pb.FXRateResponse, error) {
+    rate, err := service.GetFXRate(req.GetBase(), req.GetQuote(), req.GetSymbol())
+    if err != nil {
+        return nil, err
+    }
+    return &pb.FXRateResponse{
+        Base:      rate.Base,
+        Quote:     rate.Quote,
+        Rate:      rate.Rate,
+        Source:    rate.Source,
+        Timestamp: formatTime(rate.Timestamp),
     }, nil
 }
 
+func toQuoteResponse(q *models.Quote, fresh service.Freshness) *pb.GetQuoteResponse {
+    return &pb.GetQuoteResponse{
+        Symbol:      q.Symbol,
+        Price:       q.Price,
+        Timestamp:   q.Timestamp,
+        CacheStatus: fresh.Status,
+        FetchedAt:   fresh.FetchedAt.Format(time.RFC3339),
+        AgeMs:       fresh.Age.Milliseconds(),
+
+        Bid:               q.Bid,
+        BidSize:           q.BidSize,
+        Ask:               q.Ask,
+        AskSize:           q.AskSize,
+        Open:              q.Open,
+        High:              q.High,
+        Low:               q.Low,
+        Volume:            q.Volume,
+        PreviousClose:     q.PreviousClose,
+        Change:            q.Change,
+        ChangePercent:     q.ChangePercent,
+        Source:            q.Source,
+        ExchangeTimestamp: q.ExchangeTimestamp,
+        Currency:          service.InstrumentCurrency(q.Symbol),
+    }
+}
+
+// StreamQuotes subscribes the stream to the quote hub. The first request sets the
+// buffer options; every request adds or removes symbols.
+func (s *server) StreamQuotes(stream pb.MarketDataService_StreamQuotesServer) error {
+    first, err := stream.Recv()
+    if err == io.EOF {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    sub := s.hub.NewSubscriber(int(first.GetBufferSize()), strings.ToUpper(first.GetSlowConsumerPolicy()))
+    defer s.hub.Close(sub)
+    sub.LimitSymbols(service.MaxStreamSymbols)
+    if err := applyStreamRequest(s.hub, sub, first); err != nil {
+        return err
+    }
+
+    ctx, cancel := context.WithCancel(stream.Context())
+    defer cancel()
+
+    // Read subscription changes until the client closes its side
+    recvErr := make(chan error, 1)
+    go func() {
+        for {
+            req, err := stream.Recv()
+            if err == nil {
+                err = applyStreamRequest(s.hub, sub, req)
+            }
+            if err != nil {
+                recvErr <- err
+                cancel()
+                return
+            }
+        }
+    }()
+
+    for {
+        q, err := sub.Next(ctx)
+        if err != nil {
+            select {
+            case err := <-recvErr:
+                if err == io.EOF {
+                    return nil
+                }
+                return err
+            default:
+                return stream.Context().Err()
+            }
+        }
+        if err := stream.Send(&pb.QuoteUpdate{
+            Symbol:    q.Symbol,
+            Price:     q.Price,
+            Timestamp: q.Timestamp,
+            Dropped:   sub.Dropped(),
+
+            Bid:               q.Bid,
+            BidSize:           q.BidSize,
+            Ask:               q.Ask,
+            AskSize:           q.AskSize,
+            Open:              q.Open,
+            High:              q.High,
+            Low:               q.Low,
+            Volume:            q.Volume,
+            PreviousClose:     q.PreviousClose,
+            Change:            q.Change,
+            ChangePercent:     q.ChangePercent,
+            Source:            q.Source,
+            ExchangeTimestamp: q.ExchangeTimestamp,
+        }); err != nil {
+            return err
+        }
+    }
+}
+
+// GetProviderHealth reports each upstream provider's state, primary first.
+func (s *server) GetProviderHealth(ctx context.Context, req *pb.ProviderHealthRequest) (*pb.ProviderHealthResponse, error) {
+    resp := &pb.ProviderHealthResponse{}
+    for _, h := range service.ProvidersHealth() {
+        resp.Providers = append(resp.Providers, &pb.ProviderHealth{
+            Name:                h.Name,
+            Healthy:             h.Healthy,
+            Throttled:           h.Throttled,
+            CooldownUntil:       formatTime(h.CooldownUntil),
+            ConsecutiveFailures: int32(h.ConsecutiveFailures),
+            TotalRequests:       h.TotalRequests,
+            TotalFailures:       h.TotalFailures,
+            TotalThrottled:      h.TotalThrottled,
+            LastError:           h.LastError,
+            LastSuccess:         formatTime(h.LastSuccess),
+            LastFailure:         formatTime(h.LastFailure),
+        })
+    }
+    return resp, nil
+}
+
+// GetBars returns stored OHLCV bars for the requested interval and range.
+func (s *server) GetBars(ctx context.Context, req *pb.GetBarsRequest) (*pb.GetBarsResponse, error) {
+    from, err := parseOptionalTime(req.GetFrom())
+    if err != nil {
+        return nil, fmt.Errorf("invalid from: %v", err)
+    }
+    to, err := parseOptionalTime(req.GetTo())
+    if err != nil {
+        return nil, fmt.Errorf("invalid to: %v", err)
+    }
+    symbol := strings.ToUpper(req.GetSymbol())
+    bars, err := service.GetBars(symbol, req.GetInterval(), from, to, req.GetLimit())
+    if err != nil {
+        return nil, err
+    }
+
+    resp := &pb.GetBarsResponse{Symbol: symbol, Interval: req.GetInterval()}
+    for _, b := range bars {
+        resp.Bars = append(resp.Bars, &pb.Bar{
+            Start:  b.Start.Format(time.RFC3339),
+            Open:   b.Open,
+            High:   b.High,
+            Low:    b.Low,
+            Close:  b.Close,
+            Volume: b.Volume,
+        })
+    }
+    return resp, nil
+}
+
+// GetIndicators computes the requested indicators over stored bars.
+func (s *server) GetIndicators(ctx context.Context, req *pb.GetIndicatorsRequest) (*pb.GetIndicatorsResponse, error) {
+    from, err := parseOptionalTime(req.GetFrom())
+    if err != nil {
+        return nil, fmt.Errorf("invalid from: %v", err)
+    }
+    to, err := parseOptionalTime(req.GetTo())
+    if err != nil {
+        return nil, fmt.Errorf("invalid to: %v", err)
+    }
+    symbol := strings.ToUpper(req.GetSymbol())
+    points, err := service.ComputeIndicators(symbol, req.GetInterval(), toIndicatorSpecs(req.GetIndicators()), from, to)
+    if err != nil {
+        return nil, err
+    }
+
+    resp := &pb.GetIndicatorsResponse{Symbol: symbol, Interval: req.GetInterval()}
+    for _, p := range points {
+        resp.Points = append(resp.Points, toIndicatorPoint(p))
+    }
+    return resp, nil
+}
+
+// StreamIndicators warms the indicators up from stored bars, sends the current
+// values, then an update for every live quote of the symbol.
+func (s *server) StreamIndicators(req *pb.StreamIndicatorsRequest, stream pb.MarketDataService_StreamIndicatorsServer) error {
+    symbol := strings.ToUpper(req.GetSymbol())
+    if symbol == "" {
+        return fmt.Errorf("symbol is required")
+    }
+    ind, err := service.NewIndicatorStream(symbol, req.GetInterval(), toIndicatorSpecs(req.GetIndicators()))
+    if err != nil {
+        return err
+    }
+    if err := stream.Send(toIndicatorPoint(ind.Current())); err != nil {
+        return err
+    }
+
+    sub := s.hub.NewSubscriber(0, service.PolicyConflate)
+    defer s.hub.Close(sub)
+    s.hub.Subscribe(sub, symbol)
+
+    for {
+        q, err := sub.Next(stream.Context())
+        if err != nil {
+            return nil
+        }
+        if err := stream.Send(toIndicatorPoint(ind.OnQuote(q, time.Now()))); err != nil {
+            return err
+        }
+    }
+}
+
+func toIndicatorSpecs(in []*pb.IndicatorSpec) []service.IndicatorSpec {
+    specs := make([]service.IndicatorSpec, 0, len(in))
+    for _, spec := range in {
+        specs = append(specs, service.IndicatorSpec{
+            Name:   spec.GetName(),
+            Period: int(spec.GetPeriod()),
+            Fast:   int(spec.GetFastPeriod()),
+            Slow:   int(spec.GetSlowPeriod()),
+            Signal: int(spec.GetSignalPeriod()),
+            StdDev: spec.GetStdDev(),
+        })
+    }
+    return specs
+}
+
+func toIndicatorPoint(p service.IndicatorPoint) *pb.IndicatorPoint {
+    out := &pb.IndicatorPoint{Start: p.Start.Format(time.RFC3339)}
+    keys := make([]string, 0, len(p.Values))
+    for key := range p.Values {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        out.Indicators = append(out.Indicators, &pb.IndicatorValue{Key: key, Values: p.Values[key]})
+    }
+    return out
+}
+
+// RecordTrade stores a trade print reported by trade-service (services and admins only).
+func (s *server) RecordTrade(ctx context.Context, req *pb.RecordTradeRequest) (*pb.RecordTradeResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    ts, err := parseOptionalTime(req.GetTimestamp())
+    if err != nil {
+        return nil, fmt.Errorf("invalid timestamp: %v", err)
+    }
+    if err := service.RecordTrade(strings.ToUpper(req.GetSymbol()), req.GetPrice(), req.GetQuantity(), ts); err != nil {
+        return nil, err
+    }
+    return &pb.RecordTradeResponse{Success: true}, nil
+}
+
+// AdjustHistory back-adjusts stored bars for a split and/or symbol change.
+// Only the corporate-action scheduler (an admin token) may call it.
+func (s *server) AdjustHistory(ctx context.Context, req *pb.AdjustHistoryRequest) (*pb.AdjustHistoryResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    effective, err := parseOptionalTime(req.GetEffectiveDate())
+    if err != nil {
+        return nil, fmt.Errorf("invalid effective_date: %v", err)
+    }
+    n, err := service.AdjustHistory(strings.ToUpper(req.GetSymbol()), strings.ToUpper(req.GetNewSymbol()), req.GetSplitRatio(), effective)
+    if err != nil {
+        return &pb.AdjustHistoryResponse{Success: false, BarsAdjusted: n}, err
+    }
+    return &pb.AdjustHistoryResponse{Success: true, BarsAdjusted: n}, nil
+}
+
+// CreatePriceAlert stores a new alert for the user.
+func (s *server) CreatePriceAlert(ctx context.Context, req *pb.CreatePriceAlertRequest) (*pb.PriceAlertResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    alert, err := s.alerts.CreateAlert(userID, service.AlertSpec{
+        Symbol:          req.GetSymbol(),
+        Condition:       req.GetCondition(),
+        Threshold:       req.GetThreshold(),
+        ReferencePrice:  req.GetReferencePrice(),
+        Recurring:       req.GetRecurring(),
+        CooldownSeconds: req.GetCooldownSeconds(),
+        Channel:         req.GetChannel(),
+        Active:          true,
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &pb.PriceAlertResponse{Alert: toProtoAlert(alert)}, nil
+}
+
+// UpdatePriceAlert replaces the alert's settings and re-arms it.
+func (s *server) UpdatePriceAlert(ctx context.Context, req *pb.UpdatePriceAlertRequest) (*pb.PriceAlertResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    alert, err := s.alerts.UpdateAlert(userID, req.GetAlertId(), service.AlertSpec{
+        Symbol:          req.GetSymbol(),
+        Condition:       req.GetCondition(),
+        Threshold:       req.GetThreshold(),
+        ReferencePrice:  req.GetReferencePrice(),
+        Recurring:       req.GetRecurring(),
+        CooldownSeconds: req.GetCooldownSeconds(),
+        Channel:         req.GetChannel(),
+        Active:          req.GetActive(),
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &pb.PriceAlertResponse{Alert: toProtoAlert(alert)}, nil
+}
+
+// DeletePriceAlert removes one of the user's alerts.
+func (s *server) DeletePriceAlert(ctx context.Context, req *pb.DeletePriceAlertRequest) (*pb.DeletePriceAlertResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    if err := s.alerts.DeleteAlert(userID, req.GetAlertId()); err != nil {
+        return nil, err
+    }
+    return &pb.DeletePriceAlertResponse{Success: true}, nil
+}
+
+// ListPriceAlerts returns the user's alerts.
+func (s *server) ListPriceAlerts(ctx context.Context, req *pb.ListPriceAlertsRequest) (*pb.ListPriceAlertsResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    alerts, err := s.alerts.ListAlerts(userID)
+    if err != nil {
+        return nil, err
+    }
+    resp := &pb.ListPriceAlertsResponse{}
+    for i := range alerts {
+        resp.Alerts = append(resp.Alerts, toProtoAlert(&alerts[i]))
+    }
+    return resp, nil
+}
+
+func toProtoAlert(a *models.PriceAlert) *pb.PriceAlert {
+    return &pb.PriceAlert{
+        AlertId:         a.AlertID,
+        UserId:          a.UserID,
+        Symbol:          a.Symbol,
+        Condition:       a.Condition,
+        Threshold:       a.Threshold,
+        ReferencePrice:  a.ReferencePrice,
+        Recurring:       a.Recurring,
+        CooldownSeconds: a.CooldownSeconds,
+        Channel:         a.Channel,
+        Active:          a.Active,
+        TriggerCount:    a.TriggerCount,
+        LastTriggeredAt: a.LastTriggeredAt,
+        LastPrice:       a.LastPrice,
+        CreatedAt:       a.CreatedAt,
+        UpdatedAt:       a.UpdatedAt,
+    }
+}
+
+// PublishTopOfBook stores the internal book's best bid/ask reported by trade-service
+// (services and admins only).
+func (s *server) PublishTopOfBook(ctx context.Context, req *pb.TopOfBookUpdate) (*pb.TopOfBookResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    ts, err := parseOptionalTime(req.GetTimestamp())
+    if err != nil {
+        return nil, fmt.Errorf("invalid timestamp: %v", err)
+    }
+    err = service.RecordTopOfBook(strings.ToUpper(req.GetSymbol()),
+        req.GetBid(), req.GetBidSize(), req.GetAsk(), req.GetAskSize(), ts)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.TopOfBookResponse{Success: true}, nil
+}
+
+func parseOptionalTime(v string) (time.Time, error) {
+    if v == "" {
+        return time.Time{}, nil
+    }
+    return time.Parse(time.RFC3339, v)
+}
+
+func formatTime(t time.Time) string {
+    if t.IsZero() {
+        return ""
+    }
+    return t.Format(time.RFC3339)
+}
+
+// maxSymbolLength bounds the symbols a quote stream may name.
+const maxSymbolLength = 20
+
+// applyStreamRequest subscribes or unsubscribes the request's symbols. A request that
+// would take the stream past service.MaxStreamSymbols, or names a malformed symbol,
+// is rejected as a whole and ends the stream.
+func applyStreamRequest(hub *service.QuoteHub, sub *service.Subscriber, req *pb.StreamQuotesRequest) error {
+    symbols := make([]string, 0, len(req.GetSymbols()))
+    for _, sym := range req.GetSymbols() {
+        symbol := strings.ToUpper(strings.TrimSpace(sym))
+        if len(symbol) > maxSymbolLength {
+            return fmt.Errorf("invalid symbol %q", symbol)
+        }
+        symbols = append(symbols, symbol)
+    }
+    if strings.ToUpper(req.GetAction()) == "UNSUBSCRIBE" {
+        hub.Unsubscribe(sub, symbols...)
+        return nil
+    }
+    return hub.Subscribe(sub, symbols...)
+}
+
 func init() {
     // Optional: Load .env file so we can read ALPHA_VANTAGE_KEY, etc.
     if err := godotenv.Load(); err != nil {
@@ -43,7 +505,9 @@ func init() {
     }
 
     // (Optional) Debug: print ALPHA_VANTAGE_KEY if needed
-    if key := os.Getenv("ALPHA_VANTAGE_KEY"); key == "" {
+    if p := os.Getenv("MARKET_DATA_PROVIDER"); p != "" && p != service.ProviderAlphaVantage {
+        log.Printf("MARKET_DATA_PROVIDER=%s; Alpha Vantage key not required.", p)
+    } else if key := os.Getenv("ALPHA_VANTAGE_KEY"); key == "" {
         log.Println("ALPHA_VANTAGE_KEY not set; real quotes won't work!")
     } else {
         log.Println("ALPHA_VANTAGE_KEY is set (not printing for security).")
@@ -51,17 +515,69 @@ func init() {
 }
 
 func main() {
+    // Pick the quote source (Alpha Vantage, simulated or CSV replay).
+    if err := service.UseProviderFromEnv(); err != nil {
+        log.Fatalf("Failed to configure market data provider: %v", err)
+    }
+    if err := service.UseQuoteCacheFromEnv(); err != nil {
+        log.Fatalf("Failed to configure quote cache: %v", err)
+    }
+    if err := service.UseSourcePriorityFromEnv(); err != nil {
+        log.Fatalf("Failed to configure quote source priority: %v", err)
+    }
+    if err := service.LoadInstrumentsFromEnv(); err != nil {
+        log.Fatalf("Failed to load instruments: %v", err)
+    }
+    if err := service.UseFXProviderFromEnv(); err != nil {
+        log.Fatalf("Failed to configure FX rates: %v", err)
+    }
+
+    // Store quotes and trade prints, and keep the OHLCV bars up to date.
+    config.ConnectDB()
+    historyCfg, err := service.HistoryConfigFromEnv()
+    if err != nil {
+        log.Fatalf("Failed to configure market data history: %v", err)
+    }
+    if err := service.StartHistoryRecorder(historyCfg); err != nil {
+        log.Fatalf("%v", err)
+    }
+
     // Listen on port 50054 (or whichever you prefer).
     lis, err := net.Listen("tcp", ":50054") // or any free port
     if err != nil {
         log.Fatalf("Failed to listen on port 50054: %v", err)
     }
 
-    // Create a new gRPC server.
-    grpcServer := grpc.NewServer()
+    // Create a new gRPC server with the JWT interceptors.
+    grpcServer := grpc.NewServer(
+        grpc.UnaryInterceptor(middleware.UnaryJWTInterceptor),
+        grpc.StreamInterceptor(middleware.StreamJWTInterceptor),
+    )
+
+    // Poll each streamed symbol once per interval, shared by all subscribers.
+    pollInterval := 15 * time.Second
+    if v := os.Getenv("QUOTE_POLL_INTERVAL"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil && d > 0 {
+            pollInterval = d
+        }
+    }
+    hub := service.NewQuoteHub(service.FetchQuoteModel, pollInterval)
+
+    // Internal prints and book changes reach stream subscribers right away.
+    service.OnInternalUpdate(func(symbol string) {
+        if q, ok := service.MergedQuote(symbol); ok {
+            hub.Publish(*q)
+        }
+    })
+
+    // Price alerts subscribe to the hub for the symbols they watch.
+    alerts := service.NewAlertEngine(hub)
+    if err := alerts.Start(); err != nil {
+        log.Fatalf("%v", err)
+    }
 
     // Register the MarketDataServiceServer implementation.
-    pb.RegisterMarketDataServiceServer(grpcServer, &server{})
+    pb.RegisterMarketDataServiceServer(grpcServer, &server{hub: hub, alerts: alerts})
 
     log.Printf("Market Data Service listening on %v", lis.Addr())
     // Start serving gRPC
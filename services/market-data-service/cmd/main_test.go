@@ -0,0 +1,107 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/middleware"
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+    pb "github.com/ankan8/swapsync/backend/services/market-data-service/proto"
+    "github.com/ankan8/swapsync/backend/services/market-data-service/service"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+)
+
+// callAs runs handler behind the JWT interceptor with a token for a plain user.
+func callAs(t *testing.T, method string, handler grpc.UnaryHandler) error {
+    t.Helper()
+    token, err := middleware.UserToken("alice@example.com")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", token))
+    _, err = middleware.UnaryJWTInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+    return err
+}
+
+func TestPublishRPCsRequireAdmin(t *testing.T) {
+    t.Setenv("JWT_SECRET", "test-secret")
+    s := &server{}
+
+    err := callAs(t, "/marketdata.MarketDataService/RecordTrade", func(ctx context.Context, _ interface{}) (interface{}, error) {
+        return s.RecordTrade(ctx, &pb.RecordTradeRequest{Symbol: "AAPL", Price: 1, Quantity: 1})
+    })
+    if err == nil || !strings.Contains(err.Error(), "permission denied") {
+        t.Fatalf("RecordTrade by a user: err = %v, want permission denied", err)
+    }
+
+    err = callAs(t, "/marketdata.MarketDataService/PublishTopOfBook", func(ctx context.Context, _ interface{}) (interface{}, error) {
+        return s.PublishTopOfBook(ctx, &pb.TopOfBookUpdate{Symbol: "AAPL", Bid: 1, Ask: 2})
+    })
+    if err == nil || !strings.Contains(err.Error(), "permission denied") {
+        t.Fatalf("PublishTopOfBook by a user: err = %v, want permission denied", err)
+    }
+}
+
+func TestAdjustHistoryRequiresAdmin(t *testing.T) {
+    t.Setenv("JWT_SECRET", "test-secret")
+    s := &server{}
+
+    err := callAs(t, "/marketdata.MarketDataService/AdjustHistory", func(ctx context.Context, _ interface{}) (interface{}, error) {
+        return s.AdjustHistory(ctx, &pb.AdjustHistoryRequest{Symbol: "AAPL", SplitRatio: 2})
+    })
+    if err == nil || !strings.Contains(err.Error(), "permission denied") {
+        t.Fatalf("AdjustHistory by a user: err = %v, want permission denied", err)
+    }
+}
+
+func TestAlertsRejectAnotherUsersID(t *testing.T) {
+    t.Setenv("JWT_SECRET", "test-secret")
+    s := &server{}
+
+    calls := map[string]grpc.UnaryHandler{
+        "CreatePriceAlert": func(ctx context.Context, _ interface{}) (interface{}, error) {
+            return s.CreatePriceAlert(ctx, &pb.CreatePriceAlertRequest{UserId: "bob@example.com", Symbol: "AAPL", Condition: "ABOVE", Threshold: 1})
+        },
+        "UpdatePriceAlert": func(ctx context.Context, _ interface{}) (interface{}, error) {
+            return s.UpdatePriceAlert(ctx, &pb.UpdatePriceAlertRequest{UserId: "bob@example.com", AlertId: "a-1"})
+        },
+        "DeletePriceAlert": func(ctx context.Context, _ interface{}) (interface{}, error) {
+            return s.DeletePriceAlert(ctx, &pb.DeletePriceAlertRequest{UserId: "bob@example.com", AlertId: "a-1"})
+        },
+        "ListPriceAlerts": func(ctx context.Context, _ interface{}) (interface{}, error) {
+            return s.ListPriceAlerts(ctx, &pb.ListPriceAlertsRequest{UserId: "bob@example.com"})
+        },
+    }
+    for name, handler := range calls {
+        err := callAs(t, "/marketdata.MarketDataService/"+name, handler)
+        if err == nil || !strings.Contains(err.Error(), "permission denied") {
+            t.Errorf("%s for another user: err = %v, want permission denied", name, err)
+        }
+    }
+}
+
+func TestStreamRequestsAreCapped(t *testing.T) {
+    hub := service.NewQuoteHub(func(string) (*models.Quote, error) { return nil, errors.New("no upstream") }, time.Hour)
+    sub := hub.NewSubscriber(0, "")
+    defer hub.Close(sub)
+    sub.LimitSymbols(service.MaxStreamSymbols)
+
+    symbols := make([]string, service.MaxStreamSymbols+1)
+    for i := range symbols {
+        symbols[i] = fmt.Sprintf("sym%d", i)
+    }
+    if err := applyStreamRequest(hub, sub, &pb.StreamQuotesRequest{Symbols: symbols}); err == nil {
+        t.Fatal("stream subscribed past the symbol cap")
+    }
+    if err := applyStreamRequest(hub, sub, &pb.StreamQuotesRequest{Symbols: symbols[:service.MaxStreamSymbols]}); err != nil {
+        t.Fatal(err)
+    }
+    if err := applyStreamRequest(hub, sub, &pb.StreamQuotesRequest{Symbols: []string{strings.Repeat("X", 100)}}); err == nil {
+        t.Fatal("stream accepted a malformed symbol")
+    }
+}
@@ -0,0 +1,21 @@
+package models
+
+// PriceAlert is a user's price condition on a symbol.
+type PriceAlert struct {
+  AlertID         string  `bson:"alert_id"`
+  UserID          string  `bson:"user_id"`
+  Symbol          string  `bson:"symbol"`
+  Condition       string  `bson:"condition"` // "ABOVE", "BELOW" or "PERCENT_CHANGE"
+  Threshold       float64 `bson:"threshold"` // price, or percent (signed) for PERCENT_CHANGE
+  ReferencePrice  float64 `bson:"reference_price"` // base price for PERCENT_CHANGE
+  Recurring       bool    `bson:"recurring"`
+  CooldownSeconds int64   `bson:"cooldown_seconds"` // minimum gap between recurring firings
+  Channel         string  `bson:"channel"`          // notification channel, e.g. "PUSH"
+  Active          bool    `bson:"active"`
+  Armed           bool    `bson:"armed"` // false while the condition holds, so it fires once per crossing
+  TriggerCount    int64   `bson:"trigger_count"`
+  LastTriggeredAt string  `bson:"last_triggered_at"`
+  LastPrice       float64 `bson:"last_price"` // price that last triggered the alert
+  CreatedAt       string  `bson:"created_at"`
+  UpdatedAt       string  `bson:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Bar is an OHLCV bar in the shared "bars" collection (trade-service reads the 1m bars).
+type Bar struct {
+  Symbol   string    `bson:"symbol"`
+  Interval string    `bson:"interval"` // "1m", "5m", "1h" or "1d"
+  Start    time.Time `bson:"start"`
+  Open     float64   `bson:"open"`
+  High     float64   `bson:"high"`
+  Low      float64   `bson:"low"`
+  Close    float64   `bson:"close"`
+  Volume   float64   `bson:"volume"`
+  ExpireAt time.Time `bson:"expire_at,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// Instrument is a tradable symbol offered by symbol search.
+type Instrument struct {
+  Symbol   string `bson:"symbol"`
+  Name     string `bson:"name"`
+  Type     string `bson:"type"` // e.g. "EQUITY", "ETF", "CRYPTO"
+  Region   string `bson:"region"`
+  Currency string `bson:"currency"`
+  Source   string `bson:"source"` // "local" or the provider that found it
+}
@@ -1,7 +1,31 @@
 package models
 
+// Quote is a provider's view of a symbol. Fields a provider doesn't supply stay zero
+// (e.g. Alpha Vantage's GLOBAL_QUOTE has no bid/ask).
 type Quote struct {
-  Symbol    string  `bson:"symbol"`
-  Price     float64 `bson:"price"`
-  Timestamp string  `bson:"timestamp"`
+  Symbol            string  `bson:"symbol"`
+  Price             float64 `bson:"price"`     // last trade
+  Timestamp         string  `bson:"timestamp"` // when we received it (RFC3339)
+  Bid               float64 `bson:"bid"`
+  BidSize           float64 `bson:"bid_size"`
+  Ask               float64 `bson:"ask"`
+  AskSize           float64 `bson:"ask_size"`
+  Open              float64 `bson:"open"`
+  High              float64 `bson:"high"`
+  Low               float64 `bson:"low"`
+  Volume            float64 `bson:"volume"`
+  PreviousClose     float64 `bson:"previous_close"`
+  Change            float64 `bson:"change"`
+  ChangePercent     float64 `bson:"change_percent"`
+  Source            string  `bson:"source"`
+  ExchangeTimestamp string  `bson:"exchange_timestamp"` // as reported by the source
+}
+
+// FillChange derives Change/ChangePercent from PreviousClose when the source didn't.
+func (q *Quote) FillChange() {
+  if q.PreviousClose <= 0 || q.Change != 0 || q.ChangePercent != 0 {
+    return
+  }
+  q.Change = q.Price - q.PreviousClose
+  q.ChangePercent = q.Change / q.PreviousClose * 100
 }
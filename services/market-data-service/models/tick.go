@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Tick is one quote or trade print in the "market_ticks" time-series collection.
+type Tick struct {
+  Symbol string    `bson:"symbol"`
+  Kind   string    `bson:"kind"` // "QUOTE" or "TRADE"
+  Price  float64   `bson:"price"`
+  Volume float64   `bson:"volume"`
+  Time   time.Time `bson:"ts"`
+}
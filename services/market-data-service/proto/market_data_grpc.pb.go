@@ -19,8 +19,22 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	MarketDataService_GetQuote_FullMethodName     = "/marketdata.MarketDataService/GetQuote"
-	MarketDataService_StreamQuotes_FullMethodName = "/marketdata.MarketDataService/StreamQuotes"
+	MarketDataService_GetQuote_FullMethodName          = "/marketdata.MarketDataService/GetQuote"
+	MarketDataService_GetQuotes_FullMethodName         = "/marketdata.MarketDataService/GetQuotes"
+	MarketDataService_SearchSymbols_FullMethodName     = "/marketdata.MarketDataService/SearchSymbols"
+	MarketDataService_GetFXRate_FullMethodName         = "/marketdata.MarketDataService/GetFXRate"
+	MarketDataService_StreamQuotes_FullMethodName      = "/marketdata.MarketDataService/StreamQuotes"
+	MarketDataService_GetProviderHealth_FullMethodName = "/marketdata.MarketDataService/GetProviderHealth"
+	MarketDataService_GetBars_FullMethodName           = "/marketdata.MarketDataService/GetBars"
+	MarketDataService_GetIndicators_FullMethodName     = "/marketdata.MarketDataService/GetIndicators"
+	MarketDataService_StreamIndicators_FullMethodName  = "/marketdata.MarketDataService/StreamIndicators"
+	MarketDataService_RecordTrade_FullMethodName       = "/marketdata.MarketDataService/RecordTrade"
+	MarketDataService_PublishTopOfBook_FullMethodName  = "/marketdata.MarketDataService/PublishTopOfBook"
+	MarketDataService_AdjustHistory_FullMethodName     = "/marketdata.MarketDataService/AdjustHistory"
+	MarketDataService_CreatePriceAlert_FullMethodName  = "/marketdata.MarketDataService/CreatePriceAlert"
+	MarketDataService_UpdatePriceAlert_FullMethodName  = "/marketdata.MarketDataService/UpdatePriceAlert"
+	MarketDataService_DeletePriceAlert_FullMethodName  = "/marketdata.MarketDataService/DeletePriceAlert"
+	MarketDataService_ListPriceAlerts_FullMethodName   = "/marketdata.MarketDataService/ListPriceAlerts"
 )
 
 // MarketDataServiceClient is the client API for MarketDataService service.
@@ -28,8 +42,34 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type MarketDataServiceClient interface {
 	GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*GetQuoteResponse, error)
-	// Optional: server-side streaming for real-time updates
-	StreamQuotes(ctx context.Context, in *StreamQuotesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QuoteUpdate], error)
+	// Batch quotes, fetched concurrently; failing symbols are listed in errors.
+	GetQuotes(ctx context.Context, in *GetQuotesRequest, opts ...grpc.CallOption) (*GetQuotesResponse, error)
+	// Symbol autocomplete from the local instrument list (plus provider search if enabled).
+	SearchSymbols(ctx context.Context, in *SearchSymbolsRequest, opts ...grpc.CallOption) (*SearchSymbolsResponse, error)
+	// Currency conversion rate from the configured FX source (simulated or CSV).
+	GetFXRate(ctx context.Context, in *GetFXRateRequest, opts ...grpc.CallOption) (*FXRateResponse, error)
+	// Bidirectional: send SUBSCRIBE/UNSUBSCRIBE requests at any time, receive updates
+	// for the current symbol set. Buffer options are read from the first request.
+	StreamQuotes(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamQuotesRequest, QuoteUpdate], error)
+	// Health of the upstream quote providers, in failover order.
+	GetProviderHealth(ctx context.Context, in *ProviderHealthRequest, opts ...grpc.CallOption) (*ProviderHealthResponse, error)
+	// OHLCV bars built from stored quotes and trade prints.
+	GetBars(ctx context.Context, in *GetBarsRequest, opts ...grpc.CallOption) (*GetBarsResponse, error)
+	// Technical indicators computed over stored bars.
+	GetIndicators(ctx context.Context, in *GetIndicatorsRequest, opts ...grpc.CallOption) (*GetIndicatorsResponse, error)
+	// Indicators kept current from live quotes; the last point includes the forming bar.
+	StreamIndicators(ctx context.Context, in *StreamIndicatorsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndicatorPoint], error)
+	// Trade-service reports each execution so it's stored as a trade print.
+	RecordTrade(ctx context.Context, in *RecordTradeRequest, opts ...grpc.CallOption) (*RecordTradeResponse, error)
+	// Trade-service reports best bid/ask changes of its internal order book.
+	PublishTopOfBook(ctx context.Context, in *TopOfBookUpdate, opts ...grpc.CallOption) (*TopOfBookResponse, error)
+	// Portfolio-service back-adjusts stored bars for splits and symbol changes.
+	AdjustHistory(ctx context.Context, in *AdjustHistoryRequest, opts ...grpc.CallOption) (*AdjustHistoryResponse, error)
+	// Price alerts, evaluated against the quote stream and delivered via notification-service.
+	CreatePriceAlert(ctx context.Context, in *CreatePriceAlertRequest, opts ...grpc.CallOption) (*PriceAlertResponse, error)
+	UpdatePriceAlert(ctx context.Context, in *UpdatePriceAlertRequest, opts ...grpc.CallOption) (*PriceAlertResponse, error)
+	DeletePriceAlert(ctx context.Context, in *DeletePriceAlertRequest, opts ...grpc.CallOption) (*DeletePriceAlertResponse, error)
+	ListPriceAlerts(ctx context.Context, in *ListPriceAlertsRequest, opts ...grpc.CallOption) (*ListPriceAlertsResponse, error)
 }
 
 type marketDataServiceClient struct {
@@ -50,13 +90,86 @@ func (c *marketDataServiceClient) GetQuote(ctx context.Context, in *GetQuoteRequ
 	return out, nil
 }
 
-func (c *marketDataServiceClient) StreamQuotes(ctx context.Context, in *StreamQuotesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QuoteUpdate], error) {
+func (c *marketDataServiceClient) GetQuotes(ctx context.Context, in *GetQuotesRequest, opts ...grpc.CallOption) (*GetQuotesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetQuotesResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_GetQuotes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) SearchSymbols(ctx context.Context, in *SearchSymbolsRequest, opts ...grpc.CallOption) (*SearchSymbolsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchSymbolsResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_SearchSymbols_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) GetFXRate(ctx context.Context, in *GetFXRateRequest, opts ...grpc.CallOption) (*FXRateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FXRateResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_GetFXRate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) StreamQuotes(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamQuotesRequest, QuoteUpdate], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &MarketDataService_ServiceDesc.Streams[0], MarketDataService_StreamQuotes_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	x := &grpc.GenericClientStream[StreamQuotesRequest, QuoteUpdate]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MarketDataService_StreamQuotesClient = grpc.BidiStreamingClient[StreamQuotesRequest, QuoteUpdate]
+
+func (c *marketDataServiceClient) GetProviderHealth(ctx context.Context, in *ProviderHealthRequest, opts ...grpc.CallOption) (*ProviderHealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProviderHealthResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_GetProviderHealth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) GetBars(ctx context.Context, in *GetBarsRequest, opts ...grpc.CallOption) (*GetBarsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBarsResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_GetBars_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) GetIndicators(ctx context.Context, in *GetIndicatorsRequest, opts ...grpc.CallOption) (*GetIndicatorsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetIndicatorsResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_GetIndicators_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) StreamIndicators(ctx context.Context, in *StreamIndicatorsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndicatorPoint], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MarketDataService_ServiceDesc.Streams[1], MarketDataService_StreamIndicators_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamIndicatorsRequest, IndicatorPoint]{ClientStream: stream}
 	if err := x.ClientStream.SendMsg(in); err != nil {
 		return nil, err
 	}
@@ -67,15 +180,111 @@ func (c *marketDataServiceClient) StreamQuotes(ctx context.Context, in *StreamQu
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type MarketDataService_StreamQuotesClient = grpc.ServerStreamingClient[QuoteUpdate]
+type MarketDataService_StreamIndicatorsClient = grpc.ServerStreamingClient[IndicatorPoint]
+
+func (c *marketDataServiceClient) RecordTrade(ctx context.Context, in *RecordTradeRequest, opts ...grpc.CallOption) (*RecordTradeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecordTradeResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_RecordTrade_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) PublishTopOfBook(ctx context.Context, in *TopOfBookUpdate, opts ...grpc.CallOption) (*TopOfBookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TopOfBookResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_PublishTopOfBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) AdjustHistory(ctx context.Context, in *AdjustHistoryRequest, opts ...grpc.CallOption) (*AdjustHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdjustHistoryResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_AdjustHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) CreatePriceAlert(ctx context.Context, in *CreatePriceAlertRequest, opts ...grpc.CallOption) (*PriceAlertResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceAlertResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_CreatePriceAlert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) UpdatePriceAlert(ctx context.Context, in *UpdatePriceAlertRequest, opts ...grpc.CallOption) (*PriceAlertResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceAlertResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_UpdatePriceAlert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) DeletePriceAlert(ctx context.Context, in *DeletePriceAlertRequest, opts ...grpc.CallOption) (*DeletePriceAlertResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePriceAlertResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_DeletePriceAlert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketDataServiceClient) ListPriceAlerts(ctx context.Context, in *ListPriceAlertsRequest, opts ...grpc.CallOption) (*ListPriceAlertsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPriceAlertsResponse)
+	err := c.cc.Invoke(ctx, MarketDataService_ListPriceAlerts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
 // MarketDataServiceServer is the server API for MarketDataService service.
 // All implementations must embed UnimplementedMarketDataServiceServer
 // for forward compatibility.
 type MarketDataServiceServer interface {
 	GetQuote(context.Context, *GetQuoteRequest) (*GetQuoteResponse, error)
-	// Optional: server-side streaming for real-time updates
-	StreamQuotes(*StreamQuotesRequest, grpc.ServerStreamingServer[QuoteUpdate]) error
+	// Batch quotes, fetched concurrently; failing symbols are listed in errors.
+	GetQuotes(context.Context, *GetQuotesRequest) (*GetQuotesResponse, error)
+	// Symbol autocomplete from the local instrument list (plus provider search if enabled).
+	SearchSymbols(context.Context, *SearchSymbolsRequest) (*SearchSymbolsResponse, error)
+	// Currency conversion rate from the configured FX source (simulated or CSV).
+	GetFXRate(context.Context, *GetFXRateRequest) (*FXRateResponse, error)
+	// Bidirectional: send SUBSCRIBE/UNSUBSCRIBE requests at any time, receive updates
+	// for the current symbol set. Buffer options are read from the first request.
+	StreamQuotes(grpc.BidiStreamingServer[StreamQuotesRequest, QuoteUpdate]) error
+	// Health of the upstream quote providers, in failover order.
+	GetProviderHealth(context.Context, *ProviderHealthRequest) (*ProviderHealthResponse, error)
+	// OHLCV bars built from stored quotes and trade prints.
+	GetBars(context.Context, *GetBarsRequest) (*GetBarsResponse, error)
+	// Technical indicators computed over stored bars.
+	GetIndicators(context.Context, *GetIndicatorsRequest) (*GetIndicatorsResponse, error)
+	// Indicators kept current from live quotes; the last point includes the forming bar.
+	StreamIndicators(*StreamIndicatorsRequest, grpc.ServerStreamingServer[IndicatorPoint]) error
+	// Trade-service reports each execution so it's stored as a trade print.
+	RecordTrade(context.Context, *RecordTradeRequest) (*RecordTradeResponse, error)
+	// Trade-service reports best bid/ask changes of its internal order book.
+	PublishTopOfBook(context.Context, *TopOfBookUpdate) (*TopOfBookResponse, error)
+	// Portfolio-service back-adjusts stored bars for splits and symbol changes.
+	AdjustHistory(context.Context, *AdjustHistoryRequest) (*AdjustHistoryResponse, error)
+	// Price alerts, evaluated against the quote stream and delivered via notification-service.
+	CreatePriceAlert(context.Context, *CreatePriceAlertRequest) (*PriceAlertResponse, error)
+	UpdatePriceAlert(context.Context, *UpdatePriceAlertRequest) (*PriceAlertResponse, error)
+	DeletePriceAlert(context.Context, *DeletePriceAlertRequest) (*DeletePriceAlertResponse, error)
+	ListPriceAlerts(context.Context, *ListPriceAlertsRequest) (*ListPriceAlertsResponse, error)
 	mustEmbedUnimplementedMarketDataServiceServer()
 }
 
@@ -89,9 +298,51 @@ type UnimplementedMarketDataServiceServer struct{}
 func (UnimplementedMarketDataServiceServer) GetQuote(context.Context, *GetQuoteRequest) (*GetQuoteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetQuote not implemented")
 }
-func (UnimplementedMarketDataServiceServer) StreamQuotes(*StreamQuotesRequest, grpc.ServerStreamingServer[QuoteUpdate]) error {
+func (UnimplementedMarketDataServiceServer) GetQuotes(context.Context, *GetQuotesRequest) (*GetQuotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQuotes not implemented")
+}
+func (UnimplementedMarketDataServiceServer) SearchSymbols(context.Context, *SearchSymbolsRequest) (*SearchSymbolsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchSymbols not implemented")
+}
+func (UnimplementedMarketDataServiceServer) GetFXRate(context.Context, *GetFXRateRequest) (*FXRateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFXRate not implemented")
+}
+func (UnimplementedMarketDataServiceServer) StreamQuotes(grpc.BidiStreamingServer[StreamQuotesRequest, QuoteUpdate]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamQuotes not implemented")
 }
+func (UnimplementedMarketDataServiceServer) GetProviderHealth(context.Context, *ProviderHealthRequest) (*ProviderHealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProviderHealth not implemented")
+}
+func (UnimplementedMarketDataServiceServer) GetBars(context.Context, *GetBarsRequest) (*GetBarsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBars not implemented")
+}
+func (UnimplementedMarketDataServiceServer) GetIndicators(context.Context, *GetIndicatorsRequest) (*GetIndicatorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIndicators not implemented")
+}
+func (UnimplementedMarketDataServiceServer) StreamIndicators(*StreamIndicatorsRequest, grpc.ServerStreamingServer[IndicatorPoint]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamIndicators not implemented")
+}
+func (UnimplementedMarketDataServiceServer) RecordTrade(context.Context, *RecordTradeRequest) (*RecordTradeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordTrade not implemented")
+}
+func (UnimplementedMarketDataServiceServer) PublishTopOfBook(context.Context, *TopOfBookUpdate) (*TopOfBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishTopOfBook not implemented")
+}
+func (UnimplementedMarketDataServiceServer) AdjustHistory(context.Context, *AdjustHistoryRequest) (*AdjustHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdjustHistory not implemented")
+}
+func (UnimplementedMarketDataServiceServer) CreatePriceAlert(context.Context, *CreatePriceAlertRequest) (*PriceAlertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePriceAlert not implemented")
+}
+func (UnimplementedMarketDataServiceServer) UpdatePriceAlert(context.Context, *UpdatePriceAlertRequest) (*PriceAlertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePriceAlert not implemented")
+}
+func (UnimplementedMarketDataServiceServer) DeletePriceAlert(context.Context, *DeletePriceAlertRequest) (*DeletePriceAlertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePriceAlert not implemented")
+}
+func (UnimplementedMarketDataServiceServer) ListPriceAlerts(context.Context, *ListPriceAlertsRequest) (*ListPriceAlertsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPriceAlerts not implemented")
+}
 func (UnimplementedMarketDataServiceServer) mustEmbedUnimplementedMarketDataServiceServer() {}
 func (UnimplementedMarketDataServiceServer) testEmbeddedByValue()                           {}
 
@@ -131,16 +382,257 @@ func _MarketDataService_GetQuote_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MarketDataService_GetQuotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQuotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).GetQuotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_GetQuotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).GetQuotes(ctx, req.(*GetQuotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_SearchSymbols_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchSymbolsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).SearchSymbols(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_SearchSymbols_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).SearchSymbols(ctx, req.(*SearchSymbolsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_GetFXRate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFXRateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).GetFXRate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_GetFXRate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).GetFXRate(ctx, req.(*GetFXRateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MarketDataService_StreamQuotes_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(StreamQuotesRequest)
+	return srv.(MarketDataServiceServer).StreamQuotes(&grpc.GenericServerStream[StreamQuotesRequest, QuoteUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MarketDataService_StreamQuotesServer = grpc.BidiStreamingServer[StreamQuotesRequest, QuoteUpdate]
+
+func _MarketDataService_GetProviderHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProviderHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).GetProviderHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_GetProviderHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).GetProviderHealth(ctx, req.(*ProviderHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_GetBars_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBarsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).GetBars(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_GetBars_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).GetBars(ctx, req.(*GetBarsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_GetIndicators_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIndicatorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).GetIndicators(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_GetIndicators_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).GetIndicators(ctx, req.(*GetIndicatorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_StreamIndicators_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamIndicatorsRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(MarketDataServiceServer).StreamQuotes(m, &grpc.GenericServerStream[StreamQuotesRequest, QuoteUpdate]{ServerStream: stream})
+	return srv.(MarketDataServiceServer).StreamIndicators(m, &grpc.GenericServerStream[StreamIndicatorsRequest, IndicatorPoint]{ServerStream: stream})
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type MarketDataService_StreamQuotesServer = grpc.ServerStreamingServer[QuoteUpdate]
+type MarketDataService_StreamIndicatorsServer = grpc.ServerStreamingServer[IndicatorPoint]
+
+func _MarketDataService_RecordTrade_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordTradeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).RecordTrade(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_RecordTrade_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).RecordTrade(ctx, req.(*RecordTradeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_PublishTopOfBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TopOfBookUpdate)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).PublishTopOfBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_PublishTopOfBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).PublishTopOfBook(ctx, req.(*TopOfBookUpdate))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_AdjustHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdjustHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).AdjustHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_AdjustHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).AdjustHistory(ctx, req.(*AdjustHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_CreatePriceAlert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePriceAlertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).CreatePriceAlert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_CreatePriceAlert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).CreatePriceAlert(ctx, req.(*CreatePriceAlertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_UpdatePriceAlert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePriceAlertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).UpdatePriceAlert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_UpdatePriceAlert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).UpdatePriceAlert(ctx, req.(*UpdatePriceAlertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_DeletePriceAlert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePriceAlertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).DeletePriceAlert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_DeletePriceAlert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).DeletePriceAlert(ctx, req.(*DeletePriceAlertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketDataService_ListPriceAlerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPriceAlertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketDataServiceServer).ListPriceAlerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketDataService_ListPriceAlerts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketDataServiceServer).ListPriceAlerts(ctx, req.(*ListPriceAlertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
 // MarketDataService_ServiceDesc is the grpc.ServiceDesc for MarketDataService service.
 // It's only intended for direct use with grpc.RegisterService,
@@ -153,12 +645,70 @@ var MarketDataService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetQuote",
 			Handler:    _MarketDataService_GetQuote_Handler,
 		},
+		{
+			MethodName: "GetQuotes",
+			Handler:    _MarketDataService_GetQuotes_Handler,
+		},
+		{
+			MethodName: "SearchSymbols",
+			Handler:    _MarketDataService_SearchSymbols_Handler,
+		},
+		{
+			MethodName: "GetFXRate",
+			Handler:    _MarketDataService_GetFXRate_Handler,
+		},
+		{
+			MethodName: "GetProviderHealth",
+			Handler:    _MarketDataService_GetProviderHealth_Handler,
+		},
+		{
+			MethodName: "GetBars",
+			Handler:    _MarketDataService_GetBars_Handler,
+		},
+		{
+			MethodName: "GetIndicators",
+			Handler:    _MarketDataService_GetIndicators_Handler,
+		},
+		{
+			MethodName: "RecordTrade",
+			Handler:    _MarketDataService_RecordTrade_Handler,
+		},
+		{
+			MethodName: "PublishTopOfBook",
+			Handler:    _MarketDataService_PublishTopOfBook_Handler,
+		},
+		{
+			MethodName: "AdjustHistory",
+			Handler:    _MarketDataService_AdjustHistory_Handler,
+		},
+		{
+			MethodName: "CreatePriceAlert",
+			Handler:    _MarketDataService_CreatePriceAlert_Handler,
+		},
+		{
+			MethodName: "UpdatePriceAlert",
+			Handler:    _MarketDataService_UpdatePriceAlert_Handler,
+		},
+		{
+			MethodName: "DeletePriceAlert",
+			Handler:    _MarketDataService_DeletePriceAlert_Handler,
+		},
+		{
+			MethodName: "ListPriceAlerts",
+			Handler:    _MarketDataService_ListPriceAlerts_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "StreamQuotes",
 			Handler:       _MarketDataService_StreamQuotes_Handler,
 			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamIndicators",
+			Handler:       _MarketDataService_StreamIndicators_Handler,
+			ServerStreams: true,
 		},
 	},
 	Metadata: "market_data.proto",
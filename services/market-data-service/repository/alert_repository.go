@@ -0,0 +1,72 @@
+package repository
+
+import (
+    "context"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveAlert upserts the alert document keyed by alert_id.
+func SaveAlert(alert *models.PriceAlert) error {
+    coll := config.DB.Collection("price_alerts")
+    _, err := coll.ReplaceOne(
+        context.Background(),
+        bson.M{"alert_id": alert.AlertID},
+        alert,
+        options.Replace().SetUpsert(true),
+    )
+    return err
+}
+
+// DeleteAlert removes one of userID's alerts.
+func DeleteAlert(userID, alertID string) (bool, error) {
+    coll := config.DB.Collection("price_alerts")
+    res, err := coll.DeleteOne(context.Background(), bson.M{"alert_id": alertID, "user_id": userID})
+    if err != nil {
+        return false, err
+    }
+    return res.DeletedCount > 0, nil
+}
+
+// GetAlertsByUser returns all of a user's alerts.
+func GetAlertsByUser(userID string) ([]models.PriceAlert, error) {
+    return findAlerts(bson.M{"user_id": userID})
+}
+
+// GetActiveAlerts returns every alert that is still being evaluated.
+func GetActiveAlerts() ([]models.PriceAlert, error) {
+    return findAlerts(bson.M{"active": true})
+}
+
+func findAlerts(filter bson.M) ([]models.PriceAlert, error) {
+    coll := config.DB.Collection("price_alerts")
+    cursor, err := coll.Find(context.Background(), filter, options.Find().SetSort(bson.M{"created_at": 1}))
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var alerts []models.PriceAlert
+    for cursor.Next(context.Background()) {
+        var a models.PriceAlert
+        if err := cursor.Decode(&a); err != nil {
+            return nil, err
+        }
+        alerts = append(alerts, a)
+    }
+    return alerts, nil
+}
+
+// GetAlert loads an alert by its ID.
+func GetAlert(alertID string) (*models.PriceAlert, error) {
+    coll := config.DB.Collection("price_alerts")
+    var alert models.PriceAlert
+    if err := coll.FindOne(context.Background(), bson.M{"alert_id": alertID}).Decode(&alert); err != nil {
+        return nil, err
+    }
+    return &alert, nil
+}
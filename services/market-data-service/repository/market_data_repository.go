@@ -2,12 +2,131 @@ package repository
 
 import (
     "context"
+    "errors"
+    "time"
+
     "github.com/ankan8/swapsync/backend/internal/config"
     "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func SaveQuote(q *models.Quote) error {
-    coll := config.DB.Collection("quotes")
-    _, err := coll.InsertOne(context.Background(), q)
+const (
+    ticksCollection = "market_ticks"
+    barsCollection  = "bars"
+)
+
+// EnsureCollections creates the tick time-series collection (expiring after
+// tickRetention) and the bar indexes. Bars expire through their expire_at field.
+func EnsureCollections(tickRetention time.Duration) error {
+    ctx := context.Background()
+
+    tsOpts := options.CreateCollection().SetTimeSeriesOptions(
+        options.TimeSeries().SetTimeField("ts").SetMetaField("symbol").SetGranularity("seconds"))
+    if tickRetention > 0 {
+        tsOpts.SetExpireAfterSeconds(int64(tickRetention.Seconds()))
+    }
+    err := config.DB.CreateCollection(ctx, ticksCollection, tsOpts)
+    var cmdErr mongo.CommandError
+    if err != nil && !(errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceExists") {
+        return err
+    }
+
+    _, err = config.DB.Collection(barsCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {
+            Keys:    bson.D{{Key: "symbol", Value: 1}, {Key: "interval", Value: 1}, {Key: "start", Value: 1}},
+            Options: options.Index().SetUnique(true),
+        },
+        {
+            Keys:    bson.D{{Key: "expire_at", Value: 1}},
+            Options: options.Index().SetExpireAfterSeconds(0),
+        },
+    })
     return err
 }
+
+func InsertTick(t *models.Tick) error {
+    _, err := config.DB.Collection(ticksCollection).InsertOne(context.Background(), t)
+    return err
+}
+
+// UpsertBar folds one price/volume into the bar starting at start.
+func UpsertBar(symbol, interval string, start time.Time, price, volume float64, expireAt time.Time) error {
+    filter := bson.M{"symbol": symbol, "interval": interval, "start": start}
+    setOnInsert := bson.M{"open": price}
+    if !expireAt.IsZero() {
+        setOnInsert["expire_at"] = expireAt
+    }
+    update := bson.M{
+        "$setOnInsert": setOnInsert,
+        "$max":         bson.M{"high": price},
+        "$min":         bson.M{"low": price},
+        "$set":         bson.M{"close": price},
+        "$inc":         bson.M{"volume": volume},
+    }
+    _, err := config.DB.Collection(barsCollection).UpdateOne(context.Background(), filter, update, options.Update().SetUpsert(true))
+    return err
+}
+
+// GetBars returns symbol's bars of one interval with from <= start < to, oldest first.
+func GetBars(symbol, interval string, from, to time.Time, limit int64) ([]models.Bar, error) {
+    filter := bson.M{
+        "symbol":   symbol,
+        "interval": interval,
+        "start":    bson.M{"$gte": from, "$lt": to},
+    }
+    opts := options.Find().SetSort(bson.M{"start": 1})
+    if limit > 0 {
+        opts.SetLimit(limit)
+    }
+    cursor, err := config.DB.Collection(barsCollection).Find(context.Background(), filter, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var bars []models.Bar
+    for cursor.Next(context.Background()) {
+        var b models.Bar
+        if err := cursor.Decode(&b); err != nil {
+            return nil, err
+        }
+        bars = append(bars, b)
+    }
+    return bars, nil
+}
+
+// AdjustBarsForSplit restates symbol's bars that start before `before` in post-split
+// terms: prices are divided by ratio and volume multiplied by it.
+func AdjustBarsForSplit(symbol string, before time.Time, ratio float64) (int64, error) {
+    filter := bson.M{"symbol": symbol, "start": bson.M{"$lt": before}}
+    update := bson.M{"$mul": bson.M{
+        "open":   1 / ratio,
+        "high":   1 / ratio,
+        "low":    1 / ratio,
+        "close":  1 / ratio,
+        "volume": ratio,
+    }}
+    res, err := config.DB.Collection(barsCollection).UpdateMany(context.Background(), filter, update)
+    if err != nil {
+        return 0, err
+    }
+    return res.ModifiedCount, nil
+}
+
+// RenameSymbol moves symbol's bars and ticks to newSymbol. Ticks can only have their
+// meta field (the symbol) updated, so their prices are never split-adjusted.
+func RenameSymbol(symbol, newSymbol string) (int64, error) {
+    filter := bson.M{"symbol": symbol}
+    update := bson.M{"$set": bson.M{"symbol": newSymbol}}
+    res, err := config.DB.Collection(barsCollection).UpdateMany(context.Background(), filter, update)
+    if err != nil {
+        return 0, err
+    }
+    if _, err := config.DB.Collection(ticksCollection).UpdateMany(context.Background(), filter, update); err != nil {
+        return res.ModifiedCount, err
+    }
+    return res.ModifiedCount, nil
+}
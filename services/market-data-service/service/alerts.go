@@ -0,0 +1,325 @@
+package service
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+    "github.com/ankan8/swapsync/backend/services/market-data-service/repository"
+
+    "github.com/google/uuid"
+)
+
+const (
+    AlertAbove         = "ABOVE"
+    AlertBelow         = "BELOW"
+    AlertPercentChange = "PERCENT_CHANGE"
+
+    defaultAlertChannel = "PUSH"
+)
+
+// AlertSpec holds the user-editable part of a price alert.
+type AlertSpec struct {
+    Symbol          string
+    Condition       string
+    Threshold       float64
+    ReferencePrice  float64 // PERCENT_CHANGE only; 0 uses the current price
+    Recurring       bool
+    CooldownSeconds int64
+    Channel         string
+    Active          bool
+}
+
+// AlertEngine evaluates active price alerts against the quote hub. An alert fires
+// when its condition becomes true and is re-armed only once the condition is false
+// again, so a price hovering past a threshold doesn't notify on every tick.
+type AlertEngine struct {
+    hub    *QuoteHub
+    sub    *Subscriber
+    notify func(userID, message, channel string)
+
+    mu     sync.Mutex
+    alerts map[string]map[string]*models.PriceAlert // symbol -> alert ID -> alert
+}
+
+func NewAlertEngine(hub *QuoteHub) *AlertEngine {
+    return &AlertEngine{
+        hub:    hub,
+        sub:    hub.NewSubscriber(1024, PolicyConflate),
+        notify: notifyUserMarketData,
+        alerts: map[string]map[string]*models.PriceAlert{},
+    }
+}
+
+// Start loads the active alerts and begins evaluating quotes.
+func (e *AlertEngine) Start() error {
+    active, err := repository.GetActiveAlerts()
+    if err != nil {
+        return fmt.Errorf("failed to load price alerts: %v", err)
+    }
+    e.mu.Lock()
+    for i := range active {
+        e.trackLocked(&active[i])
+    }
+    e.mu.Unlock()
+    log.Printf("Alert engine: evaluating %d active alerts\n", len(active))
+
+    go func() {
+        for {
+            q, err := e.sub.Next(context.Background())
+            if err != nil {
+                return
+            }
+            e.Evaluate(q)
+        }
+    }()
+    return nil
+}
+
+// CreateAlert validates and stores a new alert for userID.
+func (e *AlertEngine) CreateAlert(userID string, spec AlertSpec) (*models.PriceAlert, error) {
+    if userID == "" {
+        return nil, fmt.Errorf("user_id is required")
+    }
+    now := time.Now().Format(time.RFC3339)
+    alert := &models.PriceAlert{
+        AlertID:   uuid.NewString(),
+        UserID:    userID,
+        CreatedAt: now,
+    }
+    if err := applyAlertSpec(alert, spec); err != nil {
+        return nil, err
+    }
+    alert.UpdatedAt = now
+
+    if err := repository.SaveAlert(alert); err != nil {
+        return nil, fmt.Errorf("failed to save alert: %v", err)
+    }
+    if alert.Active {
+        e.mu.Lock()
+        e.trackLocked(alert)
+        e.mu.Unlock()
+    }
+    log.Printf("[ALERT] created %s for user=%s: %s %s %.2f\n", alert.AlertID, userID, alert.Symbol, alert.Condition, alert.Threshold)
+    return copyAlert(alert), nil
+}
+
+// UpdateAlert replaces the editable fields of one of userID's alerts and re-arms it.
+func (e *AlertEngine) UpdateAlert(userID, alertID string, spec AlertSpec) (*models.PriceAlert, error) {
+    alert, err := repository.GetAlert(alertID)
+    if err != nil || alert.UserID != userID {
+        return nil, fmt.Errorf("alert %s not found", alertID)
+    }
+    if spec.Condition == AlertPercentChange && spec.ReferencePrice <= 0 && alert.Condition == AlertPercentChange {
+        spec.ReferencePrice = alert.ReferencePrice
+    }
+    if err := applyAlertSpec(alert, spec); err != nil {
+        return nil, err
+    }
+    alert.UpdatedAt = time.Now().Format(time.RFC3339)
+
+    if err := repository.SaveAlert(alert); err != nil {
+        return nil, fmt.Errorf("failed to save alert: %v", err)
+    }
+    e.mu.Lock()
+    e.untrackLocked(alertID)
+    if alert.Active {
+        e.trackLocked(alert)
+    }
+    e.mu.Unlock()
+    return copyAlert(alert), nil
+}
+
+// DeleteAlert removes one of userID's alerts.
+func (e *AlertEngine) DeleteAlert(userID, alertID string) error {
+    deleted, err := repository.DeleteAlert(userID, alertID)
+    if err != nil {
+        return fmt.Errorf("failed to delete alert: %v", err)
+    }
+    if !deleted {
+        return fmt.Errorf("alert %s not found", alertID)
+    }
+    e.mu.Lock()
+    e.untrackLocked(alertID)
+    e.mu.Unlock()
+    return nil
+}
+
+// ListAlerts returns all of userID's alerts, active or not.
+func (e *AlertEngine) ListAlerts(userID string) ([]models.PriceAlert, error) {
+    return repository.GetAlertsByUser(userID)
+}
+
+// Evaluate checks every active alert on q's symbol, then persists state changes
+// and notifies the owners of the alerts that fired.
+func (e *AlertEngine) Evaluate(q models.Quote) {
+    if q.Price <= 0 {
+        return
+    }
+    now := time.Now()
+
+    var changed, fired []*models.PriceAlert
+    e.mu.Lock()
+    for _, alert := range e.alerts[q.Symbol] {
+        if !alertConditionMet(alert, q.Price) {
+            if !alert.Armed {
+                alert.Armed = true
+                changed = append(changed, copyAlert(alert))
+            }
+            continue
+        }
+        if !alert.Armed || alertCoolingDown(alert, now) {
+            continue
+        }
+
+        alert.Armed = false
+        alert.TriggerCount++
+        alert.LastTriggeredAt = now.Format(time.RFC3339)
+        alert.LastPrice = q.Price
+        alert.UpdatedAt = alert.LastTriggeredAt
+        if !alert.Recurring {
+            alert.Active = false
+            e.untrackLocked(alert.AlertID)
+        }
+        fired = append(fired, copyAlert(alert))
+    }
+    e.mu.Unlock()
+
+    for _, alert := range append(changed, fired...) {
+        if err := repository.SaveAlert(alert); err != nil {
+            log.Printf("Error saving alert %s: %v\n", alert.AlertID, err)
+        }
+    }
+    for _, alert := range fired {
+        log.Printf("[ALERT] %s fired for user=%s at %.2f\n", alert.AlertID, alert.UserID, q.Price)
+        e.notify(alert.UserID, alertMessage(alert, q.Price), alert.Channel)
+    }
+}
+
+// trackLocked indexes an active alert and makes sure its symbol is streamed. The caller must hold e.mu.
+func (e *AlertEngine) trackLocked(alert *models.PriceAlert) {
+    bySymbol, ok := e.alerts[alert.Symbol]
+    if !ok {
+        bySymbol = map[string]*models.PriceAlert{}
+        e.alerts[alert.Symbol] = bySymbol
+        e.hub.Subscribe(e.sub, alert.Symbol)
+    }
+    bySymbol[alert.AlertID] = alert
+}
+
+// untrackLocked drops an alert and stops streaming its symbol when no alert needs it. The caller must hold e.mu.
+func (e *AlertEngine) untrackLocked(alertID string) {
+    for symbol, bySymbol := range e.alerts {
+        if _, ok := bySymbol[alertID]; !ok {
+            continue
+        }
+        delete(bySymbol, alertID)
+        if len(bySymbol) == 0 {
+            delete(e.alerts, symbol)
+            e.hub.Unsubscribe(e.sub, symbol)
+        }
+        return
+    }
+}
+
+// applyAlertSpec validates spec and copies it onto alert, re-arming it.
+func applyAlertSpec(alert *models.PriceAlert, spec AlertSpec) error {
+    symbol := strings.ToUpper(strings.TrimSpace(spec.Symbol))
+    if symbol == "" {
+        return fmt.Errorf("symbol is required")
+    }
+    condition := strings.ToUpper(spec.Condition)
+    switch condition {
+    case AlertAbove, AlertBelow:
+        if spec.Threshold <= 0 {
+            return fmt.Errorf("%s alerts need a positive price threshold", condition)
+        }
+    case AlertPercentChange:
+        if spec.Threshold == 0 {
+            return fmt.Errorf("PERCENT_CHANGE alerts need a non-zero percent threshold")
+        }
+        if spec.ReferencePrice <= 0 {
+            q, _, err := FetchQuoteWithFreshness(symbol)
+            if err != nil {
+                return fmt.Errorf("failed to fetch reference price for %s: %v", symbol, err)
+            }
+            spec.ReferencePrice = q.Price
+        }
+    default:
+        return fmt.Errorf("unknown alert condition %q (use ABOVE, BELOW or PERCENT_CHANGE)", spec.Condition)
+    }
+    if spec.CooldownSeconds < 0 {
+        return fmt.Errorf("cooldown must not be negative")
+    }
+    if spec.Channel == "" {
+        spec.Channel = defaultAlertChannel
+    }
+
+    alert.Symbol = symbol
+    alert.Condition = condition
+    alert.Threshold = spec.Threshold
+    alert.ReferencePrice = 0
+    if condition == AlertPercentChange {
+        alert.ReferencePrice = spec.ReferencePrice
+    }
+    alert.Recurring = spec.Recurring
+    alert.CooldownSeconds = spec.CooldownSeconds
+    alert.Channel = strings.ToUpper(spec.Channel)
+    alert.Active = spec.Active
+    alert.Armed = true
+    return nil
+}
+
+// alertConditionMet reports whether price satisfies the alert. A positive percent
+// threshold waits for a rise of at least that much, a negative one for a fall.
+func alertConditionMet(alert *models.PriceAlert, price float64) bool {
+    switch alert.Condition {
+    case AlertAbove:
+        return price >= alert.Threshold
+    case AlertBelow:
+        return price <= alert.Threshold
+    case AlertPercentChange:
+        if alert.ReferencePrice <= 0 {
+            return false
+        }
+        change := (price - alert.ReferencePrice) / alert.ReferencePrice * 100
+        if alert.Threshold > 0 {
+            return change >= alert.Threshold
+        }
+        return change <= alert.Threshold
+    }
+    return false
+}
+
+func alertCoolingDown(alert *models.PriceAlert, now time.Time) bool {
+    if alert.CooldownSeconds <= 0 || alert.LastTriggeredAt == "" {
+        return false
+    }
+    last, err := time.Parse(time.RFC3339, alert.LastTriggeredAt)
+    if err != nil {
+        return false
+    }
+    return now.Sub(last) < time.Duration(alert.CooldownSeconds)*time.Second
+}
+
+func alertMessage(alert *models.PriceAlert, price float64) string {
+    switch alert.Condition {
+    case AlertAbove:
+        return fmt.Sprintf("Price of %s is now %.2f, at or above your alert at %.2f", alert.Symbol, price, alert.Threshold)
+    case AlertBelow:
+        return fmt.Sprintf("Price of %s is now %.2f, at or below your alert at %.2f", alert.Symbol, price, alert.Threshold)
+    default:
+        change := (price - alert.ReferencePrice) / alert.ReferencePrice * 100
+        return fmt.Sprintf("Price of %s is now %.2f, %+.2f%% from %.2f (alert at %+.2f%%)",
+            alert.Symbol, price, change, alert.ReferencePrice, alert.Threshold)
+    }
+}
+
+func copyAlert(alert *models.PriceAlert) *models.PriceAlert {
+    c := *alert
+    return &c
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+    "strings"
+    "sync"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// maxBatchConcurrency bounds parallel upstream fetches of one GetQuotes call.
+const maxBatchConcurrency = 8
+
+// QuoteResult is one symbol's outcome in a batch.
+type QuoteResult struct {
+    Symbol    string
+    Quote     *models.Quote
+    Freshness Freshness
+    Err       error
+}
+
+// FetchQuotes fetches symbols concurrently. Duplicates and blanks are dropped and
+// results keep the request order; a failing symbol doesn't fail the batch.
+func FetchQuotes(symbols []string) []QuoteResult {
+    seen := map[string]bool{}
+    var results []QuoteResult
+    for _, symbol := range symbols {
+        symbol = strings.ToUpper(strings.TrimSpace(symbol))
+        if symbol == "" || seen[symbol] {
+            continue
+        }
+        seen[symbol] = true
+        results = append(results, QuoteResult{Symbol: symbol})
+    }
+
+    sem := make(chan struct{}, maxBatchConcurrency)
+    var wg sync.WaitGroup
+    for i := range results {
+        wg.Add(1)
+        sem <- struct{}{}
+        go func(r *QuoteResult) {
+            defer wg.Done()
+            defer func() { <-sem }()
+            r.Quote, r.Freshness, r.Err = FetchQuoteWithFreshness(r.Symbol)
+        }(&results[i])
+    }
+    wg.Wait()
+    return results
+}
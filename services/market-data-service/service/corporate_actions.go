@@ -0,0 +1,46 @@
+package service
+
+import (
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/repository"
+)
+
+// AdjustHistory back-adjusts stored history for a corporate action effective at
+// `effective`. ratio is new shares per old share (0 or 1 = no split); bars before
+// the effective date are restated so charts and indicators don't show a price gap.
+// A non-empty newSymbol then moves all of symbol's history to the new symbol.
+func AdjustHistory(symbol, newSymbol string, ratio float64, effective time.Time) (int64, error) {
+    if symbol == "" {
+        return 0, fmt.Errorf("symbol is required")
+    }
+    if ratio < 0 {
+        return 0, fmt.Errorf("split ratio must not be negative")
+    }
+    if effective.IsZero() {
+        effective = time.Now()
+    }
+
+    var adjusted int64
+    if ratio != 0 && ratio != 1 {
+        n, err := repository.AdjustBarsForSplit(symbol, effective.UTC(), ratio)
+        if err != nil {
+            return 0, fmt.Errorf("failed to adjust bars: %v", err)
+        }
+        adjusted = n
+    }
+    if newSymbol != "" && newSymbol != symbol {
+        n, err := repository.RenameSymbol(symbol, newSymbol)
+        if err != nil {
+            return adjusted, fmt.Errorf("failed to rename history: %v", err)
+        }
+        if adjusted == 0 {
+            adjusted = n
+        }
+    }
+    log.Printf("AdjustHistory: %s -> %q ratio=%.4f effective=%s, %d bars adjusted\n",
+        symbol, newSymbol, ratio, effective.Format(time.RFC3339), adjusted)
+    return adjusted, nil
+}
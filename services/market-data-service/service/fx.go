@@ -0,0 +1,357 @@
+package service
+
+import (
+    "encoding/csv"
+    "fmt"
+    "hash/fnv"
+    "io"
+    "log"
+    "math"
+    "math/rand"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    FXProviderSimulated = "simulated"
+    FXProviderCSV       = "csv"
+
+    // fxPivot is the currency cross rates are triangulated through.
+    fxPivot = "USD"
+)
+
+// FXProvider supplies currency conversion rates.
+type FXProvider interface {
+    Name() string
+    // Rate returns how many units of quote one unit of base is worth.
+    Rate(base, quote string) (float64, time.Time, error)
+}
+
+// FXRate is a resolved conversion rate.
+type FXRate struct {
+    Base      string
+    Quote     string
+    Rate      float64
+    Source    string
+    Timestamp time.Time
+}
+
+var (
+    fxMu       sync.RWMutex
+    fxProvider FXProvider = NewSimulatedFXProvider(DefaultSimulatedFXConfig())
+)
+
+func SetFXProvider(p FXProvider) {
+    fxMu.Lock()
+    defer fxMu.Unlock()
+    fxProvider = p
+}
+
+func CurrentFXProvider() FXProvider {
+    fxMu.RLock()
+    defer fxMu.RUnlock()
+    return fxProvider
+}
+
+// UseFXProviderFromEnv installs FX_PROVIDER: "simulated" (default) or "csv", which
+// reads FX_RATES_FILE.
+func UseFXProviderFromEnv() error {
+    var p FXProvider
+    switch name := strings.ToLower(os.Getenv("FX_PROVIDER")); name {
+    case "", FXProviderSimulated:
+        cfg, err := SimulatedFXConfigFromEnv()
+        if err != nil {
+            return err
+        }
+        p = NewSimulatedFXProvider(cfg)
+    case FXProviderCSV:
+        path := os.Getenv("FX_RATES_FILE")
+        if path == "" {
+            return fmt.Errorf("FX_RATES_FILE is required for the csv FX provider")
+        }
+        csvProvider, err := NewCSVFXProvider(path)
+        if err != nil {
+            return err
+        }
+        p = csvProvider
+    default:
+        return fmt.Errorf("unknown FX_PROVIDER %q (use simulated or csv)", name)
+    }
+    SetFXProvider(p)
+    log.Printf("FX rate provider: %s\n", p.Name())
+    return nil
+}
+
+// GetFXRate converts from base to quote with the active FX provider. When base is
+// empty the currency of symbol's instrument is used.
+func GetFXRate(base, quote, symbol string) (*FXRate, error) {
+    base, quote = strings.ToUpper(strings.TrimSpace(base)), strings.ToUpper(strings.TrimSpace(quote))
+    if base == "" {
+        if symbol == "" {
+            return nil, fmt.Errorf("base currency or symbol is required")
+        }
+        base = InstrumentCurrency(symbol)
+    }
+    if quote == "" {
+        return nil, fmt.Errorf("quote currency is required")
+    }
+    if base == quote {
+        return &FXRate{Base: base, Quote: quote, Rate: 1, Source: "identity", Timestamp: time.Now()}, nil
+    }
+    p := CurrentFXProvider()
+    rate, ts, err := p.Rate(base, quote)
+    if err != nil {
+        return nil, err
+    }
+    return &FXRate{Base: base, Quote: quote, Rate: rate, Source: p.Name(), Timestamp: ts}, nil
+}
+
+// InstrumentCurrency is the currency symbol is quoted in, from the instrument list.
+// Unknown symbols default to DEFAULT_QUOTE_CURRENCY (USD).
+func InstrumentCurrency(symbol string) string {
+    instruments.mu.RLock()
+    inst, ok := instruments.instruments[strings.ToUpper(symbol)]
+    instruments.mu.RUnlock()
+    if ok && inst.Currency != "" {
+        return inst.Currency
+    }
+    if v := os.Getenv("DEFAULT_QUOTE_CURRENCY"); v != "" {
+        return strings.ToUpper(v)
+    }
+    return fxPivot
+}
+
+// crossRate resolves base/quote from a pair lookup: directly, inverted, or through USD.
+func crossRate(lookup func(base, quote string) (float64, time.Time, bool), base, quote string) (float64, time.Time, error) {
+    if r, ts, ok := lookup(base, quote); ok {
+        return r, ts, nil
+    }
+    if r, ts, ok := lookup(quote, base); ok {
+        return 1 / r, ts, nil
+    }
+    leg := func(from, to string) (float64, time.Time, bool) {
+        if from == to {
+            return 1, time.Time{}, true
+        }
+        if r, ts, ok := lookup(from, to); ok {
+            return r, ts, true
+        }
+        if r, ts, ok := lookup(to, from); ok {
+            return 1 / r, ts, true
+        }
+        return 0, time.Time{}, false
+    }
+    r1, ts1, ok1 := leg(base, fxPivot)
+    r2, ts2, ok2 := leg(fxPivot, quote)
+    if !ok1 || !ok2 {
+        return 0, time.Time{}, fmt.Errorf("no FX rate for %s/%s", base, quote)
+    }
+    // The cross is only as fresh as its older leg
+    ts := ts1
+    if ts.IsZero() || (!ts2.IsZero() && ts2.Before(ts)) {
+        ts = ts2
+    }
+    return r1 * r2, ts, nil
+}
+
+// SimulatedFXConfig parameterises the simulated FX feed.
+type SimulatedFXConfig struct {
+    Seed       int64
+    Volatility float64            // annualised sigma of each currency against USD
+    USDRates   map[string]float64 // units of the currency per USD at start
+}
+
+func DefaultSimulatedFXConfig() SimulatedFXConfig {
+    return SimulatedFXConfig{
+        Seed:       42,
+        Volatility: 0.08,
+        USDRates: map[string]float64{
+            "USD": 1,
+            "INR": 83.2,
+            "EUR": 0.92,
+            "GBP": 0.79,
+            "JPY": 150,
+            "CHF": 0.88,
+            "CAD": 1.36,
+            "AUD": 1.52,
+            "SGD": 1.34,
+            "HKD": 7.82,
+        },
+    }
+}
+
+// SimulatedFXConfigFromEnv reads SIM_SEED, FX_SIM_VOLATILITY and FX_SIM_RATES
+// (units per USD, e.g. "INR=83.2,EUR=0.92") on top of the defaults.
+func SimulatedFXConfigFromEnv() (SimulatedFXConfig, error) {
+    cfg := DefaultSimulatedFXConfig()
+    if v := os.Getenv("SIM_SEED"); v != "" {
+        seed, err := strconv.ParseInt(v, 10, 64)
+        if err != nil {
+            return cfg, fmt.Errorf("invalid SIM_SEED: %v", err)
+        }
+        cfg.Seed = seed
+    }
+    if v := os.Getenv("FX_SIM_VOLATILITY"); v != "" {
+        f, err := strconv.ParseFloat(v, 64)
+        if err != nil || f < 0 {
+            return cfg, fmt.Errorf("invalid FX_SIM_VOLATILITY %q", v)
+        }
+        cfg.Volatility = f
+    }
+    if v := os.Getenv("FX_SIM_RATES"); v != "" {
+        for _, pair := range strings.Split(v, ",") {
+            kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+            if len(kv) != 2 {
+                return cfg, fmt.Errorf("invalid FX_SIM_RATES entry %q", pair)
+            }
+            rate, err := strconv.ParseFloat(kv[1], 64)
+            if err != nil || rate <= 0 {
+                return cfg, fmt.Errorf("invalid FX_SIM_RATES rate %q", kv[1])
+            }
+            cfg.USDRates[strings.ToUpper(kv[0])] = rate
+        }
+    }
+    return cfg, nil
+}
+
+// SimulatedFXProvider moves every currency's USD rate as a driftless random walk
+// in wall-clock time, seeded per currency so runs are reproducible.
+type SimulatedFXProvider struct {
+    cfg SimulatedFXConfig
+
+    mu    sync.Mutex
+    state map[string]*simFXState
+}
+
+type simFXState struct {
+    rate    float64
+    updated time.Time
+    rng     *rand.Rand
+}
+
+func NewSimulatedFXProvider(cfg SimulatedFXConfig) *SimulatedFXProvider {
+    return &SimulatedFXProvider{cfg: cfg, state: map[string]*simFXState{}}
+}
+
+func (p *SimulatedFXProvider) Name() string { return FXProviderSimulated }
+
+func (p *SimulatedFXProvider) Rate(base, quote string) (float64, time.Time, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    now := time.Now()
+    lookup := func(from, to string) (float64, time.Time, bool) {
+        if from != fxPivot {
+            return 0, time.Time{}, false
+        }
+        s, ok := p.usdRate(to, now)
+        if !ok {
+            return 0, time.Time{}, false
+        }
+        return s, now, true
+    }
+    return crossRate(lookup, base, quote)
+}
+
+// usdRate advances ccy's rate to now. The caller must hold p.mu.
+func (p *SimulatedFXProvider) usdRate(ccy string, now time.Time) (float64, bool) {
+    if ccy == fxPivot {
+        return 1, true
+    }
+    s, ok := p.state[ccy]
+    if !ok {
+        start, known := p.cfg.USDRates[ccy]
+        if !known {
+            return 0, false
+        }
+        h := fnv.New64a()
+        h.Write([]byte(ccy))
+        s = &simFXState{rate: start, updated: now, rng: rand.New(rand.NewSource(p.cfg.Seed ^ int64(h.Sum64())))}
+        p.state[ccy] = s
+    }
+    if dt := now.Sub(s.updated).Hours() / (24 * 365); dt > 0 && p.cfg.Volatility > 0 {
+        sigma := p.cfg.Volatility
+        s.rate *= math.Exp(-0.5*sigma*sigma*dt + sigma*math.Sqrt(dt)*s.rng.NormFloat64())
+    }
+    s.updated = now
+    return s.rate, true
+}
+
+// CSVFXProvider serves rates from a CSV of base,quote,rate[,timestamp] rows (an
+// optional header row is skipped). The last row per pair wins.
+type CSVFXProvider struct {
+    rates map[string]csvFXRate
+}
+
+type csvFXRate struct {
+    rate float64
+    ts   time.Time
+}
+
+func NewCSVFXProvider(path string) (*CSVFXProvider, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open FX rates file: %v", err)
+    }
+    defer f.Close()
+    return newCSVFXProvider(f)
+}
+
+func newCSVFXProvider(r io.Reader) (*CSVFXProvider, error) {
+    reader := csv.NewReader(r)
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = true
+
+    p := &CSVFXProvider{rates: map[string]csvFXRate{}}
+    for line := 1; ; line++ {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("FX rates line %d: %v", line, err)
+        }
+        if len(record) < 3 {
+            return nil, fmt.Errorf("FX rates line %d: need base,quote,rate", line)
+        }
+        rate, err := strconv.ParseFloat(record[2], 64)
+        if err != nil {
+            if line == 1 {
+                continue // header
+            }
+            return nil, fmt.Errorf("FX rates line %d: invalid rate %q", line, record[2])
+        }
+        if rate <= 0 {
+            return nil, fmt.Errorf("FX rates line %d: rate must be positive", line)
+        }
+        entry := csvFXRate{rate: rate}
+        if len(record) > 3 && record[3] != "" {
+            ts, err := time.Parse(time.RFC3339, record[3])
+            if err != nil {
+                return nil, fmt.Errorf("FX rates line %d: invalid timestamp %q", line, record[3])
+            }
+            entry.ts = ts
+        }
+        p.rates[fxPairKey(record[0], record[1])] = entry
+    }
+    if len(p.rates) == 0 {
+        return nil, fmt.Errorf("FX rates file has no rates")
+    }
+    return p, nil
+}
+
+func fxPairKey(base, quote string) string {
+    return strings.ToUpper(strings.TrimSpace(base)) + "/" + strings.ToUpper(strings.TrimSpace(quote))
+}
+
+func (p *CSVFXProvider) Name() string { return FXProviderCSV }
+
+func (p *CSVFXProvider) Rate(base, quote string) (float64, time.Time, error) {
+    return crossRate(func(from, to string) (float64, time.Time, bool) {
+        r, ok := p.rates[fxPairKey(from, to)]
+        return r.rate, r.ts, ok
+    }, base, quote)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+    "github.com/ankan8/swapsync/backend/services/market-data-service/repository"
+)
+
+const (
+    TickQuote = "QUOTE"
+    TickTrade = "TRADE"
+)
+
+// BarIntervals are the bar sizes maintained for every tick, smallest first.
+var BarIntervals = []string{"1m", "5m", "1h", "1d"}
+
+var barDurations = map[string]time.Duration{
+    "1m": time.Minute,
+    "5m": 5 * time.Minute,
+    "1h": time.Hour,
+    "1d": 24 * time.Hour,
+}
+
+// HistoryConfig sets how long ticks and each bar interval are kept (0 = forever).
+type HistoryConfig struct {
+    TickRetention time.Duration
+    BarRetention  map[string]time.Duration
+}
+
+func DefaultHistoryConfig() HistoryConfig {
+    return HistoryConfig{
+        TickRetention: 7 * 24 * time.Hour,
+        BarRetention: map[string]time.Duration{
+            "1m": 30 * 24 * time.Hour,
+            "5m": 90 * 24 * time.Hour,
+            "1h": 2 * 365 * 24 * time.Hour,
+            "1d": 0,
+        },
+    }
+}
+
+// HistoryConfigFromEnv reads TICK_RETENTION and BAR_RETENTION_<INTERVAL> (Go durations).
+func HistoryConfigFromEnv() (HistoryConfig, error) {
+    cfg := DefaultHistoryConfig()
+    if v := os.Getenv("TICK_RETENTION"); v != "" {
+        d, err := time.ParseDuration(v)
+        if err != nil || d < 0 {
+            return cfg, fmt.Errorf("invalid TICK_RETENTION %q", v)
+        }
+        cfg.TickRetention = d
+    }
+    for _, interval := range BarIntervals {
+        env := "BAR_RETENTION_" + interval
+        if v := os.Getenv(env); v != "" {
+            d, err := time.ParseDuration(v)
+            if err != nil || d < 0 {
+                return cfg, fmt.Errorf("invalid %s %q", env, v)
+            }
+            cfg.BarRetention[interval] = d
+        }
+    }
+    return cfg, nil
+}
+
+// historyRecorder writes ticks and bars from a single goroutine so bar closes
+// follow arrival order and callers never wait on MongoDB.
+type historyRecorder struct {
+    cfg   HistoryConfig
+    ticks chan models.Tick
+}
+
+var recorder *historyRecorder
+
+// StartHistoryRecorder prepares the collections and starts persisting ticks.
+func StartHistoryRecorder(cfg HistoryConfig) error {
+    if err := repository.EnsureCollections(cfg.TickRetention); err != nil {
+        return fmt.Errorf("failed to prepare market data collections: %v", err)
+    }
+    r := &historyRecorder{cfg: cfg, ticks: make(chan models.Tick, 1024)}
+    go r.run()
+    recorder = r
+    return nil
+}
+
+// RecordQuote stores a quote fetched from a provider, at its exchange time when the
+// source gives a full timestamp.
+func RecordQuote(q *models.Quote) {
+    ts, err := time.Parse(time.RFC3339, q.ExchangeTimestamp)
+    if err != nil {
+        ts, err = time.Parse(time.RFC3339, q.Timestamp)
+    }
+    if err != nil {
+        ts = time.Now()
+    }
+    recordTick(models.Tick{Symbol: q.Symbol, Kind: TickQuote, Price: q.Price, Time: ts})
+}
+
+// RecordTrade stores a trade print (only prints add bar volume) and makes it the
+// internal last trade for source merging.
+func RecordTrade(symbol string, price, quantity float64, ts time.Time) error {
+    if symbol == "" || price <= 0 || quantity <= 0 {
+        return fmt.Errorf("trade print needs a symbol, a positive price and a positive quantity")
+    }
+    if ts.IsZero() {
+        ts = time.Now()
+    }
+    recordTick(models.Tick{Symbol: symbol, Kind: TickTrade, Price: price, Volume: quantity, Time: ts})
+    internalFeed.recordTrade(symbol, price, quantity, ts)
+    return nil
+}
+
+func recordTick(t models.Tick) {
+    if recorder == nil || config.DB == nil {
+        return
+    }
+    select {
+    case recorder.ticks <- t:
+    default:
+        log.Printf("History: buffer full, dropping %s tick for %s\n", t.Kind, t.Symbol)
+    }
+}
+
+func (r *historyRecorder) run() {
+    for t := range r.ticks {
+        t.Time = t.Time.UTC()
+        if err := repository.InsertTick(&t); err != nil {
+            log.Printf("History: failed to store tick for %s: %v\n", t.Symbol, err)
+        }
+        for _, interval := range BarIntervals {
+            start := t.Time.Truncate(barDurations[interval])
+            var expireAt time.Time
+            if keep := r.cfg.BarRetention[interval]; keep > 0 {
+                expireAt = start.Add(barDurations[interval] + keep)
+            }
+            if err := repository.UpsertBar(t.Symbol, interval, start, t.Price, t.Volume, expireAt); err != nil {
+                log.Printf("History: failed to update %s bar for %s: %v\n", interval, t.Symbol, err)
+            }
+        }
+    }
+}
+
+// GetBars returns OHLCV bars for symbol in [from, to). A zero to means now and a
+// zero from means 100 intervals before to.
+func GetBars(symbol, interval string, from, to time.Time, limit int64) ([]models.Bar, error) {
+    step, ok := barDurations[interval]
+    if !ok {
+        return nil, fmt.Errorf("unsupported interval %q (use 1m, 5m, 1h or 1d)", interval)
+    }
+    if symbol == "" {
+        return nil, fmt.Errorf("symbol is required")
+    }
+    if to.IsZero() {
+        to = time.Now()
+    }
+    if from.IsZero() {
+        from = to.Add(-100 * step)
+    }
+    if !from.Before(to) {
+        return nil, fmt.Errorf("from must be before to")
+    }
+    return repository.GetBars(symbol, interval, from.UTC().Truncate(step), to.UTC(), limit)
+}
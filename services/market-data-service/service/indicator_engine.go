@@ -0,0 +1,180 @@
+package service
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+    "github.com/ankan8/swapsync/backend/services/market-data-service/repository"
+)
+
+// IndicatorPoint holds every ready indicator's outputs for one bar, keyed by spec key.
+type IndicatorPoint struct {
+    Start  time.Time
+    Values map[string]map[string]float64
+}
+
+// indicatorSet runs several indicators over the same bars.
+type indicatorSet struct {
+    keys       []string
+    indicators []Indicator
+}
+
+func newIndicatorSet(specs []IndicatorSpec) (*indicatorSet, error) {
+    if len(specs) == 0 {
+        return nil, fmt.Errorf("at least one indicator is required")
+    }
+    set := &indicatorSet{}
+    for i := range specs {
+        ind, err := NewIndicator(&specs[i])
+        if err != nil {
+            return nil, err
+        }
+        set.keys = append(set.keys, specs[i].Key())
+        set.indicators = append(set.indicators, ind)
+    }
+    return set, nil
+}
+
+func (s *indicatorSet) lookback() int {
+    n := 0
+    for _, ind := range s.indicators {
+        if l := ind.Lookback(); l > n {
+            n = l
+        }
+    }
+    return n
+}
+
+func (s *indicatorSet) update(bar models.Bar) {
+    for _, ind := range s.indicators {
+        ind.Update(bar)
+    }
+}
+
+// point reads the current values; a non-nil forming bar is applied to copies only.
+func (s *indicatorSet) point(start time.Time, forming *models.Bar) IndicatorPoint {
+    p := IndicatorPoint{Start: start, Values: map[string]map[string]float64{}}
+    for i, ind := range s.indicators {
+        if forming != nil {
+            ind = ind.Clone()
+            ind.Update(*forming)
+        }
+        if v, ok := ind.Value(); ok {
+            p.Values[s.keys[i]] = v
+        }
+    }
+    return p
+}
+
+// ComputeIndicators evaluates specs over symbol's stored bars in [from, to). Bars
+// before from are read as warm-up so the first returned values are already settled.
+func ComputeIndicators(symbol, interval string, specs []IndicatorSpec, from, to time.Time) ([]IndicatorPoint, error) {
+    step, ok := barDurations[interval]
+    if !ok {
+        return nil, fmt.Errorf("unsupported interval %q (use 1m, 5m, 1h or 1d)", interval)
+    }
+    set, err := newIndicatorSet(specs)
+    if err != nil {
+        return nil, err
+    }
+    if to.IsZero() {
+        to = time.Now()
+    }
+    if from.IsZero() {
+        from = to.Add(-100 * step)
+    }
+    from = from.UTC().Truncate(step)
+
+    warmup := from.Add(-time.Duration(set.lookback()) * step)
+    bars, err := repository.GetBars(symbol, interval, warmup, to.UTC(), 0)
+    if err != nil {
+        return nil, err
+    }
+
+    var points []IndicatorPoint
+    for _, bar := range bars {
+        set.update(bar)
+        if !bar.Start.Before(from) {
+            points = append(points, set.point(bar.Start, nil))
+        }
+    }
+    return points, nil
+}
+
+// IndicatorStream keeps indicators current from live quotes. Closed bars are
+// committed; the bar still forming is applied to copies on every update.
+type IndicatorStream struct {
+    symbol  string
+    step    time.Duration
+    set     *indicatorSet
+    forming *models.Bar
+}
+
+// NewIndicatorStream warms the indicators up from stored bars.
+func NewIndicatorStream(symbol, interval string, specs []IndicatorSpec) (*IndicatorStream, error) {
+    step, ok := barDurations[interval]
+    if !ok {
+        return nil, fmt.Errorf("unsupported interval %q (use 1m, 5m, 1h or 1d)", interval)
+    }
+    set, err := newIndicatorSet(specs)
+    if err != nil {
+        return nil, err
+    }
+    s := &IndicatorStream{symbol: symbol, step: step, set: set}
+
+    now := time.Now().UTC()
+    current := now.Truncate(step)
+    bars, err := repository.GetBars(symbol, interval, current.Add(-time.Duration(set.lookback()+1)*step), now.Add(step), 0)
+    if err != nil {
+        return nil, err
+    }
+    for i := range bars {
+        if bars[i].Start.Equal(current) {
+            s.forming = &bars[i]
+            break
+        }
+        set.update(bars[i])
+    }
+    return s, nil
+}
+
+// Current returns the values including the forming bar.
+func (s *IndicatorStream) Current() IndicatorPoint {
+    if s.forming == nil {
+        return s.set.point(time.Now().UTC().Truncate(s.step), nil)
+    }
+    return s.set.point(s.forming.Start, s.forming)
+}
+
+// OnQuote folds a live quote into the forming bar and returns the updated values.
+// Quotes carry no per-trade size, so live updates don't add bar volume.
+func (s *IndicatorStream) OnQuote(q models.Quote, at time.Time) IndicatorPoint {
+    start := at.UTC().Truncate(s.step)
+    switch {
+    case s.forming == nil || start.After(s.forming.Start):
+        if s.forming != nil {
+            s.set.update(*s.forming)
+        }
+        s.forming = &models.Bar{Symbol: s.symbol, Start: start, Open: q.Price, High: q.Price, Low: q.Price, Close: q.Price}
+    case start.Equal(s.forming.Start):
+        s.forming.High = maxFloat(s.forming.High, q.Price)
+        s.forming.Low = minFloat(s.forming.Low, q.Price)
+        s.forming.Close = q.Price
+    }
+    return s.set.point(s.forming.Start, s.forming)
+}
+
+func maxFloat(a, b float64) float64 {
+    if a > b {
+        return a
+    }
+    return b
+}
+
+func minFloat(a, b float64) float64 {
+    if a < b {
+        return a
+    }
+    return b
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+    "fmt"
+    "math"
+    "strings"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// IndicatorSpec selects an indicator and its parameters; zero values use the defaults.
+type IndicatorSpec struct {
+    Name   string  // "SMA", "EMA", "RSI", "MACD", "BOLLINGER" or "VWAP"
+    Period int     // SMA/EMA/RSI/BOLLINGER lookback
+    Fast   int     // MACD fast EMA (12)
+    Slow   int     // MACD slow EMA (26)
+    Signal int     // MACD signal EMA (9)
+    StdDev float64 // Bollinger band width in standard deviations (2)
+}
+
+// Indicator consumes closed bars one at a time and keeps only the state it needs.
+type Indicator interface {
+    Update(bar models.Bar)
+    // Value returns the current outputs, or false while warming up.
+    Value() (map[string]float64, bool)
+    // Clone copies the state so a forming bar can be applied without committing it.
+    Clone() Indicator
+    // Lookback is how many bars the indicator needs before its values settle.
+    Lookback() int
+}
+
+// Key names the spec in responses, e.g. "SMA(20)" or "MACD(12,26,9)".
+func (s IndicatorSpec) Key() string {
+    switch s.Name {
+    case "MACD":
+        return fmt.Sprintf("MACD(%d,%d,%d)", s.Fast, s.Slow, s.Signal)
+    case "BOLLINGER":
+        return fmt.Sprintf("BOLLINGER(%d,%g)", s.Period, s.StdDev)
+    case "VWAP":
+        return "VWAP"
+    default:
+        return fmt.Sprintf("%s(%d)", s.Name, s.Period)
+    }
+}
+
+// NewIndicator validates spec, fills in defaults and builds the indicator.
+func NewIndicator(spec *IndicatorSpec) (Indicator, error) {
+    spec.Name = strings.ToUpper(strings.TrimSpace(spec.Name))
+    defaultInt := func(v *int, d int) {
+        if *v == 0 {
+            *v = d
+        }
+    }
+    switch spec.Name {
+    case "SMA", "EMA":
+        defaultInt(&spec.Period, 20)
+    case "RSI":
+        defaultInt(&spec.Period, 14)
+    case "MACD":
+        defaultInt(&spec.Fast, 12)
+        defaultInt(&spec.Slow, 26)
+        defaultInt(&spec.Signal, 9)
+        if spec.Fast >= spec.Slow {
+            return nil, fmt.Errorf("MACD fast period must be shorter than the slow period")
+        }
+    case "BOLLINGER":
+        defaultInt(&spec.Period, 20)
+        if spec.StdDev == 0 {
+            spec.StdDev = 2
+        }
+    case "VWAP":
+    default:
+        return nil, fmt.Errorf("unknown indicator %q (use SMA, EMA, RSI, MACD, BOLLINGER or VWAP)", spec.Name)
+    }
+    if spec.Period < 0 || spec.Fast < 0 || spec.Slow < 0 || spec.Signal < 0 || spec.StdDev < 0 {
+        return nil, fmt.Errorf("%s parameters must not be negative", spec.Name)
+    }
+
+    switch spec.Name {
+    case "SMA":
+        return newSMA(spec.Period), nil
+    case "EMA":
+        return newEMA(spec.Period), nil
+    case "RSI":
+        return &rsi{period: spec.Period}, nil
+    case "MACD":
+        return &macd{fast: newEMA(spec.Fast), slow: newEMA(spec.Slow), signal: newEMA(spec.Signal)}, nil
+    case "BOLLINGER":
+        return &bollinger{window: newWindow(spec.Period), k: spec.StdDev}, nil
+    default:
+        return &vwap{}, nil
+    }
+}
+
+// window is a fixed-size ring of the most recent values with running sums.
+type window struct {
+    values []float64
+    next   int
+    full   bool
+    sum    float64
+    sumSq  float64
+}
+
+func newWindow(n int) *window {
+    return &window{values: make([]float64, n)}
+}
+
+func (w *window) push(v float64) {
+    old := w.values[w.next]
+    if w.full {
+        w.sum -= old
+        w.sumSq -= old * old
+    }
+    w.values[w.next] = v
+    w.sum += v
+    w.sumSq += v * v
+    w.next = (w.next + 1) % len(w.values)
+    if w.next == 0 {
+        w.full = true
+    }
+}
+
+func (w *window) mean() float64 {
+    return w.sum / float64(len(w.values))
+}
+
+// stddev is the population standard deviation, as Bollinger bands use.
+func (w *window) stddev() float64 {
+    n := float64(len(w.values))
+    variance := w.sumSq/n - (w.sum/n)*(w.sum/n)
+    return math.Sqrt(math.Max(variance, 0))
+}
+
+func (w *window) clone() *window {
+    c := *w
+    c.values = append([]float64(nil), w.values...)
+    return &c
+}
+
+// sma is the simple moving average of closes.
+type sma struct{ window *window }
+
+func newSMA(period int) *sma { return &sma{window: newWindow(period)} }
+
+func (s *sma) Update(bar models.Bar) { s.window.push(bar.Close) }
+func (s *sma) Lookback() int          { return len(s.window.values) }
+func (s *sma) Clone() Indicator       { return &sma{window: s.window.clone()} }
+func (s *sma) Value() (map[string]float64, bool) {
+    if !s.window.full {
+        return nil, false
+    }
+    return map[string]float64{"value": s.window.mean()}, true
+}
+
+// ema is seeded with the SMA of its first period values, then smoothed with 2/(n+1).
+type ema struct {
+    period int
+    count  int
+    seed   float64
+    value  float64
+}
+
+func newEMA(period int) *ema { return &ema{period: period} }
+
+func (e *ema) add(v float64) {
+    e.count++
+    if e.count <= e.period {
+        e.seed += v
+        if e.count == e.period {
+            e.value = e.seed / float64(e.period)
+        }
+        return
+    }
+    alpha := 2 / float64(e.period+1)
+    e.value += alpha * (v - e.value)
+}
+
+func (e *ema) ready() bool           { return e.count >= e.period }
+func (e *ema) Update(bar models.Bar) { e.add(bar.Close) }
+func (e *ema) Lookback() int         { return 3 * e.period }
+func (e *ema) Clone() Indicator      { c := *e; return &c }
+func (e *ema) Value() (map[string]float64, bool) {
+    if !e.ready() {
+        return nil, false
+    }
+    return map[string]float64{"value": e.value}, true
+}
+
+// rsi uses Wilder's smoothing, seeded with the average gain/loss of the first period changes.
+type rsi struct {
+    period  int
+    count   int // price changes seen
+    prev    float64
+    hasPrev bool
+    avgGain float64
+    avgLoss float64
+}
+
+func (r *rsi) Update(bar models.Bar) {
+    if !r.hasPrev {
+        r.prev, r.hasPrev = bar.Close, true
+        return
+    }
+    change := bar.Close - r.prev
+    r.prev = bar.Close
+    gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+    r.count++
+    n := float64(r.period)
+    if r.count <= r.period {
+        r.avgGain += gain / n
+        r.avgLoss += loss / n
+        return
+    }
+    r.avgGain = (r.avgGain*(n-1) + gain) / n
+    r.avgLoss = (r.avgLoss*(n-1) + loss) / n
+}
+
+func (r *rsi) Lookback() int    { return 3 * r.period }
+func (r *rsi) Clone() Indicator { c := *r; return &c }
+func (r *rsi) Value() (map[string]float64, bool) {
+    if r.count < r.period {
+        return nil, false
+    }
+    if r.avgLoss == 0 {
+        return map[string]float64{"value": 100}, true
+    }
+    rs := r.avgGain / r.avgLoss
+    return map[string]float64{"value": 100 - 100/(1+rs)}, true
+}
+
+// macd is EMA(fast) - EMA(slow), with an EMA of that line as the signal.
+type macd struct {
+    fast, slow, signal *ema
+}
+
+func (m *macd) Update(bar models.Bar) {
+    m.fast.add(bar.Close)
+    m.slow.add(bar.Close)
+    if m.slow.ready() {
+        m.signal.add(m.fast.value - m.slow.value)
+    }
+}
+
+func (m *macd) Lookback() int { return 3*m.slow.period + m.signal.period }
+func (m *macd) Clone() Indicator {
+    f, s, sig := *m.fast, *m.slow, *m.signal
+    return &macd{fast: &f, slow: &s, signal: &sig}
+}
+func (m *macd) Value() (map[string]float64, bool) {
+    if !m.signal.ready() {
+        return nil, false
+    }
+    line := m.fast.value - m.slow.value
+    return map[string]float64{"macd": line, "signal": m.signal.value, "histogram": line - m.signal.value}, true
+}
+
+// bollinger is the SMA of closes with bands k population standard deviations away.
+type bollinger struct {
+    window *window
+    k      float64
+}
+
+func (b *bollinger) Update(bar models.Bar) { b.window.push(bar.Close) }
+func (b *bollinger) Lookback() int          { return len(b.window.values) }
+func (b *bollinger) Clone() Indicator       { return &bollinger{window: b.window.clone(), k: b.k} }
+func (b *bollinger) Value() (map[string]float64, bool) {
+    if !b.window.full {
+        return nil, false
+    }
+    mid, dev := b.window.mean(), b.window.stddev()
+    return map[string]float64{"upper": mid + b.k*dev, "middle": mid, "lower": mid - b.k*dev}, true
+}
+
+// vwap is the volume-weighted typical price, (high+low+close)/3, reset every UTC day.
+type vwap struct {
+    day    time.Time
+    pv     float64
+    volume float64
+}
+
+func (v *vwap) Update(bar models.Bar) {
+    day := bar.Start.UTC().Truncate(24 * time.Hour)
+    if !day.Equal(v.day) {
+        v.day, v.pv, v.volume = day, 0, 0
+    }
+    typical := (bar.High + bar.Low + bar.Close) / 3
+    v.pv += typical * bar.Volume
+    v.volume += bar.Volume
+}
+
+func (v *vwap) Lookback() int    { return 0 }
+func (v *vwap) Clone() Indicator { c := *v; return &c }
+func (v *vwap) Value() (map[string]float64, bool) {
+    if v.volume <= 0 {
+        return nil, false
+    }
+    return map[string]float64{"value": v.pv / v.volume}, true
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// Reference series from the StockCharts ChartSchool worked examples
+// ("Moving Averages - Simple and Exponential" and "Relative Strength Index").
+var (
+    maCloses = []float64{
+        22.2734, 22.1940, 22.0847, 22.1741, 22.1840, 22.1344, 22.2337, 22.4323, 22.2436, 22.2933,
+        22.1542, 22.3926, 22.3816, 22.6109, 23.3558, 24.0519, 23.7530, 23.8324, 23.9516, 23.6338,
+        23.8225, 23.8722, 23.6537, 23.1870, 23.0976, 23.3261, 22.6805, 23.0976, 22.4025, 22.1725,
+    }
+    // 10-day SMA and EMA, rounded to cents, from the 10th close on
+    maSMA10 = []float64{
+        22.22, 22.21, 22.23, 22.26, 22.31, 22.42, 22.61, 22.77, 22.91, 23.08, 23.21,
+        23.38, 23.53, 23.65, 23.71, 23.69, 23.61, 23.51, 23.43, 23.28, 23.13,
+    }
+    maEMA10 = []float64{
+        22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28, 23.34,
+        23.43, 23.51, 23.54, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08, 22.92,
+    }
+
+    rsiCloses = []float64{
+        44.3389, 44.0902, 44.1497, 43.6124, 44.3278, 44.8264, 45.0955, 45.4245, 45.8433, 46.0826,
+        45.8931, 46.0328, 45.6140, 46.2820, 46.2820, 46.0028, 46.0328, 46.4116, 46.2222, 45.6439,
+        46.2122, 46.2521, 45.7137, 46.4515, 45.7835, 45.3548, 44.0288, 44.1783, 44.2181, 44.5672,
+        43.4205, 42.6628, 43.1314,
+    }
+    // 14-day RSI from the 15th close on
+    rsi14 = []float64{
+        70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+        54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.08, 37.77,
+    }
+)
+
+func closeBars(closes []float64) []models.Bar {
+    start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+    bars := make([]models.Bar, len(closes))
+    for i, c := range closes {
+        bars[i] = models.Bar{Symbol: "TEST", Start: start.Add(time.Duration(i) * time.Hour), Open: c, High: c, Low: c, Close: c, Volume: 100}
+    }
+    return bars
+}
+
+func mustIndicator(t *testing.T, spec IndicatorSpec) Indicator {
+    t.Helper()
+    ind, err := NewIndicator(&spec)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return ind
+}
+
+// series feeds bars through ind and returns output key of every settled value.
+func series(ind Indicator, bars []models.Bar, key string) []float64 {
+    var out []float64
+    for _, b := range bars {
+        ind.Update(b)
+        if v, ok := ind.Value(); ok {
+            out = append(out, v[key])
+        }
+    }
+    return out
+}
+
+func assertSeries(t *testing.T, got, want []float64, tolerance float64) {
+    t.Helper()
+    if len(got) != len(want) {
+        t.Fatalf("got %d values, want %d: %v", len(got), len(want), got)
+    }
+    for i := range want {
+        if math.Abs(got[i]-want[i]) > tolerance {
+            t.Fatalf("value %d = %.4f, want %.4f (all: %v)", i, got[i], want[i], got)
+        }
+    }
+}
+
+func TestIndicatorsAgainstReferenceSeries(t *testing.T) {
+    tests := []struct {
+        name string
+        spec IndicatorSpec
+        bars []models.Bar
+        key  string
+        want []float64
+    }{
+        {"SMA(10)", IndicatorSpec{Name: "SMA", Period: 10}, closeBars(maCloses), "value", maSMA10},
+        {"EMA(10)", IndicatorSpec{Name: "EMA", Period: 10}, closeBars(maCloses), "value", maEMA10},
+        {"RSI(14)", IndicatorSpec{Name: "RSI", Period: 14}, closeBars(rsiCloses), "value", rsi14},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            // Published figures are rounded to cents
+            assertSeries(t, series(mustIndicator(t, tt.spec), tt.bars, tt.key), tt.want, 0.0051)
+        })
+    }
+}
+
+// refEMA is the textbook EMA: seeded with the SMA of the first n values, then
+// smoothed with 2/(n+1). Entries before the seed are NaN.
+func refEMA(values []float64, n int) []float64 {
+    out := make([]float64, len(values))
+    alpha := 2 / float64(n+1)
+    sum := 0.0
+    for i, v := range values {
+        switch {
+        case i < n-1:
+            sum += v
+            out[i] = math.NaN()
+        case i == n-1:
+            out[i] = (sum + v) / float64(n)
+        default:
+            out[i] = out[i-1] + alpha*(v-out[i-1])
+        }
+    }
+    return out
+}
+
+func TestMACDMatchesEMADefinition(t *testing.T) {
+    fast, slow := refEMA(maCloses, 5), refEMA(maCloses, 10)
+    var line []float64
+    for i := 9; i < len(maCloses); i++ {
+        line = append(line, fast[i]-slow[i])
+    }
+    signal := refEMA(line, 4)
+
+    var want []float64
+    for i := range line {
+        if !math.IsNaN(signal[i]) {
+            want = append(want, line[i])
+        }
+    }
+    ind := mustIndicator(t, IndicatorSpec{Name: "MACD", Fast: 5, Slow: 10, Signal: 4})
+    assertSeries(t, series(ind, closeBars(maCloses), "macd"), want, 1e-9)
+
+    got := series(mustIndicator(t, IndicatorSpec{Name: "MACD", Fast: 5, Slow: 10, Signal: 4}), closeBars(maCloses), "histogram")
+    for i, h := range got {
+        k := len(line) - len(got) + i
+        if want := line[k] - signal[k]; math.Abs(h-want) > 1e-9 {
+            t.Fatalf("histogram %d = %.6f, want %.6f", i, h, want)
+        }
+    }
+}
+
+func TestBollingerBands(t *testing.T) {
+    // Closes 1..21: each 20-bar window of consecutive integers has a population
+    // standard deviation of sqrt((20^2-1)/12).
+    var closes []float64
+    for i := 1; i <= 21; i++ {
+        closes = append(closes, float64(i))
+    }
+    dev := math.Sqrt(399.0 / 12)
+    ind := mustIndicator(t, IndicatorSpec{Name: "BOLLINGER"})
+    bars := closeBars(closes)
+    assertSeries(t, series(ind.Clone(), bars, "middle"), []float64{10.5, 11.5}, 1e-9)
+    assertSeries(t, series(ind.Clone(), bars, "upper"), []float64{10.5 + 2*dev, 11.5 + 2*dev}, 1e-9)
+    assertSeries(t, series(ind.Clone(), bars, "lower"), []float64{10.5 - 2*dev, 11.5 - 2*dev}, 1e-9)
+}
+
+func TestVWAPResetsEachDay(t *testing.T) {
+    day := time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC)
+    bars := []models.Bar{
+        {Start: day, High: 10, Low: 8, Close: 9, Volume: 100},                      // typical 9
+        {Start: day.Add(time.Hour), High: 12, Low: 10, Close: 11, Volume: 300},     // typical 11
+        {Start: day.Add(24 * time.Hour), High: 21, Low: 19, Close: 20, Volume: 50}, // next day
+    }
+    // (9*100 + 11*300) / 400 = 10.5
+    assertSeries(t, series(mustIndicator(t, IndicatorSpec{Name: "VWAP"}), bars, "value"), []float64{9, 10.5, 20}, 1e-9)
+}
+
+// allSpecs covers every indicator with short periods so they settle quickly.
+var allSpecs = []IndicatorSpec{
+    {Name: "SMA", Period: 5},
+    {Name: "EMA", Period: 5},
+    {Name: "RSI", Period: 5},
+    {Name: "MACD", Fast: 3, Slow: 6, Signal: 3},
+    {Name: "BOLLINGER", Period: 5, StdDev: 2},
+    {Name: "VWAP"},
+}
+
+func ohlcBars() []models.Bar {
+    bars := closeBars(rsiCloses)
+    for i := range bars {
+        bars[i].Open = bars[i].Close - 0.1
+        bars[i].High = bars[i].Close + 0.3
+        bars[i].Low = bars[i].Close - 0.4
+        bars[i].Volume = float64(100 + 10*i)
+    }
+    return bars
+}
+
+// recompute evaluates specs from scratch over bars.
+func recompute(t *testing.T, bars []models.Bar) IndicatorPoint {
+    t.Helper()
+    set, err := newIndicatorSet(allSpecs)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, b := range bars {
+        set.update(b)
+    }
+    return set.point(time.Time{}, nil)
+}
+
+func assertSameValues(t *testing.T, step int, got, want IndicatorPoint) {
+    t.Helper()
+    if len(got.Values) != len(want.Values) {
+        t.Fatalf("step %d: got indicators %v, want %v", step, got.Values, want.Values)
+    }
+    for key, wv := range want.Values {
+        gv, ok := got.Values[key]
+        if !ok || len(gv) != len(wv) {
+            t.Fatalf("step %d: %s = %v, want %v", step, key, gv, wv)
+        }
+        for k, w := range wv {
+            if math.Abs(gv[k]-w) > 1e-9 {
+                t.Fatalf("step %d: %s.%s = %.10f, want %.10f", step, key, k, gv[k], w)
+            }
+        }
+    }
+}
+
+func TestIncrementalUpdatesMatchRecompute(t *testing.T) {
+    bars := ohlcBars()
+    set, err := newIndicatorSet(allSpecs)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for i := range bars {
+        // Previewing the next bar on copies must match committing it, and must
+        // leave the committed state untouched.
+        preview := set.point(bars[i].Start, &bars[i])
+        assertSameValues(t, i, preview, recompute(t, bars[:i+1]))
+        assertSameValues(t, i, set.point(bars[i].Start, nil), recompute(t, bars[:i]))
+
+        set.update(bars[i])
+        assertSameValues(t, i, set.point(bars[i].Start, nil), recompute(t, bars[:i+1]))
+    }
+}
+
+func TestIndicatorStreamMatchesRecompute(t *testing.T) {
+    set, err := newIndicatorSet(allSpecs)
+    if err != nil {
+        t.Fatal(err)
+    }
+    stream := &IndicatorStream{symbol: "TEST", step: time.Minute, set: set}
+
+    start := time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC)
+    var closed []models.Bar
+    var forming *models.Bar
+    for i, price := range rsiCloses {
+        // Three quotes per minute: 0s, 20s and 40s into the bar
+        at := start.Add(time.Duration(i) * 20 * time.Second)
+        barStart := at.Truncate(time.Minute)
+        if forming == nil || barStart.After(forming.Start) {
+            if forming != nil {
+                closed = append(closed, *forming)
+            }
+            forming = &models.Bar{Symbol: "TEST", Start: barStart, Open: price, High: price, Low: price, Close: price}
+        } else {
+            forming.High = math.Max(forming.High, price)
+            forming.Low = math.Min(forming.Low, price)
+            forming.Close = price
+        }
+
+        got := stream.OnQuote(models.Quote{Symbol: "TEST", Price: price}, at)
+        if !got.Start.Equal(barStart) {
+            t.Fatalf("quote %d: point start %v, want %v", i, got.Start, barStart)
+        }
+        assertSameValues(t, i, got, recompute(t, append(append([]models.Bar(nil), closed...), *forming)))
+    }
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "sort"
+    "strings"
+    "sync"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+const SourceLocal = "local"
+
+// SymbolSearcher is implemented by providers that can look up symbols by keyword.
+type SymbolSearcher interface {
+    Search(keywords string) ([]models.Instrument, error)
+}
+
+// defaultInstruments seeds the local list when no INSTRUMENTS_FILE is configured.
+var defaultInstruments = []models.Instrument{
+    {Symbol: "AAPL", Name: "Apple Inc.", Type: "EQUITY"},
+    {Symbol: "MSFT", Name: "Microsoft Corporation", Type: "EQUITY"},
+    {Symbol: "GOOGL", Name: "Alphabet Inc. Class A", Type: "EQUITY"},
+    {Symbol: "AMZN", Name: "Amazon.com Inc.", Type: "EQUITY"},
+    {Symbol: "META", Name: "Meta Platforms Inc.", Type: "EQUITY"},
+    {Symbol: "NVDA", Name: "NVIDIA Corporation", Type: "EQUITY"},
+    {Symbol: "TSLA", Name: "Tesla Inc.", Type: "EQUITY"},
+    {Symbol: "NFLX", Name: "Netflix Inc.", Type: "EQUITY"},
+    {Symbol: "JPM", Name: "JPMorgan Chase & Co.", Type: "EQUITY"},
+    {Symbol: "V", Name: "Visa Inc.", Type: "EQUITY"},
+    {Symbol: "IBM", Name: "International Business Machines Corp.", Type: "EQUITY"},
+    {Symbol: "SPY", Name: "SPDR S&P 500 ETF Trust", Type: "ETF"},
+    {Symbol: "QQQ", Name: "Invesco QQQ Trust", Type: "ETF"},
+    {Symbol: "VOO", Name: "Vanguard S&P 500 ETF", Type: "ETF"},
+}
+
+// InstrumentIndex is the local instrument list used for symbol search. Symbols
+// found through a provider's search are added to it.
+type InstrumentIndex struct {
+    mu          sync.RWMutex
+    instruments map[string]models.Instrument
+}
+
+var instruments = newInstrumentIndex(defaultInstruments)
+
+func newInstrumentIndex(list []models.Instrument) *InstrumentIndex {
+    idx := &InstrumentIndex{instruments: map[string]models.Instrument{}}
+    for _, inst := range list {
+        idx.add(inst)
+    }
+    return idx
+}
+
+// LoadInstrumentsFromEnv replaces the built-in list with INSTRUMENTS_FILE, a CSV with
+// a header naming any of symbol,name,type,region,currency.
+func LoadInstrumentsFromEnv() error {
+    path := os.Getenv("INSTRUMENTS_FILE")
+    if path == "" {
+        return nil
+    }
+    f, err := os.Open(path)
+    if err != nil {
+        return fmt.Errorf("failed to open instruments file: %v", err)
+    }
+    defer f.Close()
+
+    list, err := readInstruments(f)
+    if err != nil {
+        return err
+    }
+    instruments = newInstrumentIndex(list)
+    log.Printf("Loaded %d instruments from %s\n", len(list), path)
+    return nil
+}
+
+func readInstruments(r io.Reader) ([]models.Instrument, error) {
+    reader := csv.NewReader(r)
+    reader.TrimLeadingSpace = true
+    header, err := reader.Read()
+    if err != nil {
+        return nil, fmt.Errorf("failed to read instruments header: %v", err)
+    }
+    col := map[string]int{}
+    for i, name := range header {
+        col[strings.ToLower(strings.TrimSpace(name))] = i
+    }
+    if _, ok := col["symbol"]; !ok {
+        return nil, fmt.Errorf("instruments file needs a symbol column")
+    }
+    field := func(record []string, name string) string {
+        if i, ok := col[name]; ok && i < len(record) {
+            return strings.TrimSpace(record[i])
+        }
+        return ""
+    }
+
+    var list []models.Instrument
+    for {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to read instruments file: %v", err)
+        }
+        list = append(list, models.Instrument{
+            Symbol:   field(record, "symbol"),
+            Name:     field(record, "name"),
+            Type:     field(record, "type"),
+            Region:   field(record, "region"),
+            Currency: field(record, "currency"),
+        })
+    }
+    return list, nil
+}
+
+func (idx *InstrumentIndex) add(inst models.Instrument) {
+    inst.Symbol = strings.ToUpper(strings.TrimSpace(inst.Symbol))
+    if inst.Symbol == "" {
+        return
+    }
+    if inst.Region == "" {
+        inst.Region = "United States"
+    }
+    if inst.Currency == "" {
+        inst.Currency = "USD"
+    }
+    if inst.Source == "" {
+        inst.Source = SourceLocal
+    }
+    idx.mu.Lock()
+    defer idx.mu.Unlock()
+    if _, ok := idx.instruments[inst.Symbol]; !ok {
+        idx.instruments[inst.Symbol] = inst
+    }
+}
+
+// search ranks exact symbol matches first, then symbol prefixes, then name matches.
+func (idx *InstrumentIndex) search(query string) []models.Instrument {
+    q := strings.ToUpper(query)
+    type hit struct {
+        inst models.Instrument
+        rank int
+    }
+    var hits []hit
+
+    idx.mu.RLock()
+    for _, inst := range idx.instruments {
+        switch {
+        case inst.Symbol == q:
+            hits = append(hits, hit{inst, 0})
+        case strings.HasPrefix(inst.Symbol, q):
+            hits = append(hits, hit{inst, 1})
+        case strings.Contains(strings.ToUpper(inst.Name), q):
+            hits = append(hits, hit{inst, 2})
+        }
+    }
+    idx.mu.RUnlock()
+
+    sort.Slice(hits, func(i, j int) bool {
+        if hits[i].rank != hits[j].rank {
+            return hits[i].rank < hits[j].rank
+        }
+        return hits[i].inst.Symbol < hits[j].inst.Symbol
+    })
+    out := make([]models.Instrument, len(hits))
+    for i, h := range hits {
+        out[i] = h.inst
+    }
+    return out
+}
+
+// SearchSymbols looks query up in the local list. When SYMBOL_SEARCH_ENABLED is
+// "true" and the local list has fewer than limit matches, the active provider's
+// symbol search fills in the rest; those symbols are then kept locally.
+func SearchSymbols(query string, limit int) ([]models.Instrument, error) {
+    query = strings.TrimSpace(query)
+    if query == "" {
+        return nil, fmt.Errorf("query is required")
+    }
+    if limit <= 0 {
+        limit = 10
+    }
+
+    results := instruments.search(query)
+    if len(results) < limit && os.Getenv("SYMBOL_SEARCH_ENABLED") == "true" {
+        if searcher, ok := Provider().(SymbolSearcher); ok {
+            remote, err := searcher.Search(query)
+            if err != nil {
+                log.Printf("Remote symbol search for %q failed: %v\n", query, err)
+            }
+            seen := map[string]bool{}
+            for _, inst := range results {
+                seen[inst.Symbol] = true
+            }
+            for _, inst := range remote {
+                inst.Symbol = strings.ToUpper(inst.Symbol)
+                if seen[inst.Symbol] {
+                    continue
+                }
+                seen[inst.Symbol] = true
+                instruments.add(inst)
+                results = append(results, inst)
+            }
+        }
+    }
+    if len(results) > limit {
+        results = results[:limit]
+    }
+    return results, nil
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+const (
+    // SourceInternal is trade-service's own order book; SourceExternal is the provider chain.
+    SourceInternal = "internal"
+    SourceExternal = "external"
+
+    // CacheLive marks a quote served from internal book data only.
+    CacheLive = "LIVE"
+)
+
+// internalQuote is the latest order-book state reported by trade-service for a symbol.
+type internalQuote struct {
+    LastPrice   float64
+    LastSize    float64
+    LastTradeAt time.Time
+    Bid         float64
+    BidSize     float64
+    Ask         float64
+    AskSize     float64
+    BookAt      time.Time
+}
+
+// InternalFeed keeps internal prints and top-of-book per symbol and decides, per
+// symbol, which source wins when merging with external quotes.
+type InternalFeed struct {
+    mu              sync.Mutex
+    quotes          map[string]*internalQuote
+    maxAge          time.Duration
+    priorities      map[string][]string
+    defaultPriority []string
+    onUpdate        func(symbol string)
+}
+
+func NewInternalFeed(maxAge time.Duration, defaultPriority []string) *InternalFeed {
+    return &InternalFeed{
+        quotes:          map[string]*internalQuote{},
+        maxAge:          maxAge,
+        priorities:      map[string][]string{},
+        defaultPriority: defaultPriority,
+    }
+}
+
+var internalFeed = NewInternalFeed(5*time.Minute, []string{SourceExternal, SourceInternal})
+
+// UseSourcePriorityFromEnv reads MARKET_DATA_SOURCE_PRIORITY, e.g.
+// "AAPL=internal,external;*=external,internal", and INTERNAL_QUOTE_MAX_AGE (Go duration).
+func UseSourcePriorityFromEnv() error {
+    f := internalFeed
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    if v := os.Getenv("INTERNAL_QUOTE_MAX_AGE"); v != "" {
+        d, err := time.ParseDuration(v)
+        if err != nil || d <= 0 {
+            return fmt.Errorf("invalid INTERNAL_QUOTE_MAX_AGE %q", v)
+        }
+        f.maxAge = d
+    }
+    v := os.Getenv("MARKET_DATA_SOURCE_PRIORITY")
+    if v == "" {
+        return nil
+    }
+    for _, entry := range strings.Split(v, ";") {
+        kv := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+        if len(kv) != 2 {
+            return fmt.Errorf("invalid MARKET_DATA_SOURCE_PRIORITY entry %q", entry)
+        }
+        var order []string
+        for _, src := range strings.Split(kv[1], ",") {
+            src = strings.ToLower(strings.TrimSpace(src))
+            if src != SourceInternal && src != SourceExternal {
+                return fmt.Errorf("unknown quote source %q (use internal or external)", src)
+            }
+            order = append(order, src)
+        }
+        if symbol := strings.ToUpper(strings.TrimSpace(kv[0])); symbol == "*" {
+            f.defaultPriority = order
+        } else {
+            f.priorities[symbol] = order
+        }
+    }
+    return nil
+}
+
+// OnInternalUpdate registers fn to run after each internal print or top-of-book change.
+func OnInternalUpdate(fn func(symbol string)) {
+    internalFeed.mu.Lock()
+    defer internalFeed.mu.Unlock()
+    internalFeed.onUpdate = fn
+}
+
+// RecordTopOfBook stores trade-service's best bid/ask for symbol. A zero price means an empty side.
+func RecordTopOfBook(symbol string, bid, bidSize, ask, askSize float64, ts time.Time) error {
+    if symbol == "" {
+        return fmt.Errorf("symbol is required")
+    }
+    if bid > 0 && ask > 0 && bid >= ask {
+        return fmt.Errorf("crossed top of book for %s: bid %.2f >= ask %.2f", symbol, bid, ask)
+    }
+    if ts.IsZero() {
+        ts = time.Now()
+    }
+    internalFeed.update(symbol, func(q *internalQuote) {
+        q.Bid, q.BidSize, q.Ask, q.AskSize, q.BookAt = bid, bidSize, ask, askSize, ts
+    })
+    return nil
+}
+
+func (f *InternalFeed) recordTrade(symbol string, price, size float64, ts time.Time) {
+    f.update(symbol, func(q *internalQuote) {
+        q.LastPrice, q.LastSize, q.LastTradeAt = price, size, ts
+    })
+}
+
+func (f *InternalFeed) update(symbol string, apply func(q *internalQuote)) {
+    f.mu.Lock()
+    q, ok := f.quotes[symbol]
+    if !ok {
+        q = &internalQuote{}
+        f.quotes[symbol] = q
+    }
+    apply(q)
+    onUpdate := f.onUpdate
+    f.mu.Unlock()
+
+    if onUpdate != nil {
+        onUpdate(symbol)
+    }
+}
+
+// priority returns the source order for symbol.
+func (f *InternalFeed) priority(symbol string) []string {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if order, ok := f.priorities[symbol]; ok {
+        return order
+    }
+    return f.defaultPriority
+}
+
+// snapshot returns the parts of symbol's internal state that are still fresh.
+func (f *InternalFeed) snapshot(symbol string) (internalQuote, bool) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    q, ok := f.quotes[symbol]
+    if !ok {
+        return internalQuote{}, false
+    }
+    snap := *q
+    now := time.Now()
+    if now.Sub(snap.LastTradeAt) > f.maxAge {
+        snap.LastPrice, snap.LastSize = 0, 0
+    }
+    if now.Sub(snap.BookAt) > f.maxAge {
+        snap.Bid, snap.BidSize, snap.Ask, snap.AskSize = 0, 0, 0, 0
+    }
+    return snap, snap.LastPrice > 0 || snap.Bid > 0 || snap.Ask > 0
+}
+
+// complete reports whether the internal state alone makes a full quote.
+func (q internalQuote) complete() bool {
+    return q.LastPrice > 0 && q.Bid > 0 && q.Ask > 0
+}
+
+func (q internalQuote) updatedAt() time.Time {
+    if q.BookAt.After(q.LastTradeAt) {
+        return q.BookAt
+    }
+    return q.LastTradeAt
+}
+
+// toQuote builds a quote from internal data only; a missing last price uses the mid.
+func (q internalQuote) toQuote(symbol string) *models.Quote {
+    price := q.LastPrice
+    if price <= 0 {
+        switch {
+        case q.Bid > 0 && q.Ask > 0:
+            price = (q.Bid + q.Ask) / 2
+        case q.Bid > 0:
+            price = q.Bid
+        default:
+            price = q.Ask
+        }
+    }
+    return &models.Quote{
+        Symbol:            symbol,
+        Price:             price,
+        Timestamp:         time.Now().Format(time.RFC3339),
+        Bid:               q.Bid,
+        BidSize:           q.BidSize,
+        Ask:               q.Ask,
+        AskSize:           q.AskSize,
+        Source:            SourceInternal,
+        ExchangeTimestamp: q.updatedAt().Format(time.RFC3339),
+    }
+}
+
+// mergeQuotes overlays internal data on an external quote following order: the last
+// price and the touch each come from the first source that has them.
+func mergeQuotes(order []string, ext *models.Quote, in internalQuote) *models.Quote {
+    merged := *ext
+    priceSource, touchSource := ext.Source, ext.Source
+
+    for _, src := range order {
+        if src == SourceExternal && ext.Price > 0 {
+            break
+        }
+        if src == SourceInternal && in.LastPrice > 0 {
+            merged.Price = in.LastPrice
+            priceSource = SourceInternal
+            break
+        }
+    }
+    for _, src := range order {
+        if src == SourceExternal && ext.Bid > 0 && ext.Ask > 0 {
+            break
+        }
+        if src == SourceInternal && in.Bid > 0 && in.Ask > 0 {
+            merged.Bid, merged.BidSize, merged.Ask, merged.AskSize = in.Bid, in.BidSize, in.Ask, in.AskSize
+            touchSource = SourceInternal
+            break
+        }
+    }
+
+    if priceSource != touchSource {
+        merged.Source = priceSource + "+" + touchSource
+    } else {
+        merged.Source = priceSource
+    }
+    if priceSource == SourceInternal {
+        merged.Change, merged.ChangePercent = 0, 0
+        merged.FillChange()
+        merged.ExchangeTimestamp = in.LastTradeAt.Format(time.RFC3339)
+    }
+    return &merged
+}
+
+// MergedQuote merges internal data into the cached external quote without fetching.
+// It is what the hub pushes when trade-service reports a change.
+func MergedQuote(symbol string) (*models.Quote, bool) {
+    in, hasInternal := internalFeed.snapshot(symbol)
+    order := internalFeed.priority(symbol)
+    if !hasInternal || !containsSource(order, SourceInternal) {
+        return nil, false
+    }
+    if ext, ok := quoteCache.Peek(symbol); ok && containsSource(order, SourceExternal) {
+        return mergeQuotes(order, ext, in), true
+    }
+    return in.toQuote(symbol), true
+}
+
+func containsSource(order []string, src string) bool {
+    for _, s := range order {
+        if s == src {
+            return true
+        }
+    }
+    return false
+}
@@ -2,92 +2,63 @@ package service
 
 import (
     "context"
-    "encoding/json"
     "fmt"
     "log"
-    "net/http"
-    "os"
-    "strconv"
     "time"
 
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
     notificationpb "github.com/ankan8/swapsync/backend/services/notification-service/proto"
     "google.golang.org/grpc"
 )
 
-// alphaVantageResponse is the structure to parse Alpha Vantage's GLOBAL_QUOTE response.
-type alphaVantageResponse struct {
-    GlobalQuote struct {
-        Symbol        string `json:"01. symbol"`
-        Open          string `json:"02. open"`
-        High          string `json:"03. high"`
-        Low           string `json:"04. low"`
-        Price         string `json:"05. price"`
-        Volume        string `json:"06. volume"`
-        LatestTrading string `json:"07. latest trading day"`
-        PreviousClose string `json:"08. previous close"`
-        Change        string `json:"09. change"`
-        ChangePercent string `json:"10. change percent"`
-    } `json:"Global Quote"`
-}
-
-// FetchQuote gets a real quote from Alpha Vantage for the given symbol.
+// FetchQuote gets a quote for the given symbol from the configured provider.
 func FetchQuote(symbol string) (string, float64, string, error) {
-    // 1) Get your API key from environment or set it here
-    //    It's best to store it in an env variable ALPHA_VANTAGE_KEY
-    apiKey := os.Getenv("ALPHA_VANTAGE_KEY")
-    if apiKey == "" {
-        // For demonstration, you could hardcode your key or handle error
-        // apiKey = "YOUR_ALPHAVANTAGE_API_KEY"
-        return symbol, 0, "", fmt.Errorf("ALPHA_VANTAGE_KEY not set in environment")
-    }
-
-    // 2) Construct the Alpha Vantage URL
-    url := fmt.Sprintf(
-        "https://www.alphavantage.co/query?function=GLOBAL_QUOTE&symbol=%s&apikey=%s",
-        symbol,
-        apiKey,
-    )
-
-    // 3) Make the HTTP request
-    resp, err := http.Get(url)
+    q, _, err := FetchQuoteWithFreshness(symbol)
     if err != nil {
-        return symbol, 0, "", fmt.Errorf("failed to fetch quote from Alpha Vantage: %v", err)
+        return symbol, 0, "", err
     }
-    defer resp.Body.Close()
+    return symbol, q.Price, q.Timestamp, nil
+}
 
-    if resp.StatusCode != 200 {
-        return symbol, 0, "", fmt.Errorf("non-200 response from Alpha Vantage: %d", resp.StatusCode)
+// FetchQuoteWithFreshness gets a quote through the quote cache, merges in internal
+// order-book data by the symbol's source priority and reports how fresh it is.
+// When internal data comes first and is complete, the external fetch is skipped.
+func FetchQuoteWithFreshness(symbol string) (*models.Quote, Freshness, error) {
+    order := internalFeed.priority(symbol)
+    in, hasInternal := internalFeed.snapshot(symbol)
+    hasInternal = hasInternal && containsSource(order, SourceInternal)
+    live := func() (*models.Quote, Freshness, error) {
+        at := in.updatedAt()
+        return in.toQuote(symbol), Freshness{Status: CacheLive, FetchedAt: at, Age: time.Since(at)}, nil
     }
-
-    // 4) Parse the JSON
-    var avResp alphaVantageResponse
-    if err := json.NewDecoder(resp.Body).Decode(&avResp); err != nil {
-        return symbol, 0, "", fmt.Errorf("failed to parse Alpha Vantage JSON: %v", err)
+    if hasInternal && order[0] == SourceInternal && in.complete() {
+        return live()
     }
-
-    // 5) Extract price
-    priceStr := avResp.GlobalQuote.Price
-    if priceStr == "" {
-        return symbol, 0, "", fmt.Errorf("no price returned for symbol=%s", symbol)
+    if !containsSource(order, SourceExternal) {
+        if hasInternal {
+            return live()
+        }
+        return nil, Freshness{}, fmt.Errorf("no internal market data for %s", symbol)
     }
 
-    price, err := strconv.ParseFloat(priceStr, 64)
+    q, fresh, err := quoteCache.Get(symbol)
     if err != nil {
-        return symbol, 0, "", fmt.Errorf("failed to parse price string: %v", err)
+        if hasInternal {
+            log.Printf("External quote for %s failed (%v); serving internal data\n", symbol, err)
+            return live()
+        }
+        return nil, Freshness{}, err
     }
-
-    // 6) Optionally notify if above threshold
-    fmt.Printf("DEBUG: Fetched real price=%.2f for symbol=%s\n", price, symbol)
-
-    if price > 200 {
-        notifyUserMarketData("user123",
-            fmt.Sprintf("Price of %s is now %.2f, above your threshold!", symbol, price),
-            "PUSH",
-        )
+    if hasInternal {
+        q = mergeQuotes(order, q, in)
     }
+    return q, fresh, nil
+}
 
-    timestamp := time.Now().Format(time.RFC3339)
-    return symbol, price, timestamp, nil
+// FetchQuoteModel adapts FetchQuoteWithFreshness to a QuoteFetcher for the quote hub.
+func FetchQuoteModel(symbol string) (*models.Quote, error) {
+    q, _, err := FetchQuoteWithFreshness(symbol)
+    return q, err
 }
 
 // notifyUserMarketData calls the Notification Service to send a message.
@@ -0,0 +1,179 @@
+package service
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+const (
+    ProviderAlphaVantage = "alphavantage"
+    ProviderSimulated    = "simulated"
+    ProviderReplay       = "replay"
+)
+
+var (
+    // ErrThrottled means the provider refused the request because of its quota.
+    ErrThrottled = errors.New("provider throttled")
+    // ErrSymbolNotFound means the provider doesn't know the symbol; other providers aren't asked.
+    ErrSymbolNotFound = errors.New("symbol not found")
+)
+
+// QuoteProvider is a source of quotes. Implementations must be safe for concurrent use.
+type QuoteProvider interface {
+    Name() string
+    Quote(symbol string) (*models.Quote, error)
+}
+
+var (
+    providerMu     sync.RWMutex
+    activeProvider QuoteProvider
+)
+
+// SetProvider replaces the provider used by FetchQuote.
+func SetProvider(p QuoteProvider) {
+    providerMu.Lock()
+    defer providerMu.Unlock()
+    activeProvider = p
+}
+
+// Provider returns the provider used by FetchQuote, defaulting to Alpha Vantage.
+func Provider() QuoteProvider {
+    providerMu.RLock()
+    p := activeProvider
+    providerMu.RUnlock()
+    if p != nil {
+        return p
+    }
+
+    providerMu.Lock()
+    defer providerMu.Unlock()
+    if activeProvider == nil {
+        activeProvider = NewAlphaVantageProvider(os.Getenv("ALPHA_VANTAGE_KEY"))
+    }
+    return activeProvider
+}
+
+// NewProviderFromEnv builds the provider named by MARKET_DATA_PROVIDER
+// ("alphavantage" (default), "simulated" or "replay") with the optional
+// MARKET_DATA_FALLBACK_PROVIDER behind it. Each is rate limited and retried; the
+// PROVIDER_* variables override the per-provider defaults.
+func NewProviderFromEnv() (QuoteProvider, error) {
+    names := []string{os.Getenv("MARKET_DATA_PROVIDER")}
+    if fallback := os.Getenv("MARKET_DATA_FALLBACK_PROVIDER"); fallback != "" {
+        names = append(names, fallback)
+    }
+
+    retry := DefaultRetryPolicy()
+    if v := os.Getenv("PROVIDER_MAX_RETRIES"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 0 {
+            return nil, fmt.Errorf("invalid PROVIDER_MAX_RETRIES %q", v)
+        }
+        retry.MaxRetries = n
+    }
+
+    var guarded []*GuardedProvider
+    for _, name := range names {
+        name = strings.ToLower(strings.TrimSpace(name))
+        p, err := buildProvider(name)
+        if err != nil {
+            return nil, err
+        }
+        limiter, maxWait, cooldown, err := providerLimitsFromEnv(p.Name())
+        if err != nil {
+            return nil, err
+        }
+        guarded = append(guarded, NewGuardedProvider(p, limiter, maxWait, retry, cooldown))
+    }
+    return NewFailoverProvider(guarded...), nil
+}
+
+func buildProvider(name string) (QuoteProvider, error) {
+    switch name {
+    case "", ProviderAlphaVantage:
+        return NewAlphaVantageProvider(os.Getenv("ALPHA_VANTAGE_KEY")), nil
+    case ProviderSimulated:
+        cfg, err := SimulatedConfigFromEnv()
+        if err != nil {
+            return nil, err
+        }
+        return NewSimulatedProvider(cfg), nil
+    case ProviderReplay:
+        path := os.Getenv("REPLAY_FILE")
+        if path == "" {
+            return nil, fmt.Errorf("REPLAY_FILE must be set for the replay provider")
+        }
+        return NewReplayProvider(path, os.Getenv("REPLAY_LOOP") != "false")
+    default:
+        return nil, fmt.Errorf("unknown market data provider %q", name)
+    }
+}
+
+// providerLimitsFromEnv returns the limiter, the max wait for a token and the throttle
+// cooldown for a provider. Alpha Vantage's free tier allows 5 requests per minute;
+// local providers are unlimited unless PROVIDER_<NAME>_RATE_PER_MIN is set.
+func providerLimitsFromEnv(name string) (*TokenBucket, time.Duration, time.Duration, error) {
+    perMin, burst, maxWait, cooldown := 0.0, 1, time.Duration(0), time.Minute
+    if name == ProviderAlphaVantage {
+        perMin, burst, maxWait = 5, 5, 2*time.Second
+    }
+
+    prefix := "PROVIDER_" + strings.ToUpper(name) + "_"
+    if v := os.Getenv(prefix + "RATE_PER_MIN"); v != "" {
+        f, err := strconv.ParseFloat(v, 64)
+        if err != nil || f < 0 {
+            return nil, 0, 0, fmt.Errorf("invalid %sRATE_PER_MIN %q", prefix, v)
+        }
+        perMin = f
+    }
+    if v := os.Getenv(prefix + "BURST"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 {
+            return nil, 0, 0, fmt.Errorf("invalid %sBURST %q", prefix, v)
+        }
+        burst = n
+    }
+    for suffix, dst := range map[string]*time.Duration{"MAX_WAIT": &maxWait, "COOLDOWN": &cooldown} {
+        if v := os.Getenv(prefix + suffix); v != "" {
+            d, err := time.ParseDuration(v)
+            if err != nil || d < 0 {
+                return nil, 0, 0, fmt.Errorf("invalid %s%s %q", prefix, suffix, v)
+            }
+            *dst = d
+        }
+    }
+    return NewTokenBucket(perMin/60, burst), maxWait, cooldown, nil
+}
+
+// ProvidersHealth reports the health of the active provider(s) in failover order.
+func ProvidersHealth() []ProviderHealth {
+    switch p := Provider().(type) {
+    case *FailoverProvider:
+        return p.Health()
+    case *GuardedProvider:
+        return []ProviderHealth{p.Health()}
+    default:
+        return []ProviderHealth{{Name: p.Name(), Healthy: true}}
+    }
+}
+
+// UseProviderFromEnv installs the configured provider and logs which one is active.
+func UseProviderFromEnv() error {
+    p, err := NewProviderFromEnv()
+    if err != nil {
+        return err
+    }
+    SetProvider(p)
+    for _, h := range ProvidersHealth() {
+        log.Printf("Market data provider: %s\n", h.Name)
+    }
+    return nil
+}
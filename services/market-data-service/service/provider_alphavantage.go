@@ -0,0 +1,185 @@
+package service
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// alphaVantageResponse is the structure to parse Alpha Vantage's GLOBAL_QUOTE response.
+// Throttled requests still return 200, with only a "Note" or "Information" message.
+type alphaVantageResponse struct {
+    Note         string `json:"Note"`
+    Information  string `json:"Information"`
+    ErrorMessage string `json:"Error Message"`
+
+    GlobalQuote struct {
+        Symbol        string `json:"01. symbol"`
+        Open          string `json:"02. open"`
+        High          string `json:"03. high"`
+        Low           string `json:"04. low"`
+        Price         string `json:"05. price"`
+        Volume        string `json:"06. volume"`
+        LatestTrading string `json:"07. latest trading day"`
+        PreviousClose string `json:"08. previous close"`
+        Change        string `json:"09. change"`
+        ChangePercent string `json:"10. change percent"`
+    } `json:"Global Quote"`
+}
+
+// AlphaVantageProvider fetches quotes from Alpha Vantage's GLOBAL_QUOTE endpoint.
+type AlphaVantageProvider struct {
+    apiKey  string
+    baseURL string
+    client  *http.Client
+}
+
+func NewAlphaVantageProvider(apiKey string) *AlphaVantageProvider {
+    return &AlphaVantageProvider{
+        apiKey:  apiKey,
+        baseURL: "https://www.alphavantage.co/query",
+        client:  &http.Client{Timeout: 10 * time.Second},
+    }
+}
+
+func (p *AlphaVantageProvider) Name() string { return ProviderAlphaVantage }
+
+func (p *AlphaVantageProvider) Quote(symbol string) (*models.Quote, error) {
+    if p.apiKey == "" {
+        return nil, fmt.Errorf("ALPHA_VANTAGE_KEY not set in environment")
+    }
+
+    // 1) Construct the Alpha Vantage URL
+    params := url.Values{}
+    params.Set("function", "GLOBAL_QUOTE")
+    params.Set("symbol", symbol)
+    params.Set("apikey", p.apiKey)
+
+    // 2) Make the HTTP request
+    resp, err := p.client.Get(p.baseURL + "?" + params.Encode())
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch quote from Alpha Vantage: %v", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode == http.StatusTooManyRequests {
+        return nil, fmt.Errorf("%w: Alpha Vantage returned 429", ErrThrottled)
+    }
+    if resp.StatusCode != 200 {
+        return nil, fmt.Errorf("non-200 response from Alpha Vantage: %d", resp.StatusCode)
+    }
+
+    // 3) Parse the JSON
+    var avResp alphaVantageResponse
+    if err := json.NewDecoder(resp.Body).Decode(&avResp); err != nil {
+        return nil, fmt.Errorf("failed to parse Alpha Vantage JSON: %v", err)
+    }
+
+    // 4) Detect throttling and errors before looking for a price
+    if msg := avResp.Note + avResp.Information; msg != "" {
+        return nil, fmt.Errorf("%w: Alpha Vantage: %s", ErrThrottled, msg)
+    }
+    if avResp.ErrorMessage != "" {
+        return nil, fmt.Errorf("Alpha Vantage error for symbol=%s: %s", symbol, avResp.ErrorMessage)
+    }
+
+    // 5) Extract price (an empty "Global Quote" means an unknown symbol)
+    priceStr := avResp.GlobalQuote.Price
+    if priceStr == "" {
+        return nil, fmt.Errorf("%w: no price returned for symbol=%s", ErrSymbolNotFound, symbol)
+    }
+    price, err := strconv.ParseFloat(priceStr, 64)
+    if err != nil {
+        return nil, fmt.Errorf("failed to parse price string: %v", err)
+    }
+
+    gq := avResp.GlobalQuote
+    q := &models.Quote{
+        Symbol:            symbol,
+        Price:             price,
+        Timestamp:         time.Now().Format(time.RFC3339),
+        Open:              parseAVFloat(gq.Open),
+        High:              parseAVFloat(gq.High),
+        Low:               parseAVFloat(gq.Low),
+        Volume:            parseAVFloat(gq.Volume),
+        PreviousClose:     parseAVFloat(gq.PreviousClose),
+        Change:            parseAVFloat(gq.Change),
+        ChangePercent:     parseAVFloat(strings.TrimSuffix(gq.ChangePercent, "%")),
+        Source:            ProviderAlphaVantage,
+        ExchangeTimestamp: gq.LatestTrading,
+    }
+    q.FillChange()
+    return q, nil
+}
+
+// parseAVFloat parses an optional numeric field; Alpha Vantage sends every number as a string.
+func parseAVFloat(v string) float64 {
+    f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+    if err != nil {
+        return 0
+    }
+    return f
+}
+
+// alphaVantageSearchResponse is the SYMBOL_SEARCH response.
+type alphaVantageSearchResponse struct {
+    Note        string `json:"Note"`
+    Information string `json:"Information"`
+    BestMatches []struct {
+        Symbol   string `json:"1. symbol"`
+        Name     string `json:"2. name"`
+        Type     string `json:"3. type"`
+        Region   string `json:"4. region"`
+        Currency string `json:"8. currency"`
+    } `json:"bestMatches"`
+}
+
+// Search looks up symbols by keyword with SYMBOL_SEARCH.
+func (p *AlphaVantageProvider) Search(keywords string) ([]models.Instrument, error) {
+    if p.apiKey == "" {
+        return nil, fmt.Errorf("ALPHA_VANTAGE_KEY not set in environment")
+    }
+    params := url.Values{}
+    params.Set("function", "SYMBOL_SEARCH")
+    params.Set("keywords", keywords)
+    params.Set("apikey", p.apiKey)
+
+    resp, err := p.client.Get(p.baseURL + "?" + params.Encode())
+    if err != nil {
+        return nil, fmt.Errorf("failed to search symbols on Alpha Vantage: %v", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode == http.StatusTooManyRequests {
+        return nil, fmt.Errorf("%w: Alpha Vantage returned 429", ErrThrottled)
+    }
+    if resp.StatusCode != 200 {
+        return nil, fmt.Errorf("non-200 response from Alpha Vantage: %d", resp.StatusCode)
+    }
+
+    var avResp alphaVantageSearchResponse
+    if err := json.NewDecoder(resp.Body).Decode(&avResp); err != nil {
+        return nil, fmt.Errorf("failed to parse Alpha Vantage JSON: %v", err)
+    }
+    if msg := avResp.Note + avResp.Information; msg != "" {
+        return nil, fmt.Errorf("%w: Alpha Vantage: %s", ErrThrottled, msg)
+    }
+
+    matches := make([]models.Instrument, 0, len(avResp.BestMatches))
+    for _, m := range avResp.BestMatches {
+        matches = append(matches, models.Instrument{
+            Symbol:   m.Symbol,
+            Name:     m.Name,
+            Type:     strings.ToUpper(m.Type),
+            Region:   m.Region,
+            Currency: m.Currency,
+            Source:   ProviderAlphaVantage,
+        })
+    }
+    return matches, nil
+}
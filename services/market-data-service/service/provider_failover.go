@@ -0,0 +1,227 @@
+package service
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "math/rand"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// RetryPolicy controls retries of transient provider errors. Throttling is never
+// retried against the same provider: the quota won't recover within a backoff.
+type RetryPolicy struct {
+    MaxRetries int
+    BaseDelay  time.Duration
+    MaxDelay   time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+    return RetryPolicy{MaxRetries: 2, BaseDelay: 200 * time.Millisecond, MaxDelay: 2 * time.Second}
+}
+
+// backoff returns a full-jitter delay for the given attempt (0-based).
+func (r RetryPolicy) backoff(attempt int) time.Duration {
+    d := r.BaseDelay << uint(attempt)
+    if d <= 0 || d > r.MaxDelay {
+        d = r.MaxDelay
+    }
+    if d <= 0 {
+        return 0
+    }
+    return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// ProviderHealth is a snapshot of one provider's recent behaviour.
+type ProviderHealth struct {
+    Name                string
+    Healthy             bool
+    Throttled           bool
+    CooldownUntil       time.Time
+    ConsecutiveFailures int
+    TotalRequests       int64
+    TotalFailures       int64
+    TotalThrottled      int64
+    LastError           string
+    LastSuccess         time.Time
+    LastFailure         time.Time
+}
+
+// GuardedProvider puts a rate limiter, retries and health tracking around a provider.
+type GuardedProvider struct {
+    provider QuoteProvider
+    limiter  *TokenBucket
+    maxWait  time.Duration // how long to wait for a limiter token before giving up
+    retry    RetryPolicy
+    cooldown time.Duration // how long to skip the provider after it throttled us
+
+    mu     sync.Mutex
+    health ProviderHealth
+}
+
+func NewGuardedProvider(p QuoteProvider, limiter *TokenBucket, maxWait time.Duration, retry RetryPolicy, cooldown time.Duration) *GuardedProvider {
+    return &GuardedProvider{
+        provider: p,
+        limiter:  limiter,
+        maxWait:  maxWait,
+        retry:    retry,
+        cooldown: cooldown,
+        health:   ProviderHealth{Name: p.Name(), Healthy: true},
+    }
+}
+
+func (g *GuardedProvider) Name() string { return g.provider.Name() }
+
+func (g *GuardedProvider) Quote(symbol string) (*models.Quote, error) {
+    if until := g.coolingDown(); !until.IsZero() {
+        return nil, fmt.Errorf("%w: %s cooling down until %s", ErrThrottled, g.Name(), until.Format(time.RFC3339))
+    }
+
+    var err error
+    for attempt := 0; ; attempt++ {
+        if !g.limiter.Wait(g.maxWait) {
+            err = fmt.Errorf("%w: local rate limit for %s exhausted", ErrThrottled, g.Name())
+            g.record(err, false)
+            return nil, err
+        }
+
+        var q *models.Quote
+        q, err = g.provider.Quote(symbol)
+        g.record(err, true)
+        if err == nil {
+            return q, nil
+        }
+        if errors.Is(err, ErrThrottled) || errors.Is(err, ErrSymbolNotFound) || attempt >= g.retry.MaxRetries {
+            return nil, err
+        }
+        delay := g.retry.backoff(attempt)
+        log.Printf("Provider %s: attempt %d for %s failed (%v), retrying in %v\n", g.Name(), attempt+1, symbol, err, delay)
+        time.Sleep(delay)
+    }
+}
+
+// Search runs the wrapped provider's symbol search under the same rate limit and
+// throttle cooldown as quotes, without retries.
+func (g *GuardedProvider) Search(keywords string) ([]models.Instrument, error) {
+    searcher, ok := g.provider.(SymbolSearcher)
+    if !ok {
+        return nil, fmt.Errorf("%s does not support symbol search", g.Name())
+    }
+    if until := g.coolingDown(); !until.IsZero() {
+        return nil, fmt.Errorf("%w: %s cooling down until %s", ErrThrottled, g.Name(), until.Format(time.RFC3339))
+    }
+    if !g.limiter.Wait(g.maxWait) {
+        err := fmt.Errorf("%w: local rate limit for %s exhausted", ErrThrottled, g.Name())
+        g.record(err, false)
+        return nil, err
+    }
+    matches, err := searcher.Search(keywords)
+    g.record(err, true)
+    return matches, err
+}
+
+// Health returns a copy of the provider's health.
+func (g *GuardedProvider) Health() ProviderHealth {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    h := g.health
+    h.Throttled = time.Now().Before(h.CooldownUntil)
+    h.Healthy = !h.Throttled && h.ConsecutiveFailures == 0
+    return h
+}
+
+func (g *GuardedProvider) coolingDown() time.Time {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    if time.Now().Before(g.health.CooldownUntil) {
+        return g.health.CooldownUntil
+    }
+    return time.Time{}
+}
+
+// record updates health after a request. upstream is false for requests refused locally.
+func (g *GuardedProvider) record(err error, upstream bool) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+
+    now := time.Now()
+    if upstream {
+        g.health.TotalRequests++
+    }
+    if err == nil || errors.Is(err, ErrSymbolNotFound) {
+        g.health.ConsecutiveFailures = 0
+        g.health.LastSuccess = now
+        return
+    }
+    g.health.ConsecutiveFailures++
+    g.health.TotalFailures++
+    g.health.LastError = err.Error()
+    g.health.LastFailure = now
+    if errors.Is(err, ErrThrottled) {
+        g.health.TotalThrottled++
+        if upstream {
+            g.health.CooldownUntil = now.Add(g.cooldown)
+        }
+    }
+}
+
+// FailoverProvider asks its providers in order and moves on to the next one when a
+// provider is throttled or failing. Unknown symbols are not retried elsewhere.
+type FailoverProvider struct {
+    providers []*GuardedProvider
+}
+
+func NewFailoverProvider(providers ...*GuardedProvider) *FailoverProvider {
+    return &FailoverProvider{providers: providers}
+}
+
+func (f *FailoverProvider) Name() string { return f.providers[0].Name() }
+
+func (f *FailoverProvider) Quote(symbol string) (*models.Quote, error) {
+    var errs []error
+    for i, p := range f.providers {
+        q, err := p.Quote(symbol)
+        if err == nil {
+            if i > 0 {
+                log.Printf("Failover: served %s from %s (%v)\n", symbol, p.Name(), errors.Join(errs...))
+            }
+            return q, nil
+        }
+        if errors.Is(err, ErrSymbolNotFound) {
+            return nil, err
+        }
+        errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+    }
+    return nil, fmt.Errorf("all quote providers failed: %w", errors.Join(errs...))
+}
+
+// Search asks the first provider that supports symbol search and answers.
+func (f *FailoverProvider) Search(keywords string) ([]models.Instrument, error) {
+    var errs []error
+    for _, p := range f.providers {
+        if _, ok := p.provider.(SymbolSearcher); !ok {
+            continue
+        }
+        matches, err := p.Search(keywords)
+        if err == nil {
+            return matches, nil
+        }
+        errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+    }
+    if len(errs) == 0 {
+        return nil, fmt.Errorf("no configured provider supports symbol search")
+    }
+    return nil, errors.Join(errs...)
+}
+
+// Health reports every provider in failover order.
+func (f *FailoverProvider) Health() []ProviderHealth {
+    health := make([]ProviderHealth, 0, len(f.providers))
+    for _, p := range f.providers {
+        health = append(health, p.Health())
+    }
+    return health
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// scriptedProvider returns the queued errors in order, then quotes at price.
+type scriptedProvider struct {
+    name  string
+    price float64
+
+    mu    sync.Mutex
+    errs  []error
+    calls int
+}
+
+func (p *scriptedProvider) Name() string { return p.name }
+
+func (p *scriptedProvider) Quote(symbol string) (*models.Quote, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.calls++
+    if len(p.errs) > 0 {
+        err := p.errs[0]
+        p.errs = p.errs[1:]
+        return nil, err
+    }
+    return &models.Quote{Symbol: symbol, Price: p.price, Source: p.name}, nil
+}
+
+func (p *scriptedProvider) count() int {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    return p.calls
+}
+
+func guarded(p QuoteProvider, cooldown time.Duration) *GuardedProvider {
+    return NewGuardedProvider(p, nil, 0, RetryPolicy{MaxRetries: 2}, cooldown)
+}
+
+func TestFailoverOnThrottle(t *testing.T) {
+    primary := &scriptedProvider{name: "primary", price: 1, errs: []error{fmt.Errorf("%w: quota", ErrThrottled)}}
+    backup := &scriptedProvider{name: "backup", price: 2}
+    f := NewFailoverProvider(guarded(primary, time.Minute), guarded(backup, time.Minute))
+
+    q, err := f.Quote("AAPL")
+    if err != nil || q.Source != "backup" {
+        t.Fatalf("got %v, %v; want the backup's quote", q, err)
+    }
+    if primary.count() != 1 {
+        t.Fatalf("primary asked %d times, want 1 (throttling isn't retried)", primary.count())
+    }
+    h := f.Health()
+    if !h[0].Throttled || h[0].Healthy || h[0].TotalThrottled != 1 {
+        t.Fatalf("primary health %+v, want throttled", h[0])
+    }
+
+    // While it cools down the primary isn't asked at all
+    if q, err := f.Quote("AAPL"); err != nil || q.Source != "backup" {
+        t.Fatalf("got %v, %v; want the backup's quote", q, err)
+    }
+    if primary.count() != 1 {
+        t.Fatalf("primary asked during its cooldown")
+    }
+}
+
+func TestNoFailoverOnUnknownSymbol(t *testing.T) {
+    primary := &scriptedProvider{name: "primary", errs: []error{fmt.Errorf("%w: NOPE", ErrSymbolNotFound)}}
+    backup := &scriptedProvider{name: "backup", price: 2}
+    f := NewFailoverProvider(guarded(primary, time.Minute), guarded(backup, time.Minute))
+
+    if _, err := f.Quote("NOPE"); !errors.Is(err, ErrSymbolNotFound) {
+        t.Fatalf("err = %v, want ErrSymbolNotFound", err)
+    }
+    if backup.count() != 0 {
+        t.Fatal("an unknown symbol was retried on the backup")
+    }
+    if primary.count() != 1 {
+        t.Fatalf("primary asked %d times, want 1 (unknown symbols aren't retried)", primary.count())
+    }
+    if h := f.Health()[0]; !h.Healthy {
+        t.Fatalf("an unknown symbol marked the primary unhealthy: %+v", h)
+    }
+}
+
+func TestThrottleCooldownExpires(t *testing.T) {
+    primary := &scriptedProvider{name: "primary", price: 1, errs: []error{ErrThrottled}}
+    backup := &scriptedProvider{name: "backup", price: 2}
+    f := NewFailoverProvider(guarded(primary, 20*time.Millisecond), guarded(backup, time.Minute))
+
+    if q, _ := f.Quote("AAPL"); q == nil || q.Source != "backup" {
+        t.Fatalf("got %v, want the backup's quote", q)
+    }
+    time.Sleep(30 * time.Millisecond)
+
+    q, err := f.Quote("AAPL")
+    if err != nil || q.Source != "primary" {
+        t.Fatalf("got %v, %v; want the primary back after its cooldown", q, err)
+    }
+    if h := f.Health()[0]; !h.Healthy || h.Throttled {
+        t.Fatalf("primary health %+v, want healthy", h)
+    }
+}
+
+func TestTransientErrorsAreRetried(t *testing.T) {
+    flaky := errors.New("connection reset")
+    primary := &scriptedProvider{name: "primary", price: 1, errs: []error{flaky, flaky}}
+    backup := &scriptedProvider{name: "backup", price: 2}
+    f := NewFailoverProvider(guarded(primary, time.Minute), guarded(backup, time.Minute))
+
+    q, err := f.Quote("AAPL")
+    if err != nil || q.Source != "primary" {
+        t.Fatalf("got %v, %v; want the primary after two retries", q, err)
+    }
+    if primary.count() != 3 || backup.count() != 0 {
+        t.Fatalf("primary %d / backup %d calls, want 3 / 0", primary.count(), backup.count())
+    }
+
+    // Out of retries, the backup answers
+    primary.errs = []error{flaky, flaky, flaky}
+    if q, err := f.Quote("AAPL"); err != nil || q.Source != "backup" {
+        t.Fatalf("got %v, %v; want the backup's quote", q, err)
+    }
+}
+
+func TestLocalRateLimitFailsOver(t *testing.T) {
+    primary := &scriptedProvider{name: "primary", price: 1}
+    backup := &scriptedProvider{name: "backup", price: 2}
+    limited := NewGuardedProvider(primary, NewTokenBucket(1.0/60, 1), 0, RetryPolicy{}, time.Minute)
+    f := NewFailoverProvider(limited, guarded(backup, time.Minute))
+
+    if q, _ := f.Quote("AAPL"); q == nil || q.Source != "primary" {
+        t.Fatalf("got %v, want the primary's quote", q)
+    }
+    // The bucket is empty; the backup answers without the primary being asked
+    if q, _ := f.Quote("AAPL"); q == nil || q.Source != "backup" {
+        t.Fatalf("got %v, want the backup's quote", q)
+    }
+    if primary.count() != 1 {
+        t.Fatalf("primary asked %d times, want 1", primary.count())
+    }
+}
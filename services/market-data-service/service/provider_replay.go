@@ -0,0 +1,136 @@
+package service
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// ReplayProvider plays back quotes from a CSV file. Without a header row the columns
+// are symbol,price[,timestamp]; with one, any of replayColumns may appear in any order.
+// The file's timestamp becomes the exchange timestamp. Every Quote call returns the
+// symbol's next row; at the end it loops or keeps returning the last row.
+type ReplayProvider struct {
+    loop bool
+
+    mu     sync.Mutex
+    rows   map[string][]models.Quote
+    cursor map[string]int
+}
+
+var replayColumns = map[string]func(q *models.Quote) *float64{
+    "price":          func(q *models.Quote) *float64 { return &q.Price },
+    "bid":            func(q *models.Quote) *float64 { return &q.Bid },
+    "bid_size":       func(q *models.Quote) *float64 { return &q.BidSize },
+    "ask":            func(q *models.Quote) *float64 { return &q.Ask },
+    "ask_size":       func(q *models.Quote) *float64 { return &q.AskSize },
+    "open":           func(q *models.Quote) *float64 { return &q.Open },
+    "high":           func(q *models.Quote) *float64 { return &q.High },
+    "low":            func(q *models.Quote) *float64 { return &q.Low },
+    "volume":         func(q *models.Quote) *float64 { return &q.Volume },
+    "previous_close": func(q *models.Quote) *float64 { return &q.PreviousClose },
+}
+
+func NewReplayProvider(path string, loop bool) (*ReplayProvider, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open replay file: %v", err)
+    }
+    defer f.Close()
+    return newReplayProvider(f, loop)
+}
+
+func newReplayProvider(r io.Reader, loop bool) (*ReplayProvider, error) {
+    reader := csv.NewReader(r)
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = true
+
+    columns := []string{"symbol", "price", "timestamp"}
+    p := &ReplayProvider{loop: loop, rows: map[string][]models.Quote{}, cursor: map[string]int{}}
+    for line := 1; ; line++ {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to read replay file: %v", err)
+        }
+        if len(record) < 2 {
+            return nil, fmt.Errorf("replay line %d: expected symbol,price[,timestamp]", line)
+        }
+        if line == 1 {
+            if _, err := strconv.ParseFloat(record[1], 64); err != nil {
+                columns = make([]string, len(record))
+                for i, name := range record {
+                    columns[i] = strings.ToLower(strings.TrimSpace(name))
+                }
+                continue // header
+            }
+        }
+
+        q := models.Quote{Source: ProviderReplay}
+        for i, value := range record {
+            if i >= len(columns) || value == "" {
+                continue
+            }
+            switch name := columns[i]; name {
+            case "symbol":
+                q.Symbol = strings.ToUpper(value)
+            case "timestamp":
+                q.ExchangeTimestamp = value
+            default:
+                field, ok := replayColumns[name]
+                if !ok {
+                    continue
+                }
+                f, err := strconv.ParseFloat(value, 64)
+                if err != nil {
+                    return nil, fmt.Errorf("replay line %d: invalid %s %q", line, name, value)
+                }
+                *field(&q) = f
+            }
+        }
+        if q.Symbol == "" || q.Price <= 0 {
+            return nil, fmt.Errorf("replay line %d: symbol and a positive price are required", line)
+        }
+        q.FillChange()
+        p.rows[q.Symbol] = append(p.rows[q.Symbol], q)
+    }
+    if len(p.rows) == 0 {
+        return nil, fmt.Errorf("replay file has no quotes")
+    }
+    return p, nil
+}
+
+func (p *ReplayProvider) Name() string { return ProviderReplay }
+
+func (p *ReplayProvider) Quote(symbol string) (*models.Quote, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    symbol = strings.ToUpper(symbol)
+    rows := p.rows[symbol]
+    if len(rows) == 0 {
+        return nil, fmt.Errorf("no replay data for symbol=%s", symbol)
+    }
+    i := p.cursor[symbol]
+    if i >= len(rows) {
+        if p.loop {
+            i = 0
+        } else {
+            i = len(rows) - 1
+        }
+    }
+    p.cursor[symbol] = i + 1
+
+    q := rows[i]
+    q.Timestamp = time.Now().Format(time.RFC3339)
+    return &q, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+    "fmt"
+    "hash/fnv"
+    "math"
+    "math/rand"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// SimulatedConfig parameterises the geometric Brownian motion feed.
+type SimulatedConfig struct {
+    Seed        int64
+    Drift       float64            // annualised mu
+    Volatility  float64            // annualised sigma
+    Step        time.Duration      // simulated time advanced per quote
+    SpreadBps   float64            // quoted bid/ask spread in basis points of the price
+    StartPrices map[string]float64 // per-symbol starting price; others derive from the symbol
+}
+
+func DefaultSimulatedConfig() SimulatedConfig {
+    return SimulatedConfig{
+        Seed:        42,
+        Drift:       0.05,
+        Volatility:  0.25,
+        Step:        time.Minute,
+        SpreadBps:   5,
+        StartPrices: map[string]float64{},
+    }
+}
+
+// SimulatedConfigFromEnv reads SIM_SEED, SIM_DRIFT, SIM_VOLATILITY, SIM_SPREAD_BPS, SIM_STEP and
+// SIM_START_PRICES (e.g. "AAPL=190,MSFT=410") on top of the defaults.
+func SimulatedConfigFromEnv() (SimulatedConfig, error) {
+    cfg := DefaultSimulatedConfig()
+    if v := os.Getenv("SIM_SEED"); v != "" {
+        seed, err := strconv.ParseInt(v, 10, 64)
+        if err != nil {
+            return cfg, fmt.Errorf("invalid SIM_SEED: %v", err)
+        }
+        cfg.Seed = seed
+    }
+    for env, dst := range map[string]*float64{"SIM_DRIFT": &cfg.Drift, "SIM_VOLATILITY": &cfg.Volatility, "SIM_SPREAD_BPS": &cfg.SpreadBps} {
+        if v := os.Getenv(env); v != "" {
+            f, err := strconv.ParseFloat(v, 64)
+            if err != nil {
+                return cfg, fmt.Errorf("invalid %s: %v", env, err)
+            }
+            *dst = f
+        }
+    }
+    if v := os.Getenv("SIM_STEP"); v != "" {
+        d, err := time.ParseDuration(v)
+        if err != nil || d <= 0 {
+            return cfg, fmt.Errorf("invalid SIM_STEP %q", v)
+        }
+        cfg.Step = d
+    }
+    if v := os.Getenv("SIM_START_PRICES"); v != "" {
+        for _, pair := range strings.Split(v, ",") {
+            kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+            if len(kv) != 2 {
+                return cfg, fmt.Errorf("invalid SIM_START_PRICES entry %q", pair)
+            }
+            price, err := strconv.ParseFloat(kv[1], 64)
+            if err != nil || price <= 0 {
+                return cfg, fmt.Errorf("invalid SIM_START_PRICES price %q", kv[1])
+            }
+            cfg.StartPrices[strings.ToUpper(kv[0])] = price
+        }
+    }
+    return cfg, nil
+}
+
+// SimulatedProvider produces a deterministic GBM price path per symbol. Each symbol
+// has its own RNG seeded from the config seed and the symbol, so a path doesn't depend
+// on which other symbols were requested or in what order.
+type SimulatedProvider struct {
+    cfg SimulatedConfig
+
+    mu    sync.Mutex
+    paths map[string]*simPath
+}
+
+type simPath struct {
+    rng   *rand.Rand
+    price float64
+    steps int64
+
+    // session statistics; the session starts at the first quote of the symbol
+    sessionStart time.Time
+    prevClose    float64
+    open         float64
+    high         float64
+    low          float64
+    volume       float64
+}
+
+func NewSimulatedProvider(cfg SimulatedConfig) *SimulatedProvider {
+    if cfg.Step <= 0 {
+        cfg.Step = time.Minute
+    }
+    return &SimulatedProvider{cfg: cfg, paths: map[string]*simPath{}}
+}
+
+func (p *SimulatedProvider) Name() string { return ProviderSimulated }
+
+// Quote advances symbol's path by one step and returns the new price.
+func (p *SimulatedProvider) Quote(symbol string) (*models.Quote, error) {
+    if symbol == "" {
+        return nil, fmt.Errorf("symbol is required")
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    path, ok := p.paths[symbol]
+    if !ok {
+        h := fnv.New64a()
+        h.Write([]byte(symbol))
+        sum := h.Sum64()
+        start, ok := p.cfg.StartPrices[symbol]
+        if !ok {
+            start = 50 + float64(sum%45000)/100 // 50.00 .. 499.99
+        }
+        path = &simPath{
+            rng:          rand.New(rand.NewSource(p.cfg.Seed ^ int64(sum))),
+            price:        start,
+            sessionStart: time.Now().UTC().Truncate(time.Second),
+            prevClose:    start,
+            open:         start,
+            high:         start,
+            low:          start,
+        }
+        p.paths[symbol] = path
+    } else {
+        // S(t+dt) = S(t) * exp((mu - sigma^2/2) dt + sigma sqrt(dt) Z), dt in years
+        dt := p.cfg.Step.Hours() / (24 * 365)
+        sigma := p.cfg.Volatility
+        z := path.rng.NormFloat64()
+        path.price *= math.Exp((p.cfg.Drift-sigma*sigma/2)*dt + sigma*math.Sqrt(dt)*z)
+        path.steps++
+        path.high = math.Max(path.high, path.price)
+        path.low = math.Min(path.low, path.price)
+        path.volume += float64(100 * (1 + path.rng.Intn(50)))
+    }
+
+    half := math.Max(path.price*p.cfg.SpreadBps/20000, 0.005)
+    q := &models.Quote{
+        Symbol:            symbol,
+        Price:             round2(path.price),
+        Timestamp:         time.Now().Format(time.RFC3339),
+        Bid:               round2(path.price - half),
+        BidSize:           float64(100 * (1 + path.rng.Intn(10))),
+        Ask:               round2(path.price + half),
+        AskSize:           float64(100 * (1 + path.rng.Intn(10))),
+        Open:              round2(path.open),
+        High:              round2(path.high),
+        Low:               round2(path.low),
+        Volume:            path.volume,
+        PreviousClose:     round2(path.prevClose),
+        Source:            ProviderSimulated,
+        ExchangeTimestamp: path.sessionStart.Add(time.Duration(path.steps) * p.cfg.Step).Format(time.RFC3339),
+    }
+    if q.Bid >= q.Ask {
+        q.Ask = round2(q.Bid + 0.01)
+    }
+    q.FillChange()
+    return q, nil
+}
+
+func round2(v float64) float64 {
+    return math.Round(v*100) / 100
+}
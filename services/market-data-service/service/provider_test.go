@@ -0,0 +1,66 @@
+package service
+
+import (
+    "testing"
+    "time"
+)
+
+func TestProviderLimitsFromEnv(t *testing.T) {
+    _, maxWait, cooldown, err := providerLimitsFromEnv(ProviderAlphaVantage)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if maxWait != 2*time.Second || cooldown != time.Minute {
+        t.Fatalf("alphavantage defaults: maxWait %v cooldown %v", maxWait, cooldown)
+    }
+
+    t.Setenv("PROVIDER_SIMULATED_MAX_WAIT", "5s")
+    t.Setenv("PROVIDER_SIMULATED_COOLDOWN", "10s")
+    _, maxWait, cooldown, err = providerLimitsFromEnv(ProviderSimulated)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if maxWait != 5*time.Second || cooldown != 10*time.Second {
+        t.Fatalf("overrides: maxWait %v cooldown %v", maxWait, cooldown)
+    }
+
+    for env, v := range map[string]string{
+        "PROVIDER_SIMULATED_RATE_PER_MIN": "-1",
+        "PROVIDER_SIMULATED_BURST":        "0",
+        "PROVIDER_SIMULATED_COOLDOWN":     "soon",
+    } {
+        t.Run(env, func(t *testing.T) {
+            t.Setenv(env, v)
+            if _, _, _, err := providerLimitsFromEnv(ProviderSimulated); err == nil {
+                t.Fatalf("%s=%s accepted", env, v)
+            }
+        })
+    }
+}
+
+func TestNewProviderFromEnvChainsFallback(t *testing.T) {
+    t.Setenv("MARKET_DATA_PROVIDER", "Simulated")
+    t.Setenv("MARKET_DATA_FALLBACK_PROVIDER", "alphavantage")
+    p, err := NewProviderFromEnv()
+    if err != nil {
+        t.Fatal(err)
+    }
+    f, ok := p.(*FailoverProvider)
+    if !ok {
+        t.Fatalf("provider is %T, want a failover chain", p)
+    }
+    health := f.Health()
+    if len(health) != 2 || health[0].Name != ProviderSimulated || health[1].Name != ProviderAlphaVantage {
+        t.Fatalf("chain = %+v, want simulated then alphavantage", health)
+    }
+
+    t.Setenv("MARKET_DATA_FALLBACK_PROVIDER", "nope")
+    if _, err := NewProviderFromEnv(); err == nil {
+        t.Fatal("unknown fallback provider accepted")
+    }
+    t.Setenv("MARKET_DATA_FALLBACK_PROVIDER", "")
+    t.Setenv("PROVIDER_MAX_RETRIES", "-1")
+    if _, err := NewProviderFromEnv(); err == nil {
+        t.Fatal("negative PROVIDER_MAX_RETRIES accepted")
+    }
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+const (
+    CacheHit   = "HIT"   // served from cache within the TTL
+    CacheStale = "STALE" // served past the TTL while a refresh runs in the background
+    CacheMiss  = "MISS"  // fetched upstream (possibly shared with concurrent callers)
+)
+
+// Freshness tells the caller where a quote came from and how old it is.
+type Freshness struct {
+    Status    string
+    FetchedAt time.Time
+    Age       time.Duration
+}
+
+// QuoteCache caches quotes per symbol. Within ttl a cached quote is returned as is;
+// within ttl+staleTTL it is returned while one background refresh runs; after that
+// the caller waits for a fresh fetch. Concurrent fetches of a symbol are coalesced.
+type QuoteCache struct {
+    fetch    QuoteFetcher
+    ttl      time.Duration
+    staleTTL time.Duration
+
+    mu       sync.Mutex
+    entries  map[string]*cacheEntry
+    inflight map[string]*inflightFetch
+}
+
+type cacheEntry struct {
+    quote     models.Quote
+    fetchedAt time.Time
+}
+
+type inflightFetch struct {
+    done  chan struct{}
+    entry *cacheEntry
+    err   error
+}
+
+func NewQuoteCache(fetch QuoteFetcher, ttl, staleTTL time.Duration) *QuoteCache {
+    return &QuoteCache{
+        fetch:    fetch,
+        ttl:      ttl,
+        staleTTL: staleTTL,
+        entries:  map[string]*cacheEntry{},
+        inflight: map[string]*inflightFetch{},
+    }
+}
+
+// quoteCache fronts whichever provider is active; every upstream quote goes into history.
+var quoteCache = NewQuoteCache(func(symbol string) (*models.Quote, error) {
+    q, err := Provider().Quote(symbol)
+    if err == nil {
+        RecordQuote(q)
+    }
+    return q, err
+}, 5*time.Second, 30*time.Second)
+
+// UseQuoteCacheFromEnv applies QUOTE_CACHE_TTL and QUOTE_CACHE_STALE (Go durations).
+func UseQuoteCacheFromEnv() error {
+    ttl, stale := quoteCache.ttl, quoteCache.staleTTL
+    for env, dst := range map[string]*time.Duration{"QUOTE_CACHE_TTL": &ttl, "QUOTE_CACHE_STALE": &stale} {
+        if v := os.Getenv(env); v != "" {
+            d, err := time.ParseDuration(v)
+            if err != nil || d < 0 {
+                return fmt.Errorf("invalid %s %q", env, v)
+            }
+            *dst = d
+        }
+    }
+    quoteCache.mu.Lock()
+    quoteCache.ttl, quoteCache.staleTTL = ttl, stale
+    quoteCache.mu.Unlock()
+    log.Printf("Quote cache: ttl=%v stale-while-revalidate=%v\n", ttl, stale)
+    return nil
+}
+
+// Get returns the quote for symbol and how fresh it is.
+func (c *QuoteCache) Get(symbol string) (*models.Quote, Freshness, error) {
+    c.mu.Lock()
+    if e, ok := c.entries[symbol]; ok {
+        age := time.Since(e.fetchedAt)
+        if age <= c.ttl {
+            c.mu.Unlock()
+            return e.result(CacheHit)
+        }
+        if age <= c.ttl+c.staleTTL {
+            c.startFetchLocked(symbol)
+            c.mu.Unlock()
+            return e.result(CacheStale)
+        }
+    }
+    call := c.startFetchLocked(symbol)
+    c.mu.Unlock()
+
+    <-call.done
+    if call.err != nil {
+        return nil, Freshness{}, call.err
+    }
+    return call.entry.result(CacheMiss)
+}
+
+// Peek returns the cached quote for symbol, however old, without fetching.
+func (c *QuoteCache) Peek(symbol string) (*models.Quote, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    e, ok := c.entries[symbol]
+    if !ok {
+        return nil, false
+    }
+    q := e.quote
+    return &q, true
+}
+
+// Invalidate drops symbol from the cache.
+func (c *QuoteCache) Invalidate(symbol string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    delete(c.entries, symbol)
+}
+
+// startFetchLocked joins the in-flight fetch for symbol or starts one. The caller must hold c.mu.
+func (c *QuoteCache) startFetchLocked(symbol string) *inflightFetch {
+    if call, ok := c.inflight[symbol]; ok {
+        return call
+    }
+    call := &inflightFetch{done: make(chan struct{})}
+    c.inflight[symbol] = call
+
+    go func() {
+        q, err := c.fetch(symbol)
+
+        c.mu.Lock()
+        if err == nil {
+            call.entry = &cacheEntry{quote: *q, fetchedAt: time.Now()}
+            c.entries[symbol] = call.entry
+        } else {
+            call.err = err
+            log.Printf("Quote cache: refresh of %s failed: %v\n", symbol, err)
+        }
+        delete(c.inflight, symbol)
+        c.mu.Unlock()
+        close(call.done)
+    }()
+    return call
+}
+
+func (e *cacheEntry) result(status string) (*models.Quote, Freshness, error) {
+    q := e.quote
+    return &q, Freshness{Status: status, FetchedAt: e.fetchedAt, Age: time.Since(e.fetchedAt)}, nil
+}
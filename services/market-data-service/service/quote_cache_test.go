@@ -0,0 +1,166 @@
+package service
+
+import (
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// gatedFetcher blocks every fetch until release is closed and counts the calls.
+type gatedFetcher struct {
+    mu      sync.Mutex
+    calls   int
+    price   float64
+    err     error
+    started chan struct{}
+    release chan struct{}
+}
+
+func newGatedFetcher(price float64) *gatedFetcher {
+    return &gatedFetcher{price: price, started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (f *gatedFetcher) fetch(symbol string) (*models.Quote, error) {
+    f.mu.Lock()
+    f.calls++
+    f.mu.Unlock()
+    f.started <- struct{}{}
+    <-f.release
+    if f.err != nil {
+        return nil, f.err
+    }
+    return &models.Quote{Symbol: symbol, Price: f.price}, nil
+}
+
+func (f *gatedFetcher) count() int {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.calls
+}
+
+// cachedAt puts a quote in the cache as if it had been fetched age ago.
+func cachedAt(c *QuoteCache, symbol string, price float64, age time.Duration) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.entries[symbol] = &cacheEntry{quote: models.Quote{Symbol: symbol, Price: price}, fetchedAt: time.Now().Add(-age)}
+}
+
+// waitForPrice waits until the cached quote for symbol has the given price.
+func waitForPrice(t *testing.T, c *QuoteCache, symbol string, price float64) {
+    t.Helper()
+    deadline := time.Now().Add(time.Second)
+    for time.Now().Before(deadline) {
+        if q, ok := c.Peek(symbol); ok && q.Price == price {
+            return
+        }
+        time.Sleep(time.Millisecond)
+    }
+    t.Fatalf("cache never got %s at %.2f", symbol, price)
+}
+
+func TestConcurrentMissesShareOneFetch(t *testing.T) {
+    fetcher := newGatedFetcher(100)
+    c := NewQuoteCache(fetcher.fetch, time.Minute, time.Minute)
+
+    const callers = 10
+    var wg sync.WaitGroup
+    results := make(chan Freshness, callers)
+    for i := 0; i < callers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            q, fresh, err := c.Get("AAPL")
+            if err != nil || q.Price != 100 {
+                t.Errorf("Get = %v, %v", q, err)
+                return
+            }
+            results <- fresh
+        }()
+    }
+    <-fetcher.started
+    time.Sleep(20 * time.Millisecond) // let every caller join the fetch
+    close(fetcher.release)
+    wg.Wait()
+    close(results)
+
+    if n := fetcher.count(); n != 1 {
+        t.Fatalf("%d upstream fetches for %d concurrent misses, want 1", n, callers)
+    }
+    for fresh := range results {
+        if fresh.Status != CacheMiss {
+            t.Fatalf("status %s, want MISS for callers that waited", fresh.Status)
+        }
+    }
+
+    // Within the TTL it's a hit
+    if _, fresh, _ := c.Get("AAPL"); fresh.Status != CacheHit {
+        t.Fatalf("status %s, want HIT", fresh.Status)
+    }
+}
+
+func TestStaleQuoteServedDuringRefresh(t *testing.T) {
+    fetcher := newGatedFetcher(2)
+    c := NewQuoteCache(fetcher.fetch, time.Minute, time.Minute)
+    cachedAt(c, "AAPL", 1, 90*time.Second) // past the TTL, within the stale window
+
+    // Both callers get the stale quote at once, and only one refresh runs
+    for i := 0; i < 2; i++ {
+        q, fresh, err := c.Get("AAPL")
+        if err != nil {
+            t.Fatal(err)
+        }
+        if q.Price != 1 || fresh.Status != CacheStale || fresh.Age < 90*time.Second {
+            t.Fatalf("got %.2f %s (age %v), want the stale 1", q.Price, fresh.Status, fresh.Age)
+        }
+    }
+    <-fetcher.started
+    if n := fetcher.count(); n != 1 {
+        t.Fatalf("%d refreshes, want 1", n)
+    }
+
+    close(fetcher.release)
+    waitForPrice(t, c, "AAPL", 2)
+    q, fresh, err := c.Get("AAPL")
+    if err != nil || q.Price != 2 || fresh.Status != CacheHit {
+        t.Fatalf("after the refresh: %v %s %v, want a HIT at 2", q, fresh.Status, err)
+    }
+}
+
+func TestFailedRefreshKeepsStaleQuote(t *testing.T) {
+    fetcher := newGatedFetcher(2)
+    fetcher.err = errors.New("upstream down")
+    close(fetcher.release)
+    c := NewQuoteCache(fetcher.fetch, time.Minute, time.Minute)
+    cachedAt(c, "AAPL", 1, 90*time.Second)
+
+    if q, fresh, err := c.Get("AAPL"); err != nil || q.Price != 1 || fresh.Status != CacheStale {
+        t.Fatalf("got %v %s %v, want the stale 1", q, fresh.Status, err)
+    }
+    <-fetcher.started
+    time.Sleep(10 * time.Millisecond)
+    if q, ok := c.Peek("AAPL"); !ok || q.Price != 1 {
+        t.Fatalf("failed refresh replaced the cached quote: %v", q)
+    }
+}
+
+func TestExpiredQuoteWaitsForFetch(t *testing.T) {
+    fetcher := newGatedFetcher(2)
+    close(fetcher.release)
+    c := NewQuoteCache(fetcher.fetch, time.Minute, time.Minute)
+    cachedAt(c, "AAPL", 1, 3*time.Minute) // past the stale window too
+
+    q, fresh, err := c.Get("AAPL")
+    if err != nil || q.Price != 2 || fresh.Status != CacheMiss {
+        t.Fatalf("got %v %s %v, want a MISS at 2", q, fresh.Status, err)
+    }
+
+    // An expired quote is not served when the fetch fails
+    fetcher.err = errors.New("upstream down")
+    cachedAt(c, "AAPL", 1, 3*time.Minute)
+    if _, _, err := c.Get("AAPL"); err == nil {
+        t.Fatal("expired quote served after a failed fetch")
+    }
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+const (
+    // PolicyConflate keeps only the latest undelivered quote per symbol for a slow subscriber.
+    PolicyConflate = "CONFLATE"
+    // PolicyDrop discards updates that don't fit in a slow subscriber's buffer.
+    PolicyDrop = "DROP"
+
+    DefaultSubscriberBuffer = 64
+    // MaxStreamSymbols caps the symbols of one client stream; each new symbol starts an upstream poll loop.
+    MaxStreamSymbols = 50
+)
+
+// QuoteFetcher loads the current quote for one symbol.
+type QuoteFetcher func(symbol string) (*models.Quote, error)
+
+// QuoteHub fetches each subscribed symbol once per interval and fans the updates
+// out to every subscriber of that symbol. Polling for a symbol starts with its
+// first subscriber and stops when the last one leaves.
+type QuoteHub struct {
+    fetch    QuoteFetcher
+    interval time.Duration
+
+    mu    sync.Mutex
+    feeds map[string]*symbolFeed
+}
+
+type symbolFeed struct {
+    subscribers map[*Subscriber]struct{}
+    last        *models.Quote
+    stop        context.CancelFunc
+}
+
+// NewQuoteHub creates a hub that polls fetch every interval per symbol.
+func NewQuoteHub(fetch QuoteFetcher, interval time.Duration) *QuoteHub {
+    return &QuoteHub{
+        fetch:    fetch,
+        interval: interval,
+        feeds:    map[string]*symbolFeed{},
+    }
+}
+
+// NewSubscriber creates a subscriber with a bounded buffer and a slow-consumer policy.
+func (h *QuoteHub) NewSubscriber(bufferSize int, policy string) *Subscriber {
+    if bufferSize <= 0 {
+        bufferSize = DefaultSubscriberBuffer
+    }
+    if policy != PolicyDrop {
+        policy = PolicyConflate
+    }
+    return &Subscriber{
+        updates: make(chan models.Quote, bufferSize),
+        pending: map[string]models.Quote{},
+        policy:  policy,
+        symbols: map[string]bool{},
+    }
+}
+
+// Subscribe adds symbols to the subscriber. The latest known quote is delivered right away.
+// Nothing is added if the subscriber would go over its symbol limit.
+func (h *QuoteHub) Subscribe(sub *Subscriber, symbols ...string) error {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    added := map[string]bool{}
+    for _, symbol := range symbols {
+        if symbol != "" && !sub.has(symbol) {
+            added[symbol] = true
+        }
+    }
+    if n := len(sub.list()) + len(added); sub.maxSymbols > 0 && n > sub.maxSymbols {
+        return fmt.Errorf("a subscriber can follow at most %d symbols, requested %d", sub.maxSymbols, n)
+    }
+
+    for _, symbol := range symbols {
+        if !added[symbol] || sub.has(symbol) {
+            continue
+        }
+        sub.add(symbol)
+        feed, ok := h.feeds[symbol]
+        if !ok {
+            ctx, cancel := context.WithCancel(context.Background())
+            feed = &symbolFeed{subscribers: map[*Subscriber]struct{}{}, stop: cancel}
+            h.feeds[symbol] = feed
+            go h.poll(ctx, symbol)
+            log.Printf("QuoteHub: started feed for %s\n", symbol)
+        }
+        feed.subscribers[sub] = struct{}{}
+        if feed.last != nil {
+            sub.publish(*feed.last)
+        }
+    }
+    return nil
+}
+
+// Unsubscribe removes symbols from the subscriber.
+func (h *QuoteHub) Unsubscribe(sub *Subscriber, symbols ...string) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for _, symbol := range symbols {
+        h.removeLocked(sub, symbol)
+    }
+}
+
+// Close removes the subscriber from every symbol.
+func (h *QuoteHub) Close(sub *Subscriber) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for _, symbol := range sub.list() {
+        h.removeLocked(sub, symbol)
+    }
+}
+
+// Publish pushes an externally received quote to the symbol's subscribers.
+func (h *QuoteHub) Publish(q models.Quote) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    feed, ok := h.feeds[q.Symbol]
+    if !ok {
+        return
+    }
+    feed.last = &q
+    for sub := range feed.subscribers {
+        sub.publish(q)
+    }
+}
+
+// removeLocked detaches sub from symbol. The caller must hold h.mu.
+func (h *QuoteHub) removeLocked(sub *Subscriber, symbol string) {
+    sub.remove(symbol)
+    feed, ok := h.feeds[symbol]
+    if !ok {
+        return
+    }
+    delete(feed.subscribers, sub)
+    if len(feed.subscribers) == 0 {
+        feed.stop()
+        delete(h.feeds, symbol)
+        log.Printf("QuoteHub: stopped feed for %s\n", symbol)
+    }
+}
+
+// poll fetches symbol once per interval and publishes changes until ctx is canceled.
+func (h *QuoteHub) poll(ctx context.Context, symbol string) {
+    ticker := time.NewTicker(h.interval)
+    defer ticker.Stop()
+
+    for {
+        q, err := h.fetch(symbol)
+        if err != nil {
+            log.Printf("QuoteHub: fetch %s failed: %v\n", symbol, err)
+        } else if ctx.Err() == nil {
+            h.publishIfChanged(*q)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// publishIfChanged skips polls that returned the same price and touch as last time.
+func (h *QuoteHub) publishIfChanged(q models.Quote) {
+    h.mu.Lock()
+    feed, ok := h.feeds[q.Symbol]
+    unchanged := ok && feed.last != nil && feed.last.Price == q.Price &&
+        feed.last.Bid == q.Bid && feed.last.Ask == q.Ask
+    h.mu.Unlock()
+    if ok && !unchanged {
+        h.Publish(q)
+    }
+}
+
+// Subscriber receives quotes for a dynamic set of symbols through a bounded buffer.
+type Subscriber struct {
+    updates    chan models.Quote
+    policy     string
+    maxSymbols int // 0 = no limit
+
+    mu      sync.Mutex
+    pending map[string]models.Quote // conflated quotes waiting for buffer space
+    order   []string                // pending symbols in arrival order
+    dropped uint64
+    symbols map[string]bool
+}
+
+// Next blocks until a quote is available or ctx is done.
+func (s *Subscriber) Next(ctx context.Context) (models.Quote, error) {
+    select {
+    case <-ctx.Done():
+        return models.Quote{}, ctx.Err()
+    case q := <-s.updates:
+        s.refill()
+        return q, nil
+    }
+}
+
+// LimitSymbols caps how many symbols the subscriber may follow at once (0 = no limit).
+func (s *Subscriber) LimitSymbols(n int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.maxSymbols = n
+}
+
+// Dropped returns how many updates were discarded (DROP) or overwritten (CONFLATE).
+func (s *Subscriber) Dropped() uint64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.dropped
+}
+
+// publish never blocks the hub: a full buffer either conflates or drops.
+func (s *Subscriber) publish(q models.Quote) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    // Don't let a fresh update overtake older conflated ones.
+    if len(s.order) == 0 {
+        select {
+        case s.updates <- q:
+            return
+        default:
+        }
+    }
+    if s.policy == PolicyDrop {
+        s.dropped++
+        return
+    }
+    if _, ok := s.pending[q.Symbol]; ok {
+        s.dropped++
+    } else {
+        s.order = append(s.order, q.Symbol)
+    }
+    s.pending[q.Symbol] = q
+}
+
+// refill moves conflated quotes into the buffer as space frees up.
+func (s *Subscriber) refill() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for len(s.order) > 0 {
+        symbol := s.order[0]
+        select {
+        case s.updates <- s.pending[symbol]:
+            delete(s.pending, symbol)
+            s.order = s.order[1:]
+        default:
+            return
+        }
+    }
+}
+
+func (s *Subscriber) has(symbol string) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.symbols[symbol]
+}
+
+func (s *Subscriber) add(symbol string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.symbols[symbol] = true
+}
+
+func (s *Subscriber) remove(symbol string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.symbols, symbol)
+    if _, ok := s.pending[symbol]; ok {
+        delete(s.pending, symbol)
+        for i, sym := range s.order {
+            if sym == symbol {
+                s.order = append(s.order[:i], s.order[i+1:]...)
+                break
+            }
+        }
+    }
+}
+
+func (s *Subscriber) list() []string {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    symbols := make([]string, 0, len(s.symbols))
+    for symbol := range s.symbols {
+        symbols = append(symbols, symbol)
+    }
+    return symbols
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/market-data-service/models"
+)
+
+// countingFetcher counts upstream fetches per symbol and returns price as the quote.
+type countingFetcher struct {
+    mu    sync.Mutex
+    calls map[string]int
+    price float64
+}
+
+func newCountingFetcher() *countingFetcher {
+    return &countingFetcher{calls: map[string]int{}, price: 100}
+}
+
+func (f *countingFetcher) fetch(symbol string) (*models.Quote, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.calls[symbol]++
+    return &models.Quote{Symbol: symbol, Price: f.price}, nil
+}
+
+func (f *countingFetcher) count(symbol string) int {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.calls[symbol]
+}
+
+// idleHub never publishes on its own, so a test drives every update through Publish.
+func idleHub() *QuoteHub {
+    return NewQuoteHub(func(string) (*models.Quote, error) { return nil, errors.New("no upstream") }, time.Hour)
+}
+
+func next(t *testing.T, sub *Subscriber) models.Quote {
+    t.Helper()
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    defer cancel()
+    q, err := sub.Next(ctx)
+    if err != nil {
+        t.Fatalf("no quote: %v", err)
+    }
+    return q
+}
+
+func TestSlowConsumerConflates(t *testing.T) {
+    hub := idleHub()
+    sub := hub.NewSubscriber(1, PolicyConflate)
+    defer hub.Close(sub)
+    if err := hub.Subscribe(sub, "AAPL", "MSFT"); err != nil {
+        t.Fatal(err)
+    }
+
+    // The buffer holds AAPL 1; AAPL 2 is overwritten by AAPL 3 while waiting
+    for _, q := range []models.Quote{{Symbol: "AAPL", Price: 1}, {Symbol: "AAPL", Price: 2},
+        {Symbol: "MSFT", Price: 10}, {Symbol: "AAPL", Price: 3}} {
+        hub.Publish(q)
+    }
+    var got []string
+    for i := 0; i < 3; i++ {
+        q := next(t, sub)
+        got = append(got, fmt.Sprintf("%s %.0f", q.Symbol, q.Price))
+    }
+    want := []string{"AAPL 1", "AAPL 3", "MSFT 10"}
+    if fmt.Sprint(got) != fmt.Sprint(want) {
+        t.Fatalf("delivered %v, want %v", got, want)
+    }
+    if sub.Dropped() != 1 {
+        t.Fatalf("dropped = %d, want the one overwritten quote", sub.Dropped())
+    }
+
+    // Once drained, updates go straight through again
+    hub.Publish(models.Quote{Symbol: "MSFT", Price: 11})
+    if q := next(t, sub); q.Price != 11 {
+        t.Fatalf("got %.0f, want 11", q.Price)
+    }
+}
+
+func TestSlowConsumerDrops(t *testing.T) {
+    hub := idleHub()
+    sub := hub.NewSubscriber(1, PolicyDrop)
+    defer hub.Close(sub)
+    if err := hub.Subscribe(sub, "AAPL"); err != nil {
+        t.Fatal(err)
+    }
+    for p := 1; p <= 3; p++ {
+        hub.Publish(models.Quote{Symbol: "AAPL", Price: float64(p)})
+    }
+    if q := next(t, sub); q.Price != 1 {
+        t.Fatalf("got %.0f, want the buffered 1", q.Price)
+    }
+    if sub.Dropped() != 2 {
+        t.Fatalf("dropped = %d, want 2", sub.Dropped())
+    }
+}
+
+func TestQuotesFanOutFromOneFeed(t *testing.T) {
+    fetcher := newCountingFetcher()
+    hub := NewQuoteHub(fetcher.fetch, time.Hour)
+    a, b := hub.NewSubscriber(0, ""), hub.NewSubscriber(0, "")
+    defer hub.Close(a)
+    defer hub.Close(b)
+    if err := hub.Subscribe(a, "AAPL"); err != nil {
+        t.Fatal(err)
+    }
+    if q := next(t, a); q.Price != 100 {
+        t.Fatalf("first subscriber got %.0f, want 100", q.Price)
+    }
+    // A late subscriber gets the last quote without another fetch
+    if err := hub.Subscribe(b, "AAPL"); err != nil {
+        t.Fatal(err)
+    }
+    if q := next(t, b); q.Price != 100 {
+        t.Fatalf("second subscriber got %.0f, want 100", q.Price)
+    }
+    if n := fetcher.count("AAPL"); n != 1 {
+        t.Fatalf("%d fetches for two subscribers, want 1", n)
+    }
+
+    hub.Publish(models.Quote{Symbol: "AAPL", Price: 101})
+    for _, sub := range []*Subscriber{a, b} {
+        if q := next(t, sub); q.Price != 101 {
+            t.Fatalf("got %.0f, want 101 on both subscribers", q.Price)
+        }
+    }
+}
+
+func TestUnsubscribeStopsThePoller(t *testing.T) {
+    fetcher := newCountingFetcher()
+    hub := NewQuoteHub(fetcher.fetch, 5*time.Millisecond)
+    a, b := hub.NewSubscriber(0, ""), hub.NewSubscriber(0, "")
+    if err := hub.Subscribe(a, "AAPL"); err != nil {
+        t.Fatal(err)
+    }
+    if err := hub.Subscribe(b, "AAPL"); err != nil {
+        t.Fatal(err)
+    }
+    for fetcher.count("AAPL") < 3 {
+        time.Sleep(time.Millisecond)
+    }
+
+    // The feed keeps polling while anyone is subscribed
+    hub.Unsubscribe(a, "AAPL")
+    n := fetcher.count("AAPL")
+    for fetcher.count("AAPL") == n {
+        time.Sleep(time.Millisecond)
+    }
+
+    hub.Close(b)
+    time.Sleep(10 * time.Millisecond) // let an in-flight fetch finish
+    n = fetcher.count("AAPL")
+    time.Sleep(50 * time.Millisecond)
+    if got := fetcher.count("AAPL"); got != n {
+        t.Fatalf("%d fetches after the last subscriber left", got-n)
+    }
+    hub.mu.Lock()
+    _, ok := hub.feeds["AAPL"]
+    hub.mu.Unlock()
+    if ok {
+        t.Fatal("feed still registered")
+    }
+}
+
+func TestSubscribeLimitsSymbols(t *testing.T) {
+    hub := idleHub()
+    sub := hub.NewSubscriber(0, "")
+    defer hub.Close(sub)
+    sub.LimitSymbols(3)
+
+    if err := hub.Subscribe(sub, "A", "B"); err != nil {
+        t.Fatal(err)
+    }
+    // Re-subscribing counts once; going over the limit adds nothing
+    if err := hub.Subscribe(sub, "B", "C", "D"); err == nil {
+        t.Fatal("subscribed past the limit")
+    }
+    if got := len(sub.list()); got != 2 {
+        t.Fatalf("%d symbols after a rejected request, want 2", got)
+    }
+    if err := hub.Subscribe(sub, "B", "C"); err != nil {
+        t.Fatal(err)
+    }
+    hub.Unsubscribe(sub, "A")
+    if err := hub.Subscribe(sub, "D"); err != nil {
+        t.Fatalf("freed slot not reusable: %v", err)
+    }
+    hub.mu.Lock()
+    feeds := len(hub.feeds)
+    hub.mu.Unlock()
+    if feeds != 3 {
+        t.Fatalf("%d feeds, want 3", feeds)
+    }
+}
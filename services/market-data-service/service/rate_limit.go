@@ -0,0 +1,61 @@
+package service
+
+import (
+    "sync"
+    "time"
+)
+
+// TokenBucket allows bursts of up to burst requests and refills at rate tokens per second.
+type TokenBucket struct {
+    rate  float64
+    burst float64
+
+    mu     sync.Mutex
+    tokens float64
+    last   time.Time
+}
+
+// NewTokenBucket creates a full bucket. A non-positive rate means unlimited.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+    if burst < 1 {
+        burst = 1
+    }
+    return &TokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Reserve takes a token if one is available within maxWait and returns how long the
+// caller has to wait before using it. ok is false (and nothing is taken) otherwise.
+func (b *TokenBucket) Reserve(maxWait time.Duration) (wait time.Duration, ok bool) {
+    if b == nil || b.rate <= 0 {
+        return 0, true
+    }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    now := time.Now()
+    b.tokens += now.Sub(b.last).Seconds() * b.rate
+    if b.tokens > b.burst {
+        b.tokens = b.burst
+    }
+    b.last = now
+
+    if b.tokens >= 1 {
+        b.tokens--
+        return 0, true
+    }
+    wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+    if wait > maxWait {
+        return wait, false
+    }
+    b.tokens--
+    return wait, true
+}
+
+// Wait blocks for a token for at most maxWait and reports whether it got one.
+func (b *TokenBucket) Wait(maxWait time.Duration) bool {
+    wait, ok := b.Reserve(maxWait)
+    if ok && wait > 0 {
+        time.Sleep(wait)
+    }
+    return ok
+}
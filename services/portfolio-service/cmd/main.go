@@ -7,6 +7,7 @@ import (
 
     "google.golang.org/grpc"
 
+    "github.com/ankan8/swapsync/backend/services/portfolio-service/models"
     pb "github.com/ankan8/swapsync/backend/services/portfolio-service/proto"
     "github.com/ankan8/swapsync/backend/services/portfolio-service/service"
     "github.com/ankan8/swapsync/backend/internal/config"
@@ -42,10 +43,98 @@ func (s *server) UpdateHoldings(ctx context.Context, req *pb.UpdateHoldingsReque
     return &pb.UpdateHoldingsResponse{Success: true}, nil
 }
 
+// ScheduleCorporateAction lets an admin schedule a split, dividend or symbol change.
+func (s *server) ScheduleCorporateAction(ctx context.Context, req *pb.ScheduleCorporateActionRequest) (*pb.CorporateActionResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    action, err := service.ScheduleCorporateAction(service.CorporateActionSpec{
+        Type:             req.GetType(),
+        Symbol:           req.GetSymbol(),
+        NewSymbol:        req.GetNewSymbol(),
+        SplitTo:          req.GetSplitTo(),
+        SplitFrom:        req.GetSplitFrom(),
+        DividendPerShare: req.GetDividendPerShare(),
+        EffectiveDate:    req.GetEffectiveDate(),
+    }, middleware.CallerEmail(ctx))
+    if err != nil {
+        return &pb.CorporateActionResponse{Success: false}, err
+    }
+    return &pb.CorporateActionResponse{Success: true, Action: toCorporateAction(action)}, nil
+}
+
+// CancelCorporateAction cancels an action that hasn't been applied yet.
+func (s *server) CancelCorporateAction(ctx context.Context, req *pb.CancelCorporateActionRequest) (*pb.CorporateActionResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    action, err := service.CancelCorporateAction(req.GetActionId())
+    if err != nil {
+        return &pb.CorporateActionResponse{Success: false}, err
+    }
+    return &pb.CorporateActionResponse{Success: true, Action: toCorporateAction(action)}, nil
+}
+
+// ListCorporateActions returns scheduled and past corporate actions.
+func (s *server) ListCorporateActions(ctx context.Context, req *pb.ListCorporateActionsRequest) (*pb.ListCorporateActionsResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    actions, err := service.ListCorporateActions(req.GetSymbol(), req.GetStatus())
+    if err != nil {
+        return nil, err
+    }
+    resp := &pb.ListCorporateActionsResponse{}
+    for i := range actions {
+        resp.Actions = append(resp.Actions, toCorporateAction(&actions[i]))
+    }
+    return resp, nil
+}
+
+func toCorporateAction(a *models.CorporateAction) *pb.CorporateAction {
+    out := &pb.CorporateAction{
+        ActionId:         a.ActionID,
+        Type:             a.Type,
+        Symbol:           a.Symbol,
+        NewSymbol:        a.NewSymbol,
+        SplitTo:          a.SplitTo,
+        SplitFrom:        a.SplitFrom,
+        DividendPerShare: a.DividendPerShare,
+        EffectiveDate:    a.EffectiveDate,
+        Status:           a.Status,
+        CompletedSteps:   a.CompletedSteps,
+        HoldingsAdjusted: a.HoldingsAdjusted,
+        OrdersAdjusted:   a.OrdersAdjusted,
+        BarsAdjusted:     a.BarsAdjusted,
+        Attempts:         int32(a.Attempts),
+        LastError:        a.LastError,
+        CreatedBy:        a.CreatedBy,
+        CreatedAt:        a.CreatedAt,
+        AppliedAt:        a.AppliedAt,
+    }
+    for _, p := range a.Payouts {
+        out.Payouts = append(out.Payouts, &pb.DividendPayout{
+            UserId:   p.UserID,
+            Quantity: p.Quantity,
+            Amount:   p.Amount,
+            Paid:     p.Paid,
+            Error:    p.Error,
+        })
+    }
+    return out
+}
+
 func main() {
     // Connect to MongoDB
     config.ConnectDB()
 
+    // Apply scheduled splits, dividends and symbol changes on their effective date
+    interval, err := service.CorporateActionIntervalFromEnv()
+    if err != nil {
+        log.Fatalf("Invalid corporate action config: %v", err)
+    }
+    service.StartCorporateActionScheduler(interval)
+
     lis, err := net.Listen("tcp", ":50052")
     if err != nil {
         log.Fatalf("Failed to listen: %v", err)
@@ -0,0 +1,41 @@
+package main
+
+import (
+    "context"
+    "strings"
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/internal/middleware"
+    pb "github.com/ankan8/swapsync/backend/services/portfolio-service/proto"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+)
+
+func TestCorporateActionRPCsRequireAdmin(t *testing.T) {
+    t.Setenv("JWT_SECRET", "test-secret")
+    token, err := middleware.UserToken("alice@example.com")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", token))
+    s := &server{}
+
+    calls := map[string]grpc.UnaryHandler{
+        "ScheduleCorporateAction": func(ctx context.Context, _ interface{}) (interface{}, error) {
+            return s.ScheduleCorporateAction(ctx, &pb.ScheduleCorporateActionRequest{Type: "SPLIT", Symbol: "AAPL", SplitTo: 2, SplitFrom: 1})
+        },
+        "CancelCorporateAction": func(ctx context.Context, _ interface{}) (interface{}, error) {
+            return s.CancelCorporateAction(ctx, &pb.CancelCorporateActionRequest{ActionId: "ca-1"})
+        },
+        "ListCorporateActions": func(ctx context.Context, _ interface{}) (interface{}, error) {
+            return s.ListCorporateActions(ctx, &pb.ListCorporateActionsRequest{})
+        },
+    }
+    for name, handler := range calls {
+        info := &grpc.UnaryServerInfo{FullMethod: "/portfolio.PortfolioService/" + name}
+        _, err := middleware.UnaryJWTInterceptor(ctx, nil, info, handler)
+        if err == nil || !strings.Contains(err.Error(), "permission denied") {
+            t.Errorf("%s by a user: err = %v, want permission denied", name, err)
+        }
+    }
+}
@@ -0,0 +1,33 @@
+package models
+
+// DividendPayout is one holder's cash dividend for a corporate action.
+type DividendPayout struct {
+  UserID   string  `bson:"user_id"`
+  Quantity float64 `bson:"quantity"` // shares held when the dividend was applied
+  Amount   float64 `bson:"amount"`
+  Paid     bool    `bson:"paid"`
+  Error    string  `bson:"error,omitempty"`
+}
+
+// CorporateAction is a split, cash dividend or symbol change scheduled for an effective date.
+type CorporateAction struct {
+  ActionID         string           `bson:"action_id"`
+  Type             string           `bson:"type"` // "SPLIT", "DIVIDEND" or "SYMBOL_CHANGE"
+  Symbol           string           `bson:"symbol"`
+  NewSymbol        string           `bson:"new_symbol,omitempty"`
+  SplitTo          float64          `bson:"split_to,omitempty"`   // SPLIT: SplitTo new shares for every
+  SplitFrom        float64          `bson:"split_from,omitempty"` // SplitFrom old shares (1-for-10 = 1/10)
+  DividendPerShare float64          `bson:"dividend_per_share,omitempty"`
+  EffectiveDate    string           `bson:"effective_date"` // YYYY-MM-DD, applied from 00:00 UTC
+  Status           string           `bson:"status"`         // "SCHEDULED", "APPLIED", "FAILED", "CANCELED"
+  CompletedSteps   []string         `bson:"completed_steps"`
+  HoldingsAdjusted int64            `bson:"holdings_adjusted"`
+  OrdersAdjusted   int64            `bson:"orders_adjusted"`
+  BarsAdjusted     int64            `bson:"bars_adjusted"`
+  Payouts          []DividendPayout `bson:"payouts,omitempty"`
+  Attempts         int              `bson:"attempts"`
+  LastError        string           `bson:"last_error,omitempty"`
+  CreatedBy        string           `bson:"created_by"`
+  CreatedAt        string           `bson:"created_at"`
+  AppliedAt        string           `bson:"applied_at,omitempty"`
+}
@@ -19,8 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PortfolioService_GetPortfolio_FullMethodName   = "/portfolio.PortfolioService/GetPortfolio"
-	PortfolioService_UpdateHoldings_FullMethodName = "/portfolio.PortfolioService/UpdateHoldings"
+	PortfolioService_GetPortfolio_FullMethodName            = "/portfolio.PortfolioService/GetPortfolio"
+	PortfolioService_UpdateHoldings_FullMethodName          = "/portfolio.PortfolioService/UpdateHoldings"
+	PortfolioService_ScheduleCorporateAction_FullMethodName = "/portfolio.PortfolioService/ScheduleCorporateAction"
+	PortfolioService_CancelCorporateAction_FullMethodName   = "/portfolio.PortfolioService/CancelCorporateAction"
+	PortfolioService_ListCorporateActions_FullMethodName    = "/portfolio.PortfolioService/ListCorporateActions"
 )
 
 // PortfolioServiceClient is the client API for PortfolioService service.
@@ -29,6 +32,11 @@ const (
 type PortfolioServiceClient interface {
 	GetPortfolio(ctx context.Context, in *GetPortfolioRequest, opts ...grpc.CallOption) (*GetPortfolioResponse, error)
 	UpdateHoldings(ctx context.Context, in *UpdateHoldingsRequest, opts ...grpc.CallOption) (*UpdateHoldingsResponse, error)
+	// Admin-only corporate actions: splits, cash dividends and symbol changes,
+	// applied automatically on their effective date.
+	ScheduleCorporateAction(ctx context.Context, in *ScheduleCorporateActionRequest, opts ...grpc.CallOption) (*CorporateActionResponse, error)
+	CancelCorporateAction(ctx context.Context, in *CancelCorporateActionRequest, opts ...grpc.CallOption) (*CorporateActionResponse, error)
+	ListCorporateActions(ctx context.Context, in *ListCorporateActionsRequest, opts ...grpc.CallOption) (*ListCorporateActionsResponse, error)
 }
 
 type portfolioServiceClient struct {
@@ -59,12 +67,47 @@ func (c *portfolioServiceClient) UpdateHoldings(ctx context.Context, in *UpdateH
 	return out, nil
 }
 
+func (c *portfolioServiceClient) ScheduleCorporateAction(ctx context.Context, in *ScheduleCorporateActionRequest, opts ...grpc.CallOption) (*CorporateActionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CorporateActionResponse)
+	err := c.cc.Invoke(ctx, PortfolioService_ScheduleCorporateAction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portfolioServiceClient) CancelCorporateAction(ctx context.Context, in *CancelCorporateActionRequest, opts ...grpc.CallOption) (*CorporateActionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CorporateActionResponse)
+	err := c.cc.Invoke(ctx, PortfolioService_CancelCorporateAction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portfolioServiceClient) ListCorporateActions(ctx context.Context, in *ListCorporateActionsRequest, opts ...grpc.CallOption) (*ListCorporateActionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCorporateActionsResponse)
+	err := c.cc.Invoke(ctx, PortfolioService_ListCorporateActions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PortfolioServiceServer is the server API for PortfolioService service.
 // All implementations must embed UnimplementedPortfolioServiceServer
 // for forward compatibility.
 type PortfolioServiceServer interface {
 	GetPortfolio(context.Context, *GetPortfolioRequest) (*GetPortfolioResponse, error)
 	UpdateHoldings(context.Context, *UpdateHoldingsRequest) (*UpdateHoldingsResponse, error)
+	// Admin-only corporate actions: splits, cash dividends and symbol changes,
+	// applied automatically on their effective date.
+	ScheduleCorporateAction(context.Context, *ScheduleCorporateActionRequest) (*CorporateActionResponse, error)
+	CancelCorporateAction(context.Context, *CancelCorporateActionRequest) (*CorporateActionResponse, error)
+	ListCorporateActions(context.Context, *ListCorporateActionsRequest) (*ListCorporateActionsResponse, error)
 	mustEmbedUnimplementedPortfolioServiceServer()
 }
 
@@ -81,6 +124,15 @@ func (UnimplementedPortfolioServiceServer) GetPortfolio(context.Context, *GetPor
 func (UnimplementedPortfolioServiceServer) UpdateHoldings(context.Context, *UpdateHoldingsRequest) (*UpdateHoldingsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateHoldings not implemented")
 }
+func (UnimplementedPortfolioServiceServer) ScheduleCorporateAction(context.Context, *ScheduleCorporateActionRequest) (*CorporateActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScheduleCorporateAction not implemented")
+}
+func (UnimplementedPortfolioServiceServer) CancelCorporateAction(context.Context, *CancelCorporateActionRequest) (*CorporateActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelCorporateAction not implemented")
+}
+func (UnimplementedPortfolioServiceServer) ListCorporateActions(context.Context, *ListCorporateActionsRequest) (*ListCorporateActionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCorporateActions not implemented")
+}
 func (UnimplementedPortfolioServiceServer) mustEmbedUnimplementedPortfolioServiceServer() {}
 func (UnimplementedPortfolioServiceServer) testEmbeddedByValue()                          {}
 
@@ -138,6 +190,60 @@ func _PortfolioService_UpdateHoldings_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PortfolioService_ScheduleCorporateAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScheduleCorporateActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortfolioServiceServer).ScheduleCorporateAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PortfolioService_ScheduleCorporateAction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortfolioServiceServer).ScheduleCorporateAction(ctx, req.(*ScheduleCorporateActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortfolioService_CancelCorporateAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelCorporateActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortfolioServiceServer).CancelCorporateAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PortfolioService_CancelCorporateAction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortfolioServiceServer).CancelCorporateAction(ctx, req.(*CancelCorporateActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortfolioService_ListCorporateActions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCorporateActionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortfolioServiceServer).ListCorporateActions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PortfolioService_ListCorporateActions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortfolioServiceServer).ListCorporateActions(ctx, req.(*ListCorporateActionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PortfolioService_ServiceDesc is the grpc.ServiceDesc for PortfolioService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +259,18 @@ var PortfolioService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateHoldings",
 			Handler:    _PortfolioService_UpdateHoldings_Handler,
 		},
+		{
+			MethodName: "ScheduleCorporateAction",
+			Handler:    _PortfolioService_ScheduleCorporateAction_Handler,
+		},
+		{
+			MethodName: "CancelCorporateAction",
+			Handler:    _PortfolioService_CancelCorporateAction_Handler,
+		},
+		{
+			MethodName: "ListCorporateActions",
+			Handler:    _PortfolioService_ListCorporateActions_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "portfolio.proto",
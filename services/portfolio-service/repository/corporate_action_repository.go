@@ -0,0 +1,122 @@
+package repository
+
+import (
+    "context"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/services/portfolio-service/models"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveCorporateAction upserts the action document keyed by action_id.
+func SaveCorporateAction(action *models.CorporateAction) error {
+    coll := config.DB.Collection("corporate_actions")
+    _, err := coll.ReplaceOne(
+        context.Background(),
+        bson.M{"action_id": action.ActionID},
+        action,
+        options.Replace().SetUpsert(true),
+    )
+    return err
+}
+
+// GetCorporateAction loads an action by its ID.
+func GetCorporateAction(actionID string) (*models.CorporateAction, error) {
+    coll := config.DB.Collection("corporate_actions")
+    var action models.CorporateAction
+    err := coll.FindOne(context.Background(), bson.M{"action_id": actionID}).Decode(&action)
+    if err != nil {
+        return nil, err
+    }
+    return &action, nil
+}
+
+// GetDueCorporateActions returns scheduled actions effective on or before date
+// (YYYY-MM-DD), oldest first so events on the same symbol apply in order.
+func GetDueCorporateActions(date string) ([]models.CorporateAction, error) {
+    return findCorporateActions(bson.M{"status": "SCHEDULED", "effective_date": bson.M{"$lte": date}})
+}
+
+// ListCorporateActions returns actions, optionally for one symbol and/or status.
+func ListCorporateActions(symbol, status string) ([]models.CorporateAction, error) {
+    filter := bson.M{}
+    if symbol != "" {
+        filter["symbol"] = symbol
+    }
+    if status != "" {
+        filter["status"] = status
+    }
+    return findCorporateActions(filter)
+}
+
+func findCorporateActions(filter bson.M) ([]models.CorporateAction, error) {
+    coll := config.DB.Collection("corporate_actions")
+    opts := options.Find().SetSort(bson.D{{Key: "effective_date", Value: 1}, {Key: "created_at", Value: 1}})
+    cursor, err := coll.Find(context.Background(), filter, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var actions []models.CorporateAction
+    for cursor.Next(context.Background()) {
+        var a models.CorporateAction
+        if err := cursor.Decode(&a); err != nil {
+            return nil, err
+        }
+        actions = append(actions, a)
+    }
+    return actions, nil
+}
+
+// GetPortfoliosHolding returns every portfolio with a holding in symbol.
+func GetPortfoliosHolding(symbol string) ([]models.Portfolio, error) {
+    coll := config.DB.Collection("portfolios")
+    cursor, err := coll.Find(context.Background(), bson.M{"holdings.symbol": symbol})
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var portfolios []models.Portfolio
+    for cursor.Next(context.Background()) {
+        var p models.Portfolio
+        if err := cursor.Decode(&p); err != nil {
+            return nil, err
+        }
+        portfolios = append(portfolios, p)
+    }
+    return portfolios, nil
+}
+
+// SplitHoldings multiplies every symbol holding's quantity by ratio and divides its
+// average price, so each position's cost basis is unchanged.
+func SplitHoldings(symbol string, ratio float64) (int64, error) {
+    coll := config.DB.Collection("portfolios")
+    filter := bson.M{"holdings.symbol": symbol}
+    update := bson.M{"$mul": bson.M{
+        "holdings.$[h].quantity":      ratio,
+        "holdings.$[h].average_price": 1 / ratio,
+    }}
+    opts := options.Update().SetArrayFilters(options.ArrayFilters{
+        Filters: []interface{}{bson.M{"h.symbol": symbol}},
+    })
+    res, err := coll.UpdateMany(context.Background(), filter, update, opts)
+    if err != nil {
+        return 0, err
+    }
+    return res.ModifiedCount, nil
+}
+
+// ReplaceHoldings swaps a user's holdings from old to updated, only if they are
+// still old. Returns false when a concurrent trade changed them in between.
+func ReplaceHoldings(userID string, old, updated []models.Holding) (bool, error) {
+    coll := config.DB.Collection("portfolios")
+    filter := bson.M{"user_id": userID, "holdings": old}
+    res, err := coll.UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"holdings": updated}})
+    if err != nil {
+        return false, err
+    }
+    return res.MatchedCount > 0, nil
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+
+    "github.com/ankan8/swapsync/backend/internal/middleware"
+    pbBilling "github.com/ankan8/swapsync/backend/services/billing-service/proto"
+    pbMarketData "github.com/ankan8/swapsync/backend/services/market-data-service/proto"
+    "github.com/ankan8/swapsync/backend/services/portfolio-service/models"
+    "github.com/ankan8/swapsync/backend/services/portfolio-service/repository"
+    pbTrade "github.com/ankan8/swapsync/backend/services/trade-service/proto"
+)
+
+const (
+    ActionSplit        = "SPLIT"
+    ActionDividend     = "DIVIDEND"
+    ActionSymbolChange = "SYMBOL_CHANGE"
+
+    ActionScheduled = "SCHEDULED"
+    ActionApplied   = "APPLIED"
+    ActionFailed    = "FAILED"
+    ActionCanceled  = "CANCELED"
+
+    // Steps of an action; each is recorded once done so a retry never repeats it.
+    stepHoldings = "HOLDINGS"
+    stepOrders   = "ORDERS"
+    stepHistory  = "HISTORY"
+    stepPayouts  = "PAYOUTS"
+
+    effectiveDateLayout = "2006-01-02"
+
+    // maxActionAttempts is how many scheduler passes may fail before an action is marked FAILED.
+    maxActionAttempts = 5
+)
+
+// CorporateActionSpec is what an admin schedules.
+type CorporateActionSpec struct {
+    Type             string
+    Symbol           string
+    NewSymbol        string
+    SplitTo          float64
+    SplitFrom        float64
+    DividendPerShare float64
+    EffectiveDate    string // YYYY-MM-DD
+}
+
+// ScheduleCorporateAction validates and stores an action. It is applied by the
+// scheduler once its effective date (00:00 UTC) is reached; a past date applies
+// on the next pass.
+func ScheduleCorporateAction(spec CorporateActionSpec, admin string) (*models.CorporateAction, error) {
+    spec.Type = strings.ToUpper(spec.Type)
+    spec.Symbol = strings.ToUpper(strings.TrimSpace(spec.Symbol))
+    spec.NewSymbol = strings.ToUpper(strings.TrimSpace(spec.NewSymbol))
+    if spec.Symbol == "" {
+        return nil, fmt.Errorf("symbol is required")
+    }
+    if _, err := time.Parse(effectiveDateLayout, spec.EffectiveDate); err != nil {
+        return nil, fmt.Errorf("invalid effective_date %q (use YYYY-MM-DD)", spec.EffectiveDate)
+    }
+
+    action := &models.CorporateAction{
+        ActionID:      uuid.NewString(),
+        Type:          spec.Type,
+        Symbol:        spec.Symbol,
+        EffectiveDate: spec.EffectiveDate,
+        Status:        ActionScheduled,
+        CreatedBy:     admin,
+        CreatedAt:     time.Now().Format(time.RFC3339),
+    }
+    switch spec.Type {
+    case ActionSplit:
+        if spec.SplitTo <= 0 || spec.SplitFrom <= 0 || spec.SplitTo == spec.SplitFrom {
+            return nil, fmt.Errorf("a split needs positive, different split_to and split_from")
+        }
+        action.SplitTo, action.SplitFrom = spec.SplitTo, spec.SplitFrom
+    case ActionDividend:
+        if spec.DividendPerShare <= 0 {
+            return nil, fmt.Errorf("dividend_per_share must be positive")
+        }
+        action.DividendPerShare = spec.DividendPerShare
+    case ActionSymbolChange:
+        if spec.NewSymbol == "" || spec.NewSymbol == spec.Symbol {
+            return nil, fmt.Errorf("a symbol change needs a different new_symbol")
+        }
+        action.NewSymbol = spec.NewSymbol
+    default:
+        return nil, fmt.Errorf("unknown corporate action type %q (use SPLIT, DIVIDEND or SYMBOL_CHANGE)", spec.Type)
+    }
+
+    if err := repository.SaveCorporateAction(action); err != nil {
+        return nil, fmt.Errorf("failed to save corporate action: %v", err)
+    }
+    log.Printf("[CORPORATE ACTION %s] %s %s scheduled for %s by %s\n",
+        action.ActionID, action.Type, action.Symbol, action.EffectiveDate, admin)
+    return action, nil
+}
+
+// CancelCorporateAction cancels an action that hasn't started applying yet.
+func CancelCorporateAction(actionID string) (*models.CorporateAction, error) {
+    action, err := repository.GetCorporateAction(actionID)
+    if err != nil {
+        return nil, fmt.Errorf("corporate action %s not found: %v", actionID, err)
+    }
+    if action.Status != ActionScheduled || len(action.CompletedSteps) > 0 {
+        return nil, fmt.Errorf("corporate action %s is %s and can no longer be canceled", actionID, action.Status)
+    }
+    action.Status = ActionCanceled
+    if err := repository.SaveCorporateAction(action); err != nil {
+        return nil, fmt.Errorf("failed to save corporate action: %v", err)
+    }
+    return action, nil
+}
+
+// ListCorporateActions returns actions, optionally filtered by symbol and status.
+func ListCorporateActions(symbol, status string) ([]models.CorporateAction, error) {
+    return repository.ListCorporateActions(strings.ToUpper(symbol), strings.ToUpper(status))
+}
+
+// CorporateActionIntervalFromEnv reads CORPORATE_ACTION_INTERVAL (Go duration, default 5m).
+func CorporateActionIntervalFromEnv() (time.Duration, error) {
+    v := os.Getenv("CORPORATE_ACTION_INTERVAL")
+    if v == "" {
+        return 5 * time.Minute, nil
+    }
+    d, err := time.ParseDuration(v)
+    if err != nil || d <= 0 {
+        return 0, fmt.Errorf("invalid CORPORATE_ACTION_INTERVAL %q", v)
+    }
+    return d, nil
+}
+
+// StartCorporateActionScheduler applies due actions now and then once per interval.
+func StartCorporateActionScheduler(interval time.Duration) {
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            ApplyDueCorporateActions()
+            <-ticker.C
+        }
+    }()
+    log.Printf("Corporate action scheduler started (every %s)\n", interval)
+}
+
+// ApplyDueCorporateActions applies every scheduled action whose effective date has
+// been reached. Actions on one symbol apply in order: once one fails, later ones
+// on that symbol wait for the next pass.
+func ApplyDueCorporateActions() {
+    actions, err := repository.GetDueCorporateActions(time.Now().UTC().Format(effectiveDateLayout))
+    if err != nil {
+        log.Printf("Error loading due corporate actions: %v\n", err)
+        return
+    }
+    blocked := map[string]bool{}
+    for i := range actions {
+        action := &actions[i]
+        if blocked[action.Symbol] {
+            continue
+        }
+        if !applyCorporateAction(action) {
+            blocked[action.Symbol] = true
+            if action.NewSymbol != "" {
+                blocked[action.NewSymbol] = true
+            }
+        }
+    }
+}
+
+// applyCorporateAction runs the action's remaining steps and reports whether it finished.
+func applyCorporateAction(action *models.CorporateAction) bool {
+    var steps []string
+    switch action.Type {
+    case ActionSplit, ActionSymbolChange:
+        steps = []string{stepHoldings, stepOrders, stepHistory}
+    case ActionDividend:
+        steps = []string{stepPayouts}
+    }
+
+    token, err := middleware.ServiceToken("portfolio-service")
+    if err != nil {
+        return failAction(action, err)
+    }
+    for _, step := range steps {
+        if stepDone(action, step) {
+            continue
+        }
+        if err := runStep(action, step, token); err != nil {
+            return failAction(action, fmt.Errorf("%s: %v", step, err))
+        }
+        action.CompletedSteps = append(action.CompletedSteps, step)
+        saveAction(action)
+    }
+
+    action.Status = ActionApplied
+    action.LastError = ""
+    action.AppliedAt = time.Now().Format(time.RFC3339)
+    saveAction(action)
+    log.Printf("[CORPORATE ACTION %s] %s %s applied: %d holdings, %d orders, %d bars, %d payouts\n",
+        action.ActionID, action.Type, action.Symbol, action.HoldingsAdjusted, action.OrdersAdjusted,
+        action.BarsAdjusted, len(action.Payouts))
+    return true
+}
+
+func runStep(action *models.CorporateAction, step, token string) error {
+    ratio := 0.0
+    if action.Type == ActionSplit {
+        ratio = action.SplitTo / action.SplitFrom
+    }
+    switch step {
+    case stepHoldings:
+        if action.Type == ActionSplit {
+            n, err := repository.SplitHoldings(action.Symbol, ratio)
+            action.HoldingsAdjusted = n
+            return err
+        }
+        n, err := renameHoldings(action.Symbol, action.NewSymbol)
+        action.HoldingsAdjusted = n
+        return err
+    case stepOrders:
+        n, err := adjustWorkingOrders(action.Symbol, action.NewSymbol, ratio, token)
+        action.OrdersAdjusted = n
+        return err
+    case stepHistory:
+        effective, _ := time.Parse(effectiveDateLayout, action.EffectiveDate)
+        n, err := adjustMarketHistory(action.Symbol, action.NewSymbol, ratio, effective, token)
+        action.BarsAdjusted = n
+        return err
+    case stepPayouts:
+        return payDividends(action, token)
+    }
+    return nil
+}
+
+func stepDone(action *models.CorporateAction, step string) bool {
+    for _, s := range action.CompletedSteps {
+        if s == step {
+            return true
+        }
+    }
+    return false
+}
+
+// failAction records the error and gives up after maxActionAttempts. Returns false.
+func failAction(action *models.CorporateAction, err error) bool {
+    action.Attempts++
+    action.LastError = err.Error()
+    if action.Attempts >= maxActionAttempts {
+        action.Status = ActionFailed
+    }
+    saveAction(action)
+    log.Printf("[CORPORATE ACTION %s] attempt %d failed: %v\n", action.ActionID, action.Attempts, err)
+    return false
+}
+
+func saveAction(action *models.CorporateAction) {
+    if err := repository.SaveCorporateAction(action); err != nil {
+        log.Printf("Error saving corporate action %s: %v\n", action.ActionID, err)
+    }
+}
+
+// renameHoldings moves every symbol holding to newSymbol, merging into an existing
+// newSymbol position at the quantity-weighted average price.
+func renameHoldings(symbol, newSymbol string) (int64, error) {
+    portfolios, err := repository.GetPortfoliosHolding(symbol)
+    if err != nil {
+        return 0, err
+    }
+    var n int64
+    for _, p := range portfolios {
+        for attempt := 0; ; attempt++ {
+            ok, err := repository.ReplaceHoldings(p.UserID, p.Holdings, mergeRenamed(p.Holdings, symbol, newSymbol))
+            if err != nil {
+                return n, err
+            }
+            if ok {
+                n++
+                break
+            }
+            // A trade changed the holdings in between; reload and retry
+            if attempt == 2 {
+                return n, fmt.Errorf("holdings of user %s kept changing", p.UserID)
+            }
+            reloaded, err := repository.GetPortfolioByUserID(p.UserID)
+            if err != nil {
+                return n, err
+            }
+            p = *reloaded
+        }
+    }
+    return n, nil
+}
+
+func mergeRenamed(holdings []models.Holding, symbol, newSymbol string) []models.Holding {
+    renamed := -1
+    out := make([]models.Holding, 0, len(holdings))
+    for _, h := range holdings {
+        if h.Symbol != symbol && h.Symbol != newSymbol {
+            out = append(out, h)
+            continue
+        }
+        if renamed < 0 {
+            h.Symbol = newSymbol
+            out = append(out, h)
+            renamed = len(out) - 1
+            continue
+        }
+        r := &out[renamed]
+        qty := r.Quantity + h.Quantity
+        if qty != 0 {
+            r.AveragePrice = (r.Quantity*r.AveragePrice + h.Quantity*h.AveragePrice) / qty
+        }
+        r.Quantity = qty
+    }
+    return out
+}
+
+// payDividends credits every holder's wallet. Holders are snapshotted on the first
+// attempt; retries only pay the payouts still unpaid.
+func payDividends(action *models.CorporateAction, token string) error {
+    if len(action.Payouts) == 0 {
+        portfolios, err := repository.GetPortfoliosHolding(action.Symbol)
+        if err != nil {
+            return err
+        }
+        for _, p := range portfolios {
+            for _, h := range p.Holdings {
+                if h.Symbol != action.Symbol || h.Quantity <= 0 {
+                    continue
+                }
+                action.Payouts = append(action.Payouts, models.DividendPayout{
+                    UserID:   p.UserID,
+                    Quantity: h.Quantity,
+                    Amount:   math.Round(h.Quantity*action.DividendPerShare*100) / 100,
+                })
+            }
+        }
+        action.HoldingsAdjusted = int64(len(action.Payouts))
+        saveAction(action)
+    }
+
+    failed := 0
+    for i := range action.Payouts {
+        payout := &action.Payouts[i]
+        if payout.Paid || payout.Amount <= 0 {
+            continue
+        }
+        if err := creditDividend(payout.UserID, payout.Amount, action.ActionID, token); err != nil {
+            payout.Error = err.Error()
+            failed++
+        } else {
+            payout.Paid = true
+            payout.Error = ""
+        }
+        saveAction(action)
+    }
+    if failed > 0 {
+        return fmt.Errorf("%d of %d dividend payouts failed", failed, len(action.Payouts))
+    }
+    return nil
+}
+
+// outgoingContext attaches the service token for the downstream call.
+func outgoingContext(token string) context.Context {
+    md := metadata.New(map[string]string{"authorization": token})
+    return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+// creditDividend deposits a dividend into the user's wallet through the Billing Service.
+func creditDividend(userID string, amount float64, actionID, token string) error {
+    conn, err := grpc.Dial("localhost:50055", grpc.WithInsecure())
+    if err != nil {
+        return fmt.Errorf("failed to dial billing service: %v", err)
+    }
+    defer conn.Close()
+
+    resp, err := pbBilling.NewBillingServiceClient(conn).DepositFunds(outgoingContext(token), &pbBilling.DepositFundsRequest{
+        UserId:    userID,
+        Amount:    amount,
+        Purpose:   "DIVIDEND",
+        Reference: actionID,
+    })
+    if err != nil {
+        return fmt.Errorf("DepositFunds RPC failed: %v", err)
+    }
+    if !resp.GetSuccess() {
+        return fmt.Errorf("DepositFunds responded with success=false")
+    }
+    return nil
+}
+
+// adjustWorkingOrders asks the Trade Service to split/rename resting and working orders.
+func adjustWorkingOrders(symbol, newSymbol string, ratio float64, token string) (int64, error) {
+    conn, err := grpc.Dial("localhost:50053", grpc.WithInsecure())
+    if err != nil {
+        return 0, fmt.Errorf("failed to dial trade service: %v", err)
+    }
+    defer conn.Close()
+
+    resp, err := pbTrade.NewTradeServiceClient(conn).AdjustForCorporateAction(outgoingContext(token), &pbTrade.CorporateActionAdjustmentRequest{
+        Symbol:     symbol,
+        NewSymbol:  newSymbol,
+        SplitRatio: ratio,
+    })
+    if err != nil {
+        return 0, fmt.Errorf("AdjustForCorporateAction RPC failed: %v", err)
+    }
+    return int64(resp.GetOrdersAdjusted() + resp.GetGroupsAdjusted() + resp.GetAlgoOrdersAdjusted()), nil
+}
+
+// adjustMarketHistory asks the Market Data Service to back-adjust stored bars.
+func adjustMarketHistory(symbol, newSymbol string, ratio float64, effective time.Time, token string) (int64, error) {
+    conn, err := grpc.Dial("localhost:50054", grpc.WithInsecure())
+    if err != nil {
+        return 0, fmt.Errorf("failed to dial market data service: %v", err)
+    }
+    defer conn.Close()
+
+    resp, err := pbMarketData.NewMarketDataServiceClient(conn).AdjustHistory(outgoingContext(token), &pbMarketData.AdjustHistoryRequest{
+        Symbol:        symbol,
+        NewSymbol:     newSymbol,
+        SplitRatio:    ratio,
+        EffectiveDate: effective.Format(time.RFC3339),
+    })
+    if err != nil {
+        return 0, fmt.Errorf("AdjustHistory RPC failed: %v", err)
+    }
+    return resp.GetBarsAdjusted(), nil
+}
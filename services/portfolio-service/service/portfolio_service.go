@@ -6,11 +6,17 @@ import (
 
     "github.com/ankan8/swapsync/backend/services/portfolio-service/models"
     "github.com/ankan8/swapsync/backend/services/portfolio-service/repository"
+    "go.mongodb.org/mongo-driver/mongo"
 )
 
 // GetPortfolio retrieves the portfolio document for a given user from the DB.
+// A user who has never traded has an empty portfolio.
 func GetPortfolio(userID string) (*models.Portfolio, error) {
-    return repository.GetPortfolioByUserID(userID)
+    portfolio, err := repository.GetPortfolioByUserID(userID)
+    if err == mongo.ErrNoDocuments {
+        return &models.Portfolio{UserID: userID}, nil
+    }
+    return portfolio, err
 }
 
 // UpdateHoldings updates the user's holdings for a given symbol and quantity.
@@ -2,11 +2,16 @@ package main
 
 import (
     "context"
+    "fmt"
+    "io"
     "log"
     "net"
+    "sync/atomic"
+    "time"
 
     "github.com/ankan8/swapsync/backend/internal/config"
     "github.com/ankan8/swapsync/backend/internal/middleware"
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
     pb "github.com/ankan8/swapsync/backend/services/trade-service/proto"
     "github.com/ankan8/swapsync/backend/services/trade-service/service"
     "google.golang.org/grpc"
@@ -18,32 +23,25 @@ type server struct {
     pb.UnimplementedTradeServiceServer
 }
 
-// GlobalOrderBooks is a map of symbol -> OrderBook (in-memory).
-// We'll create or retrieve an OrderBook for each symbol as needed.
-var GlobalOrderBooks = map[string]*service.OrderBook{}
-
-// PlaceOrder is called by clients to place a trade order.
+// PlaceOrder is called by clients to place a trade order. Users trade for
+// themselves; services (e.g. margin liquidation) may name any user.
 func (s *server) PlaceOrder(ctx context.Context, req *pb.PlaceOrderRequest) (*pb.PlaceOrderResponse, error) {
-    // 1) Extract the JWT token from incoming metadata (if present).
-    var token string
-    if md, ok := metadata.FromIncomingContext(ctx); ok {
-        arr := md["authorization"]
-        if len(arr) > 0 {
-            token = arr[0]
-        }
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return &pb.PlaceOrderResponse{Success: false}, err
     }
 
+    // 1) Extract the JWT token from incoming metadata (if present).
+    token := tokenFromContext(ctx)
+
     // 2) Ensure there's an OrderBook for this symbol (if you plan to do in-memory matching).
     symbol := req.GetSymbol()
-    if _, ok := GlobalOrderBooks[symbol]; !ok {
-        GlobalOrderBooks[symbol] = service.NewOrderBook(symbol)
-        log.Printf("Created a new OrderBook for symbol=%s\n", symbol)
-    }
+    service.GetOrderBook(symbol)
 
     // 3) Call PlaceOrder in the service layer. 
     //    (Currently, this references wallet checks, commission, etc.)
     orderID, err := service.PlaceOrder(
-        req.GetUserId(),
+        userID,
         symbol,
         req.GetPrice(),    // Price first
         req.GetQuantity(), // Quantity second
@@ -65,34 +63,433 @@ func (s *server) GetTradeHistory(ctx context.Context, req *pb.GetTradeHistoryReq
     var tradeRecords []*pb.TradeRecord
     for _, t := range trades {
         tradeRecords = append(tradeRecords, &pb.TradeRecord{
-            TradeId:   t.TradeID,
-            Symbol:    t.Symbol,
-            Quantity:  t.Quantity,
-            Price:     t.Price,
-            OrderType: t.OrderType,
-            Timestamp: t.Timestamp,
+            TradeId:     t.TradeID,
+            Symbol:      t.Symbol,
+            Quantity:    t.Quantity,
+            Price:       t.Price,
+            OrderType:   t.OrderType,
+            Timestamp:   t.Timestamp,
+            ExecutionId: t.ExecutionID,
+            Liquidity:   t.Liquidity,
+            Status:      t.Status,
+
+            Currency:           t.Currency,
+            SettlementCurrency: t.SettlementCurrency,
+            FxRate:             t.FXRate,
+            SettlementAmount:   t.SettlementAmount,
         })
     }
     return &pb.GetTradeHistoryResponse{Trades: tradeRecords}, nil
 }
 
+// PlaceBracketOrder enters a position with an attached take-profit and stop-loss.
+func (s *server) PlaceBracketOrder(ctx context.Context, req *pb.PlaceBracketOrderRequest) (*pb.OrderGroupResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    group, err := service.PlaceBracketOrder(
+        userID,
+        req.GetSymbol(),
+        service.OrderSide(req.GetSide()),
+        req.GetQuantity(),
+        req.GetEntryPrice(),
+        req.GetTakeProfitPrice(),
+        req.GetStopLossPrice(),
+    )
+    if err != nil {
+        return &pb.OrderGroupResponse{Success: false}, err
+    }
+    return &pb.OrderGroupResponse{Success: true, Group: toProtoOrderGroup(group)}, nil
+}
+
+// PlaceOCOOrder places a linked limit/stop pair.
+func (s *server) PlaceOCOOrder(ctx context.Context, req *pb.PlaceOCOOrderRequest) (*pb.OrderGroupResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    group, err := service.PlaceOCOOrder(
+        userID,
+        req.GetSymbol(),
+        service.OrderSide(req.GetSide()),
+        req.GetQuantity(),
+        req.GetLimitPrice(),
+        req.GetStopPrice(),
+    )
+    if err != nil {
+        return &pb.OrderGroupResponse{Success: false}, err
+    }
+    return &pb.OrderGroupResponse{Success: true, Group: toProtoOrderGroup(group)}, nil
+}
+
+// CancelOrderGroup cancels every working leg of a group.
+func (s *server) CancelOrderGroup(ctx context.Context, req *pb.OrderGroupRequest) (*pb.OrderGroupResponse, error) {
+    if _, err := ownedOrderGroup(ctx, req.GetGroupId()); err != nil {
+        return &pb.OrderGroupResponse{Success: false}, err
+    }
+    group, err := service.CancelOrderGroup(req.GetGroupId())
+    if err != nil {
+        return &pb.OrderGroupResponse{Success: false}, err
+    }
+    return &pb.OrderGroupResponse{Success: true, Group: toProtoOrderGroup(group)}, nil
+}
+
+// GetOrderGroup returns the current state of a group and its legs.
+func (s *server) GetOrderGroup(ctx context.Context, req *pb.OrderGroupRequest) (*pb.OrderGroupResponse, error) {
+    group, err := ownedOrderGroup(ctx, req.GetGroupId())
+    if err != nil {
+        return &pb.OrderGroupResponse{Success: false}, err
+    }
+    return &pb.OrderGroupResponse{Success: true, Group: toProtoOrderGroup(group)}, nil
+}
+
+// ownedOrderGroup returns the group if it belongs to the caller (or the caller is an admin).
+func ownedOrderGroup(ctx context.Context, groupID string) (*models.OrderGroup, error) {
+    group, err := service.GetOrderGroup(groupID)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := middleware.AuthorizeUser(ctx, group.UserID); err != nil {
+        return nil, err
+    }
+    return group, nil
+}
+
+func toProtoOrderGroup(g *models.OrderGroup) *pb.OrderGroup {
+    var legs []*pb.OrderGroupLeg
+    for _, l := range g.Legs {
+        legs = append(legs, &pb.OrderGroupLeg{
+            OrderId:        l.OrderID,
+            Role:           l.Role,
+            Side:           l.Side,
+            OrderType:      l.OrderType,
+            Price:          l.Price,
+            StopPrice:      l.StopPrice,
+            Quantity:       l.Quantity,
+            FilledQuantity: l.FilledQuantity,
+            Status:         l.Status,
+        })
+    }
+    return &pb.OrderGroup{
+        GroupId:   g.GroupID,
+        GroupType: g.GroupType,
+        UserId:    g.UserID,
+        Symbol:    g.Symbol,
+        Quantity:  g.Quantity,
+        Status:    g.Status,
+        Legs:      legs,
+        CreatedAt: g.CreatedAt,
+        UpdatedAt: g.UpdatedAt,
+    }
+}
+
+// StartAlgoOrder starts working a large order with the TWAP/VWAP scheduler.
+func (s *server) StartAlgoOrder(ctx context.Context, req *pb.StartAlgoOrderRequest) (*pb.AlgoOrderResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return nil, err
+    }
+    parent, err := service.StartAlgoOrder(service.AlgoOrderParams{
+        UserID:           userID,
+        Symbol:           req.GetSymbol(),
+        Side:             service.OrderSide(req.GetSide()),
+        Strategy:         req.GetStrategy(),
+        Quantity:         req.GetQuantity(),
+        ChildOrderType:   service.OrderType(req.GetChildOrderType()),
+        LimitPrice:       req.GetLimitPrice(),
+        Duration:         time.Duration(req.GetDurationSeconds()) * time.Second,
+        Slices:           int(req.GetSlices()),
+        ParticipationCap: req.GetParticipationCap(),
+    })
+    if err != nil {
+        return &pb.AlgoOrderResponse{Success: false}, err
+    }
+    return &pb.AlgoOrderResponse{Success: true, Order: toProtoAlgoOrder(parent)}, nil
+}
+
+// GetAlgoOrder reports progress and slippage of a parent order.
+func (s *server) GetAlgoOrder(ctx context.Context, req *pb.AlgoOrderRequest) (*pb.AlgoOrderResponse, error) {
+    parent, err := ownedAlgoOrder(ctx, req.GetParentId())
+    if err != nil {
+        return &pb.AlgoOrderResponse{Success: false}, err
+    }
+    return &pb.AlgoOrderResponse{Success: true, Order: toProtoAlgoOrder(parent)}, nil
+}
+
+// CancelAlgoOrder stops a parent order mid-flight.
+func (s *server) CancelAlgoOrder(ctx context.Context, req *pb.AlgoOrderRequest) (*pb.AlgoOrderResponse, error) {
+    if _, err := ownedAlgoOrder(ctx, req.GetParentId()); err != nil {
+        return &pb.AlgoOrderResponse{Success: false}, err
+    }
+    parent, err := service.CancelAlgoOrder(req.GetParentId())
+    if err != nil {
+        return &pb.AlgoOrderResponse{Success: false}, err
+    }
+    return &pb.AlgoOrderResponse{Success: true, Order: toProtoAlgoOrder(parent)}, nil
+}
+
+// ownedAlgoOrder returns the parent order if it belongs to the caller (or the caller is an admin).
+func ownedAlgoOrder(ctx context.Context, parentID string) (*models.ParentOrder, error) {
+    parent, err := service.GetAlgoOrder(parentID)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := middleware.AuthorizeUser(ctx, parent.UserID); err != nil {
+        return nil, err
+    }
+    return parent, nil
+}
+
+func toProtoAlgoOrder(p *models.ParentOrder) *pb.AlgoOrder {
+    var children []*pb.AlgoChildSlice
+    for _, c := range p.Children {
+        children = append(children, &pb.AlgoChildSlice{
+            ChildId:        c.ChildID,
+            ScheduledAt:    c.ScheduledAt.Format(time.RFC3339),
+            TargetQuantity: c.TargetQuantity,
+            Quantity:       c.Quantity,
+            FilledQuantity: c.FilledQuantity,
+            Price:          c.Price,
+            TradeId:        c.TradeID,
+            Status:         c.Status,
+            Reason:         c.Reason,
+        })
+    }
+    return &pb.AlgoOrder{
+        ParentId:         p.ParentID,
+        UserId:           p.UserID,
+        Symbol:           p.Symbol,
+        Side:             p.Side,
+        Strategy:         p.Strategy,
+        ChildOrderType:   p.ChildOrderType,
+        LimitPrice:       p.LimitPrice,
+        TotalQuantity:    p.TotalQuantity,
+        FilledQuantity:   p.FilledQuantity,
+        ParticipationCap: p.ParticipationCap,
+        ArrivalPrice:     p.ArrivalPrice,
+        AvgFillPrice:     p.AvgFillPrice,
+        SlippageBps:      p.SlippageBps,
+        StartTime:        p.StartTime.Format(time.RFC3339),
+        EndTime:          p.EndTime.Format(time.RFC3339),
+        Status:           p.Status,
+        Children:         children,
+    }
+}
+
+// SubmitQuote atomically replaces the caller's bid and ask for a symbol.
+func (s *server) SubmitQuote(ctx context.Context, req *pb.SubmitQuoteRequest) (*pb.SubmitQuoteResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return &pb.SubmitQuoteResponse{Success: false}, err
+    }
+    bidID, askID, fills, err := service.SubmitQuote(
+        userID,
+        req.GetSymbol(),
+        req.GetBidPrice(),
+        req.GetBidSize(),
+        req.GetAskPrice(),
+        req.GetAskSize(),
+    )
+    if err != nil {
+        return &pb.SubmitQuoteResponse{Success: false}, err
+    }
+    return &pb.SubmitQuoteResponse{
+        Success:    true,
+        BidOrderId: bidID,
+        AskOrderId: askID,
+        Fills:      int32(len(fills)),
+    }, nil
+}
+
+// MassCancel pulls a user's resting orders by symbol and/or side.
+func (s *server) MassCancel(ctx context.Context, req *pb.MassCancelRequest) (*pb.MassCancelResponse, error) {
+    userID, err := middleware.AuthorizeUser(ctx, req.GetUserId())
+    if err != nil {
+        return &pb.MassCancelResponse{Success: false}, err
+    }
+    canceled := service.MassCancel(userID, req.GetSymbol(), service.OrderSide(req.GetSide()))
+    var ids []string
+    for _, o := range canceled {
+        ids = append(ids, o.OrderID)
+    }
+    return &pb.MassCancelResponse{
+        Success:          true,
+        CanceledCount:    int32(len(canceled)),
+        CanceledOrderIds: ids,
+    }, nil
+}
+
+// QuoteSession acknowledges heartbeats. With cancel_on_disconnect set, all of the user's
+// orders are pulled when the stream ends or heartbeats stop, unless the client logs out.
+// The session belongs to the token's user; a user_id on the first heartbeat must match it.
+func (s *server) QuoteSession(stream pb.TradeService_QuoteSessionServer) error {
+    first, err := stream.Recv()
+    if err != nil {
+        return err
+    }
+    userID, err := middleware.AuthorizeUser(stream.Context(), first.GetUserId())
+    if err != nil {
+        return err
+    }
+    interval := time.Duration(first.GetHeartbeatIntervalMs()) * time.Millisecond
+    if interval <= 0 {
+        interval = time.Second
+    }
+
+    var canceledCount int32
+    var sw *service.DeadMansSwitch
+    if first.GetCancelOnDisconnect() {
+        sw = service.NewDeadMansSwitch(userID, 3*interval, func(n int) {
+            atomic.StoreInt32(&canceledCount, int32(n))
+        })
+        // Any exit other than a logout pulls the orders.
+        defer sw.Fire()
+    }
+    log.Printf("Quote session opened for user=%s (cancel_on_disconnect=%v, interval=%v)\n",
+        userID, first.GetCancelOnDisconnect(), interval)
+
+    hb := first
+    for {
+        if hb.GetLogout() {
+            if sw != nil {
+                sw.Disarm()
+            }
+            return stream.Send(sessionEvent("LOGOUT", hb.GetSequence(), 0))
+        }
+        if sw != nil && !sw.Beat() {
+            stream.Send(sessionEvent("CANCELED", hb.GetSequence(), atomic.LoadInt32(&canceledCount)))
+            return fmt.Errorf("heartbeat timeout, orders for user %s were canceled", userID)
+        }
+        if err := stream.Send(sessionEvent("ACK", hb.GetSequence(), 0)); err != nil {
+            return err
+        }
+
+        hb, err = stream.Recv()
+        if err == io.EOF {
+            log.Printf("Quote session closed by user=%s\n", userID)
+            return nil
+        }
+        if err != nil {
+            log.Printf("Quote session for user=%s dropped: %v\n", userID, err)
+            return err
+        }
+    }
+}
+
+func sessionEvent(event string, sequence int64, canceled int32) *pb.QuoteSessionEvent {
+    return &pb.QuoteSessionEvent{
+        Event:         event,
+        Sequence:      sequence,
+        CanceledCount: canceled,
+        Timestamp:     time.Now().Format(time.RFC3339),
+    }
+}
+
+// BustTrade lets an admin cancel an erroneous trade.
+func (s *server) BustTrade(ctx context.Context, req *pb.BustTradeRequest) (*pb.TradeCorrectionResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    audit, err := service.BustTrade(req.GetTradeId(), req.GetReason(), middleware.CallerEmail(ctx), tokenFromContext(ctx))
+    return toCorrectionResponse(audit, err)
+}
+
+// AmendTrade lets an admin correct the price/quantity of a trade.
+func (s *server) AmendTrade(ctx context.Context, req *pb.AmendTradeRequest) (*pb.TradeCorrectionResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    audit, err := service.AmendTrade(req.GetTradeId(), req.GetNewPrice(), req.GetNewQuantity(),
+        req.GetReason(), middleware.CallerEmail(ctx), tokenFromContext(ctx))
+    return toCorrectionResponse(audit, err)
+}
+
+// AdjustForCorporateAction applies a split or symbol change to working orders.
+func (s *server) AdjustForCorporateAction(ctx context.Context, req *pb.CorporateActionAdjustmentRequest) (*pb.CorporateActionAdjustmentResponse, error) {
+    if err := middleware.RequireRole(ctx, middleware.RoleAdmin); err != nil {
+        return nil, err
+    }
+    res, err := service.AdjustForCorporateAction(req.GetSymbol(), req.GetNewSymbol(), req.GetSplitRatio())
+    if err != nil {
+        return &pb.CorporateActionAdjustmentResponse{Success: false}, err
+    }
+    return &pb.CorporateActionAdjustmentResponse{
+        Success:            true,
+        OrdersAdjusted:     int32(res.Orders),
+        GroupsAdjusted:     int32(res.Groups),
+        AlgoOrdersAdjusted: int32(res.AlgoOrders),
+    }, nil
+}
+
+func toCorrectionResponse(audit *models.TradeAudit, err error) (*pb.TradeCorrectionResponse, error) {
+    if audit == nil {
+        return &pb.TradeCorrectionResponse{Success: false}, err
+    }
+    resp := &pb.TradeCorrectionResponse{AuditId: audit.AuditID}
+    for _, t := range audit.After {
+        resp.Trades = append(resp.Trades, &pb.TradeRecord{
+            TradeId:     t.TradeID,
+            Symbol:      t.Symbol,
+            Quantity:    t.Quantity,
+            Price:       t.Price,
+            OrderType:   t.OrderType,
+            Timestamp:   t.Timestamp,
+            ExecutionId: t.ExecutionID,
+            Liquidity:   t.Liquidity,
+            Status:      t.Status,
+
+            Currency:           t.Currency,
+            SettlementCurrency: t.SettlementCurrency,
+            FxRate:             t.FXRate,
+            SettlementAmount:   t.SettlementAmount,
+        })
+    }
+    for _, a := range audit.Adjustments {
+        if a.Error != "" {
+            resp.Errors = append(resp.Errors, fmt.Sprintf("%s (user %s): %s", a.TradeID, a.UserID, a.Error))
+        }
+    }
+    resp.Success = err == nil && len(resp.Errors) == 0
+    return resp, err
+}
+
+// tokenFromContext extracts the JWT token from incoming metadata (if present).
+func tokenFromContext(ctx context.Context) string {
+    if md, ok := metadata.FromIncomingContext(ctx); ok {
+        arr := md["authorization"]
+        if len(arr) > 0 {
+            return arr[0]
+        }
+    }
+    return ""
+}
+
 func main() {
     // 1) Connect to MongoDB
     config.ConnectDB()
 
     // For demonstration, pre-create an OrderBook for AAPL
-    GlobalOrderBooks["AAPL"] = service.NewOrderBook("AAPL")
+    service.GetOrderBook("AAPL")
     log.Println("Initialized OrderBook for AAPL")
 
+    // Pick up algo orders that were running before a restart
+    if n, err := service.ResumeAlgoOrders(); err != nil {
+        log.Printf("Failed to resume algo orders: %v", err)
+    } else if n > 0 {
+        log.Printf("Resumed %d algo orders", n)
+    }
+
     // 2) Listen on port 50053
     lis, err := net.Listen("tcp", ":50053")
     if err != nil {
         log.Fatalf("Failed to listen: %v", err)
     }
 
-    // 3) Create a gRPC server with the JWT interceptor
+    // 3) Create a gRPC server with the JWT interceptors
     grpcServer := grpc.NewServer(
         grpc.UnaryInterceptor(middleware.UnaryJWTInterceptor),
+        grpc.StreamInterceptor(middleware.StreamJWTInterceptor),
     )
 
     // 4) Register the TradeService
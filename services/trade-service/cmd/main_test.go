@@ -0,0 +1,47 @@
+package main
+
+import (
+    "context"
+    "strings"
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/internal/middleware"
+    pb "github.com/ankan8/swapsync/backend/services/trade-service/proto"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+)
+
+// asUser runs handler behind the JWT interceptor with a user token for email.
+func asUser(t *testing.T, email, method string, handler grpc.UnaryHandler) error {
+    t.Helper()
+    t.Setenv("JWT_SECRET", "test-secret")
+    token, err := middleware.UserToken(email)
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", token))
+    info := &grpc.UnaryServerInfo{FullMethod: "/trade.TradeService/" + method}
+    _, err = middleware.UnaryJWTInterceptor(ctx, nil, info, handler)
+    return err
+}
+
+func TestPlaceOrderRejectsAnotherUsersID(t *testing.T) {
+    s := &server{}
+    err := asUser(t, "alice@example.com", "PlaceOrder", func(ctx context.Context, _ interface{}) (interface{}, error) {
+        return s.PlaceOrder(ctx, &pb.PlaceOrderRequest{UserId: "bob@example.com", Symbol: "AAPL", Quantity: 1, OrderType: "BUY"})
+    })
+    if err == nil || !strings.Contains(err.Error(), "permission denied") {
+        t.Fatalf("err = %v, want permission denied", err)
+    }
+}
+
+func TestStartAlgoOrderRejectsAnotherUsersID(t *testing.T) {
+    s := &server{}
+    err := asUser(t, "alice@example.com", "StartAlgoOrder", func(ctx context.Context, _ interface{}) (interface{}, error) {
+        return s.StartAlgoOrder(ctx, &pb.StartAlgoOrderRequest{UserId: "bob@example.com", Symbol: "AAPL", Side: "BUY",
+            Strategy: "TWAP", Quantity: 100, DurationSeconds: 60})
+    })
+    if err == nil || !strings.Contains(err.Error(), "permission denied") {
+        t.Fatalf("err = %v, want permission denied", err)
+    }
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Bar is an OHLCV bar from the shared "bars" collection.
+type Bar struct {
+  Symbol   string    `bson:"symbol"`
+  Interval string    `bson:"interval"` // e.g. "1m"
+  Start    time.Time `bson:"start"`
+  Open     float64   `bson:"open"`
+  High     float64   `bson:"high"`
+  Low      float64   `bson:"low"`
+  Close    float64   `bson:"close"`
+  Volume   float64   `bson:"volume"`
+}
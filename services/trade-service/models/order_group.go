@@ -0,0 +1,27 @@
+package models
+
+// OrderGroupLeg is one order inside a bracket or OCO group.
+type OrderGroupLeg struct {
+  OrderID        string  `bson:"order_id"`
+  Role           string  `bson:"role"`       // "ENTRY", "TAKE_PROFIT", "STOP_LOSS"
+  Side           string  `bson:"side"`       // "BUY" or "SELL"
+  OrderType      string  `bson:"order_type"` // "MARKET", "LIMIT" or "STOP"
+  Price          float64 `bson:"price"`
+  StopPrice      float64 `bson:"stop_price"`
+  Quantity       float64 `bson:"quantity"` // currently working quantity
+  FilledQuantity float64 `bson:"filled_quantity"`
+  Status         string  `bson:"status"` // "PENDING", "WORKING", "FILLED", "CANCELED"
+}
+
+// OrderGroup links orders whose activation and cancellation depend on each other.
+type OrderGroup struct {
+  GroupID   string          `bson:"group_id"`
+  GroupType string          `bson:"group_type"` // "BRACKET" or "OCO"
+  UserID    string          `bson:"user_id"`
+  Symbol    string          `bson:"symbol"`
+  Quantity  float64         `bson:"quantity"`
+  Status    string          `bson:"status"` // "ACTIVE", "COMPLETED", "CANCELED"
+  Legs      []OrderGroupLeg `bson:"legs"`
+  CreatedAt string          `bson:"created_at"`
+  UpdatedAt string          `bson:"updated_at"`
+}
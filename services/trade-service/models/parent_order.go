@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ChildSlice is one scheduled child order of an algorithmic parent order.
+type ChildSlice struct {
+  ChildID        string    `bson:"child_id"`
+  ScheduledAt    time.Time `bson:"scheduled_at"`
+  TargetQuantity float64   `bson:"target_quantity"` // from the TWAP/VWAP curve, before carry-over and caps
+  ExpectedVolume float64   `bson:"expected_volume"` // historical market volume in the slice window, 0 if unknown
+  Quantity       float64   `bson:"quantity"`        // actually sent
+  FilledQuantity float64   `bson:"filled_quantity"`
+  Price          float64   `bson:"price"`
+  TradeID        string    `bson:"trade_id"`
+  Status         string    `bson:"status"` // "SCHEDULED", "WORKING", "FILLED", "SKIPPED", "FAILED", "CANCELED"
+  Reason         string    `bson:"reason"`
+}
+
+// ParentOrder is a large order worked over time by the TWAP/VWAP scheduler.
+type ParentOrder struct {
+  ParentID         string       `bson:"parent_id"`
+  UserID           string       `bson:"user_id"`
+  Symbol           string       `bson:"symbol"`
+  Side             string       `bson:"side"`     // "BUY" or "SELL"
+  Strategy         string       `bson:"strategy"` // "TWAP" or "VWAP"
+  ChildOrderType   string       `bson:"child_order_type"` // "MARKET" or "LIMIT"
+  LimitPrice       float64      `bson:"limit_price"`
+  TotalQuantity    float64      `bson:"total_quantity"`
+  FilledQuantity   float64      `bson:"filled_quantity"`
+  ParticipationCap float64      `bson:"participation_cap"` // max fraction of expected slice volume, 0 = none
+  ArrivalPrice     float64      `bson:"arrival_price"`
+  AvgFillPrice     float64      `bson:"avg_fill_price"`
+  SlippageBps      float64      `bson:"slippage_bps"` // vs arrival price, positive = worse for the user
+  StartTime        time.Time    `bson:"start_time"`
+  EndTime          time.Time    `bson:"end_time"`
+  Status           string       `bson:"status"` // "RUNNING", "COMPLETED", "EXPIRED", "CANCELED"
+  Children         []ChildSlice `bson:"children"`
+  CreatedAt        string       `bson:"created_at"`
+  UpdatedAt        string       `bson:"updated_at"`
+}
@@ -1,11 +1,35 @@
 package models
 
 type TradeRecord struct {
-  TradeID   string  `bson:"trade_id"`
-  Symbol    string  `bson:"symbol"`
-  Quantity  float64 `bson:"quantity"`
-  Price     float64 `bson:"price"`
-  OrderType string  `bson:"order_type"`
-  Timestamp string  `bson:"timestamp"`
-  UserID    string  `bson:"user_id"`
+  TradeID     string  `bson:"trade_id"`
+  Symbol      string  `bson:"symbol"`
+  Quantity    float64 `bson:"quantity"`
+  Price       float64 `bson:"price"`
+  OrderType   string  `bson:"order_type"`
+  Timestamp   string  `bson:"timestamp"`
+  UserID      string  `bson:"user_id"`
+  ExecutionID string  `bson:"execution_id"` // shared by both sides of an order-book fill
+  Liquidity   string  `bson:"liquidity"`    // "MAKER" or "TAKER"
+  Status      string  `bson:"status"`       // "" (active), "BUSTED" or "AMENDED"
+
+  // Price is in the instrument's Currency; the wallet is debited in SettlementCurrency
+  // at FXRate (units of SettlementCurrency per unit of Currency) fixed at execution.
+  Currency           string  `bson:"currency"`
+  SettlementCurrency string  `bson:"settlement_currency"`
+  FXRate             float64 `bson:"fx_rate"`
+  SettlementAmount   float64 `bson:"settlement_amount"` // Price * Quantity * FXRate
+
+  // What settlement charged or repaid alongside the trade, so a bust can undo it.
+  Commission       float64 `bson:"commission,omitempty"`
+  CommissionTxID   string  `bson:"commission_tx_id,omitempty"`  // Billing Service transaction
+  MarginRepaid     float64 `bson:"margin_repaid,omitempty"`     // sale proceeds applied to the margin loan
+  ProceedsCredited float64 `bson:"proceeds_credited,omitempty"` // sale proceeds paid into the wallet
+}
+
+// SettlementRate is the trade's FX rate; trades booked before FX conversion settled 1:1.
+func (t TradeRecord) SettlementRate() float64 {
+  if t.FXRate <= 0 {
+    return 1
+  }
+  return t.FXRate
 }
@@ -0,0 +1,27 @@
+package models
+
+// TradeAdjustment is what a bust/amend did (or tried to do) for one side of an execution.
+type TradeAdjustment struct {
+  TradeID           string  `bson:"trade_id"`
+  UserID            string  `bson:"user_id"`
+  WalletDelta       float64 `bson:"wallet_delta"`   // + credited back, - debited
+  HoldingsDelta     float64 `bson:"holdings_delta"` // change applied to the holding quantity
+  CommissionRefund  float64 `bson:"commission_refund,omitempty"`
+  CommissionCharged float64 `bson:"commission_charged,omitempty"` // re-priced commission of an amended side
+  MarginRestored    float64 `bson:"margin_restored,omitempty"`    // loan put back after busting a sale that repaid it
+  Error             string  `bson:"error,omitempty"`
+}
+
+// TradeAudit is the immutable record of an admin trade correction.
+type TradeAudit struct {
+  AuditID     string            `bson:"audit_id"`
+  Action      string            `bson:"action"` // "BUST" or "AMEND"
+  TradeID     string            `bson:"trade_id"`
+  ExecutionID string            `bson:"execution_id"`
+  Reason      string            `bson:"reason"`
+  Admin       string            `bson:"admin"`
+  Before      []TradeRecord     `bson:"before"`
+  After       []TradeRecord     `bson:"after"`
+  Adjustments []TradeAdjustment `bson:"adjustments"`
+  Timestamp   string            `bson:"timestamp"`
+}
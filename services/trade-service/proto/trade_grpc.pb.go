@@ -19,8 +19,21 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TradeService_PlaceOrder_FullMethodName      = "/trade.TradeService/PlaceOrder"
-	TradeService_GetTradeHistory_FullMethodName = "/trade.TradeService/GetTradeHistory"
+	TradeService_PlaceOrder_FullMethodName               = "/trade.TradeService/PlaceOrder"
+	TradeService_GetTradeHistory_FullMethodName          = "/trade.TradeService/GetTradeHistory"
+	TradeService_PlaceBracketOrder_FullMethodName        = "/trade.TradeService/PlaceBracketOrder"
+	TradeService_PlaceOCOOrder_FullMethodName            = "/trade.TradeService/PlaceOCOOrder"
+	TradeService_CancelOrderGroup_FullMethodName         = "/trade.TradeService/CancelOrderGroup"
+	TradeService_GetOrderGroup_FullMethodName            = "/trade.TradeService/GetOrderGroup"
+	TradeService_StartAlgoOrder_FullMethodName           = "/trade.TradeService/StartAlgoOrder"
+	TradeService_GetAlgoOrder_FullMethodName             = "/trade.TradeService/GetAlgoOrder"
+	TradeService_CancelAlgoOrder_FullMethodName          = "/trade.TradeService/CancelAlgoOrder"
+	TradeService_SubmitQuote_FullMethodName              = "/trade.TradeService/SubmitQuote"
+	TradeService_MassCancel_FullMethodName               = "/trade.TradeService/MassCancel"
+	TradeService_QuoteSession_FullMethodName             = "/trade.TradeService/QuoteSession"
+	TradeService_BustTrade_FullMethodName                = "/trade.TradeService/BustTrade"
+	TradeService_AmendTrade_FullMethodName               = "/trade.TradeService/AmendTrade"
+	TradeService_AdjustForCorporateAction_FullMethodName = "/trade.TradeService/AdjustForCorporateAction"
 )
 
 // TradeServiceClient is the client API for TradeService service.
@@ -29,6 +42,27 @@ const (
 type TradeServiceClient interface {
 	PlaceOrder(ctx context.Context, in *PlaceOrderRequest, opts ...grpc.CallOption) (*PlaceOrderResponse, error)
 	GetTradeHistory(ctx context.Context, in *GetTradeHistoryRequest, opts ...grpc.CallOption) (*GetTradeHistoryResponse, error)
+	// Order groups: a bracket enters a position with an attached take-profit and stop-loss,
+	// an OCO pair links two exits so that filling one cancels (or shrinks) the other.
+	PlaceBracketOrder(ctx context.Context, in *PlaceBracketOrderRequest, opts ...grpc.CallOption) (*OrderGroupResponse, error)
+	PlaceOCOOrder(ctx context.Context, in *PlaceOCOOrderRequest, opts ...grpc.CallOption) (*OrderGroupResponse, error)
+	CancelOrderGroup(ctx context.Context, in *OrderGroupRequest, opts ...grpc.CallOption) (*OrderGroupResponse, error)
+	GetOrderGroup(ctx context.Context, in *OrderGroupRequest, opts ...grpc.CallOption) (*OrderGroupResponse, error)
+	// Algorithmic parent orders: TWAP/VWAP slicing of a large order over a time window.
+	StartAlgoOrder(ctx context.Context, in *StartAlgoOrderRequest, opts ...grpc.CallOption) (*AlgoOrderResponse, error)
+	GetAlgoOrder(ctx context.Context, in *AlgoOrderRequest, opts ...grpc.CallOption) (*AlgoOrderResponse, error)
+	CancelAlgoOrder(ctx context.Context, in *AlgoOrderRequest, opts ...grpc.CallOption) (*AlgoOrderResponse, error)
+	// Market making: atomically replace a two-sided quote, pull orders in bulk,
+	// and keep a heartbeat session that cancels everything if the client goes away.
+	SubmitQuote(ctx context.Context, in *SubmitQuoteRequest, opts ...grpc.CallOption) (*SubmitQuoteResponse, error)
+	MassCancel(ctx context.Context, in *MassCancelRequest, opts ...grpc.CallOption) (*MassCancelResponse, error)
+	QuoteSession(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[QuoteHeartbeat, QuoteSessionEvent], error)
+	// Admin-only trade corrections. Wallets and holdings of every side are adjusted
+	// and an audit record is kept.
+	BustTrade(ctx context.Context, in *BustTradeRequest, opts ...grpc.CallOption) (*TradeCorrectionResponse, error)
+	AmendTrade(ctx context.Context, in *AmendTradeRequest, opts ...grpc.CallOption) (*TradeCorrectionResponse, error)
+	// Admin-only: portfolio-service applies splits and symbol changes to working orders.
+	AdjustForCorporateAction(ctx context.Context, in *CorporateActionAdjustmentRequest, opts ...grpc.CallOption) (*CorporateActionAdjustmentResponse, error)
 }
 
 type tradeServiceClient struct {
@@ -59,12 +93,166 @@ func (c *tradeServiceClient) GetTradeHistory(ctx context.Context, in *GetTradeHi
 	return out, nil
 }
 
+func (c *tradeServiceClient) PlaceBracketOrder(ctx context.Context, in *PlaceBracketOrderRequest, opts ...grpc.CallOption) (*OrderGroupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderGroupResponse)
+	err := c.cc.Invoke(ctx, TradeService_PlaceBracketOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) PlaceOCOOrder(ctx context.Context, in *PlaceOCOOrderRequest, opts ...grpc.CallOption) (*OrderGroupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderGroupResponse)
+	err := c.cc.Invoke(ctx, TradeService_PlaceOCOOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) CancelOrderGroup(ctx context.Context, in *OrderGroupRequest, opts ...grpc.CallOption) (*OrderGroupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderGroupResponse)
+	err := c.cc.Invoke(ctx, TradeService_CancelOrderGroup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) GetOrderGroup(ctx context.Context, in *OrderGroupRequest, opts ...grpc.CallOption) (*OrderGroupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderGroupResponse)
+	err := c.cc.Invoke(ctx, TradeService_GetOrderGroup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) StartAlgoOrder(ctx context.Context, in *StartAlgoOrderRequest, opts ...grpc.CallOption) (*AlgoOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AlgoOrderResponse)
+	err := c.cc.Invoke(ctx, TradeService_StartAlgoOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) GetAlgoOrder(ctx context.Context, in *AlgoOrderRequest, opts ...grpc.CallOption) (*AlgoOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AlgoOrderResponse)
+	err := c.cc.Invoke(ctx, TradeService_GetAlgoOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) CancelAlgoOrder(ctx context.Context, in *AlgoOrderRequest, opts ...grpc.CallOption) (*AlgoOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AlgoOrderResponse)
+	err := c.cc.Invoke(ctx, TradeService_CancelAlgoOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) SubmitQuote(ctx context.Context, in *SubmitQuoteRequest, opts ...grpc.CallOption) (*SubmitQuoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitQuoteResponse)
+	err := c.cc.Invoke(ctx, TradeService_SubmitQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) MassCancel(ctx context.Context, in *MassCancelRequest, opts ...grpc.CallOption) (*MassCancelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MassCancelResponse)
+	err := c.cc.Invoke(ctx, TradeService_MassCancel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) QuoteSession(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[QuoteHeartbeat, QuoteSessionEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TradeService_ServiceDesc.Streams[0], TradeService_QuoteSession_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[QuoteHeartbeat, QuoteSessionEvent]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TradeService_QuoteSessionClient = grpc.BidiStreamingClient[QuoteHeartbeat, QuoteSessionEvent]
+
+func (c *tradeServiceClient) BustTrade(ctx context.Context, in *BustTradeRequest, opts ...grpc.CallOption) (*TradeCorrectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TradeCorrectionResponse)
+	err := c.cc.Invoke(ctx, TradeService_BustTrade_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) AmendTrade(ctx context.Context, in *AmendTradeRequest, opts ...grpc.CallOption) (*TradeCorrectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TradeCorrectionResponse)
+	err := c.cc.Invoke(ctx, TradeService_AmendTrade_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) AdjustForCorporateAction(ctx context.Context, in *CorporateActionAdjustmentRequest, opts ...grpc.CallOption) (*CorporateActionAdjustmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CorporateActionAdjustmentResponse)
+	err := c.cc.Invoke(ctx, TradeService_AdjustForCorporateAction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TradeServiceServer is the server API for TradeService service.
 // All implementations must embed UnimplementedTradeServiceServer
 // for forward compatibility.
 type TradeServiceServer interface {
 	PlaceOrder(context.Context, *PlaceOrderRequest) (*PlaceOrderResponse, error)
 	GetTradeHistory(context.Context, *GetTradeHistoryRequest) (*GetTradeHistoryResponse, error)
+	// Order groups: a bracket enters a position with an attached take-profit and stop-loss,
+	// an OCO pair links two exits so that filling one cancels (or shrinks) the other.
+	PlaceBracketOrder(context.Context, *PlaceBracketOrderRequest) (*OrderGroupResponse, error)
+	PlaceOCOOrder(context.Context, *PlaceOCOOrderRequest) (*OrderGroupResponse, error)
+	CancelOrderGroup(context.Context, *OrderGroupRequest) (*OrderGroupResponse, error)
+	GetOrderGroup(context.Context, *OrderGroupRequest) (*OrderGroupResponse, error)
+	// Algorithmic parent orders: TWAP/VWAP slicing of a large order over a time window.
+	StartAlgoOrder(context.Context, *StartAlgoOrderRequest) (*AlgoOrderResponse, error)
+	GetAlgoOrder(context.Context, *AlgoOrderRequest) (*AlgoOrderResponse, error)
+	CancelAlgoOrder(context.Context, *AlgoOrderRequest) (*AlgoOrderResponse, error)
+	// Market making: atomically replace a two-sided quote, pull orders in bulk,
+	// and keep a heartbeat session that cancels everything if the client goes away.
+	SubmitQuote(context.Context, *SubmitQuoteRequest) (*SubmitQuoteResponse, error)
+	MassCancel(context.Context, *MassCancelRequest) (*MassCancelResponse, error)
+	QuoteSession(grpc.BidiStreamingServer[QuoteHeartbeat, QuoteSessionEvent]) error
+	// Admin-only trade corrections. Wallets and holdings of every side are adjusted
+	// and an audit record is kept.
+	BustTrade(context.Context, *BustTradeRequest) (*TradeCorrectionResponse, error)
+	AmendTrade(context.Context, *AmendTradeRequest) (*TradeCorrectionResponse, error)
+	// Admin-only: portfolio-service applies splits and symbol changes to working orders.
+	AdjustForCorporateAction(context.Context, *CorporateActionAdjustmentRequest) (*CorporateActionAdjustmentResponse, error)
 	mustEmbedUnimplementedTradeServiceServer()
 }
 
@@ -81,6 +269,45 @@ func (UnimplementedTradeServiceServer) PlaceOrder(context.Context, *PlaceOrderRe
 func (UnimplementedTradeServiceServer) GetTradeHistory(context.Context, *GetTradeHistoryRequest) (*GetTradeHistoryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTradeHistory not implemented")
 }
+func (UnimplementedTradeServiceServer) PlaceBracketOrder(context.Context, *PlaceBracketOrderRequest) (*OrderGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlaceBracketOrder not implemented")
+}
+func (UnimplementedTradeServiceServer) PlaceOCOOrder(context.Context, *PlaceOCOOrderRequest) (*OrderGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlaceOCOOrder not implemented")
+}
+func (UnimplementedTradeServiceServer) CancelOrderGroup(context.Context, *OrderGroupRequest) (*OrderGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOrderGroup not implemented")
+}
+func (UnimplementedTradeServiceServer) GetOrderGroup(context.Context, *OrderGroupRequest) (*OrderGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderGroup not implemented")
+}
+func (UnimplementedTradeServiceServer) StartAlgoOrder(context.Context, *StartAlgoOrderRequest) (*AlgoOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartAlgoOrder not implemented")
+}
+func (UnimplementedTradeServiceServer) GetAlgoOrder(context.Context, *AlgoOrderRequest) (*AlgoOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAlgoOrder not implemented")
+}
+func (UnimplementedTradeServiceServer) CancelAlgoOrder(context.Context, *AlgoOrderRequest) (*AlgoOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelAlgoOrder not implemented")
+}
+func (UnimplementedTradeServiceServer) SubmitQuote(context.Context, *SubmitQuoteRequest) (*SubmitQuoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitQuote not implemented")
+}
+func (UnimplementedTradeServiceServer) MassCancel(context.Context, *MassCancelRequest) (*MassCancelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MassCancel not implemented")
+}
+func (UnimplementedTradeServiceServer) QuoteSession(grpc.BidiStreamingServer[QuoteHeartbeat, QuoteSessionEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method QuoteSession not implemented")
+}
+func (UnimplementedTradeServiceServer) BustTrade(context.Context, *BustTradeRequest) (*TradeCorrectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BustTrade not implemented")
+}
+func (UnimplementedTradeServiceServer) AmendTrade(context.Context, *AmendTradeRequest) (*TradeCorrectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AmendTrade not implemented")
+}
+func (UnimplementedTradeServiceServer) AdjustForCorporateAction(context.Context, *CorporateActionAdjustmentRequest) (*CorporateActionAdjustmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdjustForCorporateAction not implemented")
+}
 func (UnimplementedTradeServiceServer) mustEmbedUnimplementedTradeServiceServer() {}
 func (UnimplementedTradeServiceServer) testEmbeddedByValue()                      {}
 
@@ -138,6 +365,229 @@ func _TradeService_GetTradeHistory_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TradeService_PlaceBracketOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceBracketOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).PlaceBracketOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_PlaceBracketOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).PlaceBracketOrder(ctx, req.(*PlaceBracketOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_PlaceOCOOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceOCOOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).PlaceOCOOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_PlaceOCOOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).PlaceOCOOrder(ctx, req.(*PlaceOCOOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_CancelOrderGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrderGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).CancelOrderGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_CancelOrderGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).CancelOrderGroup(ctx, req.(*OrderGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_GetOrderGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrderGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).GetOrderGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_GetOrderGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).GetOrderGroup(ctx, req.(*OrderGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_StartAlgoOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartAlgoOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).StartAlgoOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_StartAlgoOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).StartAlgoOrder(ctx, req.(*StartAlgoOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_GetAlgoOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AlgoOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).GetAlgoOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_GetAlgoOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).GetAlgoOrder(ctx, req.(*AlgoOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_CancelAlgoOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AlgoOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).CancelAlgoOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_CancelAlgoOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).CancelAlgoOrder(ctx, req.(*AlgoOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_SubmitQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).SubmitQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_SubmitQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).SubmitQuote(ctx, req.(*SubmitQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_MassCancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MassCancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).MassCancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_MassCancel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).MassCancel(ctx, req.(*MassCancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_QuoteSession_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TradeServiceServer).QuoteSession(&grpc.GenericServerStream[QuoteHeartbeat, QuoteSessionEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TradeService_QuoteSessionServer = grpc.BidiStreamingServer[QuoteHeartbeat, QuoteSessionEvent]
+
+func _TradeService_BustTrade_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BustTradeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).BustTrade(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_BustTrade_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).BustTrade(ctx, req.(*BustTradeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_AmendTrade_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AmendTradeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).AmendTrade(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_AmendTrade_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).AmendTrade(ctx, req.(*AmendTradeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_AdjustForCorporateAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CorporateActionAdjustmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).AdjustForCorporateAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_AdjustForCorporateAction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).AdjustForCorporateAction(ctx, req.(*CorporateActionAdjustmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TradeService_ServiceDesc is the grpc.ServiceDesc for TradeService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,7 +603,62 @@ var TradeService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTradeHistory",
 			Handler:    _TradeService_GetTradeHistory_Handler,
 		},
+		{
+			MethodName: "PlaceBracketOrder",
+			Handler:    _TradeService_PlaceBracketOrder_Handler,
+		},
+		{
+			MethodName: "PlaceOCOOrder",
+			Handler:    _TradeService_PlaceOCOOrder_Handler,
+		},
+		{
+			MethodName: "CancelOrderGroup",
+			Handler:    _TradeService_CancelOrderGroup_Handler,
+		},
+		{
+			MethodName: "GetOrderGroup",
+			Handler:    _TradeService_GetOrderGroup_Handler,
+		},
+		{
+			MethodName: "StartAlgoOrder",
+			Handler:    _TradeService_StartAlgoOrder_Handler,
+		},
+		{
+			MethodName: "GetAlgoOrder",
+			Handler:    _TradeService_GetAlgoOrder_Handler,
+		},
+		{
+			MethodName: "CancelAlgoOrder",
+			Handler:    _TradeService_CancelAlgoOrder_Handler,
+		},
+		{
+			MethodName: "SubmitQuote",
+			Handler:    _TradeService_SubmitQuote_Handler,
+		},
+		{
+			MethodName: "MassCancel",
+			Handler:    _TradeService_MassCancel_Handler,
+		},
+		{
+			MethodName: "BustTrade",
+			Handler:    _TradeService_BustTrade_Handler,
+		},
+		{
+			MethodName: "AmendTrade",
+			Handler:    _TradeService_AmendTrade_Handler,
+		},
+		{
+			MethodName: "AdjustForCorporateAction",
+			Handler:    _TradeService_AdjustForCorporateAction_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "QuoteSession",
+			Handler:       _TradeService_QuoteSession_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "trade.proto",
 }
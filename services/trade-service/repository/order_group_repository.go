@@ -0,0 +1,34 @@
+package repository
+
+import (
+    "context"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveOrderGroup upserts the group document keyed by group_id.
+func SaveOrderGroup(group *models.OrderGroup) error {
+    coll := config.DB.Collection("order_groups")
+    _, err := coll.ReplaceOne(
+        context.Background(),
+        bson.M{"group_id": group.GroupID},
+        group,
+        options.Replace().SetUpsert(true),
+    )
+    return err
+}
+
+// GetOrderGroup loads a group by its ID.
+func GetOrderGroup(groupID string) (*models.OrderGroup, error) {
+    coll := config.DB.Collection("order_groups")
+    var group models.OrderGroup
+    err := coll.FindOne(context.Background(), bson.M{"group_id": groupID}).Decode(&group)
+    if err != nil {
+        return nil, err
+    }
+    return &group, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+    "context"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/config"
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveParentOrder upserts the parent order document keyed by parent_id.
+func SaveParentOrder(parent *models.ParentOrder) error {
+    coll := config.DB.Collection("parent_orders")
+    _, err := coll.ReplaceOne(
+        context.Background(),
+        bson.M{"parent_id": parent.ParentID},
+        parent,
+        options.Replace().SetUpsert(true),
+    )
+    return err
+}
+
+// GetParentOrder loads a parent order by its ID.
+func GetParentOrder(parentID string) (*models.ParentOrder, error) {
+    coll := config.DB.Collection("parent_orders")
+    var parent models.ParentOrder
+    err := coll.FindOne(context.Background(), bson.M{"parent_id": parentID}).Decode(&parent)
+    if err != nil {
+        return nil, err
+    }
+    return &parent, nil
+}
+
+// GetParentOrdersByStatus loads every parent order in the given status.
+func GetParentOrdersByStatus(status string) ([]models.ParentOrder, error) {
+    coll := config.DB.Collection("parent_orders")
+    cursor, err := coll.Find(context.Background(), bson.M{"status": status})
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var parents []models.ParentOrder
+    for cursor.Next(context.Background()) {
+        var p models.ParentOrder
+        if err := cursor.Decode(&p); err != nil {
+            return nil, err
+        }
+        parents = append(parents, p)
+    }
+    return parents, nil
+}
+
+// GetBarsSince returns the stored bars of one interval for a symbol since the given time.
+func GetBarsSince(symbol, interval string, since time.Time) ([]models.Bar, error) {
+    coll := config.DB.Collection("bars")
+    filter := bson.M{
+        "symbol":   symbol,
+        "interval": interval,
+        "start":    bson.M{"$gte": since},
+    }
+    cursor, err := coll.Find(context.Background(), filter, options.Find().SetSort(bson.M{"start": 1}))
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+
+    var bars []models.Bar
+    for cursor.Next(context.Background()) {
+        var b models.Bar
+        if err := cursor.Decode(&b); err != nil {
+            return nil, err
+        }
+        bars = append(bars, b)
+    }
+    return bars, nil
+}
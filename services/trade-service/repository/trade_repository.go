@@ -33,3 +33,87 @@ func GetTradesByUserID(userID string) ([]models.TradeRecord, error) {
     }
     return trades, nil
 }
+
+// GetTradeByID loads a single trade record.
+func GetTradeByID(tradeID string) (*models.TradeRecord, error) {
+    coll := config.DB.Collection("trades")
+    var trade models.TradeRecord
+    if err := coll.FindOne(context.Background(), bson.M{"trade_id": tradeID}).Decode(&trade); err != nil {
+        return nil, err
+    }
+    return &trade, nil
+}
+
+// GetTradesByExecutionID returns both sides of an order-book fill.
+func GetTradesByExecutionID(executionID string) ([]models.TradeRecord, error) {
+    coll := config.DB.Collection("trades")
+    var trades []models.TradeRecord
+    cursor, err := coll.Find(context.Background(), bson.M{"execution_id": executionID})
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(context.Background())
+    for cursor.Next(context.Background()) {
+        var record models.TradeRecord
+        if err := cursor.Decode(&record); err != nil {
+            return nil, err
+        }
+        trades = append(trades, record)
+    }
+    return trades, nil
+}
+
+// UpdateTradeCorrection overwrites the price, quantity and status of a corrected
+// trade. It only applies while the trade still has the price, quantity and status
+// of before, so of two concurrent corrections only one wins; it reports whether
+// this one did.
+func UpdateTradeCorrection(before models.TradeRecord, price, quantity, settlementAmount float64, status string) (bool, error) {
+    coll := config.DB.Collection("trades")
+    filter := bson.M{"trade_id": before.TradeID, "price": before.Price, "quantity": before.Quantity, "status": before.Status}
+    if before.Status == "" {
+        // Trades booked before corrections existed have no status field
+        filter["status"] = bson.M{"$in": bson.A{"", nil}}
+    }
+    res, err := coll.UpdateOne(
+        context.Background(),
+        filter,
+        bson.M{"$set": bson.M{"price": price, "quantity": quantity, "settlement_amount": settlementAmount, "status": status}},
+    )
+    if err != nil {
+        return false, err
+    }
+    return res.MatchedCount > 0, nil
+}
+
+// SetTradeCommission records the commission charged for a trade.
+func SetTradeCommission(tradeID string, commission float64, transactionID string) error {
+    coll := config.DB.Collection("trades")
+    _, err := coll.UpdateOne(
+        context.Background(),
+        bson.M{"trade_id": tradeID},
+        bson.M{"$set": bson.M{"commission": commission, "commission_tx_id": transactionID}},
+    )
+    return err
+}
+
+// SetTradeMarginRepaid records how much of a sale's proceeds repaid the seller's margin loan.
+func SetTradeMarginRepaid(tradeID string, amount float64) error {
+    coll := config.DB.Collection("trades")
+    _, err := coll.UpdateOne(context.Background(), bson.M{"trade_id": tradeID}, bson.M{"$set": bson.M{"margin_repaid": amount}})
+    return err
+}
+
+// AddTradeProceedsCredited adds amount (negative when a correction takes some
+// back) to the sale proceeds recorded as paid into the seller's wallet.
+func AddTradeProceedsCredited(tradeID string, amount float64) error {
+    coll := config.DB.Collection("trades")
+    _, err := coll.UpdateOne(context.Background(), bson.M{"trade_id": tradeID}, bson.M{"$inc": bson.M{"proceeds_credited": amount}})
+    return err
+}
+
+// InsertTradeAudit stores the audit record of a bust/amend.
+func InsertTradeAudit(audit *models.TradeAudit) error {
+    coll := config.DB.Collection("trade_audit")
+    _, err := coll.InsertOne(context.Background(), audit)
+    return err
+}
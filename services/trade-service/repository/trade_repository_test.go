@@ -0,0 +1,58 @@
+package repository
+
+import (
+    "sync"
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/internal/testmongo"
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
+)
+
+func TestUpdateTradeCorrectionOnlyOneWins(t *testing.T) {
+    testmongo.Use(t)
+
+    trade := models.TradeRecord{TradeID: "t-1", UserID: "alice@example.com", Symbol: "AAPL", Quantity: 10, Price: 100, OrderType: "BUY"}
+    if err := InsertTradeRecord(&trade); err != nil {
+        t.Fatal(err)
+    }
+
+    const attempts = 20
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    wins := 0
+    for i := 0; i < attempts; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            won, err := UpdateTradeCorrection(trade, trade.Price, trade.Quantity, 0, "BUSTED")
+            if err != nil {
+                t.Error(err)
+                return
+            }
+            if won {
+                mu.Lock()
+                wins++
+                mu.Unlock()
+            }
+        }()
+    }
+    wg.Wait()
+    if wins != 1 {
+        t.Fatalf("%d concurrent busts won, want exactly 1", wins)
+    }
+
+    // A correction based on stale values doesn't apply either
+    stale := trade
+    stale.Status = "BUSTED"
+    stale.Price = 99
+    if won, err := UpdateTradeCorrection(stale, 98, 10, 980, "AMENDED"); err != nil || won {
+        t.Fatalf("stale amend: won=%v err=%v, want no match", won, err)
+    }
+    got, err := GetTradeByID("t-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got.Status != "BUSTED" || got.Price != 100 {
+        t.Fatalf("trade is %s @ %.2f, want BUSTED @ 100", got.Status, got.Price)
+    }
+}
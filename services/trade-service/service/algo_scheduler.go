@@ -0,0 +1,480 @@
+package service
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/middleware"
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
+    "github.com/ankan8/swapsync/backend/services/trade-service/repository"
+
+    "github.com/google/uuid"
+)
+
+const (
+    AlgoTWAP = "TWAP"
+    AlgoVWAP = "VWAP"
+
+    ParentRunning   = "RUNNING"
+    ParentCompleted = "COMPLETED"
+    ParentExpired   = "EXPIRED" // window ended before the full quantity filled
+    ParentCanceled  = "CANCELED"
+
+    SliceScheduled = "SCHEDULED"
+    SliceWorking   = "WORKING" // sent; only seen on a slice interrupted by a restart
+    SliceFilled    = "FILLED"
+    SliceSkipped   = "SKIPPED"
+    SliceFailed    = "FAILED"
+    SliceCanceled  = "CANCELED"
+
+    // vwapLookbackDays is how much 1m bar history feeds the VWAP volume curve.
+    vwapLookbackDays = 20
+    defaultSlices    = 10
+)
+
+// AlgoOrderParams describes a parent order for the TWAP/VWAP scheduler.
+type AlgoOrderParams struct {
+    UserID           string
+    Symbol           string
+    Side             OrderSide
+    Strategy         string
+    Quantity         float64
+    ChildOrderType   OrderType // MARKET or LIMIT
+    LimitPrice       float64   // required for LIMIT children
+    Duration         time.Duration
+    Slices           int
+    ParticipationCap float64 // e.g. 0.1 = at most 10% of expected slice volume
+}
+
+// algoScheduler owns the running parent orders. Each runs in its own goroutine
+// that wakes up at every slice time and sends one child order.
+type algoScheduler struct {
+    mu      sync.Mutex
+    running map[string]*runningParent
+}
+
+type runningParent struct {
+    parent *models.ParentOrder
+    cancel context.CancelFunc
+}
+
+var scheduler = &algoScheduler{running: map[string]*runningParent{}}
+
+// The scheduler quotes, settles and persists through these; tests replace them.
+var (
+    algoQuote       = fetchCurrentQuote
+    algoSettleFills = settleFills
+    algoExecute     = settleExecution
+    saveParentOrder = repository.SaveParentOrder
+)
+
+// StartAlgoOrder validates the parent order, builds its slice schedule and starts working it.
+// The scheduler acts for p.UserID with a short-lived token minted per slice.
+func StartAlgoOrder(p AlgoOrderParams) (*models.ParentOrder, error) {
+    if p.Quantity <= 0 {
+        return nil, fmt.Errorf("invalid quantity %.2f", p.Quantity)
+    }
+    if p.Side != BUY && p.Side != SELL {
+        return nil, fmt.Errorf("invalid side %q", p.Side)
+    }
+    if p.Strategy != AlgoTWAP && p.Strategy != AlgoVWAP {
+        return nil, fmt.Errorf("invalid strategy %q, expected TWAP or VWAP", p.Strategy)
+    }
+    if p.ChildOrderType == "" {
+        p.ChildOrderType = MARKET
+    }
+    if p.ChildOrderType == LIMIT && p.LimitPrice <= 0 {
+        return nil, fmt.Errorf("LIMIT child orders need a limit price")
+    }
+    if p.ChildOrderType != MARKET && p.ChildOrderType != LIMIT {
+        return nil, fmt.Errorf("invalid child order type %q", p.ChildOrderType)
+    }
+    if p.Duration <= 0 {
+        return nil, fmt.Errorf("invalid duration %v", p.Duration)
+    }
+    if p.Slices <= 0 {
+        p.Slices = defaultSlices
+    }
+    if p.ParticipationCap < 0 || p.ParticipationCap > 1 {
+        return nil, fmt.Errorf("participation cap must be between 0 and 1")
+    }
+
+    // 1) Arrival price is the benchmark for slippage.
+    token, err := middleware.UserToken(p.UserID)
+    if err != nil {
+        return nil, err
+    }
+    arrival, err := algoQuote(p.Symbol, token)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch arrival price for %s: %v", p.Symbol, err)
+    }
+
+    // 2) Build the schedule.
+    start := time.Now()
+    step := p.Duration / time.Duration(p.Slices)
+    expected := volumeProfile(p.Symbol, start, step, p.Slices)
+    weights := make([]float64, p.Slices)
+    total := 0.0
+    for _, v := range expected {
+        total += v
+    }
+    for i := range weights {
+        weights[i] = 1 / float64(p.Slices)
+        if p.Strategy == AlgoVWAP && total > 0 {
+            weights[i] = expected[i] / total
+        }
+    }
+    if p.Strategy == AlgoVWAP && total == 0 {
+        log.Printf("No bar history for %s, VWAP falls back to an even (TWAP) schedule\n", p.Symbol)
+    }
+
+    now := start.Format(time.RFC3339)
+    parent := &models.ParentOrder{
+        ParentID:         uuid.NewString(),
+        UserID:           p.UserID,
+        Symbol:           p.Symbol,
+        Side:             string(p.Side),
+        Strategy:         p.Strategy,
+        ChildOrderType:   string(p.ChildOrderType),
+        LimitPrice:       p.LimitPrice,
+        TotalQuantity:    p.Quantity,
+        ParticipationCap: p.ParticipationCap,
+        ArrivalPrice:     arrival.Last,
+        StartTime:        start,
+        EndTime:          start.Add(p.Duration),
+        Status:           ParentRunning,
+        CreatedAt:        now,
+        UpdatedAt:        now,
+    }
+    for i := 0; i < p.Slices; i++ {
+        parent.Children = append(parent.Children, models.ChildSlice{
+            ChildID:        uuid.NewString(),
+            ScheduledAt:    start.Add(time.Duration(i) * step),
+            TargetQuantity: p.Quantity * weights[i],
+            ExpectedVolume: expected[i],
+            Status:         SliceScheduled,
+        })
+    }
+
+    // 3) Register and run.
+    snapshot := scheduler.start(parent)
+    log.Printf("[ALGO %s] %s %s %.2f %s over %v in %d slices (arrival=%.2f)\n",
+        parent.ParentID, p.Strategy, p.Side, p.Quantity, p.Symbol, p.Duration, p.Slices, arrival.Last)
+    return snapshot, nil
+}
+
+// ResumeAlgoOrders picks up the parent orders that were running when the service
+// stopped. A slice that was in flight at the time is marked failed rather than
+// sent again; its fills, if any, are in the trade history. Orders whose window
+// has passed expire. Returns how many orders were resumed.
+func ResumeAlgoOrders() (int, error) {
+    parents, err := repository.GetParentOrdersByStatus(ParentRunning)
+    if err != nil {
+        return 0, err
+    }
+    n := 0
+    for i := range parents {
+        parent := &parents[i]
+        scheduler.mu.Lock()
+        _, running := scheduler.running[parent.ParentID]
+        scheduler.mu.Unlock()
+        if running {
+            continue
+        }
+        for j := range parent.Children {
+            if parent.Children[j].Status == SliceWorking {
+                parent.Children[j].Status = SliceFailed
+                parent.Children[j].Reason = "interrupted by a restart"
+            }
+        }
+        if time.Now().After(parent.EndTime) {
+            for j := range parent.Children {
+                if parent.Children[j].Status == SliceScheduled {
+                    parent.Children[j].Status = SliceCanceled
+                    parent.Children[j].Reason = "window ended while the scheduler was down"
+                }
+            }
+            parent.Status = ParentExpired
+            scheduler.mu.Lock()
+            saveParent(parent)
+            scheduler.mu.Unlock()
+            log.Printf("[ALGO %s] expired while the scheduler was down with %.2f/%.2f filled\n",
+                parent.ParentID, parent.FilledQuantity, parent.TotalQuantity)
+            continue
+        }
+        scheduler.start(parent)
+        log.Printf("[ALGO %s] resumed with %.2f/%.2f filled\n", parent.ParentID, parent.FilledQuantity, parent.TotalQuantity)
+        n++
+    }
+    return n, nil
+}
+
+// start registers parent as running, persists it and starts its goroutine.
+func (s *algoScheduler) start(parent *models.ParentOrder) *models.ParentOrder {
+    ctx, cancel := context.WithCancel(context.Background())
+    rp := &runningParent{parent: parent, cancel: cancel}
+    s.mu.Lock()
+    s.running[parent.ParentID] = rp
+    saveParent(parent)
+    snapshot := copyParent(parent)
+    s.mu.Unlock()
+
+    go s.run(ctx, rp)
+    return snapshot
+}
+
+// GetAlgoOrder returns progress for a running or finished parent order.
+func GetAlgoOrder(parentID string) (*models.ParentOrder, error) {
+    scheduler.mu.Lock()
+    rp, ok := scheduler.running[parentID]
+    if ok {
+        snapshot := copyParent(rp.parent)
+        scheduler.mu.Unlock()
+        return snapshot, nil
+    }
+    scheduler.mu.Unlock()
+    return repository.GetParentOrder(parentID)
+}
+
+// CancelAlgoOrder stops a running parent order; slices already sent keep their fills.
+func CancelAlgoOrder(parentID string) (*models.ParentOrder, error) {
+    scheduler.mu.Lock()
+    defer scheduler.mu.Unlock()
+
+    rp, ok := scheduler.running[parentID]
+    if !ok {
+        return nil, fmt.Errorf("no running algo order %s", parentID)
+    }
+    rp.cancel()
+    for i := range rp.parent.Children {
+        if rp.parent.Children[i].Status == SliceScheduled {
+            rp.parent.Children[i].Status = SliceCanceled
+        }
+    }
+    rp.parent.Status = ParentCanceled
+    delete(scheduler.running, parentID)
+    saveParent(rp.parent)
+    log.Printf("[ALGO %s] canceled with %.2f/%.2f filled\n", parentID, rp.parent.FilledQuantity, rp.parent.TotalQuantity)
+    return copyParent(rp.parent), nil
+}
+
+// run sends each slice at its scheduled time until the schedule ends or the order is canceled.
+// Slices already done (after a restart) are passed over. A slice that is overdue while
+// the next one is due too is skipped, and its quantity carries over.
+func (s *algoScheduler) run(ctx context.Context, rp *runningParent) {
+    for i := range rp.parent.Children {
+        s.mu.Lock()
+        child := rp.parent.Children[i]
+        overdue := i+1 < len(rp.parent.Children) && !rp.parent.Children[i+1].ScheduledAt.After(time.Now())
+        s.mu.Unlock()
+        if child.Status != SliceScheduled {
+            continue
+        }
+        if overdue {
+            s.finishSlice(rp, i, 0, 0, 0, "", SliceSkipped, "missed its slot, carried over")
+            continue
+        }
+
+        timer := time.NewTimer(time.Until(child.ScheduledAt))
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return
+        case <-timer.C:
+        }
+        s.executeSlice(ctx, rp, i)
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if rp.parent.Status != ParentRunning {
+        return
+    }
+    rp.parent.Status = ParentCompleted
+    if rp.parent.FilledQuantity < rp.parent.TotalQuantity {
+        rp.parent.Status = ParentExpired
+    }
+    delete(s.running, rp.parent.ParentID)
+    saveParent(rp.parent)
+    log.Printf("[ALGO %s] %s: filled %.2f/%.2f avg=%.4f slippage=%.2fbps\n", rp.parent.ParentID, rp.parent.Status,
+        rp.parent.FilledQuantity, rp.parent.TotalQuantity, rp.parent.AvgFillPrice, rp.parent.SlippageBps)
+}
+
+// executeSlice sizes slice i and sends it as a child order. The child first goes
+// through our book as an immediate-or-cancel limit at the market touch (or the
+// parent's limit), so it takes resting liquidity and moves the book like any
+// other order; what the book can't fill executes against the market at the touch.
+// Quantity missed by earlier slices (skipped, capped or failed) carries over.
+func (s *algoScheduler) executeSlice(ctx context.Context, rp *runningParent, i int) {
+    s.mu.Lock()
+    parent := rp.parent
+    cumulativeTarget := 0.0
+    for j := 0; j <= i; j++ {
+        cumulativeTarget += parent.Children[j].TargetQuantity
+    }
+    remaining := parent.TotalQuantity - parent.FilledQuantity
+    qty := math.Min(cumulativeTarget-parent.FilledQuantity, remaining)
+    if i == len(parent.Children)-1 {
+        qty = remaining
+    }
+    child := parent.Children[i]
+    if parent.ParticipationCap > 0 && child.ExpectedVolume > 0 {
+        qty = math.Min(qty, parent.ParticipationCap*child.ExpectedVolume)
+    }
+    userID, symbol, side := parent.UserID, parent.Symbol, OrderSide(parent.Side)
+    orderType, limit := OrderType(parent.ChildOrderType), parent.LimitPrice
+    s.mu.Unlock()
+
+    if qty <= 0 {
+        s.finishSlice(rp, i, 0, 0, 0, "", SliceSkipped, "nothing to send")
+        return
+    }
+
+    token, err := middleware.UserToken(userID)
+    if err != nil {
+        s.finishSlice(rp, i, qty, 0, 0, "", SliceFailed, err.Error())
+        return
+    }
+    quote, err := algoQuote(symbol, token)
+    if err != nil {
+        s.finishSlice(rp, i, qty, 0, 0, "", SliceFailed, err.Error())
+        return
+    }
+    price := quote.touch(side)
+    if orderType == LIMIT && !quote.marketable(side, limit) {
+        s.finishSlice(rp, i, 0, 0, price, "", SliceSkipped, fmt.Sprintf("market %.2f through limit %.2f", price, limit))
+        return
+    }
+
+    // Mark the slice as sent, unless the order was canceled meanwhile.
+    s.mu.Lock()
+    if ctx.Err() != nil {
+        s.mu.Unlock()
+        return
+    }
+    parent.Children[i].Status = SliceWorking
+    parent.Children[i].Quantity = qty
+    saveParent(parent)
+    s.mu.Unlock()
+
+    // 1) Our book first; stops it triggers settle along with the child's fills.
+    order := InMemoryOrder{OrderID: child.ChildID, UserID: userID, Symbol: symbol, Side: side,
+        OrderType: LIMIT, Quantity: qty, Price: price, Timestamp: time.Now()}
+    fills, unfilled := GetOrderBook(symbol).PlaceIOCOrder(order)
+    filled, notional := 0.0, 0.0
+    for _, f := range algoSettleFills(orderGroups.handleFills(symbol, fills)) {
+        if f.TakerOrderID == child.ChildID {
+            filled += f.Quantity
+            notional += f.Quantity * f.Price
+        }
+    }
+    // Book fills that didn't settle aren't retried against the market.
+    if unfilled <= 0 {
+        if filled == 0 {
+            s.finishSlice(rp, i, qty, 0, 0, "", SliceFailed, "book fills failed to settle")
+            return
+        }
+        s.finishSlice(rp, i, qty, filled, notional/filled, "", SliceFilled, "")
+        return
+    }
+
+    // 2) The rest executes against the market at the touch, as PlaceOrder does.
+    algoSettleFills(TriggerStops(symbol, quote.Last))
+    tradeID, err := algoExecute(userID, symbol, string(side), unfilled, price, LiquidityTaker, child.ChildID)
+    reason := ""
+    if err != nil {
+        // With a trade ID the trade is booked; only a downstream step (commission/holdings) failed.
+        reason = err.Error()
+    }
+    if tradeID != "" {
+        filled += unfilled
+        notional += unfilled * price
+    }
+    if filled == 0 {
+        s.finishSlice(rp, i, qty, 0, 0, "", SliceFailed, reason)
+        return
+    }
+    s.finishSlice(rp, i, qty, filled, notional/filled, tradeID, SliceFilled, reason)
+}
+
+// finishSlice records the slice outcome and refreshes the parent's progress figures.
+// price is the child's average fill price, or the market price that made it skip.
+func (s *algoScheduler) finishSlice(rp *runningParent, i int, qty, filled, price float64, tradeID, status, reason string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    parent := rp.parent
+    child := &parent.Children[i]
+    child.Quantity = qty
+    child.FilledQuantity = filled
+    child.Price = price
+    child.TradeID = tradeID
+    child.Status = status
+    child.Reason = reason
+
+    if filled > 0 {
+        notional := parent.AvgFillPrice*parent.FilledQuantity + price*filled
+        parent.FilledQuantity += filled
+        parent.AvgFillPrice = notional / parent.FilledQuantity
+        if parent.ArrivalPrice > 0 {
+            parent.SlippageBps = (parent.AvgFillPrice - parent.ArrivalPrice) / parent.ArrivalPrice * 10000
+            if parent.Side == string(SELL) {
+                parent.SlippageBps = -parent.SlippageBps
+            }
+        }
+    }
+    log.Printf("[ALGO %s] slice %d/%d %s qty=%.2f filled=%.2f price=%.2f %s\n", parent.ParentID, i+1, len(parent.Children),
+        status, qty, filled, price, reason)
+    saveParent(parent)
+}
+
+// volumeProfile averages historical 1m bar volume by time of day into the
+// schedule's slice windows. All zeros means there's no usable history.
+func volumeProfile(symbol string, start time.Time, step time.Duration, slices int) []float64 {
+    expected := make([]float64, slices)
+    if step <= 0 {
+        return expected
+    }
+    bars, err := repository.GetBarsSince(symbol, "1m", start.AddDate(0, 0, -vwapLookbackDays))
+    if err != nil {
+        log.Printf("Failed to load bars for %s: %v\n", symbol, err)
+        return expected
+    }
+
+    const day = 24 * time.Hour
+    startOfDay := start.Sub(start.Truncate(day))
+    days := map[string]bool{}
+    for _, b := range bars {
+        offset := (b.Start.Sub(b.Start.Truncate(day)) - startOfDay + day) % day
+        idx := int(offset / step)
+        if idx >= slices {
+            continue
+        }
+        expected[idx] += b.Volume
+        days[b.Start.Format("2006-01-02")] = true
+    }
+    if len(days) > 0 {
+        for i := range expected {
+            expected[i] /= float64(len(days))
+        }
+    }
+    return expected
+}
+
+// saveParent persists the parent order. The caller must hold scheduler.mu.
+func saveParent(parent *models.ParentOrder) {
+    parent.UpdatedAt = time.Now().Format(time.RFC3339)
+    if err := saveParentOrder(parent); err != nil {
+        log.Printf("Error saving parent order %s: %v\n", parent.ParentID, err)
+    }
+}
+
+func copyParent(parent *models.ParentOrder) *models.ParentOrder {
+    snapshot := *parent
+    snapshot.Children = append([]models.ChildSlice(nil), parent.Children...)
+    return &snapshot
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "math"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/testmongo"
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
+    "github.com/ankan8/swapsync/backend/services/trade-service/repository"
+)
+
+// algoExecution is one child quantity sent to the market by the scheduler.
+type algoExecution struct {
+    Quantity float64
+    Price    float64
+}
+
+// useAlgoFakes quotes quote, executes market remainders in memory and settles
+// book fills without the other services. It returns the market executions.
+func useAlgoFakes(t *testing.T, quote *marketQuote) func() []algoExecution {
+    t.Helper()
+    t.Setenv("JWT_SECRET", "test-secret")
+    prevQuote, prevSettle, prevExecute, prevSave := algoQuote, algoSettleFills, algoExecute, saveParentOrder
+    t.Cleanup(func() {
+        algoQuote, algoSettleFills, algoExecute, saveParentOrder = prevQuote, prevSettle, prevExecute, prevSave
+    })
+
+    var mu sync.Mutex
+    var executions []algoExecution
+    algoQuote = func(string, string) (marketQuote, error) { return *quote, nil }
+    algoSettleFills = func(fills []Fill) []Fill { return fills }
+    algoExecute = func(userID, symbol, orderType string, quantity, price float64, liquidity, executionID string) (string, error) {
+        mu.Lock()
+        defer mu.Unlock()
+        executions = append(executions, algoExecution{Quantity: quantity, Price: price})
+        return "trade-" + executionID, nil
+    }
+    saveParentOrder = func(*models.ParentOrder) error { return nil }
+    return func() []algoExecution {
+        mu.Lock()
+        defer mu.Unlock()
+        return append([]algoExecution(nil), executions...)
+    }
+}
+
+// testParent builds a running BUY parent with one slice per target, all due now.
+func testParent(symbol string, targets, expected []float64) *runningParent {
+    parent := &models.ParentOrder{ParentID: symbol, UserID: "alice@example.com", Symbol: symbol, Side: string(BUY),
+        Strategy: AlgoTWAP, ChildOrderType: string(MARKET), ArrivalPrice: 100, Status: ParentRunning}
+    for i, target := range targets {
+        parent.TotalQuantity += target
+        child := models.ChildSlice{ChildID: symbol + "-" + string(rune('a'+i)), ScheduledAt: time.Now(),
+            TargetQuantity: target, Status: SliceScheduled}
+        if expected != nil {
+            child.ExpectedVolume = expected[i]
+        }
+        parent.Children = append(parent.Children, child)
+    }
+    return &runningParent{parent: parent, cancel: func() {}}
+}
+
+func TestSliceSizingCarriesOverMisses(t *testing.T) {
+    executions := useAlgoFakes(t, &marketQuote{Last: 100, Bid: 99, Ask: 101})
+    rp := testParent("ALGO-SIZING", []float64{10, 10, 10}, nil)
+
+    // The first slice can't get a quote and sends nothing
+    quoteUp := algoQuote
+    algoQuote = func(string, string) (marketQuote, error) {
+        return marketQuote{}, errors.New("market data unavailable")
+    }
+    scheduler.executeSlice(context.Background(), rp, 0)
+    if c := rp.parent.Children[0]; c.Status != SliceFailed || c.FilledQuantity != 0 {
+        t.Fatalf("slice 1 %s filled %.2f, want FAILED with nothing filled", c.Status, c.FilledQuantity)
+    }
+
+    algoQuote = quoteUp
+    scheduler.executeSlice(context.Background(), rp, 1)
+    scheduler.executeSlice(context.Background(), rp, 2)
+
+    got := executions()
+    if len(got) != 2 || got[0].Quantity != 20 || got[1].Quantity != 10 {
+        t.Fatalf("executions = %+v, want 20 (with the carry-over) then 10", got)
+    }
+    if got[0].Price != 101 {
+        t.Fatalf("BUY slice executed at %.2f, want the ask 101", got[0].Price)
+    }
+    if rp.parent.FilledQuantity != 30 || rp.parent.AvgFillPrice != 101 {
+        t.Fatalf("parent filled %.2f avg %.2f, want 30 at 101", rp.parent.FilledQuantity, rp.parent.AvgFillPrice)
+    }
+    if math.Abs(rp.parent.SlippageBps-100) > 1e-9 {
+        t.Fatalf("slippage = %.2fbps, want 100", rp.parent.SlippageBps)
+    }
+}
+
+func TestParticipationCapLimitsSlices(t *testing.T) {
+    executions := useAlgoFakes(t, &marketQuote{Last: 100, Bid: 100, Ask: 100})
+    rp := testParent("ALGO-CAP", []float64{20, 20}, []float64{50, 1000})
+    rp.parent.ParticipationCap = 0.1
+
+    scheduler.executeSlice(context.Background(), rp, 0)
+    scheduler.executeSlice(context.Background(), rp, 1)
+
+    // 10% of 50 caps the first slice; the last takes everything left, within its cap of 100
+    got := executions()
+    if len(got) != 2 || got[0].Quantity != 5 || got[1].Quantity != 35 {
+        t.Fatalf("executions = %+v, want 5 then 35", got)
+    }
+
+    // A cap below the remainder leaves the parent short
+    rp = testParent("ALGO-CAP-SHORT", []float64{20, 20}, []float64{50, 50})
+    rp.parent.ParticipationCap = 0.1
+    scheduler.executeSlice(context.Background(), rp, 0)
+    scheduler.executeSlice(context.Background(), rp, 1)
+    if rp.parent.FilledQuantity != 10 {
+        t.Fatalf("filled %.2f, want 10 under the cap", rp.parent.FilledQuantity)
+    }
+}
+
+func TestLimitChildSkipsWhenMarketIsThroughLimit(t *testing.T) {
+    quote := &marketQuote{Last: 101, Bid: 100, Ask: 101}
+    executions := useAlgoFakes(t, quote)
+    rp := testParent("ALGO-LIMIT", []float64{10, 10}, nil)
+    rp.parent.ChildOrderType = string(LIMIT)
+    rp.parent.LimitPrice = 100
+
+    scheduler.executeSlice(context.Background(), rp, 0)
+    if c := rp.parent.Children[0]; c.Status != SliceSkipped || c.Price != 101 {
+        t.Fatalf("slice 1 %s at %.2f, want SKIPPED at the ask 101", c.Status, c.Price)
+    }
+    if len(executions()) != 0 {
+        t.Fatal("a child was sent through the limit")
+    }
+
+    // Once the ask comes back inside the limit the next slice sends both quantities
+    quote.Ask = 99.5
+    scheduler.executeSlice(context.Background(), rp, 1)
+    got := executions()
+    if len(got) != 1 || got[0].Quantity != 20 || got[0].Price != 99.5 {
+        t.Fatalf("executions = %+v, want 20 at 99.5", got)
+    }
+}
+
+func TestSliceTakesBookLiquidityFirst(t *testing.T) {
+    const symbol = "ALGO-BOOK"
+    executions := useAlgoFakes(t, &marketQuote{Last: 100, Bid: 99, Ask: 100})
+    ob := GetOrderBook(symbol)
+    ob.PlaceLimitOrder(InMemoryOrder{OrderID: "inside", UserID: "bob@example.com", Symbol: symbol, Side: SELL,
+        OrderType: LIMIT, Quantity: 4, Price: 99.5, Timestamp: time.Now()})
+    ob.PlaceLimitOrder(InMemoryOrder{OrderID: "outside", UserID: "bob@example.com", Symbol: symbol, Side: SELL,
+        OrderType: LIMIT, Quantity: 4, Price: 101, Timestamp: time.Now()})
+    t.Cleanup(func() { ob.CancelOrder("outside") })
+
+    rp := testParent(symbol, []float64{10}, nil)
+    scheduler.executeSlice(context.Background(), rp, 0)
+
+    // 4 from the book at 99.5, the other 6 from the market at the ask
+    if ob.HasOrder("inside") || !ob.HasOrder("outside") {
+        t.Fatal("the child should take only the resting offer inside the touch")
+    }
+    if ob.HasOrder(rp.parent.Children[0].ChildID) {
+        t.Fatal("the child rests in the book")
+    }
+    got := executions()
+    if len(got) != 1 || got[0].Quantity != 6 || got[0].Price != 100 {
+        t.Fatalf("market executions = %+v, want 6 at 100", got)
+    }
+    c := rp.parent.Children[0]
+    if c.Status != SliceFilled || c.FilledQuantity != 10 || math.Abs(c.Price-99.8) > 1e-9 {
+        t.Fatalf("child %s filled %.2f at %.4f, want 10 at 99.8", c.Status, c.FilledQuantity, c.Price)
+    }
+    if ob.LastPrice != 99.5 {
+        t.Fatalf("book last price %.2f, want the child's print at 99.5", ob.LastPrice)
+    }
+}
+
+func TestCancelMidFlightSendsNothingMore(t *testing.T) {
+    executions := useAlgoFakes(t, &marketQuote{Last: 100, Bid: 100, Ask: 100})
+    quoting, release := make(chan struct{}), make(chan struct{})
+    algoQuote = func(string, string) (marketQuote, error) {
+        close(quoting)
+        <-release
+        return marketQuote{Last: 100, Bid: 100, Ask: 100}, nil
+    }
+
+    rp := testParent("ALGO-CANCEL", []float64{10, 10}, nil)
+    rp.parent.Children[1].ScheduledAt = time.Now().Add(time.Hour)
+    ctx, cancel := context.WithCancel(context.Background())
+    rp.cancel = cancel
+    scheduler.mu.Lock()
+    scheduler.running[rp.parent.ParentID] = rp
+    scheduler.mu.Unlock()
+
+    done := make(chan struct{})
+    go func() {
+        scheduler.run(ctx, rp)
+        close(done)
+    }()
+
+    // Cancel while the first slice is waiting for its quote
+    <-quoting
+    parent, err := CancelAlgoOrder(rp.parent.ParentID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    close(release)
+    select {
+    case <-done:
+    case <-time.After(5 * time.Second):
+        t.Fatal("the scheduler kept running after the cancel")
+    }
+
+    if got := executions(); len(got) != 0 {
+        t.Fatalf("executions after cancel = %+v", got)
+    }
+    if parent.Status != ParentCanceled {
+        t.Fatalf("parent %s, want CANCELED", parent.Status)
+    }
+    for _, c := range rp.parent.Children {
+        if c.Status != SliceCanceled || c.FilledQuantity != 0 {
+            t.Errorf("slice %s %s filled %.2f, want CANCELED and unfilled", c.ChildID, c.Status, c.FilledQuantity)
+        }
+    }
+    if _, err := CancelAlgoOrder(rp.parent.ParentID); err == nil {
+        t.Fatal("canceled order is still running")
+    }
+}
+
+func TestResumeAlgoOrders(t *testing.T) {
+    testmongo.Use(t)
+    useAlgoFakes(t, &marketQuote{Last: 100, Bid: 100, Ask: 100})
+    saveParentOrder = repository.SaveParentOrder
+
+    // One order stopped mid-slice and still has an hour to go, one whose window passed
+    live := testParent("ALGO-RESUME-LIVE", []float64{10, 10}, nil).parent
+    live.EndTime = time.Now().Add(time.Hour)
+    live.Children[0].Status = SliceWorking
+    live.Children[1].ScheduledAt = time.Now().Add(time.Hour)
+    stale := testParent("ALGO-RESUME-STALE", []float64{10, 10}, nil).parent
+    stale.EndTime = time.Now().Add(-time.Minute)
+    for _, p := range []*models.ParentOrder{live, stale} {
+        if err := repository.SaveParentOrder(p); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    n, err := ResumeAlgoOrders()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n != 1 {
+        t.Fatalf("resumed %d, want 1", n)
+    }
+    got, err := GetAlgoOrder(live.ParentID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got.Status != ParentRunning || got.Children[0].Status != SliceFailed || got.Children[1].Status != SliceScheduled {
+        t.Fatalf("live order %s with slices %s/%s, want RUNNING with the interrupted slice FAILED",
+            got.Status, got.Children[0].Status, got.Children[1].Status)
+    }
+    if _, err := CancelAlgoOrder(live.ParentID); err != nil {
+        t.Fatal(err)
+    }
+    got, err = repository.GetParentOrder(stale.ParentID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got.Status != ParentExpired {
+        t.Fatalf("stale order %s, want EXPIRED", got.Status)
+    }
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+    "container/heap"
+    "fmt"
+    "log"
+
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
+)
+
+// CorporateActionResult counts what AdjustForCorporateAction changed.
+type CorporateActionResult struct {
+    Orders     int
+    Groups     int
+    AlgoOrders int
+}
+
+// AdjustForCorporateAction applies a split and/or a symbol change to everything
+// still working for symbol: resting and parked orders, active order groups and
+// running algo orders. ratio is new shares per old share (2 for a 2-for-1 split,
+// 0.1 for a 1-for-10 reverse split); quantities are multiplied by it and prices
+// divided. A ratio of 0 or 1 means no split; an empty newSymbol means no rename.
+func AdjustForCorporateAction(symbol, newSymbol string, ratio float64) (CorporateActionResult, error) {
+    var res CorporateActionResult
+    if symbol == "" {
+        return res, fmt.Errorf("symbol is required")
+    }
+    if ratio < 0 {
+        return res, fmt.Errorf("split ratio must not be negative")
+    }
+    if ratio == 0 {
+        ratio = 1
+    }
+    if newSymbol == symbol {
+        newSymbol = ""
+    }
+    if ratio == 1 && newSymbol == "" {
+        return res, fmt.Errorf("nothing to adjust: need a split ratio or a new symbol")
+    }
+
+    if ratio != 1 {
+        orderBooksMu.Lock()
+        ob, ok := orderBooks[symbol]
+        orderBooksMu.Unlock()
+        if ok {
+            res.Orders = ob.applySplit(ratio)
+        }
+    }
+    if newSymbol != "" {
+        n := renameOrderBook(symbol, newSymbol)
+        if ratio == 1 {
+            res.Orders = n
+        }
+    }
+    res.Groups = orderGroups.adjust(symbol, newSymbol, ratio)
+    res.AlgoOrders = scheduler.adjust(symbol, newSymbol, ratio)
+
+    log.Printf("[CORPORATE ACTION] %s -> %q ratio=%.4f: %d orders, %d groups, %d algo orders adjusted\n",
+        symbol, newSymbol, ratio, res.Orders, res.Groups, res.AlgoOrders)
+    return res, nil
+}
+
+// applySplit rescales every order in the book. Dividing all prices by the same
+// factor keeps the heap order, so the heaps stay valid.
+func (ob *OrderBook) applySplit(ratio float64) int {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+
+    n := 0
+    for i := range *ob.Buys {
+        splitOrder(&(*ob.Buys)[i], ratio)
+        n++
+    }
+    for i := range *ob.Sells {
+        splitOrder(&(*ob.Sells)[i], ratio)
+        n++
+    }
+    for i := range ob.Stops {
+        splitOrder(&ob.Stops[i], ratio)
+        n++
+    }
+    ob.LastPrice /= ratio
+    return n
+}
+
+func splitOrder(o *InMemoryOrder, ratio float64) {
+    o.Quantity *= ratio
+    o.Price /= ratio
+    o.StopPrice /= ratio
+}
+
+// renameOrderBook moves symbol's orders to newSymbol's book and drops the old book.
+func renameOrderBook(symbol, newSymbol string) int {
+    orderBooksMu.Lock()
+    old, ok := orderBooks[symbol]
+    if !ok {
+        orderBooksMu.Unlock()
+        return 0
+    }
+    delete(orderBooks, symbol)
+    target, ok := orderBooks[newSymbol]
+    if !ok {
+        target = NewOrderBook(newSymbol)
+        orderBooks[newSymbol] = target
+    }
+    orderBooksMu.Unlock()
+
+    old.mu.Lock()
+    orders := append([]InMemoryOrder(nil), *old.Buys...)
+    orders = append(orders, *old.Sells...)
+    stops := append([]InMemoryOrder(nil), old.Stops...)
+    lastPrice := old.LastPrice
+    *old.Buys, *old.Sells, old.Stops = BuyHeap{}, SellHeap{}, nil
+    old.checkTopOfBook()
+    old.mu.Unlock()
+
+    target.mu.Lock()
+    defer target.mu.Unlock()
+    defer target.checkTopOfBook()
+    for _, o := range orders {
+        o.Symbol = newSymbol
+        if o.Side == BUY {
+            heap.Push(target.Buys, o)
+        } else {
+            heap.Push(target.Sells, o)
+        }
+    }
+    for _, o := range stops {
+        o.Symbol = newSymbol
+        target.Stops = append(target.Stops, o)
+    }
+    if target.LastPrice == 0 {
+        target.LastPrice = lastPrice
+    }
+    return len(orders) + len(stops)
+}
+
+// adjust rescales and/or renames every active group on symbol and persists it.
+func (m *orderGroupManager) adjust(symbol, newSymbol string, ratio float64) int {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    n := 0
+    for _, group := range m.groups {
+        if group.Symbol != symbol {
+            continue
+        }
+        if newSymbol != "" {
+            group.Symbol = newSymbol
+        }
+        group.Quantity *= ratio
+        for i := range group.Legs {
+            splitLeg(&group.Legs[i], ratio)
+        }
+        m.save(group)
+        n++
+    }
+    return n
+}
+
+func splitLeg(leg *models.OrderGroupLeg, ratio float64) {
+    leg.Quantity *= ratio
+    leg.FilledQuantity *= ratio
+    leg.Price /= ratio
+    leg.StopPrice /= ratio
+}
+
+// adjust rescales and/or renames every running parent order on symbol. Filled
+// slices are restated in post-split terms so progress and averages stay consistent.
+func (s *algoScheduler) adjust(symbol, newSymbol string, ratio float64) int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    n := 0
+    for _, rp := range s.running {
+        p := rp.parent
+        if p.Symbol != symbol {
+            continue
+        }
+        if newSymbol != "" {
+            p.Symbol = newSymbol
+        }
+        p.TotalQuantity *= ratio
+        p.FilledQuantity *= ratio
+        p.LimitPrice /= ratio
+        p.ArrivalPrice /= ratio
+        p.AvgFillPrice /= ratio
+        for i := range p.Children {
+            c := &p.Children[i]
+            c.TargetQuantity *= ratio
+            c.Quantity *= ratio
+            c.FilledQuantity *= ratio
+            c.Price /= ratio
+            c.ExpectedVolume *= ratio
+        }
+        saveParent(p)
+        n++
+    }
+    return n
+}
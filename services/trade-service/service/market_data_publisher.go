@@ -0,0 +1,97 @@
+package service
+
+import (
+    "context"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/internal/middleware"
+    pbMarketData "github.com/ankan8/swapsync/backend/services/market-data-service/proto"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+)
+
+// marketDataEvent is either a trade print or a top-of-book change.
+type marketDataEvent struct {
+    print *pbMarketData.RecordTradeRequest
+    top   *pbMarketData.TopOfBookUpdate
+}
+
+// marketDataPublisher sends prints and book changes to the Market Data Service from
+// one goroutine, so they arrive in the order they happened. Callers never block:
+// when the queue is full the event is dropped and logged.
+type marketDataPublisher struct {
+    once   sync.Once
+    events chan marketDataEvent
+}
+
+var mdPublisher = &marketDataPublisher{events: make(chan marketDataEvent, 4096)}
+
+func init() {
+    onTopOfBookChange = publishTopOfBook
+}
+
+// publishTradePrint queues an execution for the Market Data Service's trade history
+// and internal last-trade price.
+func publishTradePrint(symbol string, quantity, price float64, timestamp, executionID string) {
+    mdPublisher.enqueue(marketDataEvent{print: &pbMarketData.RecordTradeRequest{
+        Symbol:      symbol,
+        Price:       price,
+        Quantity:    quantity,
+        Timestamp:   timestamp,
+        ExecutionId: executionID,
+    }})
+}
+
+// publishTopOfBook queues a change of an order book's best bid/ask.
+func publishTopOfBook(top TopOfBook) {
+    mdPublisher.enqueue(marketDataEvent{top: &pbMarketData.TopOfBookUpdate{
+        Symbol:    top.Symbol,
+        Bid:       top.Bid,
+        BidSize:   top.BidSize,
+        Ask:       top.Ask,
+        AskSize:   top.AskSize,
+        Timestamp: time.Now().Format(time.RFC3339),
+    }})
+}
+
+func (p *marketDataPublisher) enqueue(ev marketDataEvent) {
+    p.once.Do(func() { go p.run() })
+    select {
+    case p.events <- ev:
+    default:
+        log.Printf("Market data publisher queue full; dropping event\n")
+    }
+}
+
+func (p *marketDataPublisher) run() {
+    conn, err := grpc.Dial("localhost:50054", grpc.WithInsecure())
+    if err != nil {
+        log.Printf("Error dialing Market Data Service: %v\n", err)
+        return
+    }
+    defer conn.Close()
+    client := pbMarketData.NewMarketDataServiceClient(conn)
+
+    for ev := range p.events {
+        // Publishing is reserved for services; a fresh token per event never expires mid-queue
+        token, err := middleware.ServiceToken("trade-service")
+        if err != nil {
+            log.Printf("Error signing market data publisher token: %v\n", err)
+            continue
+        }
+        md := metadata.New(map[string]string{"authorization": token})
+        ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), 5*time.Second)
+        if ev.print != nil {
+            if _, err := client.RecordTrade(ctx, ev.print); err != nil {
+                log.Printf("Error recording trade print for %s: %v\n", ev.print.Symbol, err)
+            }
+        } else {
+            if _, err := client.PublishTopOfBook(ctx, ev.top); err != nil {
+                log.Printf("Error publishing top of book for %s: %v\n", ev.top.Symbol, err)
+            }
+        }
+        cancel()
+    }
+}
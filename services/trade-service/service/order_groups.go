@@ -0,0 +1,423 @@
+package service
+
+import (
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
+    "github.com/ankan8/swapsync/backend/services/trade-service/repository"
+
+    "github.com/google/uuid"
+)
+
+const (
+    GroupBracket = "BRACKET"
+    GroupOCO     = "OCO"
+
+    RoleEntry      = "ENTRY"
+    RoleTakeProfit = "TAKE_PROFIT"
+    RoleStopLoss   = "STOP_LOSS"
+
+    LegPending  = "PENDING"
+    LegWorking  = "WORKING"
+    LegFilled   = "FILLED"
+    LegCanceled = "CANCELED"
+
+    GroupActive    = "ACTIVE"
+    GroupCompleted = "COMPLETED"
+    GroupCanceled  = "CANCELED"
+)
+
+// orderGroupManager tracks bracket and OCO groups and applies their activation rules
+// whenever one of their legs fills. Legs live in the symbol's OrderBook.
+//
+// Both group types share one rule for the exit legs: each exit works
+// "exit pool - quantity already exited", where the pool is the filled entry
+// quantity for a bracket and the group quantity for an OCO pair. So a partial
+// fill on one exit shrinks the other, and a full fill cancels it.
+type orderGroupManager struct {
+    mu      sync.Mutex
+    groups  map[string]*models.OrderGroup
+    byOrder map[string]string // order ID -> group ID
+}
+
+var orderGroups = &orderGroupManager{
+    groups:  map[string]*models.OrderGroup{},
+    byOrder: map[string]string{},
+}
+
+// PlaceBracketOrder enters a position with an attached take-profit limit and protective stop.
+// entryPrice <= 0 sends the entry as a market order. The exits only go live once the entry fills.
+func PlaceBracketOrder(userID, symbol string, side OrderSide, quantity, entryPrice, takeProfit, stopLoss float64) (*models.OrderGroup, error) {
+    if quantity <= 0 {
+        return nil, fmt.Errorf("invalid quantity %.2f", quantity)
+    }
+    if takeProfit <= 0 || stopLoss <= 0 {
+        return nil, fmt.Errorf("bracket orders need both a take-profit and a stop-loss price")
+    }
+    exitSide := SELL
+    if side == BUY {
+        if takeProfit <= stopLoss || (entryPrice > 0 && (takeProfit <= entryPrice || stopLoss >= entryPrice)) {
+            return nil, fmt.Errorf("BUY bracket requires stop-loss < entry < take-profit")
+        }
+    } else if side == SELL {
+        exitSide = BUY
+        if takeProfit >= stopLoss || (entryPrice > 0 && (takeProfit >= entryPrice || stopLoss <= entryPrice)) {
+            return nil, fmt.Errorf("SELL bracket requires take-profit < entry < stop-loss")
+        }
+    } else {
+        return nil, fmt.Errorf("invalid side %q", side)
+    }
+
+    entryType := LIMIT
+    if entryPrice <= 0 {
+        entryType = MARKET
+    }
+    group := newOrderGroup(GroupBracket, userID, symbol, quantity, []models.OrderGroupLeg{
+        {Role: RoleEntry, Side: string(side), OrderType: string(entryType), Price: entryPrice, Quantity: quantity, Status: LegPending},
+        {Role: RoleTakeProfit, Side: string(exitSide), OrderType: string(LIMIT), Price: takeProfit, Status: LegPending},
+        {Role: RoleStopLoss, Side: string(exitSide), OrderType: string(STOP), StopPrice: stopLoss, Status: LegPending},
+    })
+    return orderGroups.start(group)
+}
+
+// PlaceOCOOrder places a linked limit and stop on the same side; whichever fills cancels the other.
+func PlaceOCOOrder(userID, symbol string, side OrderSide, quantity, limitPrice, stopPrice float64) (*models.OrderGroup, error) {
+    if quantity <= 0 {
+        return nil, fmt.Errorf("invalid quantity %.2f", quantity)
+    }
+    if limitPrice <= 0 || stopPrice <= 0 {
+        return nil, fmt.Errorf("OCO orders need both a limit and a stop price")
+    }
+    if side != BUY && side != SELL {
+        return nil, fmt.Errorf("invalid side %q", side)
+    }
+
+    group := newOrderGroup(GroupOCO, userID, symbol, quantity, []models.OrderGroupLeg{
+        {Role: RoleTakeProfit, Side: string(side), OrderType: string(LIMIT), Price: limitPrice, Status: LegPending},
+        {Role: RoleStopLoss, Side: string(side), OrderType: string(STOP), StopPrice: stopPrice, Status: LegPending},
+    })
+    return orderGroups.start(group)
+}
+
+// CancelOrderGroup pulls every working leg of the group from the book.
+func CancelOrderGroup(groupID string) (*models.OrderGroup, error) {
+    return orderGroups.cancel(groupID)
+}
+
+// GetOrderGroup returns the live group, falling back to the stored copy.
+func GetOrderGroup(groupID string) (*models.OrderGroup, error) {
+    orderGroups.mu.Lock()
+    group, ok := orderGroups.groups[groupID]
+    if ok {
+        snapshot := copyGroup(group)
+        orderGroups.mu.Unlock()
+        return snapshot, nil
+    }
+    orderGroups.mu.Unlock()
+    return repository.GetOrderGroup(groupID)
+}
+
+// groupOf returns the ID of the active group that owns orderID, if any.
+func (m *orderGroupManager) groupOf(orderID string) (string, bool) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    groupID, ok := m.byOrder[orderID]
+    return groupID, ok
+}
+
+// TriggerStops feeds an external market price to the book's stop orders.
+func TriggerStops(symbol string, price float64) []Fill {
+    return orderGroups.handleFills(symbol, GetOrderBook(symbol).OnMarketPrice(price))
+}
+
+func placeInBook(o InMemoryOrder) []Fill {
+    ob := GetOrderBook(o.Symbol)
+    switch o.OrderType {
+    case MARKET:
+        return ob.PlaceMarketOrder(o)
+    case STOP:
+        return ob.PlaceStopOrder(o)
+    default:
+        return ob.PlaceLimitOrder(o)
+    }
+}
+
+func newOrderGroup(groupType, userID, symbol string, quantity float64, legs []models.OrderGroupLeg) *models.OrderGroup {
+    for i := range legs {
+        legs[i].OrderID = uuid.NewString()
+    }
+    now := time.Now().Format(time.RFC3339)
+    return &models.OrderGroup{
+        GroupID:   uuid.NewString(),
+        GroupType: groupType,
+        UserID:    userID,
+        Symbol:    symbol,
+        Quantity:  quantity,
+        Status:    GroupActive,
+        Legs:      legs,
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+}
+
+// start registers the group, sends its initially active legs to the book and settles any fills.
+func (m *orderGroupManager) start(group *models.OrderGroup) (*models.OrderGroup, error) {
+    m.mu.Lock()
+    m.groups[group.GroupID] = group
+    for _, leg := range group.Legs {
+        m.byOrder[leg.OrderID] = group.GroupID
+    }
+
+    var fills []Fill
+    if group.GroupType == GroupBracket {
+        fills = m.submitLeg(group, 0, group.Quantity)
+    }
+    fills = append(fills, m.rebalanceExits(group)...)
+    m.checkCompletion(group)
+    m.save(group)
+    snapshot := copyGroup(group)
+    m.mu.Unlock()
+
+    settleFills(fills)
+    return snapshot, nil
+}
+
+func (m *orderGroupManager) cancel(groupID string) (*models.OrderGroup, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    group, ok := m.groups[groupID]
+    if !ok {
+        return nil, fmt.Errorf("no active order group %s", groupID)
+    }
+    ob := GetOrderBook(group.Symbol)
+    for i := range group.Legs {
+        leg := &group.Legs[i]
+        if leg.Status == LegWorking || leg.Status == LegPending {
+            ob.CancelOrder(leg.OrderID)
+            leg.Status = LegCanceled
+            leg.Quantity = 0
+        }
+    }
+    m.finish(group, GroupCanceled)
+    return copyGroup(group), nil
+}
+
+// handleFills applies group rules to a batch of fills on symbol's book and
+// returns all fills, including those caused by the resulting leg activations.
+func (m *orderGroupManager) handleFills(symbol string, fills []Fill) []Fill {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.processFills(symbol, fills)
+}
+
+// processFills records a batch of fills (all from one book operation) on the
+// affected legs first, then re-applies each touched group's rules. Recording the
+// whole batch before rebalancing keeps leg state in step with the book when one
+// order fills against several resting orders. Stop legs the book canceled for
+// lack of liquidity are closed too. The caller must hold m.mu.
+func (m *orderGroupManager) processFills(symbol string, fills []Fill) []Fill {
+    var touched []*models.OrderGroup
+    seen := map[string]bool{}
+    touch := func(group *models.OrderGroup) {
+        if !seen[group.GroupID] {
+            seen[group.GroupID] = true
+            touched = append(touched, group)
+        }
+    }
+    for _, f := range fills {
+        for _, orderID := range []string{f.TakerOrderID, f.MakerOrderID} {
+            groupID, ok := m.byOrder[orderID]
+            if !ok {
+                continue
+            }
+            group := m.groups[groupID]
+            recordLegFill(group, orderID, f.Quantity)
+            touch(group)
+        }
+    }
+    if ob, ok := LookupOrderBook(symbol); ok {
+        for _, stop := range ob.TakeDroppedStops() {
+            groupID, ok := m.byOrder[stop.OrderID]
+            if !ok {
+                continue
+            }
+            group := m.groups[groupID]
+            cancelLeg(group, stop.OrderID)
+            touch(group)
+        }
+    }
+
+    all := fills
+    for _, group := range touched {
+        all = append(all, m.rebalanceExits(group)...)
+        m.checkCompletion(group)
+        m.save(group)
+    }
+    return all
+}
+
+// recordLegFill books qty against the leg with the given order ID.
+func recordLegFill(group *models.OrderGroup, orderID string, qty float64) {
+    for i := range group.Legs {
+        leg := &group.Legs[i]
+        if leg.OrderID != orderID {
+            continue
+        }
+        leg.FilledQuantity += qty
+        if leg.Status == LegWorking {
+            leg.Quantity -= qty
+            if leg.Quantity <= 0 {
+                leg.Quantity = 0
+                leg.Status = LegFilled
+            }
+        }
+        log.Printf("[GROUP %s] %s leg %s filled %.2f (total %.2f)\n", group.GroupID, leg.Role, leg.OrderID, qty, leg.FilledQuantity)
+    }
+}
+
+// cancelLeg closes the working leg with the given order ID after the book
+// dropped it.
+func cancelLeg(group *models.OrderGroup, orderID string) {
+    for i := range group.Legs {
+        leg := &group.Legs[i]
+        if leg.OrderID == orderID && leg.Status == LegWorking {
+            log.Printf("[GROUP %s] %s leg %s canceled with %.2f unfilled\n", group.GroupID, leg.Role, leg.OrderID, leg.Quantity)
+            leg.Status = LegCanceled
+            leg.Quantity = 0
+        }
+    }
+}
+
+// openExitQuantity is the exit pool minus what the exit legs have already filled.
+func openExitQuantity(group *models.OrderGroup) float64 {
+    pool := group.Quantity
+    exited := 0.0
+    for _, leg := range group.Legs {
+        if leg.Role == RoleEntry {
+            pool = leg.FilledQuantity
+        } else {
+            exited += leg.FilledQuantity
+        }
+    }
+    return pool - exited
+}
+
+// entryLive reports whether a bracket's entry can still add to the exit pool.
+func entryLive(group *models.OrderGroup) bool {
+    for _, leg := range group.Legs {
+        if leg.Role == RoleEntry {
+            return leg.Status == LegPending || leg.Status == LegWorking
+        }
+    }
+    return false
+}
+
+// rebalanceExits sets every exit leg's working quantity to the open exit quantity,
+// activating, shrinking or canceling legs as needed. The caller must hold m.mu.
+func (m *orderGroupManager) rebalanceExits(group *models.OrderGroup) []Fill {
+    if group.Status != GroupActive {
+        return nil
+    }
+
+    var fills []Fill
+    ob := GetOrderBook(group.Symbol)
+    for i := range group.Legs {
+        leg := &group.Legs[i]
+        if leg.Role == RoleEntry || leg.Status == LegCanceled {
+            continue
+        }
+        // Recomputed per leg: activating one leg may fill it straight away.
+        open := openExitQuantity(group)
+        switch {
+        case open <= 0:
+            if leg.Status == LegWorking {
+                ob.CancelOrder(leg.OrderID)
+                leg.Status = LegCanceled
+                leg.Quantity = 0
+            } else if leg.Status == LegPending && !entryLive(group) {
+                leg.Status = LegCanceled
+            }
+        case leg.Status == LegWorking && ob.ResizeOrder(leg.OrderID, open):
+            leg.Quantity = open
+        default:
+            // Pending, or filled earlier while the bracket entry has since filled further.
+            fills = append(fills, m.submitLeg(group, i, open)...)
+        }
+    }
+    return fills
+}
+
+// submitLeg sends leg i to the book with the given quantity and processes the
+// resulting fills. The caller must hold m.mu.
+func (m *orderGroupManager) submitLeg(group *models.OrderGroup, i int, qty float64) []Fill {
+    leg := &group.Legs[i]
+    leg.Quantity = qty
+    leg.Status = LegWorking
+    order := InMemoryOrder{
+        OrderID:   leg.OrderID,
+        UserID:    group.UserID,
+        Symbol:    group.Symbol,
+        Side:      OrderSide(leg.Side),
+        OrderType: OrderType(leg.OrderType),
+        Quantity:  qty,
+        Price:     leg.Price,
+        StopPrice: leg.StopPrice,
+        Timestamp: time.Now(),
+    }
+    log.Printf("[GROUP %s] activating %s leg %s qty=%.2f\n", group.GroupID, leg.Role, leg.OrderID, qty)
+    fills := placeInBook(order)
+    resting := GetOrderBook(group.Symbol).HasOrder(leg.OrderID)
+    fills = m.processFills(group.Symbol, fills)
+
+    // A market entry drops whatever it couldn't fill; stop waiting for the rest.
+    if !resting && leg.Status == LegWorking {
+        log.Printf("[GROUP %s] %s leg %s left %.2f unfilled\n", group.GroupID, leg.Role, leg.OrderID, leg.Quantity)
+        leg.Status = LegCanceled
+        leg.Quantity = 0
+        fills = append(fills, m.rebalanceExits(group)...)
+        m.checkCompletion(group)
+    }
+    return fills
+}
+
+// checkCompletion closes the group once nothing is left working. The caller must hold m.mu.
+func (m *orderGroupManager) checkCompletion(group *models.OrderGroup) {
+    if group.Status != GroupActive {
+        return
+    }
+    for _, leg := range group.Legs {
+        if leg.Status == LegWorking || leg.Status == LegPending {
+            return
+        }
+    }
+    m.finish(group, GroupCompleted)
+}
+
+// finish marks the group done and stops routing its order IDs. The caller must hold m.mu.
+func (m *orderGroupManager) finish(group *models.OrderGroup, status string) {
+    group.Status = status
+    for _, leg := range group.Legs {
+        delete(m.byOrder, leg.OrderID)
+    }
+    delete(m.groups, group.GroupID)
+    m.save(group)
+    log.Printf("[GROUP %s] %s\n", group.GroupID, status)
+}
+
+// save persists the group; the in-memory copy stays authoritative if the write fails.
+func (m *orderGroupManager) save(group *models.OrderGroup) {
+    group.UpdatedAt = time.Now().Format(time.RFC3339)
+    if err := repository.SaveOrderGroup(group); err != nil {
+        log.Printf("Error saving order group %s: %v\n", group.GroupID, err)
+    }
+}
+
+func copyGroup(group *models.OrderGroup) *models.OrderGroup {
+    snapshot := *group
+    snapshot.Legs = append([]models.OrderGroupLeg(nil), group.Legs...)
+    return &snapshot
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/internal/testmongo"
+)
+
+func TestDroppedStopLegIsCanceled(t *testing.T) {
+    testmongo.Use(t)
+    const symbol = "GROUP-DROPPED-STOP"
+    GetOrderBook(symbol).LastPrice = 90 // already below the stop
+
+    // The stop triggers on entry and finds no bids
+    group, err := PlaceOCOOrder("alice@example.com", symbol, SELL, 10, 120, 95)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, leg := range group.Legs {
+        switch leg.Role {
+        case RoleStopLoss:
+            if leg.Status != LegCanceled || leg.Quantity != 0 {
+                t.Errorf("stop leg %s with %.2f working, want canceled", leg.Status, leg.Quantity)
+            }
+        default:
+            if leg.Status != LegWorking || leg.Quantity != 10 {
+                t.Errorf("limit leg %s with %.2f working, want 10 working", leg.Status, leg.Quantity)
+            }
+        }
+    }
+    if group.Status != GroupActive {
+        t.Fatalf("group %s, want ACTIVE while the limit works", group.Status)
+    }
+    if _, err := CancelOrderGroup(group.GroupID); err != nil {
+        t.Fatal(err)
+    }
+}
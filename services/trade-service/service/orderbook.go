@@ -3,9 +3,10 @@ package service
 import (
     "container/heap"
     "log"
+    "sync"
     "time"
 
-    
+
 )
 
 // Basic order struct
@@ -16,11 +17,20 @@ const (
     SELL OrderSide = "SELL"
 )
 
+// Liquidity flags passed to the Billing Service fee schedule.
+const (
+    LiquidityMaker = "MAKER"
+    LiquidityTaker = "TAKER"
+)
+
 type OrderType string
 
 const (
     MARKET OrderType = "MARKET"
     LIMIT  OrderType = "LIMIT"
+    // STOP rests off-book until the last price reaches StopPrice, then sweeps the
+    // opposite side like a market order. Any remainder is canceled (see TakeDroppedStops).
+    STOP OrderType = "STOP"
 )
 
 type InMemoryOrder struct {
@@ -31,13 +41,35 @@ type InMemoryOrder struct {
     OrderType  OrderType
     Quantity   float64
     Price      float64
+    StopPrice  float64
     Timestamp  time.Time
+    // Quote marks one side of a market maker's two-sided quote (see ReplaceQuote).
+    Quote      bool
+}
+
+// Fill is one execution between an incoming (taker) order and a resting (maker) order.
+type Fill struct {
+    Symbol       string
+    TakerOrderID string
+    TakerUserID  string
+    TakerSide    OrderSide
+    MakerOrderID string
+    MakerUserID  string
+    Quantity     float64
+    Price        float64
+    Timestamp    time.Time
 }
 
 // We store two heaps: one for BUY (max-heap by price), one for SELL (min-heap by price).
+// Orders at the same price keep time priority.
 type BuyHeap []InMemoryOrder
 func (h BuyHeap) Len() int           { return len(h) }
-func (h BuyHeap) Less(i, j int) bool { return h[i].Price > h[j].Price } // highest price first
+func (h BuyHeap) Less(i, j int) bool {
+    if h[i].Price == h[j].Price {
+        return h[i].Timestamp.Before(h[j].Timestamp)
+    }
+    return h[i].Price > h[j].Price // highest price first
+}
 func (h BuyHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
 func (h *BuyHeap) Push(x interface{}) {
     *h = append(*h, x.(InMemoryOrder))
@@ -52,7 +84,12 @@ func (h *BuyHeap) Pop() interface{} {
 
 type SellHeap []InMemoryOrder
 func (h SellHeap) Len() int           { return len(h) }
-func (h SellHeap) Less(i, j int) bool { return h[i].Price < h[j].Price } // lowest price first
+func (h SellHeap) Less(i, j int) bool {
+    if h[i].Price == h[j].Price {
+        return h[i].Timestamp.Before(h[j].Timestamp)
+    }
+    return h[i].Price < h[j].Price // lowest price first
+}
 func (h SellHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
 func (h *SellHeap) Push(x interface{}) {
     *h = append(*h, x.(InMemoryOrder))
@@ -67,11 +104,31 @@ func (h *SellHeap) Pop() interface{} {
 
 // OrderBook for a single symbol
 type OrderBook struct {
-    Symbol string
-    Buys   *BuyHeap
-    Sells  *SellHeap
+    Symbol    string
+    Buys      *BuyHeap
+    Sells     *SellHeap
+    Stops     []InMemoryOrder
+    LastPrice float64
+
+    mu      sync.Mutex
+    lastTop TopOfBook
+    dropped []InMemoryOrder // triggered stops whose remainder found no liquidity
+}
+
+// TopOfBook is the best bid and ask with the total size resting at each. A zero
+// price means that side is empty.
+type TopOfBook struct {
+    Symbol  string
+    Bid     float64
+    BidSize float64
+    Ask     float64
+    AskSize float64
 }
 
+// onTopOfBookChange, when set, is called (under the book lock) whenever a book's
+// touch changes. It must not block or call back into the book.
+var onTopOfBookChange func(TopOfBook)
+
 func NewOrderBook(symbol string) *OrderBook {
     bh := &BuyHeap{}
     sh := &SellHeap{}
@@ -84,105 +141,402 @@ func NewOrderBook(symbol string) *OrderBook {
     }
 }
 
+// orderBooks is the registry of in-memory books, one per symbol.
+var (
+    orderBooksMu sync.Mutex
+    orderBooks   = map[string]*OrderBook{}
+)
+
+// AllOrderBooks returns a snapshot of every book in the registry.
+func AllOrderBooks() []*OrderBook {
+    orderBooksMu.Lock()
+    defer orderBooksMu.Unlock()
+    books := make([]*OrderBook, 0, len(orderBooks))
+    for _, ob := range orderBooks {
+        books = append(books, ob)
+    }
+    return books
+}
+
+// GetOrderBook returns the OrderBook for symbol, creating it on first use.
+func GetOrderBook(symbol string) *OrderBook {
+    orderBooksMu.Lock()
+    defer orderBooksMu.Unlock()
+    ob, ok := orderBooks[symbol]
+    if !ok {
+        ob = NewOrderBook(symbol)
+        orderBooks[symbol] = ob
+        log.Printf("Created a new OrderBook for symbol=%s\n", symbol)
+    }
+    return ob
+}
+
+// LookupOrderBook returns the OrderBook for symbol if one exists, without creating it.
+func LookupOrderBook(symbol string) (*OrderBook, bool) {
+    orderBooksMu.Lock()
+    defer orderBooksMu.Unlock()
+    ob, ok := orderBooks[symbol]
+    return ob, ok
+}
+
 // PlaceMarketOrder matches immediately with the opposite side
-func (ob *OrderBook) PlaceMarketOrder(o InMemoryOrder) {
-    if o.Side == BUY {
-        // Fill against the Sells
-        for o.Quantity > 0 && ob.Sells.Len() > 0 {
-            bestSell := heap.Pop(ob.Sells).(InMemoryOrder)
-            // For a real matching engine, you'd determine the execution price.
-            // Here, we just use bestSell.Price
-            if bestSell.Price > 0 {
-                fillQty := min(o.Quantity, bestSell.Quantity)
-                // record a trade (fill) for fillQty at bestSell.Price
-                log.Printf("[MARKET BUY FILL] %s buys %.2f of %s at %.2f\n", o.UserID, fillQty, o.Symbol, bestSell.Price)
-
-                o.Quantity -= fillQty
-                bestSell.Quantity -= fillQty
-                if bestSell.Quantity > 0 {
-                    // put the partially unfilled order back
-                    heap.Push(ob.Sells, bestSell)
-                }
-            }
+func (ob *OrderBook) PlaceMarketOrder(o InMemoryOrder) []Fill {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+
+    fills := ob.match(&o)
+    if o.Quantity > 0 {
+        // leftover, but market orders typically fill as much as possible
+        log.Printf("[MARKET %s PARTIAL] leftover=%.2f not filled", o.Side, o.Quantity)
+    }
+    return append(fills, ob.triggerStops(ob.LastPrice)...)
+}
+
+// PlaceLimitOrder tries to match if it crosses the opposite side, otherwise rests
+func (ob *OrderBook) PlaceLimitOrder(o InMemoryOrder) []Fill {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+
+    fills := ob.match(&o)
+    // leftover rests in the book
+    if o.Quantity > 0 {
+        ob.rest(o)
+    }
+    return append(fills, ob.triggerStops(ob.LastPrice)...)
+}
+
+// PlaceIOCOrder matches a limit order like PlaceLimitOrder but cancels whatever
+// doesn't fill immediately instead of resting it. Returns the unfilled quantity too.
+func (ob *OrderBook) PlaceIOCOrder(o InMemoryOrder) ([]Fill, float64) {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+
+    fills := ob.match(&o)
+    return append(fills, ob.triggerStops(ob.LastPrice)...), o.Quantity
+}
+
+// PlaceStopOrder parks a stop order until the last price reaches its StopPrice.
+// It triggers immediately if the book has already traded through the stop.
+func (ob *OrderBook) PlaceStopOrder(o InMemoryOrder) []Fill {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+
+    ob.Stops = append(ob.Stops, o)
+    log.Printf("[STOP %s PARKED] user=%s qty=%.2f stop=%.2f\n", o.Side, o.UserID, o.Quantity, o.StopPrice)
+    return ob.triggerStops(ob.LastPrice)
+}
+
+// OnMarketPrice lets an external price (e.g. from the Market Data Service) trigger stops.
+func (ob *OrderBook) OnMarketPrice(price float64) []Fill {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+    return ob.triggerStops(price)
+}
+
+// ReplaceQuote atomically pulls userID's resting quote orders and enters the new
+// bid and/or ask (nil leaves that side empty). Either side may trade on entry.
+func (ob *OrderBook) ReplaceQuote(userID string, bid, ask *InMemoryOrder) []Fill {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+
+    var stale []string
+    for _, o := range *ob.Buys {
+        if o.UserID == userID && o.Quote {
+            stale = append(stale, o.OrderID)
         }
-        if o.Quantity > 0 {
-            // leftover, but market orders typically fill as much as possible
-            log.Printf("[MARKET BUY PARTIAL] leftover=%.2f not filled", o.Quantity)
+    }
+    for _, o := range *ob.Sells {
+        if o.UserID == userID && o.Quote {
+            stale = append(stale, o.OrderID)
         }
-    } else {
-        // SELL market => fill against Buys
-        for o.Quantity > 0 && ob.Buys.Len() > 0 {
-            bestBuy := heap.Pop(ob.Buys).(InMemoryOrder)
-            if bestBuy.Price > 0 {
-                fillQty := min(o.Quantity, bestBuy.Quantity)
-                // record a trade (fill) for fillQty at bestBuy.Price
-                log.Printf("[MARKET SELL FILL] %s sells %.2f of %s at %.2f\n", o.UserID, fillQty, o.Symbol, bestBuy.Price)
-
-                o.Quantity -= fillQty
-                bestBuy.Quantity -= fillQty
-                if bestBuy.Quantity > 0 {
-                    heap.Push(ob.Buys, bestBuy)
-                }
+    }
+    for _, id := range stale {
+        ob.remove(id)
+    }
+    log.Printf("[QUOTE] user=%s pulled %d resting quote orders\n", userID, len(stale))
+
+    var fills []Fill
+    for _, o := range []*InMemoryOrder{bid, ask} {
+        if o == nil {
+            continue
+        }
+        q := *o
+        q.OrderType = LIMIT
+        q.Quote = true
+        fills = append(fills, ob.match(&q)...)
+        if q.Quantity > 0 {
+            ob.rest(q)
+        }
+    }
+    return append(fills, ob.triggerStops(ob.LastPrice)...)
+}
+
+// CancelUserOrders removes every resting and parked order of userID on the given
+// side ("" for both) and returns them.
+func (ob *OrderBook) CancelUserOrders(userID string, side OrderSide) []InMemoryOrder {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+
+    var ids []string
+    collect := func(o InMemoryOrder) {
+        if o.UserID == userID && (side == "" || o.Side == side) {
+            ids = append(ids, o.OrderID)
+        }
+    }
+    for _, o := range *ob.Buys {
+        collect(o)
+    }
+    for _, o := range *ob.Sells {
+        collect(o)
+    }
+    for _, o := range ob.Stops {
+        collect(o)
+    }
+
+    var canceled []InMemoryOrder
+    for _, id := range ids {
+        if o, ok := ob.remove(id); ok {
+            canceled = append(canceled, o)
+        }
+    }
+    return canceled
+}
+
+// CancelOrder removes a resting or parked order and returns it.
+func (ob *OrderBook) CancelOrder(orderID string) (InMemoryOrder, bool) {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+    return ob.remove(orderID)
+}
+
+// ResizeOrder sets the remaining quantity of a resting or parked order.
+// A quantity <= 0 cancels it. Returns false if the order is not in the book.
+func (ob *OrderBook) ResizeOrder(orderID string, quantity float64) bool {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    defer ob.checkTopOfBook()
+
+    if quantity <= 0 {
+        _, ok := ob.remove(orderID)
+        return ok
+    }
+    for i := range *ob.Buys {
+        if (*ob.Buys)[i].OrderID == orderID {
+            (*ob.Buys)[i].Quantity = quantity
+            return true
+        }
+    }
+    for i := range *ob.Sells {
+        if (*ob.Sells)[i].OrderID == orderID {
+            (*ob.Sells)[i].Quantity = quantity
+            return true
+        }
+    }
+    for i := range ob.Stops {
+        if ob.Stops[i].OrderID == orderID {
+            ob.Stops[i].Quantity = quantity
+            return true
+        }
+    }
+    return false
+}
+
+// HasOrder reports whether the order is still resting or parked in the book.
+func (ob *OrderBook) HasOrder(orderID string) bool {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+
+    for _, o := range *ob.Buys {
+        if o.OrderID == orderID {
+            return true
+        }
+    }
+    for _, o := range *ob.Sells {
+        if o.OrderID == orderID {
+            return true
+        }
+    }
+    for _, o := range ob.Stops {
+        if o.OrderID == orderID {
+            return true
+        }
+    }
+    return false
+}
+
+// checkTopOfBook reports the touch if it changed since the last call. The caller must hold ob.mu.
+func (ob *OrderBook) checkTopOfBook() {
+    top := TopOfBook{Symbol: ob.Symbol}
+    if ob.Buys.Len() > 0 {
+        top.Bid = (*ob.Buys)[0].Price
+        for _, o := range *ob.Buys {
+            if o.Price == top.Bid {
+                top.BidSize += o.Quantity
             }
         }
-        if o.Quantity > 0 {
-            log.Printf("[MARKET SELL PARTIAL] leftover=%.2f not filled", o.Quantity)
+    }
+    if ob.Sells.Len() > 0 {
+        top.Ask = (*ob.Sells)[0].Price
+        for _, o := range *ob.Sells {
+            if o.Price == top.Ask {
+                top.AskSize += o.Quantity
+            }
         }
     }
+    if top == ob.lastTop {
+        return
+    }
+    ob.lastTop = top
+    if onTopOfBookChange != nil {
+        onTopOfBookChange(top)
+    }
 }
 
-// PlaceLimitOrder tries to match if it crosses the opposite side, otherwise rests
-func (ob *OrderBook) PlaceLimitOrder(o InMemoryOrder) {
-    if o.Side == BUY {
-        // Check if we can match with best SELL
-        for o.Quantity > 0 && ob.Sells.Len() > 0 {
-            bestSell := heap.Pop(ob.Sells).(InMemoryOrder)
-            // If bestSell.Price <= o.Price => cross
-            if bestSell.Price <= o.Price {
-                fillQty := min(o.Quantity, bestSell.Quantity)
-                log.Printf("[LIMIT BUY FILL] %s buys %.2f of %s at %.2f\n", o.UserID, fillQty, o.Symbol, bestSell.Price)
-
-                o.Quantity -= fillQty
-                bestSell.Quantity -= fillQty
-                if bestSell.Quantity > 0 {
-                    heap.Push(ob.Sells, bestSell)
-                    break
-                }
-            } else {
-                // no cross, push bestSell back
-                heap.Push(ob.Sells, bestSell)
+// match fills o against the opposite side for as long as it crosses.
+// MARKET (and triggered STOP) orders cross at any price; LIMIT orders only up to o.Price.
+// The caller must hold ob.mu.
+func (ob *OrderBook) match(o *InMemoryOrder) []Fill {
+    var fills []Fill
+    for o.Quantity > 0 {
+        var best InMemoryOrder
+        if o.Side == BUY {
+            if ob.Sells.Len() == 0 {
+                break
+            }
+            best = (*ob.Sells)[0]
+            // If bestSell.Price > o.Price => no cross
+            if o.OrderType == LIMIT && best.Price > o.Price {
+                break
+            }
+            heap.Pop(ob.Sells)
+        } else {
+            if ob.Buys.Len() == 0 {
+                break
+            }
+            best = (*ob.Buys)[0]
+            if o.OrderType == LIMIT && best.Price < o.Price {
                 break
             }
+            heap.Pop(ob.Buys)
         }
-        // leftover rests in the BuyHeap
-        if o.Quantity > 0 {
-            heap.Push(ob.Buys, o)
-            log.Printf("[LIMIT BUY REST] user=%s leftover=%.2f at price=%.2f\n", o.UserID, o.Quantity, o.Price)
+
+        // The resting order sets the execution price.
+        fillQty := min(o.Quantity, best.Quantity)
+        if o.Side == BUY {
+            log.Printf("[%s BUY FILL] %s buys %.2f of %s at %.2f\n", o.OrderType, o.UserID, fillQty, ob.Symbol, best.Price)
+        } else {
+            log.Printf("[%s SELL FILL] %s sells %.2f of %s at %.2f\n", o.OrderType, o.UserID, fillQty, ob.Symbol, best.Price)
         }
+        fills = append(fills, Fill{
+            Symbol:       ob.Symbol,
+            TakerOrderID: o.OrderID,
+            TakerUserID:  o.UserID,
+            TakerSide:    o.Side,
+            MakerOrderID: best.OrderID,
+            MakerUserID:  best.UserID,
+            Quantity:     fillQty,
+            Price:        best.Price,
+            Timestamp:    time.Now(),
+        })
+        ob.LastPrice = best.Price
+
+        o.Quantity -= fillQty
+        best.Quantity -= fillQty
+        if best.Quantity > 0 {
+            // put the partially unfilled order back
+            ob.rest(best)
+        }
+    }
+    return fills
+}
+
+// rest puts a limit order on its side of the book. The caller must hold ob.mu.
+func (ob *OrderBook) rest(o InMemoryOrder) {
+    if o.Side == BUY {
+        heap.Push(ob.Buys, o)
     } else {
-        // SELL side
-        for o.Quantity > 0 && ob.Buys.Len() > 0 {
-            bestBuy := heap.Pop(ob.Buys).(InMemoryOrder)
-            if bestBuy.Price >= o.Price {
-                fillQty := min(o.Quantity, bestBuy.Quantity)
-                log.Printf("[LIMIT SELL FILL] %s sells %.2f of %s at %.2f\n", o.UserID, fillQty, o.Symbol, bestBuy.Price)
-
-                o.Quantity -= fillQty
-                bestBuy.Quantity -= fillQty
-                if bestBuy.Quantity > 0 {
-                    heap.Push(ob.Buys, bestBuy)
-                    break
-                }
-            } else {
-                heap.Push(ob.Buys, bestBuy)
+        heap.Push(ob.Sells, o)
+    }
+    log.Printf("[LIMIT %s REST] user=%s leftover=%.2f at price=%.2f\n", o.Side, o.UserID, o.Quantity, o.Price)
+}
+
+// remove deletes an order from whichever side holds it. The caller must hold ob.mu.
+func (ob *OrderBook) remove(orderID string) (InMemoryOrder, bool) {
+    for i, o := range *ob.Buys {
+        if o.OrderID == orderID {
+            heap.Remove(ob.Buys, i)
+            return o, true
+        }
+    }
+    for i, o := range *ob.Sells {
+        if o.OrderID == orderID {
+            heap.Remove(ob.Sells, i)
+            return o, true
+        }
+    }
+    for i, o := range ob.Stops {
+        if o.OrderID == orderID {
+            ob.Stops = append(ob.Stops[:i], ob.Stops[i+1:]...)
+            return o, true
+        }
+    }
+    return InMemoryOrder{}, false
+}
+
+// triggerStops fires every parked stop that price has reached. Fills from a
+// triggered stop move the price, which may in turn trigger further stops; a
+// stop that doesn't trade leaves the price where it was.
+// The caller must hold ob.mu.
+func (ob *OrderBook) triggerStops(price float64) []Fill {
+    var fills []Fill
+    for price > 0 {
+        idx := -1
+        for i, s := range ob.Stops {
+            if (s.Side == BUY && price >= s.StopPrice) || (s.Side == SELL && price <= s.StopPrice) {
+                idx = i
                 break
             }
         }
-        if o.Quantity > 0 {
-            heap.Push(ob.Sells, o)
-            log.Printf("[LIMIT SELL REST] user=%s leftover=%.2f at price=%.2f\n", o.UserID, o.Quantity, o.Price)
+        if idx < 0 {
+            break
+        }
+        stop := ob.Stops[idx]
+        ob.Stops = append(ob.Stops[:idx], ob.Stops[idx+1:]...)
+        log.Printf("[STOP %s TRIGGERED] user=%s stop=%.2f last=%.2f\n", stop.Side, stop.UserID, stop.StopPrice, price)
+
+        stop.OrderType = MARKET
+        stopFills := ob.match(&stop)
+        fills = append(fills, stopFills...)
+        if stop.Quantity > 0 {
+            // A market order doesn't rest: resting at the stop price would turn
+            // it into a limit the market has already moved through
+            log.Printf("[STOP %s CANCELED] user=%s leftover=%.2f found no liquidity\n", stop.Side, stop.UserID, stop.Quantity)
+            ob.dropped = append(ob.dropped, stop)
+        }
+        if len(stopFills) > 0 {
+            // Later stops see the price this one traded at
+            price = stopFills[len(stopFills)-1].Price
         }
     }
+    return fills
+}
+
+// TakeDroppedStops returns the triggered stops whose unfilled remainder was
+// canceled since the last call, with Quantity set to what went unfilled.
+func (ob *OrderBook) TakeDroppedStops() []InMemoryOrder {
+    ob.mu.Lock()
+    defer ob.mu.Unlock()
+    dropped := ob.dropped
+    ob.dropped = nil
+    return dropped
 }
 
 // Helper
@@ -0,0 +1,77 @@
+package service
+
+import (
+    "testing"
+    "time"
+)
+
+func parkedStop(id string, side OrderSide, stop, qty float64) InMemoryOrder {
+    return InMemoryOrder{OrderID: id, UserID: "alice@example.com", Side: side, OrderType: STOP, StopPrice: stop, Quantity: qty, Timestamp: time.Now()}
+}
+
+func hasStop(ob *OrderBook, id string) bool {
+    for _, s := range ob.Stops {
+        if s.OrderID == id {
+            return true
+        }
+    }
+    return false
+}
+
+func TestUnfilledStopDoesNotCascadeOnStalePrice(t *testing.T) {
+    ob := NewOrderBook("STOPS-STALE")
+    ob.LastPrice = 85 // an old print, below both stops
+    ob.Stops = []InMemoryOrder{parkedStop("a", SELL, 95, 1), parkedStop("b", SELL, 90, 1)}
+
+    if fills := ob.OnMarketPrice(95); len(fills) != 0 {
+        t.Fatalf("%d fills on an empty book", len(fills))
+    }
+    if !hasStop(ob, "b") {
+        t.Fatal("stop at 90 triggered by a market at 95")
+    }
+    dropped := ob.TakeDroppedStops()
+    if len(dropped) != 1 || dropped[0].OrderID != "a" || dropped[0].Quantity != 1 {
+        t.Fatalf("dropped = %+v, want stop a with 1 unfilled", dropped)
+    }
+    if ob.HasOrder("a") {
+        t.Fatal("the triggered stop rests in the book")
+    }
+}
+
+func TestStopCascadesFromFillPrice(t *testing.T) {
+    ob := NewOrderBook("STOPS-CASCADE")
+    ob.LastPrice = 100
+    ob.rest(InMemoryOrder{OrderID: "bid", UserID: "bob@example.com", Side: BUY, OrderType: LIMIT, Price: 89, Quantity: 1, Timestamp: time.Now()})
+    ob.Stops = []InMemoryOrder{parkedStop("a", SELL, 95, 1), parkedStop("b", SELL, 90, 1)}
+
+    fills := ob.OnMarketPrice(95)
+    if len(fills) != 1 || fills[0].TakerOrderID != "a" || fills[0].Price != 89 {
+        t.Fatalf("fills = %+v, want stop a filled at 89", fills)
+    }
+    // a traded at 89, which reaches b's stop; nothing is left to fill it
+    if hasStop(ob, "b") {
+        t.Fatal("stop at 90 not triggered by the fill at 89")
+    }
+    if dropped := ob.TakeDroppedStops(); len(dropped) != 1 || dropped[0].OrderID != "b" {
+        t.Fatalf("dropped = %+v, want stop b", dropped)
+    }
+}
+
+func TestStopMarketOnEmptyBookIsCanceled(t *testing.T) {
+    ob := NewOrderBook("STOPS-EMPTY")
+    ob.LastPrice = 100
+
+    // Already through the stop, so it triggers on entry
+    if fills := ob.PlaceStopOrder(parkedStop("s", BUY, 99, 5)); len(fills) != 0 {
+        t.Fatalf("%d fills on an empty book", len(fills))
+    }
+    if ob.HasOrder("s") || ob.Buys.Len() != 0 {
+        t.Fatal("the unfillable stop was left resting")
+    }
+    if dropped := ob.TakeDroppedStops(); len(dropped) != 1 || dropped[0].Quantity != 5 {
+        t.Fatalf("dropped = %+v, want the whole stop", dropped)
+    }
+    if dropped := ob.TakeDroppedStops(); len(dropped) != 0 {
+        t.Fatalf("dropped stops reported twice: %+v", dropped)
+    }
+}
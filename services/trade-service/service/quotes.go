@@ -0,0 +1,142 @@
+package service
+
+import (
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// SubmitQuote atomically replaces userID's two-sided quote on symbol. A side with
+// size 0 is pulled without replacement. Returns the new order IDs ("" for an empty side).
+func SubmitQuote(userID, symbol string, bidPrice, bidSize, askPrice, askSize float64) (string, string, []Fill, error) {
+    if userID == "" || symbol == "" {
+        return "", "", nil, fmt.Errorf("user_id and symbol are required")
+    }
+    if bidSize < 0 || askSize < 0 {
+        return "", "", nil, fmt.Errorf("quote sizes must not be negative")
+    }
+    if (bidSize > 0 && bidPrice <= 0) || (askSize > 0 && askPrice <= 0) {
+        return "", "", nil, fmt.Errorf("quoted sides need a positive price")
+    }
+    if bidSize > 0 && askSize > 0 && bidPrice >= askPrice {
+        return "", "", nil, fmt.Errorf("crossed quote: bid %.2f >= ask %.2f", bidPrice, askPrice)
+    }
+
+    now := time.Now()
+    var bid, ask *InMemoryOrder
+    if bidSize > 0 {
+        bid = &InMemoryOrder{OrderID: uuid.NewString(), UserID: userID, Symbol: symbol, Side: BUY,
+            Quantity: bidSize, Price: bidPrice, Timestamp: now}
+    }
+    if askSize > 0 {
+        ask = &InMemoryOrder{OrderID: uuid.NewString(), UserID: userID, Symbol: symbol, Side: SELL,
+            Quantity: askSize, Price: askPrice, Timestamp: now}
+    }
+
+    fills := orderGroups.handleFills(symbol, GetOrderBook(symbol).ReplaceQuote(userID, bid, ask))
+    settleFills(fills)
+
+    var bidID, askID string
+    if bid != nil {
+        bidID = bid.OrderID
+    }
+    if ask != nil {
+        askID = ask.OrderID
+    }
+    log.Printf("[QUOTE] user=%s %s %.2f x %.2f / %.2f x %.2f (%d fills)\n",
+        userID, symbol, bidSize, bidPrice, askPrice, askSize, len(fills))
+    return bidID, askID, fills, nil
+}
+
+// MassCancel pulls every resting and parked order of userID, optionally limited to one
+// symbol and/or side. Orders that belong to a bracket/OCO group cancel the whole group.
+func MassCancel(userID, symbol string, side OrderSide) []InMemoryOrder {
+    books := AllOrderBooks()
+    if symbol != "" {
+        // A symbol without a book has nothing to cancel; don't create one
+        books = nil
+        if ob, ok := LookupOrderBook(symbol); ok {
+            books = []*OrderBook{ob}
+        }
+    }
+
+    var canceled []InMemoryOrder
+    for _, ob := range books {
+        canceled = append(canceled, ob.CancelUserOrders(userID, side)...)
+    }
+
+    groups := map[string]bool{}
+    for _, o := range canceled {
+        if groupID, ok := orderGroups.groupOf(o.OrderID); ok && !groups[groupID] {
+            groups[groupID] = true
+            if _, err := CancelOrderGroup(groupID); err != nil {
+                log.Printf("Error canceling order group %s: %v\n", groupID, err)
+            }
+        }
+    }
+    log.Printf("[MASS CANCEL] user=%s symbol=%q side=%q canceled=%d groups=%d\n",
+        userID, symbol, side, len(canceled), len(groups))
+    return canceled
+}
+
+// DeadMansSwitch cancels all of a user's orders unless Beat is called at least once
+// per timeout. It backs the cancel-on-disconnect option of quote sessions.
+type DeadMansSwitch struct {
+    userID  string
+    timeout time.Duration
+
+    mu     sync.Mutex
+    timer  *time.Timer
+    fired  bool
+    onFire func(canceled int)
+}
+
+// NewDeadMansSwitch arms a switch for userID. onFire (optional) runs after the
+// orders have been pulled.
+func NewDeadMansSwitch(userID string, timeout time.Duration, onFire func(canceled int)) *DeadMansSwitch {
+    d := &DeadMansSwitch{userID: userID, timeout: timeout, onFire: onFire}
+    d.timer = time.AfterFunc(timeout, func() {
+        log.Printf("[CANCEL ON DISCONNECT] user=%s missed heartbeats for %v\n", userID, timeout)
+        d.Fire()
+    })
+    return d
+}
+
+// Beat resets the timeout. It returns false if the switch has already fired.
+func (d *DeadMansSwitch) Beat() bool {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    if d.fired {
+        return false
+    }
+    d.timer.Reset(d.timeout)
+    return true
+}
+
+// Fire cancels the user's orders now (e.g. when the stream drops). Safe to call more than once.
+func (d *DeadMansSwitch) Fire() {
+    d.mu.Lock()
+    if d.fired {
+        d.mu.Unlock()
+        return
+    }
+    d.fired = true
+    d.timer.Stop()
+    d.mu.Unlock()
+
+    canceled := MassCancel(d.userID, "", "")
+    if d.onFire != nil {
+        d.onFire(len(canceled))
+    }
+}
+
+// Disarm stops the switch without canceling anything (clean logout).
+func (d *DeadMansSwitch) Disarm() {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.fired = true
+    d.timer.Stop()
+}
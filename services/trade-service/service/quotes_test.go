@@ -0,0 +1,13 @@
+package service
+
+import "testing"
+
+func TestMassCancelDoesNotCreateBooks(t *testing.T) {
+    const symbol = "MASSCANCEL-NOBOOK"
+    if canceled := MassCancel("alice@example.com", symbol, ""); len(canceled) != 0 {
+        t.Fatalf("canceled %d orders on a symbol with no book", len(canceled))
+    }
+    if _, ok := LookupOrderBook(symbol); ok {
+        t.Fatalf("MassCancel created a book for %s", symbol)
+    }
+}
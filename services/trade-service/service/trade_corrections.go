@@ -0,0 +1,364 @@
+package service
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
+    "github.com/ankan8/swapsync/backend/services/trade-service/repository"
+
+    pbBilling "github.com/ankan8/swapsync/backend/services/billing-service/proto"
+
+    "github.com/google/uuid"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+)
+
+const (
+    TradeBusted  = "BUSTED"
+    TradeAmended = "AMENDED"
+
+    CorrectionBust  = "BUST"
+    CorrectionAmend = "AMEND"
+)
+
+// BustTrade cancels an erroneous trade. Both sides of the execution are busted:
+// the BUY side gets its trade cost back, every side's holdings are reversed and
+// its commission refunded, and a SELL that repaid a margin loan puts it back.
+func BustTrade(tradeID, reason, admin, token string) (*models.TradeAudit, error) {
+    return correctTrade(CorrectionBust, tradeID, 0, 0, reason, admin, token)
+}
+
+// AmendTrade corrects the price and/or quantity of a trade (0 keeps the current value)
+// and adjusts wallets and holdings of both sides by the difference.
+func AmendTrade(tradeID string, newPrice, newQuantity float64, reason, admin, token string) (*models.TradeAudit, error) {
+    if newPrice < 0 || newQuantity < 0 {
+        return nil, fmt.Errorf("amended price and quantity must not be negative")
+    }
+    return correctTrade(CorrectionAmend, tradeID, newPrice, newQuantity, reason, admin, token)
+}
+
+// Corrections move cash, shares and commission through these; tests replace them.
+var (
+    correctionWallet   = adjustWallet
+    correctionHoldings = updatePortfolioHoldings
+    correctionRefund   = refundCommission
+    correctionCharge   = callBillingService
+
+    recordCommission = repository.SetTradeCommission
+)
+
+// sideCorrection is one side of an execution moving from before to after.
+type sideCorrection struct {
+    before, after models.TradeRecord
+    adj           models.TradeAdjustment
+}
+
+// correctTrade applies a bust or amend to every side of the execution, then stores an audit record.
+// What the correction takes from users (cash, shares) is taken from every side
+// before any trade record changes, like settleFills reserves both legs: if one
+// side can't pay, the others get theirs back and nothing is corrected. What is
+// owed to users afterwards is paid per side, and failures are recorded in the audit.
+func correctTrade(action, tradeID string, newPrice, newQuantity float64, reason, admin, token string) (*models.TradeAudit, error) {
+    if reason == "" {
+        return nil, fmt.Errorf("a reason is required to correct a trade")
+    }
+
+    // 1) Load the trade and its counterparty side(s)
+    trade, err := repository.GetTradeByID(tradeID)
+    if err != nil {
+        return nil, fmt.Errorf("trade %s not found: %v", tradeID, err)
+    }
+    if trade.Status == TradeBusted {
+        return nil, fmt.Errorf("trade %s is already busted", tradeID)
+    }
+    sides := []models.TradeRecord{*trade}
+    if trade.ExecutionID != "" {
+        if linked, err := repository.GetTradesByExecutionID(trade.ExecutionID); err == nil && len(linked) > 0 {
+            sides = linked
+        }
+    }
+
+    audit := &models.TradeAudit{
+        AuditID:     uuid.NewString(),
+        Action:      action,
+        TradeID:     tradeID,
+        ExecutionID: trade.ExecutionID,
+        Reason:      reason,
+        Admin:       admin,
+        Timestamp:   time.Now().Format(time.RFC3339),
+    }
+    var skipped []string
+
+    // 2) Work out each side's correction and take its debits.
+    var corrections []*sideCorrection
+    for _, side := range sides {
+        if side.Status == TradeBusted {
+            skipped = append(skipped, fmt.Sprintf("%s: already busted", side.TradeID))
+            continue
+        }
+        after := side
+        if action == CorrectionBust {
+            after.Status = TradeBusted
+        } else {
+            after.Status = TradeAmended
+            if newPrice > 0 {
+                after.Price = newPrice
+            }
+            if newQuantity > 0 {
+                after.Quantity = newQuantity
+            }
+            after.SettlementAmount = after.Price * after.Quantity * side.SettlementRate()
+        }
+        c := &sideCorrection{before: side, after: after,
+            adj: models.TradeAdjustment{TradeID: side.TradeID, UserID: side.UserID}}
+        c.adj.WalletDelta, c.adj.HoldingsDelta = correctionDeltas(side, after)
+        corrections = append(corrections, c)
+    }
+    if err := reserveCorrections(corrections, token); err != nil {
+        return nil, fmt.Errorf("trade %s was not corrected: %v", tradeID, err)
+    }
+
+    // 3) Update each record, only if nobody corrected it in the meantime, so a
+    // side is never reversed twice. A side that lost the race gets its debits back.
+    for _, c := range corrections {
+        won, err := repository.UpdateTradeCorrection(c.before, c.after.Price, c.after.Quantity, c.after.SettlementAmount, c.after.Status)
+        if err != nil || !won {
+            if err == nil {
+                err = fmt.Errorf("trade changed by a concurrent correction")
+            }
+            log.Printf("Trade correction for %s/%s skipped: %v\n", c.before.TradeID, c.before.UserID, err)
+            skipped = append(skipped, fmt.Sprintf("%s: %v", c.before.TradeID, err))
+            c.release(token)
+            continue
+        }
+        c.complete(token)
+
+        audit.Before = append(audit.Before, c.before)
+        audit.After = append(audit.After, c.after)
+        audit.Adjustments = append(audit.Adjustments, c.adj)
+
+        // 4) Tell the counterparty what happened
+        side, after := c.before, c.after
+        if action == CorrectionBust {
+            notifyUser(side.UserID, fmt.Sprintf("Your %s trade of %.2f %s at %.2f was busted: %s",
+                side.OrderType, side.Quantity, side.Symbol, side.Price, reason))
+        } else {
+            notifyUser(side.UserID, fmt.Sprintf("Your %s trade of %s was amended from %.2f @ %.2f to %.2f @ %.2f: %s",
+                side.OrderType, side.Symbol, side.Quantity, side.Price, after.Quantity, after.Price, reason))
+        }
+    }
+
+    if len(audit.Adjustments) == 0 {
+        return nil, fmt.Errorf("trade %s was not corrected: %s", tradeID, strings.Join(skipped, "; "))
+    }
+
+    // 5) Keep the audit trail
+    if err := repository.InsertTradeAudit(audit); err != nil {
+        return audit, fmt.Errorf("correction applied but audit record failed: %v", err)
+    }
+    log.Printf("[TRADE %s] %s by %s (%d sides): %s\n", action, tradeID, admin, len(sides), reason)
+    return audit, nil
+}
+
+// reserveCorrections takes the debits of every side. If one fails, the sides
+// already reserved are released and the error says which side couldn't pay.
+func reserveCorrections(corrections []*sideCorrection, token string) error {
+    for i, c := range corrections {
+        if err := c.reserve(token); err != nil {
+            for _, done := range corrections[:i] {
+                done.release(token)
+            }
+            return fmt.Errorf("%s (user %s): %v", c.before.TradeID, c.before.UserID, err)
+        }
+    }
+    return nil
+}
+
+// holdingsPrice is the price holdings move at: a bust reverses at the original price.
+func (c *sideCorrection) holdingsPrice() float64 {
+    if c.after.Status == TradeBusted {
+        return c.before.Price
+    }
+    return c.after.Price
+}
+
+// reserve takes what the correction debits: cash for a negative wallet delta,
+// shares for a negative holdings delta. Nothing stays taken if either fails.
+func (c *sideCorrection) reserve(token string) error {
+    if c.adj.WalletDelta < 0 {
+        if err := correctionWallet(c.before.UserID, c.before.TradeID, c.adj.WalletDelta, token); err != nil {
+            return err
+        }
+    }
+    if c.adj.HoldingsDelta < 0 {
+        if err := correctionHoldings(c.before.UserID, c.before.Symbol, c.adj.HoldingsDelta, c.holdingsPrice(), token); err != nil {
+            if c.adj.WalletDelta < 0 {
+                if err := correctionWallet(c.before.UserID, c.before.TradeID, -c.adj.WalletDelta, token); err != nil {
+                    log.Printf("Failed to give back %.2f to %s after a failed correction of %s: %v\n",
+                        -c.adj.WalletDelta, c.before.UserID, c.before.TradeID, err)
+                }
+            }
+            return err
+        }
+    }
+    return nil
+}
+
+// release gives back what reserve took.
+func (c *sideCorrection) release(token string) {
+    if c.adj.WalletDelta < 0 {
+        if err := correctionWallet(c.before.UserID, c.before.TradeID, -c.adj.WalletDelta, token); err != nil {
+            log.Printf("Failed to give back %.2f to %s after a failed correction of %s: %v\n",
+                -c.adj.WalletDelta, c.before.UserID, c.before.TradeID, err)
+        }
+    }
+    if c.adj.HoldingsDelta < 0 {
+        if err := correctionHoldings(c.before.UserID, c.before.Symbol, -c.adj.HoldingsDelta, c.holdingsPrice(), token); err != nil {
+            log.Printf("Failed to give back %.2f %s to %s after a failed correction of %s: %v\n",
+                -c.adj.HoldingsDelta, c.before.Symbol, c.before.UserID, c.before.TradeID, err)
+        }
+    }
+}
+
+// complete pays what the correction owes the user once the record is updated.
+// Wallet adjustments are in the wallet currency at the trade's original FX rate: a BUY
+// gets back what it overpaid, a SELL pays in or gives up the change in proceeds.
+// A bust also refunds the commission and, for a sale, takes back the proceeds
+// paid into the wallet and puts back any margin loan they repaid. An amend
+// re-prices the commission at the new amount.
+func (c *sideCorrection) complete(token string) {
+    before, after, adj := c.before, c.after, &c.adj
+
+    if adj.WalletDelta > 0 {
+        if err := correctionWallet(before.UserID, before.TradeID, adj.WalletDelta, token); err != nil {
+            adj.Error = joinErr(adj.Error, err.Error())
+        }
+    }
+    if before.OrderType == "SELL" && adj.WalletDelta != 0 && after.Status != TradeBusted && adj.Error == "" {
+        if err := repository.AddTradeProceedsCredited(before.TradeID, adj.WalletDelta); err != nil {
+            log.Printf("Failed to record proceeds change of %.2f on trade %s: %v\n", adj.WalletDelta, before.TradeID, err)
+        }
+    }
+    if adj.HoldingsDelta > 0 {
+        if err := correctionHoldings(before.UserID, before.Symbol, adj.HoldingsDelta, c.holdingsPrice(), token); err != nil {
+            adj.Error = joinErr(adj.Error, err.Error())
+        }
+    }
+    if after.Status == TradeBusted {
+        if before.CommissionTxID != "" {
+            if err := correctionRefund(before.CommissionTxID, token); err != nil {
+                adj.Error = joinErr(adj.Error, err.Error())
+            } else {
+                adj.CommissionRefund = before.Commission
+            }
+        }
+        if before.MarginRepaid > 0 {
+            if err := restoreMarginLoan(before.UserID, before.MarginRepaid, before.TradeID, token); err != nil {
+                adj.Error = joinErr(adj.Error, err.Error())
+            } else {
+                adj.MarginRestored = before.MarginRepaid
+            }
+        }
+    } else if after.SettlementAmount != before.SettlementAmount {
+        if err := c.recomputeCommission(token); err != nil {
+            adj.Error = joinErr(adj.Error, err.Error())
+        }
+    }
+    if adj.Error != "" {
+        log.Printf("Trade correction for %s/%s incomplete: %s\n", before.TradeID, before.UserID, adj.Error)
+    }
+}
+
+// recomputeCommission refunds the commission paid on an amended side and charges
+// it again on the amended amount, so the trade carries one payment a later bust
+// can refund. If the new charge fails the trade is left without a commission.
+func (c *sideCorrection) recomputeCommission(token string) error {
+    before, adj := c.before, &c.adj
+    if before.CommissionTxID != "" {
+        if err := correctionRefund(before.CommissionTxID, token); err != nil {
+            return err
+        }
+        adj.CommissionRefund = before.Commission
+    }
+    commission, txID, err := correctionCharge(before.UserID, before.Symbol, c.after.SettlementAmount, before.Liquidity, token)
+    if err != nil {
+        commission, txID = 0, ""
+    } else {
+        adj.CommissionCharged = commission
+    }
+    if before.CommissionTxID != "" || txID != "" {
+        if err := recordCommission(before.TradeID, commission, txID); err != nil {
+            log.Printf("Failed to record commission %s on trade %s: %v\n", txID, before.TradeID, err)
+        }
+    }
+    c.after.Commission, c.after.CommissionTxID = commission, txID
+    return err
+}
+
+// correctionDeltas is what moving one side from before to after changes in the
+// user's wallet (positive credits it) and holdings.
+func correctionDeltas(before, after models.TradeRecord) (wallet, holdings float64) {
+    afterQty, afterCost := after.Quantity, after.Price*after.Quantity
+    if after.Status == TradeBusted {
+        afterQty, afterCost = 0, 0
+    }
+    if before.OrderType == "BUY" {
+        return (before.Price*before.Quantity - afterCost) * before.SettlementRate(), afterQty - before.Quantity
+    }
+    if after.Status == TradeBusted {
+        // Proceeds that repaid a margin loan are restored to the loan instead
+        return -before.ProceedsCredited, before.Quantity
+    }
+    return (afterCost - before.Price*before.Quantity) * before.SettlementRate(), before.Quantity - afterQty
+}
+
+// refundCommission refunds the whole commission payment of a busted trade.
+func refundCommission(transactionID, token string) error {
+    conn, err := grpc.Dial("localhost:50055", grpc.WithInsecure())
+    if err != nil {
+        return fmt.Errorf("failed to dial billing service: %v", err)
+    }
+    defer conn.Close()
+
+    md := metadata.New(map[string]string{"authorization": token})
+    resp, err := pbBilling.NewBillingServiceClient(conn).RefundPayment(metadata.NewOutgoingContext(context.Background(), md), &pbBilling.RefundPaymentRequest{
+        TransactionId: transactionID,
+    })
+    if err != nil {
+        return fmt.Errorf("RefundPayment RPC failed: %v", err)
+    }
+    if !resp.GetSuccess() {
+        return fmt.Errorf("RefundPayment responded with success=false")
+    }
+    return nil
+}
+
+// restoreMarginLoan puts back the part of the seller's margin loan that a busted sale repaid.
+func restoreMarginLoan(userID string, amount float64, tradeID, token string) error {
+    conn, err := grpc.Dial("localhost:50055", grpc.WithInsecure())
+    if err != nil {
+        return fmt.Errorf("failed to dial billing service: %v", err)
+    }
+    defer conn.Close()
+
+    md := metadata.New(map[string]string{"authorization": token})
+    if _, err := pbBilling.NewBillingServiceClient(conn).RestoreMarginLoan(metadata.NewOutgoingContext(context.Background(), md), &pbBilling.RestoreMarginLoanRequest{
+        UserId:    userID,
+        Amount:    amount,
+        Reference: tradeID,
+    }); err != nil {
+        return fmt.Errorf("RestoreMarginLoan RPC failed: %v", err)
+    }
+    return nil
+}
+
+func joinErr(existing, next string) string {
+    if existing == "" {
+        return next
+    }
+    return existing + "; " + next
+}
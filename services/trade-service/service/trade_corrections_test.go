@@ -0,0 +1,180 @@
+package service
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "strings"
+    "testing"
+
+    "github.com/ankan8/swapsync/backend/internal/testmongo"
+    "github.com/ankan8/swapsync/backend/services/trade-service/models"
+    "github.com/ankan8/swapsync/backend/services/trade-service/repository"
+)
+
+func TestCorrectionDeltas(t *testing.T) {
+    buy := models.TradeRecord{OrderType: "BUY", Price: 10, Quantity: 5, FXRate: 2}
+    // 100 of proceeds, 30 of which repaid a margin loan
+    sell := models.TradeRecord{OrderType: "SELL", Price: 10, Quantity: 5, FXRate: 2, MarginRepaid: 30, ProceedsCredited: 70}
+
+    tests := []struct {
+        name             string
+        before, after    models.TradeRecord
+        wallet, holdings float64
+    }{
+        {"bust BUY refunds the cost", buy, models.TradeRecord{Status: TradeBusted}, 100, -5},
+        {"amend BUY price down refunds the difference", buy, models.TradeRecord{Price: 8, Quantity: 5}, 20, 0},
+        {"amend BUY quantity down", buy, models.TradeRecord{Price: 10, Quantity: 3}, 40, -2},
+        {"bust SELL takes back only the credited proceeds", sell, models.TradeRecord{Status: TradeBusted}, -70, 5},
+        {"amend SELL price up credits the difference", sell, models.TradeRecord{Price: 12, Quantity: 5}, 20, 0},
+        {"amend SELL quantity down debits the difference", sell, models.TradeRecord{Price: 10, Quantity: 4}, -20, 1},
+    }
+    for _, tt := range tests {
+        wallet, holdings := correctionDeltas(tt.before, tt.after)
+        if math.Abs(wallet-tt.wallet) > 1e-9 || math.Abs(holdings-tt.holdings) > 1e-9 {
+            t.Errorf("%s: wallet %.2f, holdings %.2f; want %.2f, %.2f", tt.name, wallet, holdings, tt.wallet, tt.holdings)
+        }
+    }
+}
+
+// correctionBooks fakes the wallets, holdings and commission payments corrections move.
+type correctionBooks struct {
+    wallets   map[string]float64
+    holdings  map[string]float64
+    refunded  []string
+    charged   []float64
+    failDebit string // user whose wallet debits fail
+}
+
+func useCorrectionBooks(t *testing.T) *correctionBooks {
+    t.Helper()
+    b := &correctionBooks{wallets: map[string]float64{}, holdings: map[string]float64{}}
+    prevWallet, prevHoldings, prevRefund, prevCharge, prevRecord :=
+        correctionWallet, correctionHoldings, correctionRefund, correctionCharge, recordCommission
+    t.Cleanup(func() {
+        correctionWallet, correctionHoldings, correctionRefund, correctionCharge, recordCommission =
+            prevWallet, prevHoldings, prevRefund, prevCharge, prevRecord
+    })
+    correctionWallet = func(userID, tradeID string, delta float64, token string) error {
+        if delta < 0 && userID == b.failDebit {
+            return errors.New("insufficient funds")
+        }
+        b.wallets[userID] += delta
+        return nil
+    }
+    correctionHoldings = func(userID, symbol string, quantity, price float64, token string) error {
+        b.holdings[userID] += quantity
+        return nil
+    }
+    correctionRefund = func(transactionID, token string) error {
+        b.refunded = append(b.refunded, transactionID)
+        return nil
+    }
+    correctionCharge = func(userID, symbol string, amount float64, liquidity, token string) (float64, string, error) {
+        commission := amount * 0.01
+        b.charged = append(b.charged, commission)
+        return commission, fmt.Sprintf("tx-%d", len(b.charged)), nil
+    }
+    recordCommission = func(string, float64, string) error { return nil }
+    return b
+}
+
+func bustSides() (buy, sell models.TradeRecord) {
+    buy = models.TradeRecord{TradeID: "buy", UserID: "alice", Symbol: "AAPL", OrderType: "BUY", Price: 10, Quantity: 5}
+    sell = models.TradeRecord{TradeID: "sell", UserID: "bob", Symbol: "AAPL", OrderType: "SELL", Price: 10, Quantity: 5,
+        ProceedsCredited: 50}
+    return buy, sell
+}
+
+func newSideCorrection(before models.TradeRecord) *sideCorrection {
+    after := before
+    after.Status = TradeBusted
+    c := &sideCorrection{before: before, after: after}
+    c.adj.WalletDelta, c.adj.HoldingsDelta = correctionDeltas(before, after)
+    return c
+}
+
+func TestFailedDebitReleasesTheOtherSide(t *testing.T) {
+    books := useCorrectionBooks(t)
+    books.failDebit = "bob" // the seller already spent the proceeds
+    buy, sell := bustSides()
+
+    err := reserveCorrections([]*sideCorrection{newSideCorrection(buy), newSideCorrection(sell)}, "")
+    if err == nil || !strings.Contains(err.Error(), "sell (user bob)") {
+        t.Fatalf("err = %v, want the SELL side's failure", err)
+    }
+    // The buyer's shares were taken first and given back; the seller kept the cash and has no shares back
+    if books.holdings["alice"] != 0 || books.holdings["bob"] != 0 || books.wallets["alice"] != 0 || books.wallets["bob"] != 0 {
+        t.Fatalf("wallets %v holdings %v, want nothing moved", books.wallets, books.holdings)
+    }
+}
+
+func TestAmendRecomputesCommission(t *testing.T) {
+    books := useCorrectionBooks(t)
+    before := models.TradeRecord{TradeID: "t1", UserID: "alice", Symbol: "AAPL", OrderType: "BUY", Price: 100, Quantity: 10,
+        SettlementAmount: 1000, Commission: 10, CommissionTxID: "tx-orig", Liquidity: LiquidityTaker}
+    after := before
+    after.Status, after.Price, after.SettlementAmount = TradeAmended, 80, 800
+    c := &sideCorrection{before: before, after: after}
+    c.adj.WalletDelta, c.adj.HoldingsDelta = correctionDeltas(before, after)
+
+    c.complete("")
+    if c.adj.Error != "" {
+        t.Fatal(c.adj.Error)
+    }
+    if len(books.refunded) != 1 || books.refunded[0] != "tx-orig" {
+        t.Fatalf("refunded %v, want the original payment", books.refunded)
+    }
+    if c.adj.CommissionRefund != 10 || c.adj.CommissionCharged != 8 {
+        t.Fatalf("refund %.2f, charged %.2f; want 10 and 8", c.adj.CommissionRefund, c.adj.CommissionCharged)
+    }
+    if c.after.Commission != 8 || c.after.CommissionTxID != "tx-1" {
+        t.Fatalf("amended trade carries %.2f (%s), want 8 on the new payment", c.after.Commission, c.after.CommissionTxID)
+    }
+    if books.wallets["alice"] != 200 {
+        t.Fatalf("wallet credited %.2f, want the 200 overpaid", books.wallets["alice"])
+    }
+
+    // An amend that leaves the amount unchanged leaves the commission alone
+    books.refunded, books.charged = nil, nil
+    same := &sideCorrection{before: before, after: before}
+    same.after.Status = TradeAmended
+    same.complete("")
+    if len(books.refunded) != 0 || len(books.charged) != 0 {
+        t.Fatalf("commission re-priced on an unchanged amount")
+    }
+}
+
+func TestFailedDebitLeavesTradeUncorrected(t *testing.T) {
+    testmongo.Use(t)
+    books := useCorrectionBooks(t)
+    books.failDebit = "bob@example.com"
+
+    buy, sell := bustSides()
+    for _, tr := range []models.TradeRecord{buy, sell} {
+        tr.TradeID = "corr-" + tr.TradeID
+        tr.UserID = tr.UserID + "@example.com"
+        tr.ExecutionID = "corr-exec"
+        if err := repository.InsertTradeRecord(&tr); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    if _, err := BustTrade("corr-buy", "erroneous print", "admin@example.com", ""); err == nil {
+        t.Fatal("bust succeeded although the seller can't return the proceeds")
+    }
+    for _, id := range []string{"corr-buy", "corr-sell"} {
+        tr, err := repository.GetTradeByID(id)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if tr.Status == TradeBusted {
+            t.Fatalf("%s busted without its debits", id)
+        }
+    }
+    for user, qty := range books.holdings {
+        if qty != 0 {
+            t.Fatalf("%s holdings moved by %.2f", user, qty)
+        }
+    }
+}
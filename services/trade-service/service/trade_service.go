@@ -4,8 +4,11 @@ import (
     "context"
     "fmt"
     "log"
+    "os"
+    "strings"
     "time"
 
+    "github.com/ankan8/swapsync/backend/internal/middleware"
     "github.com/ankan8/swapsync/backend/services/trade-service/models"
     "github.com/ankan8/swapsync/backend/services/trade-service/repository"
 
@@ -31,69 +34,222 @@ import (
 // 2) Billing Service to deduct the trade cost from the user's wallet (only for BUY) + calculate/charge commission
 // 3) Portfolio Service to update holdings (negative quantity for SELL)
 // 4) Notification Service to alert the user of a successful trade
+// Steps 2-4 live in settleExecution, which also settles order-book fills.
 func PlaceOrder(userID, symbol string, userSuppliedPrice, quantity float64, orderType string, token string) (string, error) {
     // 1) Fetch the current quote from Market Data Service
-    realPrice, err := fetchCurrentPrice(symbol, token)
-    fmt.Printf("DEBUG: Fetched realPrice=%.2f\n", realPrice)
+    quote, err := fetchCurrentQuote(symbol, token)
     if err != nil {
         return "", fmt.Errorf("failed to fetch market price for %s: %v", symbol, err)
     }
+    realPrice := quote.Last
+
+    // A market order executes at the touch (ask for BUY, bid for SELL).
+    // If userSuppliedPrice > 0 => treat as limit price: it must be marketable
+    // against the touch and then also executes at the touch.
+    side := OrderSide(orderType)
+    finalPrice := quote.touch(side)
+    if userSuppliedPrice > 0 && !quote.marketable(side, userSuppliedPrice) {
+        return "", fmt.Errorf("limit %.2f is not marketable: %s touch is %.2f", userSuppliedPrice, orderType, finalPrice)
+    }
+
+    // 1.1) Let the external price trigger any stop orders resting in our book.
+    settleFills(TriggerStops(symbol, realPrice))
+
+    // PlaceOrder executes immediately against the market price, so the user is the taker.
+    return settleExecution(userID, symbol, orderType, quantity, finalPrice, LiquidityTaker, uuid.NewString())
+}
+
+// settlementLeg is one side of an execution. Reserving it takes the user's
+// cash (BUY) or shares (SELL); booking it records the trade and completes the
+// rest. Both run under the user's own identity, whoever placed the other side.
+type settlementLeg struct {
+    UserID      string
+    Symbol      string
+    OrderType   string
+    Quantity    float64
+    Price       float64
+    Liquidity   string
+    ExecutionID string
+
+    fx       settlementFX
+    amount   float64 // in the wallet currency
+    borrowed float64 // margin lent towards a BUY
+    token    string
+}
 
-    // Decide whether to use userSuppliedPrice or realPrice
-    // If userSuppliedPrice > 0 => treat as limit price, else realPrice (market).
-    finalPrice := realPrice
-    if userSuppliedPrice > 0 {
-        finalPrice = userSuppliedPrice
+// reserve fixes the FX rate and takes what the user gives up in the trade:
+// the cost of a BUY from the wallet (borrowing on margin if enabled) or the
+// shares of a SELL from the portfolio.
+func (l *settlementLeg) reserve() error {
+    token, err := middleware.UserToken(l.UserID)
+    if err != nil {
+        return err
+    }
+    l.token = token
+
+    // Fix the FX rate from the instrument's currency to the wallet's.
+    l.fx, err = fetchSettlementFX(l.UserID, l.Symbol, token)
+    if err != nil {
+        return err
     }
+    l.amount = l.Price * l.Quantity * l.fx.Rate
 
-    // 1.5) Calculate total cost for the trade (user paying from wallet).
-    // For a BUY, cost = finalPrice * quantity. For SELL, skip wallet deduction.
-    if orderType == "BUY" {
-        tradeCost := finalPrice * quantity
+    if l.OrderType == "BUY" {
         // Check user has enough funds, then withdraw
-        if err := checkAndWithdrawTradeCost(userID, tradeCost, token); err != nil {
-            return "", err // insufficient funds or billing error
-        }
+        l.borrowed, err = checkAndWithdrawTradeCost(l.UserID, l.Symbol, l.amount, l.ExecutionID, token)
+        return err // insufficient funds or billing error
+    }
+    if err := updatePortfolioHoldings(l.UserID, l.Symbol, -l.Quantity, l.Price, token); err != nil {
+        return fmt.Errorf("failed to update portfolio holdings: %v", err)
+    }
+    return nil
+}
+
+// release hands back what reserve took, for a leg that won't be booked.
+func (l *settlementLeg) release() error {
+    if l.OrderType != "BUY" {
+        return updatePortfolioHoldings(l.UserID, l.Symbol, l.Quantity, l.Price, l.token)
+    }
+    // Refunds are credits, which only a service may make
+    token, err := middleware.ServiceToken("trade-service")
+    if err != nil {
+        return err
     }
+    if err := adjustWallet(l.UserID, l.ExecutionID, l.amount, token); err != nil {
+        return err
+    }
+    _, err = repayMargin(l.UserID, l.borrowed, "WALLET", l.ExecutionID)
+    return err
+}
 
-    // 2) Insert the trade record into MongoDB
+// book completes a reserved leg:
+// 1) the trade record is inserted
+// 2) sale proceeds repay any margin loan and the rest goes to the wallet (SELL)
+// 3) Billing Service calculates/charges commission for the liquidity flag
+// 4) Portfolio Service adds the shares (BUY)
+// 5) Notification Service alerts the user
+// The taker side also reports the print to the Market Data Service.
+func (l *settlementLeg) book() (string, error) {
     trade := &models.TradeRecord{
-        TradeID:   uuid.NewString(),
-        UserID:    userID,
-        Symbol:    symbol,
-        Quantity:  quantity,
-        Price:     finalPrice,
-        OrderType: orderType,
-        Timestamp: time.Now().Format(time.RFC3339),
+        TradeID:     uuid.NewString(),
+        UserID:      l.UserID,
+        Symbol:      l.Symbol,
+        Quantity:    l.Quantity,
+        Price:       l.Price,
+        OrderType:   l.OrderType,
+        Timestamp:   time.Now().Format(time.RFC3339),
+        ExecutionID: l.ExecutionID,
+        Liquidity:   l.Liquidity,
+
+        Currency:           l.fx.Base,
+        SettlementCurrency: l.fx.Quote,
+        FXRate:             l.fx.Rate,
+        SettlementAmount:   l.amount,
     }
     if err := repository.InsertTradeRecord(trade); err != nil {
         return "", err
     }
-    fmt.Printf("Trade executed: %s %.2f shares of %s at %.2f\n", orderType, quantity, symbol, finalPrice)
+    fmt.Printf("Trade executed: %s %.2f shares of %s at %.2f\n", l.OrderType, l.Quantity, l.Symbol, l.Price)
+
+    // Sale proceeds first repay any margin loan, before anything below can fail;
+    // the rest is paid into the wallet.
+    if l.OrderType == "SELL" {
+        repaid, err := repayMarginFromSale(l.UserID, l.amount, trade.TradeID)
+        if err != nil {
+            log.Printf("Failed to apply sale proceeds of %.2f (trade %s) to the margin loan of %s: %v\n",
+                l.amount, trade.TradeID, l.UserID, err)
+        }
+        if repaid > 0 {
+            if err := repository.SetTradeMarginRepaid(trade.TradeID, repaid); err != nil {
+                log.Printf("Failed to record margin repayment of %.2f on trade %s: %v\n", repaid, trade.TradeID, err)
+            }
+        }
+        if err := creditSaleProceeds(l.UserID, trade.TradeID, l.amount-repaid); err != nil {
+            return trade.TradeID, err
+        }
+    }
 
-    // 2.1) Call the Billing Service to handle commission (for both BUY and SELL)
-    tradeAmount := finalPrice * quantity
-    if err := callBillingService(userID, tradeAmount, token); err != nil {
+    // Each execution has exactly one taker side, so only it reports the print.
+    if l.Liquidity == LiquidityTaker {
+        publishTradePrint(l.Symbol, l.Quantity, l.Price, trade.Timestamp, l.ExecutionID)
+    }
+
+    commission, txID, err := callBillingService(l.UserID, l.Symbol, l.amount, l.Liquidity, l.token)
+    if err != nil {
         // The trade record is already inserted, so handle/log the error
         return trade.TradeID, fmt.Errorf("failed to charge commission: %v", err)
     }
+    if txID != "" {
+        if err := repository.SetTradeCommission(trade.TradeID, commission, txID); err != nil {
+            log.Printf("Failed to record commission %s on trade %s: %v\n", txID, trade.TradeID, err)
+        }
+    }
 
-    // 3) Determine how much to update holdings by (negative for SELL)
-    updateQuantity := quantity
-    if orderType == "SELL" {
-        updateQuantity = -quantity
+    if l.OrderType == "BUY" {
+        if err := updatePortfolioHoldings(l.UserID, l.Symbol, l.Quantity, l.Price, l.token); err != nil {
+            return trade.TradeID, fmt.Errorf("failed to update portfolio holdings: %v", err)
+        }
     }
 
-    // 4) Call the Portfolio Service to update holdings
-    err = updatePortfolioHoldings(userID, symbol, updateQuantity, finalPrice, token)
-    if err != nil {
-        return trade.TradeID, fmt.Errorf("failed to update portfolio holdings: %v", err)
+    notifyUserTrade(l.UserID, l.Symbol, l.Quantity, l.Price, l.OrderType)
+    return trade.TradeID, nil
+}
+
+// settleExecution reserves and books a single side of an execution that has no
+// counterparty in our book, such as an order filled at the market price.
+// executionID links the trade records of both sides of one fill.
+func settleExecution(userID, symbol, orderType string, quantity, finalPrice float64, liquidity, executionID string) (string, error) {
+    leg := &settlementLeg{UserID: userID, Symbol: symbol, OrderType: orderType, Quantity: quantity,
+        Price: finalPrice, Liquidity: liquidity, ExecutionID: executionID}
+    if err := leg.reserve(); err != nil {
+        return "", err
     }
+    return leg.book()
+}
 
-    // 5) Notify the user about the successful trade
-    notifyUserTrade(userID, symbol, quantity, finalPrice, orderType)
+// settleFills books both sides of each order-book fill: the incoming order as taker
+// and the resting order as maker. Book orders don't reserve funds while they rest,
+// so both legs are reserved first, buyer first since the buyer's cash is what
+// usually runs short. If either can't be reserved the other is released and the
+// fill is logged and skipped, so neither side is booked alone. Returns the fills
+// that were settled.
+func settleFills(fills []Fill) []Fill {
+    var settled []Fill
+    for _, f := range fills {
+        makerSide := SELL
+        if f.TakerSide == SELL {
+            makerSide = BUY
+        }
+        executionID := uuid.NewString()
+        taker := &settlementLeg{UserID: f.TakerUserID, Symbol: f.Symbol, OrderType: string(f.TakerSide), Quantity: f.Quantity,
+            Price: f.Price, Liquidity: LiquidityTaker, ExecutionID: executionID}
+        maker := &settlementLeg{UserID: f.MakerUserID, Symbol: f.Symbol, OrderType: string(makerSide), Quantity: f.Quantity,
+            Price: f.Price, Liquidity: LiquidityMaker, ExecutionID: executionID}
+        legs := []*settlementLeg{taker, maker}
+        if makerSide == BUY {
+            legs = []*settlementLeg{maker, taker}
+        }
 
-    return trade.TradeID, nil
+        if err := legs[0].reserve(); err != nil {
+            log.Printf("Failed to settle fill %s/%s: %s side: %v\n", f.TakerOrderID, f.MakerOrderID, legs[0].OrderType, err)
+            continue
+        }
+        if err := legs[1].reserve(); err != nil {
+            log.Printf("Failed to settle fill %s/%s: %s side: %v\n", f.TakerOrderID, f.MakerOrderID, legs[1].OrderType, err)
+            if err := legs[0].release(); err != nil {
+                log.Printf("Failed to release %s side of fill %s/%s for user %s: %v\n",
+                    legs[0].OrderType, f.TakerOrderID, f.MakerOrderID, legs[0].UserID, err)
+            }
+            continue
+        }
+        for _, leg := range legs {
+            if _, err := leg.book(); err != nil {
+                log.Printf("Failed to book %s side of fill %s/%s: %v\n", leg.OrderType, f.TakerOrderID, f.MakerOrderID, err)
+            }
+        }
+        settled = append(settled, f)
+    }
+    return settled
 }
 
 // GetTradeHistory returns all trades for a user.
@@ -101,11 +257,38 @@ func GetTradeHistory(userID string) ([]models.TradeRecord, error) {
     return repository.GetTradesByUserID(userID)
 }
 
-// fetchCurrentPrice dials the Market Data Service to get the real-time quote.
-func fetchCurrentPrice(symbol, token string) (float64, error) {
+// marketQuote is the part of a Market Data quote that execution needs.
+type marketQuote struct {
+    Last float64
+    Bid  float64
+    Ask  float64
+}
+
+// touch is the price a taker on side executes at: the ask for a BUY, the bid for a
+// SELL, falling back to the last price when the source has no bid/ask.
+func (q marketQuote) touch(side OrderSide) float64 {
+    if side == BUY && q.Ask > 0 {
+        return q.Ask
+    }
+    if side == SELL && q.Bid > 0 {
+        return q.Bid
+    }
+    return q.Last
+}
+
+// marketable reports whether a limit order on side can execute against the touch.
+func (q marketQuote) marketable(side OrderSide, limit float64) bool {
+    if side == BUY {
+        return limit >= q.touch(BUY)
+    }
+    return limit <= q.touch(SELL)
+}
+
+// fetchCurrentQuote dials the Market Data Service to get the real-time quote.
+func fetchCurrentQuote(symbol, token string) (marketQuote, error) {
     conn, err := grpc.Dial("localhost:50054", grpc.WithInsecure())
     if err != nil {
-        return 0, fmt.Errorf("failed to dial Market Data Service: %v", err)
+        return marketQuote{}, fmt.Errorf("failed to dial Market Data Service: %v", err)
     }
     defer conn.Close()
 
@@ -121,9 +304,61 @@ func fetchCurrentPrice(symbol, token string) (float64, error) {
         Symbol: symbol,
     })
     if err != nil {
-        return 0, fmt.Errorf("GetQuote RPC failed: %v", err)
+        return marketQuote{}, fmt.Errorf("GetQuote RPC failed: %v", err)
     }
-    return resp.GetPrice(), nil
+    return marketQuote{Last: resp.GetPrice(), Bid: resp.GetBid(), Ask: resp.GetAsk()}, nil
+}
+
+// settlementFX converts an instrument's prices into the user's wallet currency.
+type settlementFX struct {
+    Base  string // instrument currency
+    Quote string // wallet currency
+    Rate  float64
+}
+
+// fetchSettlementFX looks up the wallet currency in the Billing Service and the
+// instrument-to-wallet rate in the Market Data Service. Users without a wallet
+// yet settle in the platform default WALLET_CURRENCY.
+func fetchSettlementFX(userID, symbol, token string) (settlementFX, error) {
+    ctx := context.Background()
+    if token != "" {
+        md := metadata.New(map[string]string{"authorization": token})
+        ctx = metadata.NewOutgoingContext(ctx, md)
+    }
+
+    walletCurrency := defaultWalletCurrency()
+    billingConn, err := grpc.Dial("localhost:50055", grpc.WithInsecure())
+    if err != nil {
+        return settlementFX{}, fmt.Errorf("failed to dial billing service: %v", err)
+    }
+    defer billingConn.Close()
+    if bal, err := pbBilling.NewBillingServiceClient(billingConn).GetBalance(ctx, &pbBilling.GetBalanceRequest{UserId: userID}); err == nil && bal.GetCurrency() != "" {
+        walletCurrency = bal.GetCurrency()
+    }
+
+    mdConn, err := grpc.Dial("localhost:50054", grpc.WithInsecure())
+    if err != nil {
+        return settlementFX{}, fmt.Errorf("failed to dial Market Data Service: %v", err)
+    }
+    defer mdConn.Close()
+    rate, err := pbMarketData.NewMarketDataServiceClient(mdConn).GetFXRate(ctx, &pbMarketData.GetFXRateRequest{
+        Symbol: symbol,
+        Quote:  walletCurrency,
+    })
+    if err != nil {
+        return settlementFX{}, fmt.Errorf("GetFXRate RPC failed: %v", err)
+    }
+    if rate.GetRate() <= 0 {
+        return settlementFX{}, fmt.Errorf("no usable FX rate for %s/%s", rate.GetBase(), walletCurrency)
+    }
+    return settlementFX{Base: rate.GetBase(), Quote: rate.GetQuote(), Rate: rate.GetRate()}, nil
+}
+
+func defaultWalletCurrency() string {
+    if v := os.Getenv("WALLET_CURRENCY"); v != "" {
+        return strings.ToUpper(v)
+    }
+    return "INR"
 }
 
 // updatePortfolioHoldings dials the Portfolio Service's UpdateHoldings RPC.
@@ -160,11 +395,13 @@ func updatePortfolioHoldings(userID, symbol string, quantity, price float64, tok
 }
 
 // callBillingService calculates commission for the tradeAmount, then processes the payment.
-func callBillingService(userID string, tradeAmount float64, token string) error {
+// liquidity is LiquidityMaker or LiquidityTaker and selects the fee schedule rate.
+// Returns the commission charged and its transaction ID ("" if nothing was charged).
+func callBillingService(userID, symbol string, tradeAmount float64, liquidity string, token string) (float64, string, error) {
     // 1) Connect to the Billing Service (assuming localhost:50055)
     conn, err := grpc.Dial("localhost:50055", grpc.WithInsecure())
     if err != nil {
-        return fmt.Errorf("failed to dial billing service: %v", err)
+        return 0, "", fmt.Errorf("failed to dial billing service: %v", err)
     }
     defer conn.Close()
 
@@ -180,45 +417,51 @@ func callBillingService(userID string, tradeAmount float64, token string) error
     // 3) First, calculate the commission
     commResp, err := billingClient.CalculateCommission(ctx, &pbBilling.CalculateCommissionRequest{
         TradeAmount: tradeAmount,
+        UserId:      userID,
+        Liquidity:   liquidity,
+        Symbol:      symbol,
     })
     if err != nil {
-        return fmt.Errorf("CalculateCommission RPC failed: %v", err)
+        return 0, "", fmt.Errorf("CalculateCommission RPC failed: %v", err)
     }
     commission := commResp.GetCommission()
-    fmt.Printf("Calculated commission for tradeAmount=%.2f is %.2f\n", tradeAmount, commission)
+    fmt.Printf("Calculated commission for tradeAmount=%.2f is %.2f (%s, tier=%s)\n",
+        tradeAmount, commission, liquidity, commResp.GetBreakdown().GetTier())
 
-    // 3.5) If commission < 1 INR, skip Razorpay (avoid "Order amount less than minimum allowed").
-    if commission < 1.0 {
-        log.Printf("Commission=%.2f < 1.0 INR, skipping external payment\n", commission)
-        return nil
+    if commission <= 0 {
+        return 0, "", nil
     }
 
-    // 4) Then process the payment for the commission
+    // 4) Then pay the commission from the user's wallet (Dr user cash, Cr fee income);
+    // the transaction ID lets a bust refund it.
     payResp, err := billingClient.ProcessPayment(ctx, &pbBilling.ProcessPaymentRequest{
         UserId: userID,
         Amount: commission,
-        Method: "WALLET", // or "CREDIT_CARD", "UPI", etc.
+        Method: "WALLET",
     })
     if err != nil {
-        return fmt.Errorf("ProcessPayment RPC failed: %v", err)
+        return 0, "", fmt.Errorf("ProcessPayment RPC failed: %v", err)
     }
     if !payResp.GetSuccess() {
-        return fmt.Errorf("ProcessPayment responded with success=false")
+        return 0, "", fmt.Errorf("ProcessPayment responded with success=false")
     }
 
     fmt.Printf("Commission of %.2f charged. Transaction ID=%s\n", commission, payResp.GetTransactionId())
-    return nil
+    return commission, payResp.GetTransactionId(), nil
 }
 
 // checkAndWithdrawTradeCost ensures user has enough wallet balance and withdraws the cost for a BUY order.
-func checkAndWithdrawTradeCost(userID string, cost float64, token string) error {
+// If the wallet falls short, a margin account pays for the purchase instead: the Billing Service takes
+// what the wallet holds and lends the difference within its buying power for symbol.
+// executionID is recorded on the billing ledger entry. Returns how much was borrowed.
+func checkAndWithdrawTradeCost(userID, symbol string, cost float64, executionID, token string) (float64, error) {
     if cost <= 0 {
-        return nil // no cost to deduct if cost is zero or negative
+        return 0, nil // no cost to deduct if cost is zero or negative
     }
 
     conn, err := grpc.Dial("localhost:50055", grpc.WithInsecure())
     if err != nil {
-        return fmt.Errorf("failed to dial billing service: %v", err)
+        return 0, fmt.Errorf("failed to dial billing service: %v", err)
     }
     defer conn.Close()
 
@@ -230,36 +473,132 @@ func checkAndWithdrawTradeCost(userID string, cost float64, token string) error
         ctx = metadata.NewOutgoingContext(ctx, md)
     }
 
-    // 1) Check current balance
+    // 1) Check current balance for a clear error; WithdrawFunds re-checks atomically
     balResp, err := billingClient.GetBalance(ctx, &pbBilling.GetBalanceRequest{UserId: userID})
     if err != nil {
-        return fmt.Errorf("failed to get wallet balance: %v", err)
+        return 0, fmt.Errorf("failed to get wallet balance: %v", err)
     }
     if !balResp.Success {
-        return fmt.Errorf("GetBalance responded with success=false")
+        return 0, fmt.Errorf("GetBalance responded with success=false")
     }
     if balResp.Balance < cost {
-        return fmt.Errorf("insufficient wallet funds: need %.2f, have %.2f", cost, balResp.Balance)
+        // The purchase is paid on margin, wallet cash first
+        borrowed, err := borrowMargin(billingClient, userID, symbol, cost, executionID)
+        if err != nil {
+            return 0, fmt.Errorf("insufficient wallet funds: need %.2f, have %.2f (%v)", cost, balResp.Balance, err)
+        }
+        return borrowed, nil
     }
 
     // 2) Withdraw cost
     wdrResp, err := billingClient.WithdrawFunds(ctx, &pbBilling.WithdrawFundsRequest{
-        UserId: userID,
-        Amount: cost,
+        UserId:    userID,
+        Amount:    cost,
+        Purpose:   "TRADE",
+        Reference: executionID,
     })
-    if err != nil {
-        return fmt.Errorf("failed to withdraw trade cost: %v", err)
+    if err == nil && !wdrResp.Success {
+        err = fmt.Errorf("WithdrawFunds responded with success=false")
     }
-    if !wdrResp.Success {
-        return fmt.Errorf("WithdrawFunds responded with success=false")
+    if err != nil {
+        return 0, fmt.Errorf("failed to withdraw trade cost: %v", err)
     }
 
     log.Printf("Deducted trade cost of %.2f from user %s. New balance=%.2f\n", cost, userID, wdrResp.NewBalance)
+    return 0, nil
+}
+
+// borrowMargin asks the Billing Service to pay for a purchase, lending what the
+// wallet can't cover. Borrowing is reserved for services, so it uses a service token.
+func borrowMargin(billingClient pbBilling.BillingServiceClient, userID, symbol string, cost float64, executionID string) (float64, error) {
+    token, err := middleware.ServiceToken("trade-service")
+    if err != nil {
+        return 0, err
+    }
+    md := metadata.New(map[string]string{"authorization": token})
+    resp, err := billingClient.BorrowMargin(metadata.NewOutgoingContext(context.Background(), md), &pbBilling.BorrowMarginRequest{
+        UserId:    userID,
+        Symbol:    symbol,
+        Amount:    cost,
+        Reference: executionID,
+    })
+    if err != nil {
+        return 0, fmt.Errorf("BorrowMargin RPC failed: %v", err)
+    }
+    if resp.GetBorrowed() > 0 {
+        log.Printf("Borrowed %.2f on margin for user %s (%s). Loan=%.2f\n", resp.GetBorrowed(), userID, executionID, resp.GetLoan())
+    }
+    return resp.GetBorrowed(), nil
+}
+
+// repayMarginFromSale applies a sale's proceeds to the seller's margin loan, if
+// they have one, and returns how much it repaid.
+func repayMarginFromSale(userID string, proceeds float64, tradeID string) (float64, error) {
+    return repayMargin(userID, proceeds, "SALE", tradeID)
+}
+
+// repayMargin pays up to amount of the user's margin loan from source (SALE or
+// WALLET) and returns how much it repaid. Like borrowing, this is reserved for services.
+func repayMargin(userID string, amount float64, source, reference string) (float64, error) {
+    if amount <= 0 {
+        return 0, nil
+    }
+    token, err := middleware.ServiceToken("trade-service")
+    if err != nil {
+        return 0, err
+    }
+    conn, err := grpc.Dial("localhost:50055", grpc.WithInsecure())
+    if err != nil {
+        return 0, fmt.Errorf("failed to dial billing service: %v", err)
+    }
+    defer conn.Close()
+
+    md := metadata.New(map[string]string{"authorization": token})
+    resp, err := pbBilling.NewBillingServiceClient(conn).RepayMarginLoan(metadata.NewOutgoingContext(context.Background(), md), &pbBilling.RepayMarginLoanRequest{
+        UserId:    userID,
+        Amount:    amount,
+        Source:    source,
+        Reference: reference,
+    })
+    if err != nil {
+        return 0, fmt.Errorf("RepayMarginLoan RPC failed: %v", err)
+    }
+    if resp.GetRepaid() > 0 {
+        log.Printf("%s repaid %.2f of user %s's margin loan (%s). Loan=%.2f\n", source, resp.GetRepaid(), userID, reference, resp.GetLoan())
+    }
+    return resp.GetRepaid(), nil
+}
+
+// creditSaleProceeds pays a sale's proceeds into the seller's wallet and records
+// the amount on the trade, so a bust knows how much to take back.
+func creditSaleProceeds(userID, tradeID string, proceeds float64) error {
+    if proceeds <= 0 {
+        return nil
+    }
+    // Credits are reserved for services
+    token, err := middleware.ServiceToken("trade-service")
+    if err != nil {
+        return err
+    }
+    if err := adjustWallet(userID, tradeID, proceeds, token); err != nil {
+        return fmt.Errorf("failed to credit sale proceeds: %v", err)
+    }
+    if err := repository.AddTradeProceedsCredited(tradeID, proceeds); err != nil {
+        log.Printf("Failed to record sale proceeds of %.2f on trade %s: %v\n", proceeds, tradeID, err)
+    }
     return nil
 }
 
 // notifyUserTrade calls the Notification Service to alert the user about the executed trade.
 func notifyUserTrade(userID, symbol string, quantity, finalPrice float64, orderType string) {
+    // Construct a message
+    message := fmt.Sprintf("Your %s order for %.2f shares of %s is executed at %.2f",
+        orderType, quantity, symbol, finalPrice)
+    notifyUser(userID, message)
+}
+
+// notifyUser sends a message to the user through the Notification Service.
+func notifyUser(userID, message string) {
     // 1) Connect to the Notification Service (assuming it runs on localhost:50056)
     conn, err := grpc.Dial("localhost:50056", grpc.WithInsecure())
     if err != nil {
@@ -270,11 +609,7 @@ func notifyUserTrade(userID, symbol string, quantity, finalPrice float64, orderT
 
     notifClient := notificationpb.NewNotificationServiceClient(conn)
 
-    // 2) Construct a message
-    message := fmt.Sprintf("Your %s order for %.2f shares of %s is executed at %.2f",
-        orderType, quantity, symbol, finalPrice)
-
-    // 3) Call SendNotification
+    // 2) Call SendNotification
     _, err = notifClient.SendNotification(context.Background(), &notificationpb.SendNotificationRequest{
         UserId:  userID,
         Message: message,
@@ -287,3 +622,54 @@ func notifyUserTrade(userID, symbol string, quantity, finalPrice float64, orderT
 
     log.Printf("Trade notification sent to user=%s, message=%s\n", userID, message)
 }
+
+// adjustWallet credits (delta > 0) or debits (delta < 0) the user's wallet through the Billing Service
+// as a trade settlement movement referencing tradeID.
+func adjustWallet(userID, tradeID string, delta float64, token string) error {
+    if delta == 0 {
+        return nil
+    }
+
+    conn, err := grpc.Dial("localhost:50055", grpc.WithInsecure())
+    if err != nil {
+        return fmt.Errorf("failed to dial billing service: %v", err)
+    }
+    defer conn.Close()
+
+    billingClient := pbBilling.NewBillingServiceClient(conn)
+
+    ctx := context.Background()
+    if token != "" {
+        md := metadata.New(map[string]string{"authorization": token})
+        ctx = metadata.NewOutgoingContext(ctx, md)
+    }
+
+    if delta > 0 {
+        resp, err := billingClient.DepositFunds(ctx, &pbBilling.DepositFundsRequest{
+            UserId:    userID,
+            Amount:    delta,
+            Purpose:   "TRADE",
+            Reference: tradeID,
+        })
+        if err != nil {
+            return fmt.Errorf("DepositFunds RPC failed: %v", err)
+        }
+        if !resp.GetSuccess() {
+            return fmt.Errorf("DepositFunds responded with success=false")
+        }
+        return nil
+    }
+    resp, err := billingClient.WithdrawFunds(ctx, &pbBilling.WithdrawFundsRequest{
+        UserId:    userID,
+        Amount:    -delta,
+        Purpose:   "TRADE",
+        Reference: tradeID,
+    })
+    if err != nil {
+        return fmt.Errorf("WithdrawFunds RPC failed: %v", err)
+    }
+    if !resp.GetSuccess() {
+        return fmt.Errorf("WithdrawFunds responded with success=false")
+    }
+    return nil
+}